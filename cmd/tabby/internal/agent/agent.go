@@ -0,0 +1,62 @@
+// Package agent implements the `tabby agent` subcommand: read what the
+// daemon knows about the AI agents running in this session.
+//
+//	tabby agent log <window>    the window's agent session timeline
+//
+// <window> is a window ID (@3) or index. The timeline lives in the daemon's
+// memory, so a daemon must be running.
+package agent
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/brendandebeasi/tabby/cmd/tabby/internal/ctl"
+	"github.com/brendandebeasi/tabby/pkg/daemon"
+)
+
+// Run dispatches `tabby agent <op> ...`. Returns the exit code main should
+// propagate.
+func Run(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stdout)
+		return 0
+	}
+	switch args[0] {
+	case "log":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, "usage: tabby agent log <window>")
+			return 2
+		}
+		return request("agent-log", args[1])
+	case "-h", "--help", "help":
+		usage(os.Stdout)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "tabby agent: unknown command %q\n", args[0])
+		usage(os.Stderr)
+		return 2
+	}
+}
+
+// request sends op to the daemon and prints its output.
+func request(op string, args ...string) int {
+	resp, err := ctl.Request(&daemon.CtlRequest{Op: op, Args: args})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tabby agent: daemon not reachable:", err)
+		return 1
+	}
+	if !resp.OK {
+		fmt.Fprintln(os.Stderr, "tabby agent:", resp.Error)
+		return 1
+	}
+	fmt.Print(resp.Output)
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: tabby agent <command>")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "  log <window>   agent session timeline for a window (@id or index)")
+}
@@ -0,0 +1,264 @@
+// Package appearance implements the `tabby appearance` subcommand: manage the
+// per-directory appearance memory (color, marker icon, group, pinned) that
+// Tabby seeds onto new windows.
+//
+// Subcommands:
+//
+//	tabby appearance list [--json]        show every remembered project key
+//	tabby appearance export [file]        write a versioned JSON export
+//	tabby appearance import <file|->      merge an export into the memory
+//	tabby appearance forget <key|dir>     drop one project's record
+//
+// With a daemon running in this session the edit goes through it (`tabby ctl`
+// ops appearance-*), because the daemon owns the in-memory copy and rewrites
+// cwd-colors.json on every change. Without one, the file is edited directly.
+// The schema is documented in pkg/appearance.
+package appearance
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/brendandebeasi/tabby/cmd/tabby/internal/ctl"
+	memory "github.com/brendandebeasi/tabby/pkg/appearance"
+	"github.com/brendandebeasi/tabby/pkg/daemon"
+)
+
+// Run dispatches `tabby appearance <op> ...`. Returns the exit code main
+// should propagate.
+func Run(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stdout)
+		return 0
+	}
+	var err error
+	switch args[0] {
+	case "-h", "--help", "help":
+		usage(os.Stdout)
+		return 0
+	case "list":
+		asJSON := len(args) > 1 && args[1] == "--json"
+		err = list(os.Stdout, asJSON)
+	case "export":
+		out := ""
+		if len(args) > 1 {
+			out = args[1]
+		}
+		err = export(out)
+	case "import":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, "Usage: tabby appearance import <file|->")
+			return 2
+		}
+		err = importFile(args[1])
+	case "forget":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, "Usage: tabby appearance forget <key|dir>")
+			return 2
+		}
+		err = forget(args[1])
+	default:
+		fmt.Fprintf(os.Stderr, "tabby appearance: unknown subcommand %q\n\n", args[0])
+		usage(os.Stderr)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tabby appearance %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: tabby appearance <subcommand> [args...]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Subcommands:")
+	fmt.Fprintln(w, "  list [--json]      show remembered per-project colors, markers and groups")
+	fmt.Fprintln(w, "  export [file]      write them as JSON (stdout by default)")
+	fmt.Fprintln(w, "  import <file|->    merge an export; incoming non-empty fields win")
+	fmt.Fprintln(w, "  forget <key|dir>   drop one project's record")
+}
+
+// errNoDaemon marks "no daemon in this session": fall back to the file.
+var errNoDaemon = errors.New("no daemon")
+
+// viaDaemon runs one appearance-* ctl op. It returns errNoDaemon when the
+// session has no reachable daemon.
+func viaDaemon(op string, args ...string) (string, error) {
+	resp, err := ctl.Request(&daemon.CtlRequest{Op: op, Args: args})
+	if err != nil {
+		return "", errNoDaemon
+	}
+	if !resp.OK {
+		return "", errors.New(resp.Error)
+	}
+	return resp.Output, nil
+}
+
+// load returns the current memory, from the daemon if one is running.
+func load() (map[string]memory.Record, error) {
+	out, err := viaDaemon("appearance-export")
+	if errors.Is(err, errNoDaemon) {
+		return memory.Load(memory.Path())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return memory.Decode([]byte(out))
+}
+
+func list(w io.Writer, asJSON bool) error {
+	m, err := load()
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		data, err := memory.Encode(m)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+	if len(m) == 0 {
+		fmt.Fprintln(w, "No remembered appearances.")
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tCOLOR\tICON\tGROUP\tPINNED")
+	for _, k := range keys {
+		r := m[k]
+		pinned := ""
+		if r.Pinned {
+			pinned = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", k, dash(r.Color), dash(r.Icon), dash(r.Group), dash(pinned))
+	}
+	return tw.Flush()
+}
+
+func dash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
+
+func export(path string) error {
+	m, err := load()
+	if err != nil {
+		return err
+	}
+	data, err := memory.Encode(m)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "" || path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("exported %d entries to %s\n", len(m), path)
+	return nil
+}
+
+func importFile(path string) error {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	// Validate locally first so a bad file fails the same way with or
+	// without a daemon.
+	incoming, err := memory.Decode(data)
+	if err != nil {
+		return err
+	}
+
+	out, err := viaDaemon("appearance-import", string(data))
+	if err == nil {
+		fmt.Println(out)
+		return nil
+	}
+	if !errors.Is(err, errNoDaemon) {
+		return err
+	}
+	m, err := memory.Load(memory.Path())
+	if err != nil {
+		return err
+	}
+	added, updated := memory.Merge(m, incoming)
+	if err := memory.Save(memory.Path(), m); err != nil {
+		return err
+	}
+	fmt.Printf("imported: %d added, %d updated\n", added, updated)
+	return nil
+}
+
+func forget(arg string) error {
+	key := resolveKey(arg)
+	out, err := viaDaemon("appearance-forget", key)
+	if err == nil {
+		fmt.Println(out)
+		return nil
+	}
+	if !errors.Is(err, errNoDaemon) {
+		return err
+	}
+	m, err := memory.Load(memory.Path())
+	if err != nil {
+		return err
+	}
+	if _, ok := m[key]; !ok {
+		return fmt.Errorf("no remembered appearance for %s", key)
+	}
+	delete(m, key)
+	if err := memory.Save(memory.Path(), m); err != nil {
+		return err
+	}
+	fmt.Println("forgot " + key)
+	return nil
+}
+
+// resolveKey turns a directory argument ("." or a path inside a repo) into the
+// project key the daemon uses — the git toplevel, else the absolute dir.
+// Anything that is not an existing directory (e.g. an ssh:// key) is used as
+// given.
+func resolveKey(arg string) string {
+	if strings.Contains(arg, "://") {
+		return memory.NormalizeKey(arg)
+	}
+	fi, err := os.Stat(arg)
+	if err != nil || !fi.IsDir() {
+		return memory.NormalizeKey(arg)
+	}
+	if out, err := exec.Command("git", "-C", arg, "rev-parse", "--show-toplevel").Output(); err == nil {
+		if top := strings.TrimSpace(string(out)); top != "" {
+			return memory.NormalizeKey(top)
+		}
+	}
+	if abs, err := filepath.Abs(arg); err == nil {
+		return memory.NormalizeKey(abs)
+	}
+	return memory.NormalizeKey(arg)
+}
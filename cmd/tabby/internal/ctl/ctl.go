@@ -0,0 +1,199 @@
+// Package ctl implements the `tabby ctl` subcommand: scriptable control of
+// daemon-owned state from a shell, a keybinding or a context-menu entry.
+//
+// Subcommands:
+//
+//	tabby ctl group-run <group> <cmd...>     type <cmd> + Enter into the first
+//	                                         content pane of every window in
+//	                                         <group>
+//	tabby ctl group-run-prompted <group>     run the answer of the group
+//	                                         menu's "Run in All Windows..."
+//	                                         prompt (internal)
+//	tabby ctl group-sync <group> [on|off]    toggle (or set) synchronize-panes
+//	                                         across the group's windows
+//	tabby ctl group-archive <group>          park every window of <group> in
+//	                                         the holding session
+//	tabby ctl group-unarchive <group>        bring them back, original order
+//	                                         and layouts
+//	tabby ctl focus start [duration]         start (or resume) the focus
+//	                                         timer; duration like 25m or 25
+//	tabby ctl focus pause|resume|reset|status
+//	tabby ctl todo add <text...>             add an item to the todo widget's
+//	                                         task file
+//	tabby ctl todo done <n>|list             tick the nth open item, or list
+//	                                         the open items
+//	tabby ctl todo add-prompted              add the answer of the widget's
+//	                                         "+" prompt (internal)
+//
+// Every command is one MsgCtl request/response over the session's daemon
+// socket; the daemon resolves groups and panes, so this package never talks
+// to tmux beyond discovering the session id. Request is exported so sibling
+// CLI packages can reuse the same round trip.
+package ctl
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/daemon"
+)
+
+// Run dispatches `tabby ctl <op> ...`. Returns the exit code main should
+// propagate.
+func Run(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stdout)
+		return 0
+	}
+	switch args[0] {
+	case "-h", "--help", "help":
+		usage(os.Stdout)
+		return 0
+	case "group-run":
+		if len(args) < 3 {
+			fmt.Fprintln(os.Stderr, "Usage: tabby ctl group-run <group> <cmd...>")
+			return 2
+		}
+		return runOp("group-run", args[1], strings.Join(args[2:], " "))
+	case "group-run-prompted":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, "Usage: tabby ctl group-run-prompted <group>")
+			return 2
+		}
+		return runOp("group-run-prompted", args[1])
+	case "group-sync":
+		if len(args) < 2 || len(args) > 3 {
+			fmt.Fprintln(os.Stderr, "Usage: tabby ctl group-sync <group> [on|off]")
+			return 2
+		}
+		return runOp("group-sync", args[1:]...)
+	case "group-archive", "group-unarchive":
+		if len(args) != 2 {
+			fmt.Fprintf(os.Stderr, "Usage: tabby ctl %s <group>\n", args[0])
+			return 2
+		}
+		return runOp(args[0], args[1])
+	case "focus":
+		if len(args) < 2 || len(args) > 3 {
+			fmt.Fprintln(os.Stderr, "Usage: tabby ctl focus start [duration] | pause | resume | reset | status")
+			return 2
+		}
+		return runOp("focus", args[1:]...)
+	case "todo":
+		switch {
+		case len(args) >= 3 && args[1] == "add":
+			return runOp("todo", "add", strings.Join(args[2:], " "))
+		case len(args) == 3 && args[1] == "done", len(args) == 2 && (args[1] == "list" || args[1] == "add-prompted"):
+			return runOp("todo", args[1:]...)
+		}
+		fmt.Fprintln(os.Stderr, "Usage: tabby ctl todo add <text...> | done <n> | list")
+		return 2
+	default:
+		fmt.Fprintf(os.Stderr, "tabby ctl: unknown subcommand %q\n\n", args[0])
+		usage(os.Stderr)
+		return 2
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: tabby ctl <subcommand> [args...]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Subcommands:")
+	fmt.Fprintln(w, "  group-run <group> <cmd...>   run a command in every window of a group")
+	fmt.Fprintln(w, "  group-sync <group> [on|off]  toggle synchronize-panes across a group")
+	fmt.Fprintln(w, "  group-archive <group>        hibernate a group into the holding session")
+	fmt.Fprintln(w, "  group-unarchive <group>      restore an archived group")
+	fmt.Fprintln(w, "  focus start [duration]       start the focus timer (e.g. 25m)")
+	fmt.Fprintln(w, "  focus pause|resume|reset|status")
+	fmt.Fprintln(w, "  todo add <text...>           add an item to the todo widget")
+	fmt.Fprintln(w, "  todo done <n>|list           tick an open item, or list them")
+}
+
+// runOp sends one request and prints the reply: Output to stdout on
+// success, Error to stderr (exit 1) otherwise.
+func runOp(op string, args ...string) int {
+	resp, err := Request(&daemon.CtlRequest{Op: op, Args: args})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if !resp.OK {
+		fmt.Fprintf(os.Stderr, "tabby ctl %s: %s\n", op, resp.Error)
+		return 1
+	}
+	if resp.Output != "" {
+		fmt.Println(strings.TrimRight(resp.Output, "\n"))
+	}
+	return 0
+}
+
+// Request performs one MsgCtl round trip against the caller's session
+// daemon. Mirrors pet.request: 2s dial, 5s overall deadline, one JSON line
+// each way.
+func Request(req *daemon.CtlRequest) (*daemon.CtlResponse, error) {
+	sessionID, err := currentSessionID()
+	if err != nil {
+		return nil, fmt.Errorf("tabby daemon not running in this session — start tabby first.")
+	}
+	conn, err := net.DialTimeout("unix", daemon.SocketPath(sessionID), 2*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("tabby daemon not running in this session — start tabby first.")
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	data, err := json.Marshal(daemon.Message{Type: daemon.MsgCtl, Payload: req})
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+	data = append(data, '\n')
+	if _, err := conn.Write(data); err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("read response: %w", err)
+		}
+		return nil, fmt.Errorf("daemon closed connection without a response")
+	}
+	var respMsg daemon.Message
+	if err := json.Unmarshal(scanner.Bytes(), &respMsg); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	if respMsg.Type != daemon.MsgCtl {
+		return nil, fmt.Errorf("unexpected response type %q", respMsg.Type)
+	}
+	payloadBytes, err := json.Marshal(respMsg.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode response payload: %w", err)
+	}
+	var resp daemon.CtlResponse
+	if err := json.Unmarshal(payloadBytes, &resp); err != nil {
+		return nil, fmt.Errorf("decode response payload: %w", err)
+	}
+	return &resp, nil
+}
+
+// currentSessionID returns the tmux session id ("$2") for the caller's
+// pane. Same lookup as pet.currentSessionID.
+func currentSessionID() (string, error) {
+	out, err := exec.Command("tmux", "display-message", "-p", "#{session_id}").Output()
+	if err != nil {
+		return "", err
+	}
+	id := strings.TrimSpace(string(out))
+	if id == "" {
+		return "", fmt.Errorf("no active tmux session")
+	}
+	return id, nil
+}
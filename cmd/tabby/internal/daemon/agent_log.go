@@ -0,0 +1,333 @@
+package daemon
+
+// agent_log.go keeps a timeline of what each AI agent pane did: when the
+// agent started, the prompts submitted, its state changes (agent_state.go),
+// the busy/input indicators set via `tabby hook set-indicator`, the AI titles
+// set via `tabby hook set-title`, and how the session ended.
+// It is built by diffing the pane list on every refresh, so it covers every
+// hook the daemon already sees without new wiring. A session runs from the
+// first time a pane looks like an agent until it stops looking like one; the
+// last ai.agent_log.sessions sessions per pane are kept in memory, for
+// agentLogClosedTTL once the window itself is gone.
+//
+// Read with `tabby agent log <window>` (ctl op agent-log) or "Agent Log..."
+// in the window context menu.
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/agentstate"
+	"github.com/brendandebeasi/tabby/pkg/cmdtrack"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// Timeline entry kinds.
+const (
+	agentLogStart  = "start"
+	agentLogPrompt = "prompt"
+	agentLogState  = "state"
+	agentLogTitle  = "title"
+	agentLogBusy   = "busy"
+	agentLogInput  = "input"
+	agentLogEnd    = "end"
+)
+
+// agentLogMaxEntries bounds one session's timeline.
+const agentLogMaxEntries = 500
+
+// agentLogClosedTTL is how long ended sessions of a closed window stay
+// readable by window ID.
+const agentLogClosedTTL = time.Hour
+
+type agentLogEntry struct {
+	Time  time.Time
+	Kind  string
+	State string // agentstate name, for state entries
+	Text  string
+}
+
+type agentSession struct {
+	PaneID     string
+	WindowID   string
+	WindowName string
+	Command    string
+	Start      time.Time
+	End        time.Time // zero while the agent runs
+	Outcome    string    // done or error when the last turn ended so, else "exited"
+	Entries    []agentLogEntry
+}
+
+func (s *agentSession) add(e agentLogEntry) {
+	s.Entries = append(s.Entries, e)
+	if len(s.Entries) > agentLogMaxEntries {
+		s.Entries = s.Entries[len(s.Entries)-agentLogMaxEntries:]
+	}
+}
+
+// lastState returns the most recent state entry's state, or "".
+func (s *agentSession) lastState() string {
+	for i := len(s.Entries) - 1; i >= 0; i-- {
+		if s.Entries[i].Kind == agentLogState {
+			return s.Entries[i].State
+		}
+	}
+	return ""
+}
+
+// agentPaneObs is what the previous refresh saw on a pane.
+type agentPaneObs struct {
+	state    agentstate.State
+	hasState bool
+	title    string
+	busy     bool
+	input    bool
+}
+
+// agentLog holds the timelines. Own mutex; observe is called under stateMu.
+type agentLog struct {
+	mu     sync.Mutex
+	open   map[string]*agentSession   // pane ID -> running session
+	closed map[string][]*agentSession // pane ID -> ended sessions, oldest first
+	prev   map[string]agentPaneObs
+}
+
+// observe diffs the current panes against the previous refresh and records
+// what changed. keep is the number of sessions kept per pane.
+func (l *agentLog) observe(windows []tmux.Window, now time.Time, keep int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.open == nil {
+		l.open = make(map[string]*agentSession)
+		l.closed = make(map[string][]*agentSession)
+		l.prev = make(map[string]agentPaneObs)
+	}
+	seen := make(map[string]bool)
+	for _, win := range windows {
+		for _, p := range win.Panes {
+			if isAuxiliaryPane(p) {
+				continue
+			}
+			st, hasState := agentstate.Parse(p.AgentState)
+			if !hasState && !tmux.IsAITool(p.Command) {
+				continue
+			}
+			seen[p.ID] = true
+			s := l.open[p.ID]
+			if s == nil {
+				s = &agentSession{PaneID: p.ID, Command: p.Command, Start: now}
+				s.add(agentLogEntry{Time: now, Kind: agentLogStart, Text: p.Command})
+				l.open[p.ID] = s
+				delete(l.prev, p.ID)
+			}
+			s.WindowID, s.WindowName = win.ID, win.Name
+
+			prev := l.prev[p.ID]
+			if hasState && (!prev.hasState || prev.state.Name != st.Name || !prev.state.Since.Equal(st.Since)) {
+				at := st.Since
+				if at.IsZero() {
+					at = now
+				}
+				// Thinking after an idle state means a prompt went in. The
+				// prompt text is there when the hook passed it as --detail.
+				if st.Name == agentstate.Thinking && (!prev.hasState || !prev.state.Busy()) {
+					s.add(agentLogEntry{Time: at, Kind: agentLogPrompt, Text: st.Detail})
+					s.add(agentLogEntry{Time: at, Kind: agentLogState, State: st.Name})
+				} else {
+					s.add(agentLogEntry{Time: at, Kind: agentLogState, State: st.Name, Text: st.Detail})
+				}
+			}
+			if title := strings.TrimSpace(win.AITitle); title != "" && title != prev.title {
+				s.add(agentLogEntry{Time: now, Kind: agentLogTitle, Text: title})
+			}
+			if win.Busy != prev.busy {
+				s.add(agentLogEntry{Time: now, Kind: agentLogBusy, Text: indicatorChange(win.Busy)})
+			}
+			if win.Input != prev.input {
+				s.add(agentLogEntry{Time: now, Kind: agentLogInput, Text: indicatorChange(win.Input)})
+			}
+			l.prev[p.ID] = agentPaneObs{state: st, hasState: hasState, title: strings.TrimSpace(win.AITitle), busy: win.Busy, input: win.Input}
+		}
+	}
+
+	for id, s := range l.open {
+		if seen[id] {
+			continue
+		}
+		s.End = now
+		// A session that ended mid-turn or at a prompt was quit or died.
+		s.Outcome = "exited"
+		if last := s.lastState(); last == agentstate.Done || last == agentstate.Error {
+			s.Outcome = last
+		}
+		s.add(agentLogEntry{Time: now, Kind: agentLogEnd, Text: s.Outcome})
+		if keep < 1 {
+			keep = 1
+		}
+		hist := append(l.closed[id], s)
+		if len(hist) > keep {
+			hist = hist[len(hist)-keep:]
+		}
+		l.closed[id] = hist
+		delete(l.open, id)
+		delete(l.prev, id)
+	}
+}
+
+// indicatorChange is the timeline text of a busy/input indicator flip.
+func indicatorChange(on bool) string {
+	if on {
+		return "set"
+	}
+	return "cleared"
+}
+
+// prune drops the ended sessions of windows that no longer exist once they
+// are older than agentLogClosedTTL.
+func (l *agentLog) prune(windows []tmux.Window, now time.Time) {
+	live := make(map[string]bool, len(windows))
+	for _, win := range windows {
+		live[win.ID] = true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for id, hist := range l.closed {
+		kept := hist[:0]
+		for _, s := range hist {
+			if live[s.WindowID] || now.Sub(s.End) < agentLogClosedTTL {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) == 0 {
+			delete(l.closed, id)
+		} else {
+			l.closed[id] = kept
+		}
+	}
+}
+
+// windowSessions returns copies of the sessions recorded for a window (by
+// window ID, so panes that have since closed are included), oldest first.
+func (l *agentLog) windowSessions(windowID string) []agentSession {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []agentSession
+	collect := func(s *agentSession) {
+		if s.WindowID == windowID {
+			cp := *s
+			cp.Entries = append([]agentLogEntry(nil), s.Entries...)
+			out = append(out, cp)
+		}
+	}
+	for _, hist := range l.closed {
+		for _, s := range hist {
+			collect(s)
+		}
+	}
+	for _, s := range l.open {
+		collect(s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// hasWindow reports whether any session was recorded for the window.
+func (l *agentLog) hasWindow(windowID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.open {
+		if s.WindowID == windowID {
+			return true
+		}
+	}
+	for _, hist := range l.closed {
+		for _, s := range hist {
+			if s.WindowID == windowID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// formatAgentLog renders sessions as text: one block per session, one line
+// per entry, with the time each state lasted.
+func formatAgentLog(sessions []agentSession, now time.Time) string {
+	if len(sessions) == 0 {
+		return "no agent activity recorded for this window\n"
+	}
+	var b strings.Builder
+	for i, s := range sessions {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		end := s.End
+		status := "running"
+		if !end.IsZero() {
+			status = s.Outcome
+		} else {
+			end = now
+		}
+		fmt.Fprintf(&b, "%s  %s  %s  pane %s  %s  %s\n",
+			s.Start.Format("Jan 2 15:04"), s.WindowName, s.Command, s.PaneID,
+			status, cmdtrack.FormatDuration(end.Sub(s.Start)))
+		for j, e := range s.Entries {
+			text := e.Text
+			switch e.Kind {
+			case agentLogState:
+				text = (agentstate.State{Name: e.State}).Label()
+				if e.Text != "" {
+					text += ": " + e.Text
+				}
+				// A state lasts until the next state change or the end.
+				until := end
+				for _, next := range s.Entries[j+1:] {
+					if next.Kind == agentLogState || next.Kind == agentLogEnd {
+						until = next.Time
+						break
+					}
+				}
+				text += "  (" + cmdtrack.FormatDuration(until.Sub(e.Time)) + ")"
+			case agentLogPrompt:
+				if text == "" {
+					text = "prompt submitted"
+				} else {
+					text = fmt.Sprintf("%q", truncate(text, 80))
+				}
+			}
+			fmt.Fprintf(&b, "  %s  %-6s  %s\n", e.Time.Format("15:04:05"), e.Kind, text)
+		}
+	}
+	return b.String()
+}
+
+// agentLogText resolves windowTarget and renders its timeline, for the ctl
+// op and the popup.
+func (c *Coordinator) agentLogText(windowTarget string) (string, error) {
+	c.stateMu.RLock()
+	win := findWindowByTarget(c.windows, windowTarget)
+	var windowID string
+	if win != nil {
+		windowID = win.ID
+	}
+	c.stateMu.RUnlock()
+	if windowID == "" {
+		// A window that has since closed can still be looked up by ID.
+		if !strings.HasPrefix(windowTarget, "@") || !c.agentLog.hasWindow(windowTarget) {
+			return "", fmt.Errorf("no such window: %s", windowTarget)
+		}
+		windowID = windowTarget
+	}
+	return formatAgentLog(c.agentLog.windowSessions(windowID), time.Now()), nil
+}
+
+// showAgentLogPopup opens the window's timeline in a display-popup pager.
+func (c *Coordinator) showAgentLogPopup(windowID string) {
+	popupCmd := fmt.Sprintf("%s agent log %s | less -R +G", c.getTabbyPath(), shellQuote(windowID))
+	go exec.Command("tmux", "display-popup", "-E", "-w", "80%", "-h", "70%",
+		"-T", " Agent log ", "--", popupCmd).Run()
+}
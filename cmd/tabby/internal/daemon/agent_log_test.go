@@ -0,0 +1,119 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/agentstate"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+func agentWin(state string, since time.Time, detail string) tmux.Window {
+	win := testWindow("a", false, "claude")
+	if state != "" {
+		win.Panes[0].AgentState = agentstate.Format(agentstate.State{Name: state, Since: since, Detail: detail})
+	}
+	return win
+}
+
+func TestAgentLogRecordsSession(t *testing.T) {
+	var l agentLog
+	t0 := time.Unix(1_700_000_000, 0)
+
+	l.observe([]tmux.Window{agentWin("", t0, "")}, t0, 5)
+	l.observe([]tmux.Window{agentWin(agentstate.Thinking, t0.Add(time.Second), "fix the tests")}, t0.Add(2*time.Second), 5)
+	l.observe([]tmux.Window{agentWin(agentstate.Tool, t0.Add(10*time.Second), "Bash")}, t0.Add(11*time.Second), 5)
+	titled := agentWin(agentstate.Done, t0.Add(40*time.Second), "")
+	titled.AITitle = "Fix tests"
+	l.observe([]tmux.Window{titled}, t0.Add(41*time.Second), 5)
+	l.observe([]tmux.Window{titled}, t0.Add(42*time.Second), 5)
+	l.observe(nil, t0.Add(60*time.Second), 5)
+
+	sessions := l.windowSessions("@a")
+	require.Len(t, sessions, 1)
+	s := sessions[0]
+	assert.Equal(t, agentstate.Done, s.Outcome)
+	var kinds []string
+	for _, e := range s.Entries {
+		kinds = append(kinds, e.Kind)
+	}
+	assert.Equal(t, []string{agentLogStart, agentLogPrompt, agentLogState, agentLogState, agentLogState, agentLogTitle, agentLogEnd}, kinds)
+	assert.Equal(t, "fix the tests", s.Entries[1].Text)
+	assert.True(t, l.hasWindow("@a"))
+	assert.False(t, l.hasWindow("@b"))
+}
+
+func TestAgentLogOutcomeAndKeep(t *testing.T) {
+	var l agentLog
+	t0 := time.Unix(1_700_000_000, 0)
+	for i := 0; i < 4; i++ {
+		at := t0.Add(time.Duration(i) * time.Minute)
+		l.observe([]tmux.Window{agentWin(agentstate.Tool, at, "")}, at, 2)
+		l.observe(nil, at.Add(time.Second), 2)
+	}
+	sessions := l.windowSessions("@a")
+	require.Len(t, sessions, 2, "only the last sessions per pane are kept")
+	assert.Equal(t, "exited", sessions[1].Outcome, "ended mid-turn")
+	assert.True(t, sessions[1].Start.Equal(t0.Add(3*time.Minute)))
+}
+
+func TestAgentLogIndicatorsAndPrune(t *testing.T) {
+	var l agentLog
+	t0 := time.Unix(1_700_000_000, 0)
+
+	win := agentWin(agentstate.Tool, t0, "")
+	l.observe([]tmux.Window{win}, t0, 5)
+	win.Busy = true
+	l.observe([]tmux.Window{win}, t0.Add(time.Second), 5)
+	win.Busy, win.Input = false, true
+	l.observe([]tmux.Window{win}, t0.Add(2*time.Second), 5)
+	l.observe(nil, t0.Add(3*time.Second), 5)
+
+	sessions := l.windowSessions("@a")
+	require.Len(t, sessions, 1)
+	var got []string
+	for _, e := range sessions[0].Entries {
+		if e.Kind == agentLogBusy || e.Kind == agentLogInput {
+			got = append(got, e.Kind+" "+e.Text)
+		}
+	}
+	assert.Equal(t, []string{"busy set", "busy cleared", "input set"}, got)
+
+	// A closed window's sessions outlive it by agentLogClosedTTL.
+	l.prune(nil, t0.Add(agentLogClosedTTL))
+	assert.True(t, l.hasWindow("@a"))
+	l.prune([]tmux.Window{win}, t0.Add(2*agentLogClosedTTL))
+	assert.True(t, l.hasWindow("@a"), "window still open")
+	l.prune(nil, t0.Add(2*agentLogClosedTTL))
+	assert.False(t, l.hasWindow("@a"))
+	assert.Empty(t, l.closed)
+}
+
+func TestFormatAgentLog(t *testing.T) {
+	assert.Contains(t, formatAgentLog(nil, time.Now()), "no agent activity")
+
+	t0 := time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local)
+	s := agentSession{
+		PaneID: "%1", WindowID: "@1", WindowName: "api", Command: "claude", Start: t0,
+		Entries: []agentLogEntry{
+			{Time: t0, Kind: agentLogStart, Text: "claude"},
+			{Time: t0.Add(5 * time.Second), Kind: agentLogPrompt},
+			{Time: t0.Add(5 * time.Second), Kind: agentLogState, State: agentstate.Thinking},
+			{Time: t0.Add(95 * time.Second), Kind: agentLogState, State: agentstate.Done},
+		},
+	}
+	out := formatAgentLog([]agentSession{s}, t0.Add(2*time.Minute))
+	assert.Contains(t, out, "api  claude  pane %1  running  2m00s")
+	assert.Contains(t, out, "prompt submitted")
+	assert.Contains(t, out, "thinking  (1m30s)")
+	assert.Contains(t, out, "(25s)")
+}
+
+func TestAgentLogTextUnknownWindow(t *testing.T) {
+	c := newTestCoordinator(t)
+	_, err := c.agentLogText("@nope")
+	assert.Error(t, err)
+}
@@ -0,0 +1,160 @@
+package daemon
+
+// agent_state.go draws the AI agent states reported by `tabby hook
+// agent-state` (pkg/agentstate): per-state sidebar icons, time-in-state
+// after the tab name, and the state line in the alerts menu. The states also
+// feed pane.AIBusy / pane.AIInput in processAIToolStates, so notifications,
+// toasts and sinks see them as busy/input/done like any other AI pane.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/brendandebeasi/tabby/pkg/agentstate"
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// agentShellCommands are the commands a pane shows once its agent exited.
+var agentShellCommands = map[string]bool{
+	"bash": true, "zsh": true, "fish": true, "sh": true, "dash": true,
+	"ksh": true, "tcsh": true, "csh": true,
+}
+
+// agentStaleQuietSecs is how long a pane back at its shell must be silent
+// before a busy state is treated as left over from an agent that died.
+const agentStaleQuietSecs = 60
+
+// clearStaleAgentState drops the state of a local pane whose agent has
+// exited back to the shell, and reports whether the pane option should be
+// unset. A busy state is kept while the pane still produces output: a tool
+// the agent runs can briefly own the foreground.
+func clearStaleAgentState(p *tmux.Pane) bool {
+	if p.AgentState == "" || p.Remote || !agentShellCommands[p.Command] {
+		return false
+	}
+	if st, ok := agentstate.Parse(p.AgentState); ok && st.Busy() &&
+		time.Now().Unix()-p.LastActivity < agentStaleQuietSecs {
+		return false
+	}
+	p.AgentState = ""
+	return true
+}
+
+// windowAgentState returns the most urgent agent state among win's content
+// panes, for the window row. Expanded multi-pane windows show states on
+// their pane lines instead.
+func windowAgentState(win tmux.Window) (agentstate.State, bool) {
+	var best agentstate.State
+	found := false
+	panes := 0
+	for _, p := range win.Panes {
+		if isAuxiliaryPane(p) {
+			continue
+		}
+		panes++
+		st, ok := agentstate.Parse(p.AgentState)
+		if ok && (!found || agentstate.Priority(st.Name) > agentstate.Priority(best.Name)) {
+			best, found = st, true
+		}
+	}
+	if panes > 1 && !win.Collapsed {
+		return agentstate.State{}, false
+	}
+	return best, found
+}
+
+// agentIndicator returns the configured style for a state.
+func (c *Coordinator) agentIndicator(name string) config.Indicator {
+	a := c.config.Indicators.Agent
+	switch name {
+	case agentstate.Thinking:
+		return a.Thinking
+	case agentstate.Tool:
+		return a.Tool
+	case agentstate.Permission:
+		return a.Permission
+	case agentstate.Prompt:
+		return a.Prompt
+	case agentstate.Error:
+		return a.Error
+	case agentstate.Done:
+		return a.Done
+	}
+	return config.Indicator{}
+}
+
+// agentIconsEnabled reports whether per-state icons replace busy/input.
+func (c *Coordinator) agentIconsEnabled() bool {
+	return headerBoolDefault(c.config.Indicators.Agent.Enabled)
+}
+
+// agentAlertIcon renders the sidebar icon for st, or "" when the state should
+// not show: a prompt the user has already seen (attention is the unseen-input
+// flag, win.Input or pane.AIInput), or a finished turn on the active window.
+// style builds the row's indicator style for a color.
+func (c *Coordinator) agentAlertIcon(st agentstate.State, attention, active bool, style func(color string) lipgloss.Style) string {
+	if !c.agentIconsEnabled() {
+		return ""
+	}
+	switch st.Name {
+	case agentstate.Prompt:
+		if !attention {
+			return ""
+		}
+	case agentstate.Done:
+		if active {
+			return ""
+		}
+	}
+	ind := c.agentIndicator(st.Name)
+	frames := ind.Frames
+	if len(frames) == 0 && ind.Icon == "" {
+		frames = c.getBusyFrames()
+	}
+	icon := ind.Icon
+	if len(frames) > 0 {
+		icon = frames[c.getSlowSpinnerFrame()%len(frames)]
+	}
+	if icon == "" {
+		return ""
+	}
+	return style(ind.Color).Render(icon)
+}
+
+// agentTimeSuffix returns " 12m" for the tab label, or "" when time-in-state
+// is off or unknown.
+func (c *Coordinator) agentTimeSuffix(st agentstate.State, now time.Time) string {
+	if !headerBoolDefault(c.config.Indicators.Agent.ShowTime) || st.Since.IsZero() {
+		return ""
+	}
+	return " " + agentstate.Elapsed(now.Sub(st.Since))
+}
+
+// agentMenuItems returns the alerts-menu rows for win's agent panes: a
+// disabled status line per pane and one item clearing them all.
+func agentMenuItems(win tmux.Window, now time.Time) []string {
+	var items, clear []string
+	for _, p := range win.Panes {
+		st, ok := agentstate.Parse(p.AgentState)
+		if !ok {
+			continue
+		}
+		line := "Agent " + p.ID + ": " + st.Label()
+		if !st.Since.IsZero() {
+			line += " · " + agentstate.Elapsed(now.Sub(st.Since))
+		}
+		if st.Detail != "" {
+			line += " · " + truncate(st.Detail, 40)
+		}
+		items = append(items, "-"+strings.ReplaceAll(line, "#", "##"), "", "")
+		clear = append(clear, fmt.Sprintf("set-option -p -t %s -u %s", p.ID, agentstate.Option))
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return append(items, "Clear Agent State", "g", strings.Join(clear, " ; "))
+}
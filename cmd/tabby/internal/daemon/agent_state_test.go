@@ -0,0 +1,101 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/agentstate"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+func encodedState(name string, since time.Time) string {
+	return agentstate.Format(agentstate.State{Name: name, Since: since})
+}
+
+func TestProcessAIToolStatesFollowsAgentState(t *testing.T) {
+	c := newTestCoordinator(t)
+	c.config.Indicators.Busy.Enabled = true
+	c.config.Indicators.Input.Enabled = true
+	win := testWindow("a", false, "mystery-agent")
+	win.Panes[0].AgentState = encodedState(agentstate.Tool, time.Now())
+	c.windows = []tmux.Window{win}
+
+	c.processAIToolStates(nil)
+	assert.True(t, c.windows[0].Panes[0].AIBusy, "an unknown command with a reported state is tracked")
+	assert.True(t, c.windows[0].Busy)
+
+	c.windows[0].Panes[0].AgentState = encodedState(agentstate.Permission, time.Now())
+	c.processAIToolStates(nil)
+	assert.False(t, c.windows[0].Panes[0].AIBusy)
+	assert.True(t, c.windows[0].Panes[0].AIInput)
+	assert.True(t, c.windows[0].Input)
+}
+
+func TestClearStaleAgentState(t *testing.T) {
+	now := time.Now()
+	p := tmux.Pane{ID: "%1", Command: "zsh", AgentState: encodedState(agentstate.Done, now)}
+	assert.True(t, clearStaleAgentState(&p), "agent exited to the shell")
+	assert.Empty(t, p.AgentState)
+
+	p = tmux.Pane{ID: "%1", Command: "zsh", LastActivity: now.Unix(), AgentState: encodedState(agentstate.Tool, now)}
+	assert.False(t, clearStaleAgentState(&p), "a tool may own the foreground while busy")
+
+	p = tmux.Pane{ID: "%1", Command: "ssh", Remote: true, AgentState: encodedState(agentstate.Done, now)}
+	assert.False(t, clearStaleAgentState(&p), "remote agents report over OSC")
+
+	p = tmux.Pane{ID: "%1", Command: "claude", AgentState: encodedState(agentstate.Done, now)}
+	assert.False(t, clearStaleAgentState(&p))
+}
+
+func TestWindowAgentStatePicksMostUrgent(t *testing.T) {
+	win := testWindow("a", false, "claude", "codex")
+	win.Panes[0].AgentState = encodedState(agentstate.Thinking, time.Now())
+	win.Panes[1].AgentState = encodedState(agentstate.Permission, time.Now())
+
+	_, ok := windowAgentState(win)
+	assert.False(t, ok, "expanded multi-pane windows show states on pane lines")
+
+	win.Collapsed = true
+	st, ok := windowAgentState(win)
+	require.True(t, ok)
+	assert.Equal(t, agentstate.Permission, st.Name)
+}
+
+func TestAgentAlertIconVisibility(t *testing.T) {
+	c := newTestCoordinator(t)
+	c.config.Indicators.Agent.Done.Icon = "✓"
+	c.config.Indicators.Agent.Prompt.Icon = "?"
+	plain := func(string) lipgloss.Style { return lipgloss.NewStyle() }
+
+	done := agentstate.State{Name: agentstate.Done}
+	assert.Equal(t, "✓", c.agentAlertIcon(done, false, false, plain))
+	assert.Empty(t, c.agentAlertIcon(done, false, true, plain), "finished turn on the active window")
+
+	prompt := agentstate.State{Name: agentstate.Prompt}
+	assert.Equal(t, "?", c.agentAlertIcon(prompt, true, false, plain))
+	assert.Empty(t, c.agentAlertIcon(prompt, false, false, plain), "prompt already seen")
+
+	thinking := agentstate.State{Name: agentstate.Thinking}
+	assert.Contains(t, c.getBusyFrames(), c.agentAlertIcon(thinking, false, true, plain))
+
+	off := false
+	c.config.Indicators.Agent.Enabled = &off
+	assert.Empty(t, c.agentAlertIcon(done, false, false, plain))
+}
+
+func TestAgentMenuItems(t *testing.T) {
+	now := time.Now()
+	win := testWindow("a", false, "claude")
+	assert.Nil(t, agentMenuItems(win, now))
+
+	win.Panes[0].AgentState = agentstate.Format(agentstate.State{Name: agentstate.Tool, Since: now.Add(-3 * time.Minute), Detail: "Bash #1"})
+	items := agentMenuItems(win, now)
+	require.Len(t, items, 6)
+	assert.Equal(t, "-Agent %a-0: running tool · 3m · Bash ##1", items[0])
+	assert.Equal(t, "Clear Agent State", items[3])
+	assert.Contains(t, items[5], "set-option -p -t %a-0 -u @tabby_agent_state")
+}
@@ -0,0 +1,58 @@
+package daemon
+
+// appearance_ctl.go backs `tabby appearance` when a daemon is running: the
+// daemon holds the per-directory appearance memory in c.cwdColors and rewrites
+// cwd-colors.json on every change, so edits must go through it rather than
+// the file (a direct edit would be clobbered by the next save).
+
+import (
+	"fmt"
+
+	"github.com/brendandebeasi/tabby/pkg/appearance"
+)
+
+// exportAppearance renders the in-memory appearance map as an export envelope.
+func (c *Coordinator) exportAppearance() ([]byte, error) {
+	c.cwdColorsMu.RLock()
+	snapshot := make(map[string]CWDColorMapping, len(c.cwdColors))
+	for k, v := range c.cwdColors {
+		snapshot[k] = v
+	}
+	c.cwdColorsMu.RUnlock()
+	return appearance.Encode(snapshot)
+}
+
+// importAppearance merges an export (or a bare cwd-colors.json) into memory
+// and persists the result.
+func (c *Coordinator) importAppearance(data []byte) (added, updated int, err error) {
+	incoming, err := appearance.Decode(data)
+	if err != nil {
+		return 0, 0, err
+	}
+	c.cwdColorsMu.Lock()
+	if c.cwdColors == nil {
+		c.cwdColors = make(map[string]CWDColorMapping)
+	}
+	added, updated = appearance.Merge(c.cwdColors, incoming)
+	c.cwdColorsMu.Unlock()
+	if added+updated > 0 {
+		c.saveCWDColors()
+	}
+	logEvent("APPEARANCE_IMPORT entries=%d added=%d updated=%d", len(incoming), added, updated)
+	return added, updated, nil
+}
+
+// forgetAppearance drops the record for key and persists the result.
+func (c *Coordinator) forgetAppearance(key string) error {
+	key = appearance.NormalizeKey(key)
+	c.cwdColorsMu.Lock()
+	_, ok := c.cwdColors[key]
+	delete(c.cwdColors, key)
+	c.cwdColorsMu.Unlock()
+	if !ok {
+		return fmt.Errorf("no remembered appearance for %s", key)
+	}
+	c.saveCWDColors()
+	logEvent("APPEARANCE_FORGET key=%s", key)
+	return nil
+}
@@ -0,0 +1,255 @@
+package daemon
+
+// calendar.go is the calendar widget: the next widgets.calendar.count events
+// ("Standup in 12m") from local .ics files or directories such as
+// vdirsyncer's output. Nothing is fetched; the files are reparsed when their
+// modification times or sizes change, checked every update_interval seconds
+// in a goroutine, since such a directory can hold thousands of files. The
+// loop's 15s tick only picks the next events from the parsed ones.
+// The countdown turns warn_fg within warn_minutes of the start, and with
+// toast: true a notification is posted when an event starts.
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/ics"
+)
+
+// calendarState is the parsed calendars and the events to show, guarded by
+// stateMu.
+type calendarState struct {
+	checkedAt time.Time // last look at the files
+	signature string    // paths, sizes and mtimes of the files last parsed
+	events    []ics.Event
+	upcoming  []ics.Occurrence
+	tickedAt  time.Time   // previous refresh; events starting since then are announced
+	loading   atomic.Bool // a file check is running
+}
+
+// calendarFiles lists the configured .ics files and a signature that
+// changes when any of them does.
+func calendarFiles(paths []string) ([]string, string) {
+	var files []string
+	var sig strings.Builder
+	for _, p := range paths {
+		found, err := ics.Files(expandWorkingDir(p))
+		if err != nil {
+			coordinatorDebugLog.Printf("calendar: %v", err)
+			continue
+		}
+		for _, f := range found {
+			info, err := os.Stat(f)
+			if err != nil {
+				continue
+			}
+			files = append(files, f)
+			fmt.Fprintf(&sig, "%s|%d|%d\n", f, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return files, sig.String()
+}
+
+// loadCalendars parses the configured files when their signature differs
+// from signature. ok is false when nothing changed.
+func loadCalendars(paths []string, signature string) (events []ics.Event, sig string, ok bool) {
+	files, sig := calendarFiles(paths)
+	if sig == signature {
+		return nil, sig, false
+	}
+	for _, f := range files {
+		evs, err := ics.ParseFile(f)
+		if err != nil {
+			coordinatorDebugLog.Printf("calendar: %s: %v", f, err)
+			continue
+		}
+		events = append(events, evs...)
+	}
+	return events, sig, true
+}
+
+// calendarOccurrences returns the next events as of now, and the ones that
+// started since the previous tick (none on the first).
+func calendarOccurrences(cfg config.CalendarWidget, events []ics.Event, tickedAt, now time.Time) (upcoming, started []ics.Occurrence) {
+	from := now
+	if !tickedAt.IsZero() && tickedAt.Before(now) {
+		from = tickedAt
+	}
+	for _, o := range ics.Between(events, from, now.Add(time.Duration(cfg.Lookahead)*time.Hour)) {
+		if o.AllDay && !cfg.AllDay {
+			continue
+		}
+		if !tickedAt.IsZero() && o.Start.After(tickedAt) && !o.Start.After(now) && !o.AllDay {
+			started = append(started, o)
+		}
+		if o.End.After(now) || o.Start.After(now) {
+			upcoming = append(upcoming, o)
+		}
+	}
+	if len(upcoming) > cfg.Count {
+		upcoming = upcoming[:cfg.Count]
+	}
+	return upcoming, started
+}
+
+// refreshCalendar picks the next events and notifies about the ones that
+// just started. Every update_interval it also starts a goroutine that
+// rereads changed files and renders once they are parsed.
+func (c *Coordinator) refreshCalendar(now time.Time) {
+	cfg := c.config.Widgets.Calendar
+	c.stateMu.Lock()
+	due := now.Sub(c.calendar.checkedAt) >= secondsOr(cfg.UpdateInterval, time.Minute)-widgetTickSlack
+	if due && c.calendar.loading.CompareAndSwap(false, true) {
+		c.calendar.checkedAt = now
+		go c.reloadCalendar(cfg, c.calendar.signature, now)
+	}
+	upcoming, started := calendarOccurrences(cfg, c.calendar.events, c.calendar.tickedAt, now)
+	c.calendar.upcoming, c.calendar.tickedAt = upcoming, now
+	c.stateMu.Unlock()
+
+	if !cfg.Toast {
+		return
+	}
+	for _, o := range started {
+		msg := o.Summary + " is starting"
+		if o.Location != "" {
+			msg += " (" + o.Location + ")"
+		}
+		if err := c.postNotification(msg, ""); err != nil {
+			coordinatorDebugLog.Printf("calendar: notify: %v", err)
+		}
+	}
+}
+
+// reloadCalendar rereads the calendar files off the loop if they changed
+// since signature, and renders with the new events as of now.
+func (c *Coordinator) reloadCalendar(cfg config.CalendarWidget, signature string, now time.Time) {
+	defer c.calendar.loading.Store(false)
+	events, sig, ok := loadCalendars(cfg.Paths, signature)
+	if !ok {
+		return
+	}
+	c.stateMu.Lock()
+	c.calendar.signature, c.calendar.events = sig, events
+	// Announcing stays with the tick; only the shown list is brought up
+	// to date.
+	c.calendar.upcoming, _ = calendarOccurrences(cfg, events, time.Time{}, now)
+	c.stateMu.Unlock()
+	if c.OnRefreshLayout != nil {
+		c.OnRefreshLayout()
+	}
+}
+
+// calendarCountdown formats the time until an occurrence: "now" once it has
+// started, otherwise "in 12m", "in 1h05m" or "in 2d".
+func calendarCountdown(o ics.Occurrence, now time.Time) string {
+	d := o.Start.Sub(now)
+	switch {
+	case d <= 0:
+		return "now"
+	case d < time.Minute:
+		return "in <1m"
+	case d < time.Hour:
+		return fmt.Sprintf("in %dm", int(d.Round(time.Minute)/time.Minute))
+	case d < 24*time.Hour:
+		d = d.Round(time.Minute)
+		return fmt.Sprintf("in %dh%02dm", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	}
+	return fmt.Sprintf("in %dd", int(d/(24*time.Hour)))
+}
+
+// calendarLines returns each shown event's title and countdown, and whether
+// it starts within warn minutes.
+func (c *Coordinator) calendarLines(now time.Time) (titles, countdowns []string, warn []bool) {
+	warnWithin := time.Duration(c.config.Widgets.Calendar.WarnMinutes) * time.Minute
+	for _, o := range c.calendar.upcoming {
+		if !o.End.After(now) && !o.Start.After(now) {
+			continue
+		}
+		title := o.Summary
+		if title == "" {
+			title = "(no title)"
+		}
+		titles = append(titles, title)
+		countdowns = append(countdowns, calendarCountdown(o, now))
+		left := o.Start.Sub(now)
+		warn = append(warn, left > 0 && left <= warnWithin)
+	}
+	return titles, countdowns, warn
+}
+
+// renderCalendarWidget renders one line per upcoming event.
+func (c *Coordinator) renderCalendarWidget(width int) string {
+	cfg := c.config.Widgets.Calendar
+	if !cfg.Enabled {
+		return ""
+	}
+	titles, countdowns, warn := c.calendarLines(time.Now())
+
+	fg := c.getInactiveTextColorWithFallback(cfg.Fg)
+	style := lipgloss.NewStyle()
+	if fg != "" {
+		style = style.Foreground(lipgloss.Color(fg))
+	}
+	if cfg.Bg != "" {
+		style = style.Background(lipgloss.Color(cfg.Bg))
+	}
+	warnStyle := style.Foreground(lipgloss.Color(cfg.WarnFg)).Bold(true)
+
+	var result strings.Builder
+	if len(titles) == 0 {
+		result.WriteString(style.Render(runewidth.Truncate("📅 No upcoming events", width, "…")) + "\n")
+	}
+	for i, title := range titles {
+		// "📅 <title> <countdown>", cutting the title to keep the countdown.
+		room := max(width-runewidth.StringWidth("📅 ")-runewidth.StringWidth(countdowns[i])-1, 1)
+		title = runewidth.Truncate(title, room, "…")
+		countdown := style.Render(countdowns[i])
+		if warn[i] {
+			countdown = warnStyle.Render(countdowns[i])
+		}
+		result.WriteString(style.Render("📅 "+title+" ") + countdown + "\n")
+	}
+
+	return c.wrapWidgetChrome(widgetLayout{
+		Divider: cfg.Divider, DividerFg: cfg.DividerFg,
+		MarginTop: cfg.MarginTop, PaddingTop: cfg.PaddingTop, PaddingBot: cfg.PaddingBot, MarginBot: cfg.MarginBot,
+	}, result.String(), width)
+}
+
+// calendarWidget is the registry entry for the calendar.
+type calendarWidget struct{ widgetBase }
+
+func (calendarWidget) Name() string { return "calendar" }
+
+func (calendarWidget) Config(cfg *config.Config) WidgetConfig {
+	w := cfg.Widgets.Calendar
+	return WidgetConfig{Enabled: w.Enabled, Position: widgetPosition(w.Position, "bottom"), Priority: w.Priority}
+}
+
+// Interval keeps the minute countdown current; the files themselves are
+// only checked every update_interval seconds.
+func (calendarWidget) Interval(cfg *config.Config) time.Duration {
+	if !cfg.Widgets.Calendar.Enabled {
+		return 0
+	}
+	return 15 * time.Second
+}
+
+func (calendarWidget) Refresh(c *Coordinator) { c.refreshCalendar(time.Now()) }
+
+func (calendarWidget) StateHash(c *Coordinator) string {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	titles, countdowns, warn := c.calendarLines(time.Now())
+	return fmt.Sprint(titles, countdowns, warn)
+}
+
+func (calendarWidget) Render(c *Coordinator, width int) string { return c.renderCalendarWidget(width) }
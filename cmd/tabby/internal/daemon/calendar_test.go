@@ -0,0 +1,102 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/ics"
+	"github.com/brendandebeasi/tabby/pkg/notifications"
+	"github.com/brendandebeasi/tabby/pkg/paths"
+)
+
+// writeICS writes one event per (summary, start offset) pair to dir.
+func writeICS(t *testing.T, dir, name string, now time.Time, events map[string]time.Duration) {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\n")
+	for summary, offset := range events {
+		start := now.Add(offset).UTC().Truncate(time.Second)
+		b.WriteString("BEGIN:VEVENT\r\nUID:" + summary + "\r\nSUMMARY:" + summary + "\r\n")
+		b.WriteString("DTSTART:" + start.Format("20060102T150405Z") + "\r\nDURATION:PT30M\r\nEND:VEVENT\r\n")
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(b.String()), 0644))
+}
+
+// waitCalendarLoaded waits for the file check refreshCalendar started.
+func waitCalendarLoaded(t *testing.T, c *Coordinator) {
+	t.Helper()
+	require.Eventually(t, func() bool { return !c.calendar.loading.Load() }, time.Second, time.Millisecond)
+}
+
+func TestCalendarCountdown(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) string { return calendarCountdown(ics.Occurrence{Start: now.Add(d)}, now) }
+	assert.Equal(t, "now", at(-time.Minute))
+	assert.Equal(t, "in <1m", at(30*time.Second))
+	assert.Equal(t, "in 12m", at(12*time.Minute))
+	assert.Equal(t, "in 1h05m", at(65*time.Minute))
+	assert.Equal(t, "in 2d", at(50*time.Hour))
+}
+
+func TestRefreshCalendar(t *testing.T) {
+	t.Setenv("TABBY_STATE_DIR", t.TempDir())
+	paths.ResetForTest()
+	t.Cleanup(paths.ResetForTest)
+
+	dir := t.TempDir()
+	now := time.Now()
+	writeICS(t, dir, "work.ics", now, map[string]time.Duration{
+		"Standup":  3 * time.Minute,
+		"Review":   2 * time.Hour,
+		"Planning": 3 * time.Hour,
+		"Earlier":  -time.Hour,
+	})
+	c := newTestCoordinator(t)
+	c.config.Widgets.Calendar = config.CalendarWidget{
+		Enabled: true, Paths: []string{dir}, Count: 2, Lookahead: 24,
+		WarnMinutes: 5, WarnFg: "#f7768e", UpdateInterval: 60, Toast: true,
+	}
+
+	// The files are parsed off the loop, then rendered.
+	rendered := make(chan struct{}, 1)
+	c.OnRefreshLayout = func() { rendered <- struct{}{} }
+	c.refreshCalendar(now)
+	<-rendered
+	waitCalendarLoaded(t, c)
+	require.Len(t, c.calendar.upcoming, 2)
+	titles, countdowns, warn := c.calendarLines(now)
+	assert.Equal(t, []string{"Standup", "Review"}, titles)
+	assert.Equal(t, []string{"in 3m", "in 2h00m"}, countdowns)
+	assert.Equal(t, []bool{true, false}, warn, "red within warn_minutes")
+
+	lines := strings.Split(stripAnsi(c.renderCalendarWidget(16)), "\n")
+	assert.Equal(t, "📅 Standup in 3m", lines[0])
+	assert.Equal(t, "📅 Rev… in 2h00m", lines[1])
+
+	// Files are rechecked only every update_interval.
+	writeICS(t, dir, "work.ics", now, map[string]time.Duration{"Standup": 3 * time.Minute})
+	c.refreshCalendar(now.Add(30 * time.Second))
+	assert.False(t, c.calendar.loading.Load())
+	assert.Len(t, c.calendar.upcoming, 2)
+	assert.Empty(t, c.sessionNotifications(10))
+
+	// Standup starts before the next refresh: it is announced once.
+	c.refreshCalendar(now.Add(4 * time.Minute))
+	<-rendered
+	waitCalendarLoaded(t, c)
+	require.Len(t, c.calendar.upcoming, 1)
+	assert.Equal(t, "now", calendarCountdown(c.calendar.upcoming[0], now.Add(4*time.Minute)))
+	c.refreshCalendar(now.Add(5 * time.Minute))
+	events := c.sessionNotifications(10)
+	require.Len(t, events, 1)
+	assert.Equal(t, notifications.KindNotify, events[0].Kind)
+	assert.Equal(t, "Standup is starting", events[0].Message)
+}
@@ -0,0 +1,288 @@
+package daemon
+
+// claude_usage.go feeds the Claude widget from Claude Code's JSONL transcripts
+// (pkg/claudeusage) instead of the sqlite3 binary, and attributes each
+// session's usage to the tmux pane that ran it, for the per-window cost after
+// the tab name and the per-group breakdown in the widget.
+//
+// Attribution: a session belongs to a Claude Code pane whose working
+// directory matches the session's cwd and that was running when the session
+// last wrote a message. Once attributed, a session stays with its pane, so a
+// window keeps counting earlier sessions after /clear or a restart of claude.
+// When several Claude panes share a directory, the most recently active pane
+// takes the most recent session.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/claudeusage"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// claudeAttributeGrace is how long before a pane was first seen running
+// claude a session may have last written and still be attributed to it; it
+// covers the refresh interval between claude starting and the daemon noticing.
+const claudeAttributeGrace = 2 * time.Minute
+
+// RefreshClaudeUsage ingests new transcript lines and re-attributes sessions
+// to panes. Like RefreshTeamClaude it returns immediately: the file reads run
+// in a coalesced goroutine, throttled to the widget's update_interval, which
+// triggers a render when the totals change.
+func (c *Coordinator) RefreshClaudeUsage() {
+	cfg := c.config.Widgets.Claude
+	if !cfg.Enabled && !cfg.ShowWindowCost {
+		return
+	}
+	interval := time.Duration(cfg.UpdateInterval) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	c.stateMu.RLock()
+	scannedAt := c.claudeUsageAt
+	c.stateMu.RUnlock()
+	if !scannedAt.IsZero() && time.Since(scannedAt) < interval {
+		return
+	}
+	if !c.claudeUsageScanning.CompareAndSwap(false, true) {
+		return
+	}
+
+	root := cfg.TranscriptsDir
+	if root == "" {
+		root = claudeusage.DefaultRoot()
+	} else if strings.HasPrefix(root, "~/") {
+		home, _ := os.UserHomeDir()
+		root = filepath.Join(home, root[2:])
+	}
+	go func() {
+		defer c.claudeUsageScanning.Store(false)
+		if c.claudeTracker == nil || c.claudeTracker.Root != root {
+			c.claudeTracker = claudeusage.NewTracker(root)
+		}
+		changed, err := c.claudeTracker.Scan()
+		if err != nil {
+			coordinatorDebugLog.Printf("claude usage: scan %s: %v", root, err)
+		}
+		sessions := c.claudeTracker.Sessions()
+		now := time.Now()
+
+		c.stateMu.Lock()
+		c.claudeUsageAt = now
+		if changed || c.claudeSessions == nil {
+			c.claudeSessions = make(map[string]claudeusage.Session, len(sessions))
+			for _, s := range sessions {
+				c.claudeSessions[s.ID] = s
+			}
+		}
+		attributed := c.attributeClaudeSessionsLocked(sessions, now)
+		c.stateMu.Unlock()
+
+		if (changed || attributed) && c.OnRefreshLayout != nil {
+			c.OnRefreshLayout()
+		}
+	}()
+}
+
+// attributeClaudeSessionsLocked assigns unattributed sessions to the Claude
+// panes running in their cwd and reports whether any were assigned. sessions
+// are most recently active first. Caller must hold stateMu.
+func (c *Coordinator) attributeClaudeSessionsLocked(sessions []claudeusage.Session, now time.Time) bool {
+	if c.claudePaneSessions == nil {
+		c.claudePaneSessions = make(map[string][]string)
+		c.claudePaneSeen = make(map[string]time.Time)
+	}
+
+	exists := make(map[string]bool)
+	byCwd := make(map[string][]tmux.Pane) // claude panes by working directory
+	for _, win := range c.windows {
+		for _, p := range win.Panes {
+			exists[p.ID] = true
+			if p.Remote || isAuxiliaryPane(p) || !tmux.IsClaudeCommand(p.Command) || p.CurrentPath == "" {
+				delete(c.claudePaneSeen, p.ID)
+				continue
+			}
+			if _, ok := c.claudePaneSeen[p.ID]; !ok {
+				c.claudePaneSeen[p.ID] = now
+			}
+			cwd := filepath.Clean(p.CurrentPath)
+			byCwd[cwd] = append(byCwd[cwd], p)
+		}
+	}
+	owned := make(map[string]bool)
+	for pane, ids := range c.claudePaneSessions {
+		if !exists[pane] {
+			delete(c.claudePaneSessions, pane)
+			continue
+		}
+		for _, id := range ids {
+			owned[id] = true
+		}
+	}
+	for pane := range c.claudePaneSeen {
+		if !exists[pane] {
+			delete(c.claudePaneSeen, pane)
+		}
+	}
+
+	assigned := false
+	for cwd, panes := range byCwd {
+		sort.SliceStable(panes, func(i, j int) bool { return panes[i].LastActivity > panes[j].LastActivity })
+		taken := make(map[string]bool)
+		for _, s := range sessions {
+			if owned[s.ID] || s.Cwd == "" || filepath.Clean(s.Cwd) != cwd {
+				continue
+			}
+			for _, p := range panes {
+				// With several panes in one directory each takes one new
+				// session per scan; a lone pane takes them all.
+				if len(panes) > 1 && taken[p.ID] {
+					continue
+				}
+				if s.Last.Before(c.claudePaneSeen[p.ID].Add(-claudeAttributeGrace)) {
+					continue
+				}
+				c.claudePaneSessions[p.ID] = append(c.claudePaneSessions[p.ID], s.ID)
+				owned[s.ID], taken[p.ID], assigned = true, true, true
+				break
+			}
+		}
+	}
+	return assigned
+}
+
+// windowClaudeUsage sums the usage of the sessions attributed to win's panes.
+// Caller must hold stateMu.
+func (c *Coordinator) windowClaudeUsage(win tmux.Window) claudeusage.Usage {
+	var u claudeusage.Usage
+	for _, p := range win.Panes {
+		for _, id := range c.claudePaneSessions[p.ID] {
+			if s, ok := c.claudeSessions[id]; ok {
+				u.Add(s.Total)
+			}
+		}
+	}
+	return u
+}
+
+// claudeCostSuffix returns " $1.24" for the tab label, or "" when per-window
+// cost is off or the window has spent nothing.
+func (c *Coordinator) claudeCostSuffix(win tmux.Window) string {
+	if !c.config.Widgets.Claude.ShowWindowCost {
+		return ""
+	}
+	cost := c.windowClaudeUsage(win).Cost
+	if cost < 0.005 {
+		return ""
+	}
+	return fmt.Sprintf(" $%.2f", cost)
+}
+
+// claudeTranscriptStats totals the ingested transcripts for the widget. ok is
+// false when no transcript usage has been found, so the widget can fall back
+// to the legacy database. Caller must hold stateMu.
+func (c *Coordinator) claudeTranscriptStats(now time.Time) (today, week, month, total float64, msgCount int, ok bool) {
+	if len(c.claudeSessions) == 0 {
+		return 0, 0, 0, 0, 0, false
+	}
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	weekStart := todayStart.AddDate(0, 0, -((int(now.Weekday()) + 6) % 7)) // Monday
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	for _, s := range c.claudeSessions {
+		total += s.Total.Cost
+		msgCount += s.Total.Messages
+		for day, u := range s.Days {
+			d, err := time.ParseInLocation("2006-01-02", day, now.Location())
+			if err != nil {
+				continue
+			}
+			if !d.Before(todayStart) {
+				today += u.Cost
+			}
+			if !d.Before(weekStart) {
+				week += u.Cost
+			}
+			if !d.Before(monthStart) {
+				month += u.Cost
+			}
+		}
+	}
+	return today, week, month, total, msgCount, true
+}
+
+// claudeGroupCost is one row of the widget breakdown.
+type claudeGroupCost struct {
+	Group string
+	Cost  float64
+}
+
+// claudeDayBreakdown is one day of the widget breakdown: the day's cost and
+// its split by window group, largest first.
+type claudeDayBreakdown struct {
+	Day    time.Time
+	Cost   float64
+	Groups []claudeGroupCost
+}
+
+// claudeBreakdown splits the last days of usage by the group of the window
+// each session is attributed to; unattributed sessions count as "other".
+// Days without usage are left out. Caller must hold stateMu.
+func (c *Coordinator) claudeBreakdown(now time.Time, days int) []claudeDayBreakdown {
+	groupOfWindow := make(map[string]string)
+	for _, g := range c.grouped {
+		for _, w := range g.Windows {
+			groupOfWindow[w.ID] = g.Name
+		}
+	}
+	groupOfSession := make(map[string]string)
+	for _, win := range c.windows {
+		group := groupOfWindow[win.ID]
+		if group == "" {
+			group = win.Group
+		}
+		for _, p := range win.Panes {
+			for _, id := range c.claudePaneSessions[p.ID] {
+				groupOfSession[id] = group
+			}
+		}
+	}
+
+	var out []claudeDayBreakdown
+	for i := 0; i < days; i++ {
+		day := now.AddDate(0, 0, -i)
+		key := claudeusage.DayKey(day)
+		perGroup := make(map[string]float64)
+		var cost float64
+		for _, s := range c.claudeSessions {
+			u, ok := s.Days[key]
+			if !ok || u.Cost == 0 {
+				continue
+			}
+			group := groupOfSession[s.ID]
+			if group == "" {
+				group = "other"
+			}
+			perGroup[group] += u.Cost
+			cost += u.Cost
+		}
+		if cost == 0 {
+			continue
+		}
+		b := claudeDayBreakdown{Day: day, Cost: cost}
+		for g, v := range perGroup {
+			b.Groups = append(b.Groups, claudeGroupCost{Group: g, Cost: v})
+		}
+		sort.Slice(b.Groups, func(i, j int) bool {
+			if b.Groups[i].Cost != b.Groups[j].Cost {
+				return b.Groups[i].Cost > b.Groups[j].Cost
+			}
+			return b.Groups[i].Group < b.Groups[j].Group
+		})
+		out = append(out, b)
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/claudeusage"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+func claudeSession(id, cwd string, last time.Time, cost float64) claudeusage.Session {
+	u := claudeusage.Usage{Cost: cost, Messages: 1}
+	return claudeusage.Session{ID: id, Cwd: cwd, Last: last, Total: u,
+		Days: map[string]claudeusage.Usage{claudeusage.DayKey(last): u}}
+}
+
+func TestAttributeClaudeSessions(t *testing.T) {
+	c := newTestCoordinator(t)
+	c.config.Widgets.Claude.ShowWindowCost = true
+	now := time.Now()
+	api := testWindow("api", false, "claude")
+	api.Panes[0].CurrentPath = "/src/api/"
+	web := testWindow("web", false, "2.1.17")
+	web.Panes[0].CurrentPath = "/src/web"
+	c.windows = []tmux.Window{api, web}
+
+	sessions := []claudeusage.Session{
+		claudeSession("s3", "/src/api", now, 1.50),
+		claudeSession("s2", "/src/web", now.Add(-time.Minute), 0.25),
+		claudeSession("old", "/src/api", now.Add(-time.Hour), 9),
+		claudeSession("elsewhere", "/src/other", now, 4),
+	}
+	c.claudeSessions = make(map[string]claudeusage.Session)
+	for _, s := range sessions {
+		c.claudeSessions[s.ID] = s
+	}
+
+	require.True(t, c.attributeClaudeSessionsLocked(sessions, now))
+	assert.Equal(t, []string{"s3"}, c.claudePaneSessions["%api-0"], "sessions from before the pane ran are not attributed")
+	assert.Equal(t, []string{"s2"}, c.claudePaneSessions["%web-0"])
+	assert.Equal(t, " $1.50", c.claudeCostSuffix(api))
+	assert.Equal(t, " $0.25", c.claudeCostSuffix(web))
+
+	// A new session in the same pane (/clear) adds to the window.
+	next := claudeSession("s4", "/src/api", now.Add(time.Minute), 0.50)
+	c.claudeSessions["s4"] = next
+	require.True(t, c.attributeClaudeSessionsLocked(append([]claudeusage.Session{next}, sessions...), now))
+	assert.Equal(t, " $2.00", c.claudeCostSuffix(api))
+	assert.False(t, c.attributeClaudeSessionsLocked(sessions, now), "nothing new")
+
+	c.config.Widgets.Claude.ShowWindowCost = false
+	assert.Empty(t, c.claudeCostSuffix(api))
+}
+
+func TestClaudeBreakdownByGroup(t *testing.T) {
+	c := newTestCoordinator(t)
+	now := time.Now()
+	api := testWindow("api", false, "claude")
+	api.Group = "Backend"
+	c.windows = []tmux.Window{api}
+	c.claudePaneSessions = map[string][]string{"%api-0": {"s1"}}
+	c.claudeSessions = map[string]claudeusage.Session{
+		"s1": claudeSession("s1", "/src/api", now, 3),
+		"s2": claudeSession("s2", "/src/x", now, 1),
+		"s0": claudeSession("s0", "/src/x", now.AddDate(0, 0, -10), 7),
+	}
+
+	days := c.claudeBreakdown(now, 3)
+	require.Len(t, days, 1, "days without usage are skipped")
+	assert.Equal(t, 4.0, days[0].Cost)
+	assert.Equal(t, []claudeGroupCost{{"Backend", 3}, {"other", 1}}, days[0].Groups)
+
+	today, _, _, total, msgs, ok := c.claudeTranscriptStats(now)
+	require.True(t, ok)
+	assert.Equal(t, 4.0, today)
+	assert.Equal(t, 11.0, total)
+	assert.Equal(t, 3, msgs)
+}
@@ -0,0 +1,75 @@
+package daemon
+
+// cmd_track.go draws the long-running shell commands reported by the
+// tabby-cmd shell integration (pkg/cmdtrack, scripts/tabby-cmd.sh): a
+// done/failed mark on windows whose command finished unseen (cleared when the
+// window is selected), the last command and its duration in the pane header,
+// and status lines in the alerts menu.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/brendandebeasi/tabby/pkg/cmdtrack"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// cmdAlertIcon renders the sidebar mark for a window's finished command, or
+// "" when there is none or tracking is off.
+func (c *Coordinator) cmdAlertIcon(win tmux.Window) string {
+	ind := c.config.Indicators.Command
+	if !headerBoolDefault(ind.Enabled) {
+		return ""
+	}
+	mark := ind.Done
+	switch win.CmdAlert {
+	case cmdtrack.Done:
+	case cmdtrack.Failed:
+		mark = ind.Failed
+	default:
+		return ""
+	}
+	if mark.Icon == "" {
+		return ""
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(mark.Color)).Render(mark.Icon)
+}
+
+// paneCmdSuffix returns "  ✓ make · 3m12s" for the pane header, or "" when
+// the pane has no recorded command or the header option is off.
+func (c *Coordinator) paneCmdSuffix(p tmux.Pane) string {
+	ind := c.config.Indicators.Command
+	if !headerBoolDefault(ind.Enabled) || !headerBoolDefault(ind.ShowInHeader) {
+		return ""
+	}
+	rec, ok := cmdtrack.Parse(p.LastCmd)
+	if !ok {
+		return ""
+	}
+	mark := ind.Done.Icon
+	if rec.Failed() {
+		mark = ind.Failed.Icon
+	}
+	return "  " + strings.TrimSpace(mark+" "+truncate(rec.Command, 30)+" · "+rec.Status())
+}
+
+// cmdMenuItems returns the alerts-menu rows for win's recorded commands: a
+// disabled line per pane and, when the window is flagged, an item clearing
+// the flag.
+func cmdMenuItems(win tmux.Window) []string {
+	var items []string
+	for _, p := range win.Panes {
+		rec, ok := cmdtrack.Parse(p.LastCmd)
+		if !ok {
+			continue
+		}
+		line := "Last command " + p.ID + ": " + truncate(rec.Command, 30) + " · " + rec.Status()
+		items = append(items, "-"+strings.ReplaceAll(line, "#", "##"), "", "")
+	}
+	if win.CmdAlert != "" {
+		items = append(items, "Clear Command Alert", "d", fmt.Sprintf("set-window-option -t :%d -u %s", win.Index, cmdtrack.AlertOption))
+	}
+	return items
+}
@@ -0,0 +1,50 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/cmdtrack"
+)
+
+func TestCmdAlertIcon(t *testing.T) {
+	c := newTestCoordinator(t)
+	c.config.Indicators.Command.Done.Icon = "D"
+	c.config.Indicators.Command.Failed.Icon = "F"
+	win := testWindow("a", false, "zsh")
+	assert.Empty(t, c.cmdAlertIcon(win))
+
+	win.CmdAlert = cmdtrack.Failed
+	assert.Contains(t, c.cmdAlertIcon(win), "F")
+	win.CmdAlert = cmdtrack.Done
+	assert.Contains(t, c.cmdAlertIcon(win), "D")
+
+	off := false
+	c.config.Indicators.Command.Enabled = &off
+	assert.Empty(t, c.cmdAlertIcon(win))
+}
+
+func TestPaneCmdSuffixAndMenu(t *testing.T) {
+	c := newTestCoordinator(t)
+	c.config.Indicators.Command.Failed.Icon = "✗"
+	win := testWindow("a", false, "zsh")
+	assert.Empty(t, c.paneCmdSuffix(win.Panes[0]))
+	assert.Empty(t, cmdMenuItems(win))
+
+	start := time.Unix(1_700_000_000, 0)
+	win.Panes[0].LastCmd = cmdtrack.Format(cmdtrack.Record{Command: "make #all", Exit: 2, Start: start, End: start.Add(75 * time.Second)})
+	win.CmdAlert = cmdtrack.Failed
+	assert.Equal(t, "  ✗ make #all · 1m15s · exit 2", c.paneCmdSuffix(win.Panes[0]))
+
+	items := cmdMenuItems(win)
+	require.Len(t, items, 6)
+	assert.Equal(t, "-Last command %a-0: make ##all · 1m15s · exit 2", items[0])
+	assert.Equal(t, "Clear Command Alert", items[3])
+
+	off := false
+	c.config.Indicators.Command.ShowInHeader = &off
+	assert.Empty(t, c.paneCmdSuffix(win.Panes[0]))
+}
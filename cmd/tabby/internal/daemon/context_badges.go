@@ -0,0 +1,404 @@
+package daemon
+
+// context_badges.go shows which cluster or account each window talks to
+// (sidebar.context_badges): the kube context/namespace, AWS profile and
+// chosen env vars of the window's active pane, as a badge after the tab name
+// such as " ⎈prod-eu/payments ☁admin". While a program runs in the pane its
+// environment is read from /proc (Linux). At the prompt, /proc only has the
+// shell's environment from when it started, so a later `export
+// KUBECONFIG=...` would not show: the tabby-cmd shell integration reports the
+// variables at each prompt instead (OSC 7700 tabby-env, stored as the pane
+// option @tabby_env). A shell without it falls back to /proc and its badge
+// ends in "?", as it may be stale. kubectl config files are reread when they
+// change.
+//
+// sidebar.context_badges.rules color a window whose badge matches a glob:
+// the tab's left edge is drawn in the rule's color and, with pane_border,
+// so are the window's native pane borders.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/kubeconfig"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// contextBadgeInterval throttles the environment scan.
+const contextBadgeInterval = 3 * time.Second
+
+// contextBadgeStale marks a badge read from an idle shell's startup
+// environment.
+const contextBadgeStale = "?"
+
+// readPaneEnviron returns the environment of the foreground process under a
+// pane's shell, and whether that process is the shell itself; a variable for
+// tests.
+var readPaneEnviron = func(shellPID int) (map[string]string, bool) {
+	pid := shellPID
+	// Follow the newest child down to the foreground program (bounded, in
+	// case of a cycle from pid reuse).
+	for i := 0; i < 8; i++ {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/task/%d/children", pid, pid))
+		if err != nil {
+			break
+		}
+		kids := strings.Fields(string(data))
+		if len(kids) == 0 {
+			break
+		}
+		child, err := strconv.Atoi(kids[len(kids)-1])
+		if err != nil {
+			break
+		}
+		pid = child
+	}
+	if pid != shellPID {
+		if env := procEnviron(pid); env != nil {
+			return env, false
+		}
+	}
+	return procEnviron(shellPID), true
+}
+
+// parseShellEnv decodes a pane's @tabby_env ("NAME=value" joined by \x1f),
+// or returns nil when the shell never reported one.
+func parseShellEnv(s string) map[string]string {
+	if s == "" {
+		return nil
+	}
+	env := map[string]string{}
+	for _, kv := range strings.Split(s, "\x1f") {
+		if k, v, ok := strings.Cut(kv, "="); ok && k != "" {
+			env[k] = v
+		}
+	}
+	return env
+}
+
+// procEnviron reads /proc/<pid>/environ, or nil when it can't.
+func procEnviron(pid int) map[string]string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+	if err != nil {
+		return nil
+	}
+	env := map[string]string{}
+	for _, kv := range strings.Split(string(data), "\x00") {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	return env
+}
+
+// contextBadge is one window's badge.
+type contextBadge struct {
+	values []string // kube "ctx/ns", AWS profile, env values, as shown
+	text   string   // " ⎈ctx/ns ☁profile value", "?" appended when stale
+	rule   *config.ContextBadgeRule
+}
+
+// contextBadgePane is the pane a window's badge is read from.
+type contextBadgePane struct {
+	pid      int
+	shellEnv string // @tabby_env
+}
+
+// kubeCacheEntry is the parsed current context of a set of kubeconfig files.
+type kubeCacheEntry struct {
+	sig       string
+	context   string
+	namespace string
+}
+
+// contextBadgeState is the badges per window. Own mutex; never take stateMu
+// while holding it.
+type contextBadgeState struct {
+	mu         sync.Mutex
+	badges     map[string]contextBadge   // window ID -> badge
+	kube       map[string]kubeCacheEntry // KUBECONFIG value -> parsed
+	scannedAt  time.Time
+	reported   string // the panes' @tabby_env values at the last scan
+	envVars    string // TABBY_ENV_VARS last set in the tmux environment
+	refreshing atomic.Bool
+}
+
+// contextBadgePanesLocked snapshots the active content pane of each local
+// window. Caller holds stateMu.
+func (c *Coordinator) contextBadgePanesLocked() map[string]contextBadgePane {
+	if !c.config.Sidebar.ContextBadges.Enabled {
+		return nil
+	}
+	panes := make(map[string]contextBadgePane, len(c.windows))
+	for _, win := range c.windows {
+		if win.RemoteHost != "" || hasRemoteContentPane(win) {
+			continue
+		}
+		for _, p := range win.Panes {
+			if isAuxiliaryPane(p) || p.PID == 0 {
+				continue
+			}
+			if panes[win.ID].pid == 0 || p.Active {
+				panes[win.ID] = contextBadgePane{pid: p.PID, shellEnv: p.ShellEnv}
+			}
+		}
+	}
+	return panes
+}
+
+// contextBadgeReported joins the panes' @tabby_env values, to notice a
+// report between scans.
+func contextBadgeReported(panes map[string]contextBadgePane) string {
+	ids := make([]string, 0, len(panes))
+	for id := range panes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var b strings.Builder
+	for _, id := range ids {
+		fmt.Fprintf(&b, "%s=%s\n", id, panes[id].shellEnv)
+	}
+	return b.String()
+}
+
+// refreshContextBadges rescans the panes' environments in the background,
+// at most every contextBadgeInterval or right after a shell reported new
+// variables, and renders when a badge changed.
+func (c *Coordinator) refreshContextBadges(panes map[string]contextBadgePane) {
+	if panes == nil {
+		return
+	}
+	s := &c.contextBadges
+	s.mu.Lock()
+	due := time.Since(s.scannedAt) >= contextBadgeInterval || s.reported != contextBadgeReported(panes)
+	s.mu.Unlock()
+	if !due || !s.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+	cfg := c.config.Sidebar.ContextBadges
+	go func() {
+		defer s.refreshing.Store(false)
+		c.exportContextEnvVars(cfg)
+		if c.scanContextBadges(cfg, panes) && c.OnRefreshLayout != nil {
+			c.OnRefreshLayout()
+		}
+	}()
+}
+
+// exportContextEnvVars puts sidebar.context_badges.env in the tmux global
+// environment as TABBY_ENV_VARS. The shell integration always reports
+// KUBECONFIG and the AWS profile variables; shells started from then on
+// report these too.
+func (c *Coordinator) exportContextEnvVars(cfg config.ContextBadges) {
+	names := strings.Join(cfg.Env, " ")
+	s := &c.contextBadges
+	s.mu.Lock()
+	same := s.envVars == names
+	s.envVars = names
+	s.mu.Unlock()
+	if same {
+		return
+	}
+	if names == "" {
+		tmuxRun("set-environment", "-gu", "TABBY_ENV_VARS")
+		return
+	}
+	tmuxRun("set-environment", "-g", "TABBY_ENV_VARS", names)
+}
+
+// paneContextEnv is the environment a pane's badge is built from: a running
+// program's own, else the variables the shell reported at its last prompt
+// over its startup environment. stale is true when an idle shell never
+// reported any.
+func paneContextEnv(p contextBadgePane) (env map[string]string, stale bool) {
+	env, atShell := readPaneEnviron(p.pid)
+	if env == nil {
+		env = map[string]string{}
+	}
+	if !atShell {
+		return env, false
+	}
+	reported := parseShellEnv(p.shellEnv)
+	if reported == nil {
+		return env, true
+	}
+	for k, v := range reported {
+		if v == "" {
+			delete(env, k)
+		} else {
+			env[k] = v
+		}
+	}
+	return env, false
+}
+
+// scanContextBadges reads every window's badge and reports whether any
+// changed.
+func (c *Coordinator) scanContextBadges(cfg config.ContextBadges, panes map[string]contextBadgePane) bool {
+	s := &c.contextBadges
+	badges := make(map[string]contextBadge, len(panes))
+	for id, p := range panes {
+		env, stale := paneContextEnv(p)
+		if b := c.buildContextBadge(cfg, env); b.text != "" {
+			if stale {
+				b.text += contextBadgeStale
+			}
+			badges[id] = b
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scannedAt = time.Now()
+	s.reported = contextBadgeReported(panes)
+	changed := len(badges) != len(s.badges)
+	for id, b := range badges {
+		if old, ok := s.badges[id]; !ok || old.text != b.text || old.rule != b.rule && (old.rule == nil || b.rule == nil || *old.rule != *b.rule) {
+			changed = true
+		}
+	}
+	s.badges = badges
+	return changed
+}
+
+// buildContextBadge turns a pane's environment into its badge.
+func (c *Coordinator) buildContextBadge(cfg config.ContextBadges, env map[string]string) contextBadge {
+	var b contextBadge
+	var parts []string
+	if headerBoolDefault(cfg.Kube) {
+		if ctx, ns := c.kubeContext(env["KUBECONFIG"]); ctx != "" {
+			v := ctx
+			if ns != "" && ns != "default" {
+				v += "/" + ns
+			}
+			b.values = append(b.values, ctx, ns, v)
+			parts = append(parts, "⎈"+v)
+		}
+	}
+	if headerBoolDefault(cfg.AWS) {
+		profile := env["AWS_PROFILE"]
+		if profile == "" {
+			profile = env["AWS_VAULT"]
+		}
+		if profile == "" {
+			profile = env["AWS_DEFAULT_PROFILE"]
+		}
+		if profile != "" {
+			b.values = append(b.values, profile)
+			parts = append(parts, "☁"+profile)
+		}
+	}
+	for _, name := range cfg.Env {
+		if v := strings.TrimSpace(env[name]); v != "" {
+			b.values = append(b.values, v)
+			parts = append(parts, v)
+		}
+	}
+	if len(parts) == 0 {
+		return contextBadge{}
+	}
+	b.rule = matchContextBadgeRule(cfg.Rules, b.values)
+	icon := ""
+	if b.rule != nil && strings.TrimSpace(b.rule.Icon) != "" {
+		icon = strings.TrimSpace(b.rule.Icon)
+	}
+	b.text = " " + strings.TrimSpace(icon+" "+strings.Join(parts, " "))
+	return b
+}
+
+// matchContextBadgeRule returns the first rule whose glob matches any value.
+func matchContextBadgeRule(rules []config.ContextBadgeRule, values []string) *config.ContextBadgeRule {
+	for i := range rules {
+		pat := strings.ToLower(strings.TrimSpace(rules[i].Match))
+		if pat == "" {
+			continue
+		}
+		for _, v := range values {
+			if v == "" {
+				continue
+			}
+			if ok, _ := filepath.Match(pat, strings.ToLower(v)); ok {
+				r := rules[i]
+				return &r
+			}
+		}
+	}
+	return nil
+}
+
+// kubeContext returns the current kube context and namespace for a
+// KUBECONFIG value, reparsing the files only when they changed.
+func (c *Coordinator) kubeContext(kubeconfigEnv string) (string, string) {
+	home, _ := os.UserHomeDir()
+	paths := kubeconfig.Paths(kubeconfigEnv, home)
+	var sig strings.Builder
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil {
+			fmt.Fprintf(&sig, "%s|%d|%d\n", p, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	s := &c.contextBadges
+	s.mu.Lock()
+	entry, ok := s.kube[kubeconfigEnv]
+	s.mu.Unlock()
+	if ok && entry.sig == sig.String() {
+		return entry.context, entry.namespace
+	}
+	entry = kubeCacheEntry{sig: sig.String()}
+	entry.context, entry.namespace = kubeconfig.Current(paths)
+	s.mu.Lock()
+	if s.kube == nil {
+		s.kube = map[string]kubeCacheEntry{}
+	}
+	s.kube[kubeconfigEnv] = entry
+	s.mu.Unlock()
+	return entry.context, entry.namespace
+}
+
+// contextBadgeFor returns a window's badge, if it has one.
+func (c *Coordinator) contextBadgeFor(winID string) (contextBadge, bool) {
+	if !c.config.Sidebar.ContextBadges.Enabled {
+		return contextBadge{}, false
+	}
+	s := &c.contextBadges
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.badges[winID]
+	return b, ok
+}
+
+// contextBadgeSuffix returns the badge for the tab label, or "".
+func (c *Coordinator) contextBadgeSuffix(win tmux.Window) string {
+	b, _ := c.contextBadgeFor(win.ID)
+	return b.text
+}
+
+// contextBadgeEdge returns the tab's left edge: a bar in the matching rule's
+// color, or a space.
+func (c *Coordinator) contextBadgeEdge(win tmux.Window) string {
+	b, ok := c.contextBadgeFor(win.ID)
+	if !ok || b.rule == nil || strings.TrimSpace(b.rule.Color) == "" {
+		return " "
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(strings.TrimSpace(b.rule.Color))).Render("▌")
+}
+
+// contextBorderColor returns the pane border color a matching rule with
+// pane_border sets for the window, or "".
+func (c *Coordinator) contextBorderColor(winID string) string {
+	b, ok := c.contextBadgeFor(winID)
+	if !ok || b.rule == nil || !b.rule.PaneBorder {
+		return ""
+	}
+	return strings.TrimSpace(b.rule.Color)
+}
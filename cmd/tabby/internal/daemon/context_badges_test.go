@@ -0,0 +1,115 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+func TestContextBadges(t *testing.T) {
+	dir := t.TempDir()
+	kube := func(name, ctx, ns string) string {
+		p := filepath.Join(dir, name)
+		data := "current-context: " + ctx + "\ncontexts:\n- name: " + ctx + "\n  context:\n    cluster: c\n    namespace: " + ns + "\n"
+		require.NoError(t, os.WriteFile(p, []byte(data), 0644))
+		return p
+	}
+	prod := kube("prod", "prod-eu", "payments")
+	staging := kube("staging", "staging", "default")
+
+	envs := map[int]map[string]string{
+		101: {"KUBECONFIG": prod, "AWS_PROFILE": "admin"},
+		102: {"KUBECONFIG": staging, "DEPLOY_ENV": "qa"},
+		103: {"KUBECONFIG": filepath.Join(dir, "missing")},
+	}
+	orig := readPaneEnviron
+	// 101 and 102 run a program; 103 and 104 sit at the prompt.
+	readPaneEnviron = func(pid int) (map[string]string, bool) { return envs[pid], pid > 102 }
+	t.Cleanup(func() { readPaneEnviron = orig })
+
+	c := newTestCoordinator(t)
+	c.config.Sidebar.ContextBadges = config.ContextBadges{
+		Enabled: true,
+		Env:     []string{"DEPLOY_ENV"},
+		Rules: []config.ContextBadgeRule{
+			{Match: "*PROD*", Color: "#ff0000", Icon: "🔥", PaneBorder: true},
+			{Match: "qa", Color: "#e0af68"},
+		},
+	}
+	win := func(name string, pid int) tmux.Window {
+		w := testWindow(name, false, "zsh")
+		w.ID = "@" + name
+		w.Panes[0].PID = pid
+		return w
+	}
+	c.windows = []tmux.Window{win("a", 101), win("b", 102), win("c", 103)}
+
+	changed := c.scanContextBadges(c.config.Sidebar.ContextBadges, c.contextBadgePanesLocked())
+	assert.True(t, changed)
+	assert.Equal(t, " 🔥 ⎈prod-eu/payments ☁admin", c.contextBadgeSuffix(c.windows[0]))
+	assert.Equal(t, " ⎈staging qa", c.contextBadgeSuffix(c.windows[1]), "default namespace omitted")
+	assert.Empty(t, c.contextBadgeSuffix(c.windows[2]), "no kube context")
+
+	assert.Equal(t, "#ff0000", c.contextBorderColor("@a"))
+	assert.Empty(t, c.contextBorderColor("@b"), "rule without pane_border")
+	assert.Equal(t, "▌", stripAnsi(c.contextBadgeEdge(c.windows[1])))
+	assert.Equal(t, " ", c.contextBadgeEdge(c.windows[2]))
+
+	assert.False(t, c.scanContextBadges(c.config.Sidebar.ContextBadges, c.contextBadgePanesLocked()), "nothing changed")
+
+	// Switching context in the kubeconfig is picked up on the next scan.
+	kube("staging", "prod-us-east", "default")
+	assert.True(t, c.scanContextBadges(c.config.Sidebar.ContextBadges, c.contextBadgePanesLocked()))
+	assert.Equal(t, " 🔥 ⎈prod-us-east qa", c.contextBadgeSuffix(c.windows[1]))
+
+	c.config.Sidebar.ContextBadges.Enabled = false
+	assert.Empty(t, c.contextBadgeSuffix(c.windows[0]))
+	assert.Nil(t, c.contextBadgePanesLocked())
+}
+
+func TestContextBadgesIdleShell(t *testing.T) {
+	dir := t.TempDir()
+	kube := func(name, ctx string) string {
+		p := filepath.Join(dir, name)
+		data := "current-context: " + ctx + "\ncontexts:\n- name: " + ctx + "\n  context:\n    cluster: c\n"
+		require.NoError(t, os.WriteFile(p, []byte(data), 0644))
+		return p
+	}
+	staging := kube("staging", "staging")
+	prod := kube("prod", "prod")
+
+	// Both shells started with staging and are idle at the prompt.
+	orig := readPaneEnviron
+	readPaneEnviron = func(pid int) (map[string]string, bool) {
+		return map[string]string{"KUBECONFIG": staging, "AWS_PROFILE": "staging"}, true
+	}
+	t.Cleanup(func() { readPaneEnviron = orig })
+
+	c := newTestCoordinator(t)
+	c.config.Sidebar.ContextBadges = config.ContextBadges{Enabled: true}
+	reported := testWindow("a", false, "zsh")
+	reported.ID = "@a"
+	reported.Panes[0].PID = 201
+	reported.Panes[0].ShellEnv = "KUBECONFIG=" + prod + "\x1fAWS_PROFILE=\x1fAWS_VAULT=\x1fAWS_DEFAULT_PROFILE="
+	silent := testWindow("b", false, "zsh")
+	silent.ID = "@b"
+	silent.Panes[0].PID = 202
+	c.windows = []tmux.Window{reported, silent}
+
+	panes := c.contextBadgePanesLocked()
+	assert.True(t, c.scanContextBadges(c.config.Sidebar.ContextBadges, panes))
+	assert.Equal(t, " ⎈prod", c.contextBadgeSuffix(reported), "exported at the prompt, AWS_PROFILE unset")
+	assert.Equal(t, " ⎈staging ☁staging?", c.contextBadgeSuffix(silent), "startup environment only")
+
+	// A new report makes the next refresh rescan without waiting out the
+	// interval.
+	assert.Equal(t, c.contextBadges.reported, contextBadgeReported(panes))
+	c.windows[1].Panes[0].ShellEnv = "KUBECONFIG=" + prod
+	assert.NotEqual(t, c.contextBadges.reported, contextBadgeReported(c.contextBadgePanesLocked()))
+}
@@ -27,6 +27,10 @@ import (
 	"github.com/muesli/termenv"
 	"github.com/rivo/uniseg"
 
+	"github.com/brendandebeasi/tabby/pkg/agentstate"
+	"github.com/brendandebeasi/tabby/pkg/appearance"
+	"github.com/brendandebeasi/tabby/pkg/claudeusage"
+	"github.com/brendandebeasi/tabby/pkg/cmdtrack"
 	"github.com/brendandebeasi/tabby/pkg/colors"
 	"github.com/brendandebeasi/tabby/pkg/config"
 	"github.com/brendandebeasi/tabby/pkg/daemon"
@@ -34,6 +38,7 @@ import (
 	"github.com/brendandebeasi/tabby/pkg/navtrace"
 	"github.com/brendandebeasi/tabby/pkg/paths"
 	"github.com/brendandebeasi/tabby/pkg/perf"
+	"github.com/brendandebeasi/tabby/pkg/sysstats"
 	"github.com/brendandebeasi/tabby/pkg/teamclaude"
 	"github.com/brendandebeasi/tabby/pkg/tmux"
 )
@@ -64,13 +69,9 @@ var (
 // be resurrected onto freshly-opened tabs; the legacy `name`/`nameSource` fields
 // are stripped from cwd-colors.json on load by migrateCWDColorsDropNames.
 //
-// Persisted to cwd-colors.json; all fields use omitempty for back-compat.
-type CWDColorMapping struct {
-	Color  string `json:"color,omitempty"`
-	Icon   string `json:"icon,omitempty"`
-	Group  string `json:"group,omitempty"`  // saved @tabby_group
-	Pinned bool   `json:"pinned,omitempty"` // saved @tabby_pinned
-}
+// Persisted to cwd-colors.json; the schema (and `tabby appearance`
+// import/export) lives in pkg/appearance.
+type CWDColorMapping = appearance.Record
 
 func init() {
 	// Default to discard (no logging)
@@ -629,7 +630,19 @@ type Coordinator struct {
 	windowVisualPos map[string]int // window ID -> visual position in sidebar
 	config          *config.Config
 	collapsedGroups map[string]bool
-	spinnerFrame    int
+	// groupSync marks groups whose content panes have synchronize-panes on
+	// (header badge). See group_actions.go.
+	groupSync map[string]bool
+	// groupSyncPanes are the content panes tabby turned synchronize-panes on
+	// for, kept in step with groupSync by groupSyncChangesLocked.
+	groupSyncPanes map[string]bool
+	// archivedGroups counts this session's archived windows per group
+	// (header row). See group_archive.go.
+	archivedGroups map[string]int
+	// archivedCache memoizes the holding session listing behind
+	// archivedGroups. Own mutex.
+	archivedCache archivedWindowCache
+	spinnerFrame  int
 
 	// Git state (cached)
 	gitBranch string
@@ -659,6 +672,48 @@ type Coordinator struct {
 	teamClaudeModels    teamclaude.Models
 	teamClaudeModelsErr error
 
+	// Claude Code transcript usage (claude_usage.go). claudeTracker is only
+	// touched by the RefreshClaudeUsage goroutine, which claudeUsageScanning
+	// keeps to one at a time; the rest is read under stateMu.
+	claudeTracker       *claudeusage.Tracker
+	claudeSessions      map[string]claudeusage.Session
+	claudePaneSessions  map[string][]string  // pane ID -> attributed session IDs
+	claudePaneSeen      map[string]time.Time // claude pane -> first seen running
+	claudeUsageAt       time.Time
+	claudeUsageScanning atomic.Bool
+
+	// System stats widget (stats.go). statsReader is only touched by
+	// RefreshStats on the loop goroutine; stats is read under stateMu.
+	statsReader *sysstats.Reader
+	stats       daemon.StatsState
+
+	// widgets.custom command output (custom_widgets.go). Own mutex.
+	customWidgets customWidgetState
+
+	// Built-in widget refresh schedule (widgets.go). Own mutex.
+	widgetSched widgetScheduler
+
+	// Pane pinned to the mirror widget and its last capture (mirror.go).
+	mirror mirrorState
+
+	// Focus timer, synced with focus.json (focus.go). Own mutex.
+	focus focusState
+
+	// Parsed .ics files and the next events (calendar.go).
+	calendar calendarState
+
+	// Per-window git status for tab rows (window_git.go). Own mutex.
+	windowGit windowGitState
+
+	// Kube/cloud context badges for tab rows (context_badges.go). Own mutex.
+	contextBadges contextBadgeState
+
+	// Per-window CPU/RSS for the top widget and tab rows (window_usage.go).
+	windowUsage windowUsageState
+
+	// Todo widget's task file and items (todo.go).
+	todo todoState
+
 	// Pet state
 	pet petState
 	// petIsOwner reports whether THIS daemon currently owns writing the shared
@@ -670,6 +725,26 @@ type Coordinator struct {
 	cwdColors   map[string]CWDColorMapping
 	cwdColorsMu sync.RWMutex
 
+	// Parsed .tabby.yaml per git toplevel, revalidated by mtime. See
+	// project_config.go. Own mutex, like tabAbbrevMu.
+	projectConfigMu sync.Mutex
+	projectConfigs  map[string]projectConfigEntry
+
+	// Indicator-transition history (notifications.go) and the sink and toast
+	// rate limiters (notification_sinks.go, toast.go). Own mutexes.
+	notify       notificationCenter
+	notifySinks  notificationDispatcher
+	toastLimiter notificationDispatcher
+
+	// Per-pane agent session timelines (agent_log.go). Own mutex.
+	agentLog agentLog
+
+	// Output-matcher results per AI pane (output_match.go). Own mutex.
+	outputMatch outputMatchState
+
+	// Windows flagged for a dead pane (crash.go). Own mutex.
+	crash crashState
+
 	// Parsed config.TabNames.Abbreviations (folder basename -> short code),
 	// cached by config pointer identity so it's rebuilt only on config reload.
 	// Guarded by its own mutex (never stateMu) so the render path can look up
@@ -1466,6 +1541,8 @@ func NewCoordinator(sessionID string) *Coordinator {
 		cwdColors:          make(map[string]CWDColorMapping),
 		gitTopCache:        make(map[string]string),
 		collapsedGroups:    make(map[string]bool),
+		groupSync:          make(map[string]bool),
+		archivedGroups:     make(map[string]int),
 		clientWidths:       make(map[string]int),
 		clientHeights:      make(map[string]int),
 		clientPrevWidth:    make(map[string]int),
@@ -1540,6 +1617,9 @@ func NewCoordinator(sessionID string) *Coordinator {
 	// Initial window refresh
 	c.RefreshWindows()
 
+	// Restore per-group synchronize-panes badges (needs c.grouped populated)
+	c.loadGroupSync()
+
 	// Initial git refresh
 	c.RefreshGit()
 
@@ -2287,7 +2367,7 @@ func (c *Coordinator) EnforceStatusExclusivity(sessionID string) {
 
 // HandleWindowSelect performs window-switch housekeeping that was previously
 // done by scripts/on_window_select.sh:
-//   - Clears @tabby_input and @tabby_bell indicators (user acknowledged notification)
+//   - Clears @tabby_input, @tabby_bell and @tabby_cmd_alert indicators (user acknowledged notification)
 //   - Updates global pane-active-border-style to match active window's tab color
 //     when border_from_tab is enabled
 //
@@ -2303,6 +2383,7 @@ func (c *Coordinator) HandleWindowSelect(activeWindowID string) {
 	// Clear AI tool input/bell indicators for the active window
 	exec.Command("tmux", "set-option", "-w", "-t", activeWindowID, "@tabby_input", "").Run()
 	exec.Command("tmux", "set-option", "-w", "-t", activeWindowID, "@tabby_bell", "").Run()
+	exec.Command("tmux", "set-option", "-w", "-t", activeWindowID, "-u", cmdtrack.AlertOption).Run()
 
 	cfg := c.GetConfig()
 	if cfg == nil || !cfg.PaneHeader.BorderFromTab {
@@ -2713,8 +2794,7 @@ func petStatePath() string {
 }
 
 func cwdColorsPath() string {
-	paths.EnsureStateDir()
-	return paths.StatePath("cwd-colors.json")
+	return appearance.Path()
 }
 
 // loadPetState loads pet state from disk (used once at startup for persistence across restarts).
@@ -3200,7 +3280,8 @@ func (c *Coordinator) windowProjectBasename(win tmux.Window) string {
 
 // windowDirCode returns the deterministic project prefix for a window: the
 // configured project_names abbreviation, else the tab_names.abbreviations
-// override, else an auto-derived code (abbreviateFolder) — all derived from the
+// override (each list checked in the project's .tabby.yaml before
+// config.yaml), else an auto-derived code (abbreviateFolder) — all derived from the
 // window's resolved PROJECT DIRECTORY, never from win.Name (a tmux
 // automatic-rename artifact that can be a stale/unrelated label).
 //
@@ -3209,9 +3290,18 @@ func (c *Coordinator) windowProjectBasename(win tmux.Window) string {
 // home/root/unresolved name so such a window shows no prefix.
 func (c *Coordinator) windowDirCode(win tmux.Window) string {
 	if base := c.windowProjectBasename(win); base != "" {
+		// The project's .tabby.yaml entries override config.yaml's for the
+		// same list (project_names, then abbreviations).
+		projName, abbrev := c.projectDirCodes(win, base)
+		if projName != "" {
+			return projName
+		}
 		if code, ok := c.projectNameCode(base); ok && code != "" {
 			return code
 		}
+		if abbrev != "" {
+			return abbrev
+		}
 		return c.tabAbbreviation(base)
 	}
 	name := win.Name
@@ -3739,13 +3829,20 @@ func (c *Coordinator) appearanceKey(win tmux.Window) (string, bool) {
 
 // appearanceRecordFor returns the color/icon a window should take for the given
 // appearance key. A learned per-key mapping (what the user set on that
-// ssh://host/topmost or local project) is authoritative; a configured
-// sidebar.remote_hosts rule for the window's ssh destination fills any field the
-// learned mapping leaves empty. So a hook-less remote host still gets its
-// configured look, a hooked host can use the rule as a default, and a color the
-// user set by hand (or a remembered per-dir color) always wins.
+// ssh://host/topmost or local project) is authoritative; the project's
+// committed .tabby.yaml, then a configured sidebar.remote_hosts rule for the
+// window's ssh destination, fill any field the learned mapping leaves empty. So
+// a hook-less remote host still gets its configured look, a hooked host can use
+// the rule as a default, a repo can ship a team look, and a color the user set
+// by hand (or a remembered per-dir color) always wins.
 func (c *Coordinator) appearanceRecordFor(key string, win tmux.Window) (CWDColorMapping, bool) {
 	rec, recOK := c.getCWDColorMapping(key)
+	// A committed .tabby.yaml at the project root fills what the personal
+	// record leaves empty (team defaults never override a personal choice).
+	if seed, ok := c.projectAppearanceSeed(key); ok {
+		rec = rec.FillFrom(seed)
+		recOK = true
+	}
 	color, icon, group := c.remoteHostAppearance(win.RemoteHost)
 	if color != "" && strings.TrimSpace(rec.Color) == "" {
 		rec.Color = color
@@ -3794,7 +3891,9 @@ func (c *Coordinator) seedWindowAppearance(win *tmux.Window, key string) {
 	// under that host's group. Only when it doesn't already belong to a group — a
 	// cache-restored or user-set @tabby_group always wins — and, like the
 	// color/marker seed above, exactly once (gated by @tabby_color_seeded) so
-	// moving the tab out of the group later makes it stay out.
+	// moving the tab out of the group later makes it stay out. rec.Group is the
+	// remembered group, else the project's .tabby.yaml group, else the
+	// remote_hosts rule's (see appearanceRecordFor).
 	if strings.TrimSpace(win.Group) == "" {
 		g := c.presetGroupForWindow(*win)
 		if g == "" {
@@ -4059,6 +4158,7 @@ func (c *Coordinator) RefreshWindows() {
 			windows = append(windows, pw)
 		}
 	}
+	archivedCounts := archivedGroupCounts(c.cachedArchivedWindows())
 
 	c.applyCWDIdentityMappings(windows)
 
@@ -4075,6 +4175,16 @@ func (c *Coordinator) RefreshWindows() {
 		}
 	}
 
+	// Output matchers capture AI panes with new output in the background
+	// (tmux I/O); a changed result triggers another refresh that applies it.
+	matchCfg := newCfg
+	if matchCfg == nil {
+		matchCfg = c.config
+	}
+	c.refreshOutputMatch(matchCfg, windows)
+	// Dead panes: save their output and flag their windows (crash.go).
+	c.crash.scan(matchCfg, windows)
+
 	prefixModeRaw := ""
 	{
 		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -4095,6 +4205,7 @@ func (c *Coordinator) RefreshWindows() {
 	// We don't reload here to avoid race conditions with toggle_group action
 
 	c.windows = windows
+	c.archivedGroups = archivedCounts
 
 	activeWindowID := tmuxOutputTrimmed("display-message", "-p", "#{window_id}")
 
@@ -4105,11 +4216,21 @@ func (c *Coordinator) RefreshWindows() {
 	// Detect AI tool busy/done/idle states using state transitions.
 	// Collects pending tmux set-option ops for execution after unlock.
 	aiToolOps := c.processAIToolStates(preloadedProcessTree)
+	c.agentLog.observe(c.windows, time.Now(), c.config.AI.AgentLog.Sessions)
+	c.agentLog.prune(c.windows, time.Now())
 
-	c.grouped = grouping.GroupWindowsWithOptions(windows, c.config.Groups, c.config.Sidebar.ShowEmptyGroups)
+	c.grouped = c.buildGroups(windows)
 	c.computeVisualPositions()
 	pendingMoves := c.syncWindowIndices()
 
+	// After grouping, so events carry each window's resolved group.
+	notifyEvents := c.indicatorTransitionsLocked(time.Now())
+	notifyCfg := c.config.Notifications
+	focusCfg := c.config.Widgets.Focus
+	gitCWDs, gitActive := c.windowGitCWDsLocked()
+	badgePanes := c.contextBadgePanesLocked()
+	syncOn, syncOff := c.groupSyncChangesLocked()
+
 	if prefixModeRaw != "" {
 		c.config.Sidebar.PrefixMode = (prefixModeRaw == "1" || prefixModeRaw == "true")
 	}
@@ -4120,6 +4241,15 @@ func (c *Coordinator) RefreshWindows() {
 	colorArgs := c.buildPaneHeaderColorArgs()
 	c.stateMu.Unlock()
 
+	c.recordNotifications(notifyEvents)
+	notifyEvents = c.focusFilter(focusCfg, notifyEvents)
+	c.dispatchNotifications(notifyCfg, notifyEvents)
+	c.toastNotifications(notifyCfg, notifyEvents)
+	c.scheduleWindowGit(gitCWDs, gitActive)
+	c.refreshContextBadges(badgePanes)
+	applyPaneSync(syncOn, "on")
+	applyPaneSync(syncOff, "off")
+
 	// Run the tmux set-option commands outside the lock with a timeout.
 	if len(colorArgs) > 0 {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -4129,6 +4259,14 @@ func (c *Coordinator) RefreshWindows() {
 
 	// Execute deferred AI tool state tmux set-option ops outside the lock.
 	for _, op := range aiToolOps {
+		if op.paneID != "" {
+			if op.unset {
+				tmuxRun("set-option", "-p", "-t", op.paneID, "-u", op.key)
+			} else {
+				tmuxRun("set-option", "-p", "-t", op.paneID, op.key, op.value)
+			}
+			continue
+		}
 		if op.unset {
 			tmuxRun("set-option", "-w", "-t", op.windowID, "-u", op.key)
 		} else {
@@ -4190,7 +4328,7 @@ func (c *Coordinator) SetActiveWindowOptimistic(windowID string) {
 		c.windows[i].Active = (c.windows[i].ID == windowID)
 	}
 	// Re-group so generateSidebarHeader picks up the new active window's colors
-	c.grouped = grouping.GroupWindowsWithOptions(c.windows, c.config.Groups, c.config.Sidebar.ShowEmptyGroups)
+	c.grouped = c.buildGroups(c.windows)
 	c.computeVisualPositions()
 }
 
@@ -4198,6 +4336,7 @@ func (c *Coordinator) SetActiveWindowOptimistic(windowID string) {
 // for deferred execution after the lock is released.
 type tmuxSetOption struct {
 	windowID string
+	paneID   string // set for pane options (-p); windowID is then unused
 	key      string
 	value    string // value to set (ignored when unset=true)
 	unset    bool   // true means use -u flag to unset the option
@@ -4266,13 +4405,18 @@ func (c *Coordinator) processAIToolStates(preloaded *processTree) []tmuxSetOptio
 		}
 		multiPane := contentPaneCount > 1
 
-		// Find all AI tool panes in this window
+		// Find all AI tool panes in this window. A pane whose agent reported
+		// a state (agent_state.go) counts too, even when the agent runs under
+		// a command we don't recognise or on a remote host.
 		var aiPanes []*tmux.Pane
 		for j := range win.Panes {
 			if isAuxiliaryPane(win.Panes[j]) {
 				continue
 			}
-			if tmux.IsAITool(win.Panes[j].Command) {
+			if clearStaleAgentState(&win.Panes[j]) {
+				pending = append(pending, tmuxSetOption{paneID: win.Panes[j].ID, key: agentstate.Option, unset: true})
+			}
+			if tmux.IsAITool(win.Panes[j].Command) || win.Panes[j].AgentState != "" {
 				aiPanes = append(aiPanes, &win.Panes[j])
 			}
 		}
@@ -4396,6 +4540,24 @@ func (c *Coordinator) processAIToolStates(preloaded *processTree) []tmuxSetOptio
 			pid := pane.ID
 			seenPanes[pid] = true
 
+			// === Reported agent state ===
+			// The agent's own hooks know better than any heuristic below.
+			if st, ok := agentstate.Parse(pane.AgentState); ok {
+				wasBusy := c.prevPaneBusy[pid]
+				pane.AIBusy = st.Busy()
+				pane.AIInput = st.NeedsInput()
+				c.prevPaneBusy[pid] = pane.AIBusy
+				c.prevPaneTitle[pid] = pane.Title
+				if pane.AIBusy {
+					delete(c.aiBellUntil, idx)
+				}
+				if wasBusy != pane.AIBusy {
+					coordinatorDebugLog.Printf("[AI] Pane %s (win %d, %s): -> %s (agent-state)",
+						pid, idx, pane.Command, strings.ToUpper(st.Name))
+				}
+				continue
+			}
+
 			hasSpinner := tmux.HasSpinner(pane.Title)
 			hasIdle := tmux.HasIdleIcon(pane.Title)
 
@@ -4424,6 +4586,23 @@ func (c *Coordinator) processAIToolStates(preloaded *processTree) []tmuxSetOptio
 				continue
 			}
 
+			// === Output matchers ===
+			// A configured pattern on the pane's last lines (a permission
+			// prompt, a "working" status line) sets the state directly.
+			if c.applyOutputMatch(pane, now) {
+				wasBusy := c.prevPaneBusy[pid]
+				c.prevPaneBusy[pid] = pane.AIBusy
+				c.prevPaneTitle[pid] = pane.Title
+				if pane.AIBusy {
+					delete(c.aiBellUntil, idx)
+				}
+				if wasBusy != pane.AIBusy {
+					coordinatorDebugLog.Printf("[AI] Pane %s (win %d, %s): busy=%v input=%v (output matcher)",
+						pid, idx, pane.Command, pane.AIBusy, pane.AIInput)
+				}
+				continue
+			}
+
 			// Hook-active bypass: when hooks previously controlled this pane
 			// and now say idle, trust that unless spinner overrides.
 			if c.hookPaneActive[pid] && !win.Busy && !hasSpinner {
@@ -4593,11 +4772,13 @@ func (c *Coordinator) processAIToolStates(preloaded *processTree) []tmuxSetOptio
 	return pending
 }
 
-// processTree holds pre-parsed process table data for CPU-based busy detection.
-// Call loadProcessTree() once per cycle and reuse for all windows.
+// processTree holds pre-parsed process table data for CPU-based busy detection
+// and the top widget's per-window usage. Call loadProcessTree() once per cycle
+// and reuse for all windows.
 type processTree struct {
 	children map[int][]int   // ppid -> child pids
 	cpuByPID map[int]float64 // pid -> cpu%
+	rssByPID map[int]int64   // pid -> resident set size in KiB
 }
 
 // loadProcessTree reads the system process table once. Returns nil on error.
@@ -4607,15 +4788,21 @@ func loadProcessTree() *processTree {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
-	out, err := exec.CommandContext(ctx, "ps", "-A", "-o", "pid=,ppid=,%cpu=").Output()
+	out, err := exec.CommandContext(ctx, "ps", "-A", "-o", "pid=,ppid=,%cpu=,rss=").Output()
 	if err != nil {
 		return nil
 	}
+	return parseProcessTable(string(out))
+}
+
+// parseProcessTable parses `ps -o pid=,ppid=,%cpu=,rss=` output.
+func parseProcessTable(out string) *processTree {
 	pt := &processTree{
 		children: make(map[int][]int),
 		cpuByPID: make(map[int]float64),
+		rssByPID: make(map[int]int64),
 	}
-	for _, line := range strings.Split(string(out), "\n") {
+	for _, line := range strings.Split(out, "\n") {
 		fields := strings.Fields(line)
 		if len(fields) < 3 {
 			continue
@@ -4628,16 +4815,17 @@ func loadProcessTree() *processTree {
 		}
 		pt.children[ppid] = append(pt.children[ppid], pid)
 		pt.cpuByPID[pid] = cpu
+		if len(fields) > 3 {
+			if rss, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+				pt.rssByPID[pid] = rss
+			}
+		}
 	}
 	return pt
 }
 
-// treeCPU returns the total CPU% for a process and all its descendants.
-func (pt *processTree) treeCPU(pid int) float64 {
-	if pt == nil || pid <= 0 {
-		return 0
-	}
-	visited := make(map[int]bool)
+// subtree adds pid and all its descendants to visited.
+func (pt *processTree) subtree(pid int, visited map[int]bool) {
 	queue := []int{pid}
 	for len(queue) > 0 {
 		cur := queue[0]
@@ -4648,6 +4836,15 @@ func (pt *processTree) treeCPU(pid int) float64 {
 		visited[cur] = true
 		queue = append(queue, pt.children[cur]...)
 	}
+}
+
+// treeCPU returns the total CPU% for a process and all its descendants.
+func (pt *processTree) treeCPU(pid int) float64 {
+	if pt == nil || pid <= 0 {
+		return 0
+	}
+	visited := make(map[int]bool)
+	pt.subtree(pid, visited)
 	var total float64
 	for p := range visited {
 		total += pt.cpuByPID[p]
@@ -5325,7 +5522,7 @@ func (c *Coordinator) GetWindowsStructuralHash() string {
 func (c *Coordinator) RefreshGit() {
 	// Run all git commands WITHOUT holding stateMu. Holding stateMu during
 	// network-bound git commands (e.g. @{upstream} fetch) can block for seconds,
-	// causing git_tick to exceed its timeout and kill the daemon.
+	// causing widget_tick to exceed its timeout and stall the loop.
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -7767,6 +7964,7 @@ func (c *Coordinator) listParkedMinimizedWindows() []tmux.Window {
 		strings.Join([]string{
 			"#{window_id}", "#{window_index}", "#{window_name}", "#{@tabby_min_origin}",
 			"#{@tabby_color}", "#{@tabby_group}", "#{@tabby_icon}", "#{@tabby_ai_title}", "#{@tabby_min_dir}", "#{@tabby_min_host}",
+			"#{@tabby_archived}",
 		}, "\t")).Output()
 	if err != nil {
 		return nil
@@ -7783,6 +7981,9 @@ func (c *Coordinator) listParkedMinimizedWindows() []tmux.Window {
 		if strings.TrimSpace(f[3]) != origin {
 			continue // parked by a different user session — not ours to show
 		}
+		if len(f) >= 11 && strings.TrimSpace(f[10]) != "" {
+			continue // archived with its group — shown as the group's archived row
+		}
 		idx, _ := strconv.Atoi(strings.TrimSpace(f[1]))
 		w := tmux.Window{
 			ID:          strings.TrimSpace(f[0]),
@@ -10695,7 +10896,7 @@ func (c *Coordinator) RenderPaneHeaderForClient(clientID string, width, height i
 	if hostName != "" {
 		hostSeg = hostName + ": "
 	}
-	titleSeg := title
+	titleSeg := title + c.paneCmdSuffix(*foundPane)
 	usedW := uniseg.StringWidth(prefixText) + 1 + uniseg.StringWidth(hostSeg) + uniseg.StringWidth(titleSeg)
 	pathSeg := ""
 	if foundPane.CurrentPath != "" {
@@ -11331,12 +11532,13 @@ func (c *Coordinator) dashboardRenderGroups() []grouping.GroupedWindows {
 		snap := c.dashboardOrigins[id]
 		synth = append(synth, tmux.Window{ID: id, Name: snap.Name, Group: snap.Group, Index: snap.Index})
 	}
-	return grouping.GroupWindowsWithOptions(synth, c.config.Groups, c.config.Sidebar.ShowEmptyGroups)
+	return c.buildGroups(synth)
 }
 
 // sidebarRenderGroups returns the group list for the sidebar tab area with every
 // minimized window pulled out of its group into a single synthetic "Minimized"
-// group rendered at the very bottom — visually separating de-prioritised tabs
+// group rendered at the very bottom, and a windowless header row for each
+// archived group that has no live windows left (see group_archive.go) — visually separating de-prioritised tabs
 // from the active ones (they read as a distinct, muted section below the real
 // groups). Display-only: c.grouped (which drives borders, next/prev cycling, the
 // dashboard, etc.) is untouched, and when nothing is minimized the result is
@@ -11365,6 +11567,8 @@ func (c *Coordinator) sidebarRenderGroups() []grouping.GroupedWindows {
 		g.Windows = kept
 		out = append(out, g)
 	}
+	out = appendArchivedGroupRows(out, c.archivedGroups, c.config.Groups)
+	grouping.AssignDefaultColors(out, c.config.Groups, c.groupPaletteOptions())
 	if len(minimized) > 0 {
 		// Order by STABLE window ID, not the holding-session window_index — a
 		// parked/re-parked window's index jumps every move-window, so an Index sort
@@ -11557,6 +11761,12 @@ func (c *Coordinator) generateMainContent(clientID string, width, height int) (s
 		if isCollapsed && len(group.Windows) > 0 {
 			headerText += fmt.Sprintf(" (%d)", len(group.Windows))
 		}
+		if c.groupSync[group.Name] {
+			headerText += " " + groupSyncBadge
+		}
+		if n := c.archivedGroups[group.Name]; n > 0 {
+			headerText += fmt.Sprintf(" archived (%d)", n)
+		}
 		// Always add a space between prefix and group name for consistent alignment.
 		// Icon (if present) is included INSIDE the bg-filled area so backgrounds
 		// align across all groups regardless of icon width.
@@ -11804,7 +12014,20 @@ func (c *Coordinator) generateMainContent(clientID string, width, height int) (s
 			alertIcon := ""
 			ind := c.config.Indicators
 
-			if ind.Busy.Enabled && win.Busy {
+			// A reported agent state (agent_state.go) takes the slot first.
+			agentSt, hasAgent := windowAgentState(win)
+			agentIcon := ""
+			if hasAgent {
+				agentIcon = c.agentAlertIcon(agentSt, win.Input, isActive, func(color string) lipgloss.Style {
+					return indicatorStyle(color, win.Minimized, bgColor, theme.Bg)
+				})
+			}
+
+			if crashIcon := c.crashAlertIcon(win); crashIcon != "" {
+				alertIcon = crashIcon
+			} else if agentIcon != "" {
+				alertIcon = agentIcon
+			} else if ind.Busy.Enabled && win.Busy {
 				alertStyle := indicatorStyle(ind.Busy.Color, win.Minimized, bgColor, theme.Bg)
 
 				busyFrames := c.getBusyFrames()
@@ -11822,7 +12045,9 @@ func (c *Coordinator) generateMainContent(clientID string, width, height int) (s
 					alertIcon = alertStyle.Render(inputIcon)
 				}
 			} else if !isActive {
-				if ind.Bell.Enabled && win.Bell {
+				if cmdIcon := c.cmdAlertIcon(win); cmdIcon != "" {
+					alertIcon = cmdIcon
+				} else if ind.Bell.Enabled && win.Bell {
 					alertStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ind.Bell.Color))
 
 					alertIcon = alertStyle.Render(c.getIndicatorIcon(ind.Bell))
@@ -11841,7 +12066,7 @@ func (c *Coordinator) generateMainContent(clientID string, width, height int) (s
 			if alertIcon != "" {
 				indicatorPart = alertIcon
 			} else {
-				indicatorPart = " "
+				indicatorPart = c.contextBadgeEdge(win)
 			}
 
 			// Window tree branch
@@ -11890,11 +12115,22 @@ func (c *Coordinator) generateMainContent(clientID string, width, height int) (s
 			if hasPanes && isWindowCollapsed {
 				baseContent = fmt.Sprintf("%s (%d)", baseContent, len(contentPanes))
 			}
+			if hasAgent {
+				baseContent += c.agentTimeSuffix(agentSt, time.Now())
+			}
+			baseContent += c.claudeCostSuffix(win)
+			baseContent += c.gitTabSuffix(win)
+			baseContent += c.contextBadgeSuffix(win)
+			baseContent += c.windowUsageSuffix(win)
 
 			// Calculate widths
 			// All windows: indicator(1) + branch first char(1) + [collapse icon or branch second char](1) = 3
 			prefixWidth := 3
 			menuBtnW := 2 // " ⋮"
+			replyPane := c.quickReplyPane(win)
+			if replyPane != "" {
+				menuBtnW += 2 // " ↩"
+			}
 			windowContentWidth := width - prefixWidth - menuBtnW
 
 			// Word-wrap the inline label across up to MaxLines rows. line 1 = the
@@ -11992,8 +12228,12 @@ func (c *Coordinator) generateMainContent(clientID string, width, height int) (s
 					contentRendered = c.applyGradientFill(contentRendered, fromBg, toBg, contentWidth)
 				}
 
-				// Render hamburger menu button with matching background
+				// Render hamburger menu button with matching background,
+				// after the reply button when an agent pane waits for input.
 				menuBtn := lipgloss.NewStyle().Foreground(lipgloss.Color(inactiveFg)).Render(" ⋮")
+				if replyPane != "" {
+					menuBtn = lipgloss.NewStyle().Foreground(lipgloss.Color(c.config.Indicators.Input.Color)).Bold(true).Render(quickReplyButton) + menuBtn
+				}
 				if bgColor != "" {
 					menuBtn = c.applyBackgroundFill(menuBtn, rowEndBg, menuBtnW)
 				}
@@ -12018,6 +12258,17 @@ func (c *Coordinator) generateMainContent(clientID string, width, height int) (s
 			// 1. Left area (indicator + tree branch + collapse icon) -> toggle_panes
 			// 2. Middle area (window name) -> select_window
 			// 3. Right area (menu button) -> window_menu
+			// The reply button goes first: the first matching region wins.
+			if replyPane != "" {
+				regions = append(regions, daemon.ClickableRegion{
+					StartLine: windowStartLine,
+					EndLine:   windowStartLine,
+					StartCol:  width - 4,
+					EndCol:    width - 2,
+					Action:    "agent_reply",
+					Target:    replyPane,
+				})
+			}
 			if hasPanes {
 				collapseColEnd := 5 // covers indicator(1) + tree(2) + icon(1) + space(1)
 				regions = append(regions, daemon.ClickableRegion{
@@ -12130,6 +12381,10 @@ func (c *Coordinator) generateMainContent(clientID string, width, height int) (s
 						paneLabel = pane.Command
 					}
 					paneText := fmt.Sprintf("%s %s", paneNum, paneLabel)
+					paneAgent, paneHasAgent := agentstate.Parse(pane.AgentState)
+					if paneHasAgent {
+						paneText += c.agentTimeSuffix(paneAgent, time.Now())
+					}
 
 					paneIndentWidth := 5
 					paneMenuW := 2
@@ -12155,7 +12410,15 @@ func (c *Coordinator) generateMainContent(clientID string, width, height int) (s
 					// Per-pane alert indicator (busy/input for multi-pane windows)
 					paneAlertIcon := ""
 					pInd := c.config.Indicators
-					if pane.AIBusy && pInd.Busy.Enabled {
+					paneAgentIcon := ""
+					if paneHasAgent {
+						paneAgentIcon = c.agentAlertIcon(paneAgent, pane.AIInput, pane.Active && isActive, func(color string) lipgloss.Style {
+							return lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+						})
+					}
+					if paneAgentIcon != "" {
+						paneAlertIcon = paneAgentIcon
+					} else if pane.AIBusy && pInd.Busy.Enabled {
 						alertStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(pInd.Busy.Color))
 						busyFrames := c.getBusyFrames()
 						paneAlertIcon = alertStyle.Render(busyFrames[c.getSlowSpinnerFrame()%len(busyFrames)])
@@ -12400,7 +12663,20 @@ func (c *Coordinator) generatePrefixModeContent(clientID string, width, height i
 		alertIcon := ""
 		ind := c.config.Indicators
 
-		if ind.Busy.Enabled && win.Busy {
+		// A reported agent state (agent_state.go) takes the slot first.
+		agentSt, hasAgent := windowAgentState(win)
+		agentIcon := ""
+		if hasAgent {
+			agentIcon = c.agentAlertIcon(agentSt, win.Input, isActive, func(color string) lipgloss.Style {
+				return indicatorStyle(color, win.Minimized, bgColor, theme.Bg)
+			})
+		}
+
+		if crashIcon := c.crashAlertIcon(win); crashIcon != "" {
+			alertIcon = crashIcon
+		} else if agentIcon != "" {
+			alertIcon = agentIcon
+		} else if ind.Busy.Enabled && win.Busy {
 			alertStyle := indicatorStyle(ind.Busy.Color, win.Minimized, bgColor, theme.Bg)
 
 			busyFrames := c.getBusyFrames()
@@ -12418,7 +12694,9 @@ func (c *Coordinator) generatePrefixModeContent(clientID string, width, height i
 				alertIcon = alertStyle.Render(inputIcon)
 			}
 		} else if !isActive {
-			if ind.Bell.Enabled && win.Bell {
+			if cmdIcon := c.cmdAlertIcon(win); cmdIcon != "" {
+				alertIcon = cmdIcon
+			} else if ind.Bell.Enabled && win.Bell {
 				alertStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ind.Bell.Color))
 
 				alertIcon = alertStyle.Render(c.getIndicatorIcon(ind.Bell))
@@ -12438,8 +12716,8 @@ func (c *Coordinator) generatePrefixModeContent(clientID string, width, height i
 		if alertIcon != "" {
 			indicatorPart = alertIcon
 		} else {
-			// Use empty space
-			indicatorPart = " "
+			// Empty space, or a context badge rule's colored edge
+			indicatorPart = c.contextBadgeEdge(win)
 		}
 
 		var contentPanes []tmux.Pane
@@ -12478,6 +12756,13 @@ func (c *Coordinator) generatePrefixModeContent(clientID string, width, height i
 		if hasPanes && isWindowCollapsed {
 			baseContent = fmt.Sprintf("%s (%d)", baseContent, len(contentPanes))
 		}
+		if hasAgent {
+			baseContent += c.agentTimeSuffix(agentSt, time.Now())
+		}
+		baseContent += c.claudeCostSuffix(win)
+		baseContent += c.gitTabSuffix(win)
+		baseContent += c.contextBadgeSuffix(win)
+		baseContent += c.windowUsageSuffix(win)
 
 		// Calculate widths
 		prefixWidth := 2 // indicator + space
@@ -12659,6 +12944,10 @@ func (c *Coordinator) generatePrefixModeContent(clientID string, width, height i
 					paneLabel = pane.Command
 				}
 				paneText := fmt.Sprintf("%s %s", paneNum, paneLabel)
+				paneAgent, paneHasAgent := agentstate.Parse(pane.AgentState)
+				if paneHasAgent {
+					paneText += c.agentTimeSuffix(paneAgent, time.Now())
+				}
 
 				paneIndentWidth := 5 // " " + space + branch + connector + connector
 				paneContentWidth := width - paneIndentWidth
@@ -12683,7 +12972,15 @@ func (c *Coordinator) generatePrefixModeContent(clientID string, width, height i
 				// Per-pane alert indicator
 				paneAlertIcon := ""
 				pInd := c.config.Indicators
-				if pane.AIBusy && pInd.Busy.Enabled {
+				paneAgentIcon := ""
+				if paneHasAgent {
+					paneAgentIcon = c.agentAlertIcon(paneAgent, pane.AIInput, pane.Active && isActive, func(color string) lipgloss.Style {
+						return lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+					})
+				}
+				if paneAgentIcon != "" {
+					paneAlertIcon = paneAgentIcon
+				} else if pane.AIBusy && pInd.Busy.Enabled {
 					alertStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(pInd.Busy.Color))
 					busyFrames := c.getBusyFrames()
 					paneAlertIcon = alertStyle.Render(busyFrames[c.getSlowSpinnerFrame()%len(busyFrames)])
@@ -12782,7 +13079,8 @@ type widgetEntry struct {
 	name     string
 	zone     string // "top" or "bottom"
 	priority int
-	content  string // pre-rendered content (may contain zone.Mark markers)
+	content  string       // pre-rendered content (may contain zone.Mark markers)
+	zones    []widgetZone // clickable marks beyond renderWidgetZone's fixed list
 }
 
 // collectWidgetEntries gathers all enabled widgets and action buttons into
@@ -12790,20 +13088,6 @@ type widgetEntry struct {
 func (c *Coordinator) collectWidgetEntries(width int, skipPet, skipDebugBar bool) []widgetEntry {
 	var entries []widgetEntry
 
-	// Clock widget
-	if c.config.Widgets.Clock.Enabled {
-		pos := c.config.Widgets.Clock.Position
-		if pos == "" {
-			pos = "top"
-		}
-		entries = append(entries, widgetEntry{
-			name:     "clock",
-			zone:     pos,
-			priority: c.config.Widgets.Clock.Priority,
-			content:  constrainWidgetWidth(c.renderClockWidget(width), width),
-		})
-	}
-
 	// Pet widget — skip when viewport is too small for all tabs
 	if c.config.Widgets.Pet.Enabled && !skipPet {
 		pos := c.config.Widgets.Pet.Position
@@ -12818,59 +13102,36 @@ func (c *Coordinator) collectWidgetEntries(width int, skipPet, skipDebugBar bool
 		})
 	}
 
-	// Git widget
-	if c.config.Widgets.Git.Enabled {
-		pos := c.config.Widgets.Git.Position
-		if pos == "" {
-			pos = "bottom"
-		}
-		entries = append(entries, widgetEntry{
-			name:     "git",
-			zone:     pos,
-			priority: c.config.Widgets.Git.Priority,
-			content:  constrainWidgetWidth(c.renderGitWidget(width), width),
-		})
-	}
-
-	// Session widget
-	if c.config.Widgets.Session.Enabled {
-		pos := c.config.Widgets.Session.Position
-		if pos == "" {
-			pos = "bottom"
+	// Built-in widgets (widgets.go), in registry order
+	for _, w := range widgetRegistry {
+		wc := w.Config(c.config)
+		if !wc.Enabled {
+			continue
 		}
 		entries = append(entries, widgetEntry{
-			name:     "session",
-			zone:     pos,
-			priority: c.config.Widgets.Session.Priority,
-			content:  constrainWidgetWidth(c.renderSessionWidget(width), width),
+			name:     w.Name(),
+			zone:     wc.Position,
+			priority: wc.Priority,
+			content:  constrainWidgetWidth(w.Render(c, width), width),
 		})
 	}
 
-	// Claude usage widget
-	if c.config.Widgets.Claude.Enabled {
-		pos := c.config.Widgets.Claude.Position
-		if pos == "" {
-			pos = "bottom"
+	// Custom command widgets (custom_widgets.go)
+	for _, w := range c.config.Widgets.Custom {
+		if !headerBoolDefault(w.Enabled) {
+			continue
 		}
-		entries = append(entries, widgetEntry{
-			name:     "claude",
-			zone:     pos,
-			priority: c.config.Widgets.Claude.Priority,
-			content:  constrainWidgetWidth(c.renderClaudeWidget(width), width),
-		})
-	}
-
-	// TeamClaude quota widget
-	if c.config.Widgets.TeamClaude.Enabled {
-		pos := c.config.Widgets.TeamClaude.Position
+		pos := w.Position
 		if pos == "" {
 			pos = "bottom"
 		}
+		content, zones := c.renderCustomWidget(w, width)
 		entries = append(entries, widgetEntry{
-			name:     "teamclaude",
+			name:     "custom:" + w.Name,
 			zone:     pos,
-			priority: c.config.Widgets.TeamClaude.Priority,
-			content:  constrainWidgetWidth(c.renderTeamClaudeWidget(width), width),
+			priority: w.Priority,
+			content:  constrainWidgetWidth(content, width),
+			zones:    zones,
 		})
 	}
 
@@ -12943,12 +13204,12 @@ func (c *Coordinator) renderWidgetZone(entries []widgetEntry, width int) (string
 		"pet:drop_food", "pet:air_high", "pet:air_low", "pet:ground",
 		// Button zones
 		"sidebar:new_tab", "sidebar:new_group", "sidebar:close_tab",
-		// TeamClaude degraded-model warning -> opens popup
-		"teamclaude:open_degraded",
 		// Sidebar zones
 		"sidebar:shrink", "sidebar:grow",
 		"sidebar:prev_window", "sidebar:next_window",
 	}
+	// Built-in widget zones (e.g. teamclaude:open_degraded)
+	knownZones = append(knownZones, widgetZoneIDs()...)
 	var regions []daemon.ClickableRegion
 	for _, zoneID := range knownZones {
 		if info := zone.Get(zoneID); info != nil && !info.IsZero() {
@@ -12968,6 +13229,10 @@ func (c *Coordinator) renderWidgetZone(entries []widgetEntry, width int) (string
 		}
 	}
 
+	for _, entry := range entries {
+		regions = append(regions, customWidgetRegions(entry.zones)...)
+	}
+
 	coordinatorDebugLog.Printf("BubbleZone: extracted %d widget regions from zone", len(regions))
 
 	// Apply safety constraint to the clean content (after markers are stripped)
@@ -13337,15 +13602,19 @@ func (c *Coordinator) renderClaudeWidget(width int) string {
 		result.WriteString("\n")
 	}
 
-	// Get Claude usage data
-	dbPath := claudeCfg.DBPath
-	if dbPath == "" {
-		homeDir, _ := os.UserHomeDir()
-		dbPath = filepath.Join(homeDir, ".claude", "__store.db")
+	// Get Claude usage data: Claude Code's transcripts (claude_usage.go),
+	// or the legacy database when no transcripts have been found.
+	now := time.Now()
+	todayCost, weekCost, monthCost, totalCost, msgCount, ok := c.claudeTranscriptStats(now)
+	if !ok {
+		dbPath := claudeCfg.DBPath
+		if dbPath == "" {
+			homeDir, _ := os.UserHomeDir()
+			dbPath = filepath.Join(homeDir, ".claude", "__store.db")
+		}
+		todayCost, weekCost, monthCost, totalCost, msgCount = c.getClaudeUsageStats(dbPath)
 	}
 
-	todayCost, weekCost, monthCost, totalCost, msgCount := c.getClaudeUsageStats(dbPath)
-
 	// Style for labels and values
 	labelFg := c.getInactiveTextColorWithFallback(claudeCfg.Fg)
 	costFg := claudeCfg.CostFg
@@ -13399,6 +13668,18 @@ func (c *Coordinator) renderClaudeWidget(width int) string {
 	if claudeCfg.ShowMessages {
 		result.WriteString(labelStyle.Render(fmt.Sprintf("  Msgs:  %d", msgCount)) + "\n")
 	}
+	if claudeCfg.ShowBreakdown {
+		days := claudeCfg.BreakdownDays
+		if days <= 0 {
+			days = 3
+		}
+		for _, day := range c.claudeBreakdown(now, days) {
+			result.WriteString(labelStyle.Render("  "+day.Day.Format("Jan 2")+" ") + costStyle.Render(fmt.Sprintf("$%.2f", day.Cost)) + "\n")
+			for _, g := range day.Groups {
+				result.WriteString(labelStyle.Render("    "+g.Group+" ") + costStyle.Render(fmt.Sprintf("$%.2f", g.Cost)) + "\n")
+			}
+		}
+	}
 
 	for i := 0; i < claudeCfg.PaddingBot; i++ {
 		result.WriteString("\n")
@@ -14381,6 +14662,10 @@ func (c *Coordinator) renderPetWidget(width int, skipDebugBar bool) string {
 	if thought == "" {
 		thought = "chillin'."
 	}
+	quiet := c.focusQuietPet()
+	if quiet {
+		thought = "shh, focusing."
+	}
 	thoughtStyle := lipgloss.NewStyle()
 	if petFg != "" {
 		thoughtStyle = thoughtStyle.Foreground(lipgloss.Color(petFg))
@@ -14395,7 +14680,7 @@ func (c *Coordinator) renderPetWidget(width int, skipDebugBar bool) string {
 	// stays stable across renders, and it never appears when QA is opted
 	// out (defensive double-gate; PickQuestion already filters this case).
 	teaserActive := false
-	if c.pet.PendingQuestion != nil && !c.pet.QAOptedOut {
+	if c.pet.PendingQuestion != nil && !c.pet.QAOptedOut && !quiet {
 		n := c.config.Widgets.Pet.QA.TeaserEveryNThoughts
 		if n > 0 {
 			// Cadence: split AnimFrame into ~5s blocks (~50 frames at 10fps).
@@ -15617,6 +15902,11 @@ func (c *Coordinator) handleSemanticAction(clientID string, input *daemon.InputP
 	}
 	logEvent("SEMANTIC_ACTION_CLASS class=%s action=%s client=%s target=%s", actionClass, input.ResolvedAction, clientID, input.ResolvedTarget)
 
+	// Built-in widget zones ("<widget>:<action>", widgets.go)
+	if handled, changed := c.handleWidgetClick(clientID, input.ResolvedAction, input.ResolvedTarget); handled {
+		return changed
+	}
+
 	if strings.HasPrefix(input.ResolvedAction, "window_header:") {
 		sourceWindow := strings.TrimSpace(strings.TrimPrefix(clientID, "window-header:"))
 		// When the full-width phone sidebar is open, any carousel button EXCEPT the
@@ -15915,11 +16205,10 @@ func (c *Coordinator) handleSemanticAction(clientID string, input *daemon.InputP
 		go c.saveCollapsedGroups()
 		return false // No tmux window state change
 
-	case "open_degraded":
-		// Click on the TeamClaude widget's warning icon -> open the
-		// degraded-models popup.
-		c.launchDegradedModelsPopup(clientID)
-		return true
+	case "custom_widget_action":
+		// Click on a widgets.custom line that has an action.
+		c.runCustomWidgetAction(input.ResolvedTarget)
+		return false
 
 	case "button":
 		switch input.ResolvedTarget {
@@ -15953,7 +16242,7 @@ func (c *Coordinator) handleSemanticAction(clientID string, input *daemon.InputP
 				exec.Command("tmux", "display-message", fmt.Sprintf("Error: %v", err)).Run()
 				return false
 			}
-			newGroup := config.DefaultGroupWithIndex(input.ResolvedTarget, len(cfg.Groups))
+			newGroup := config.NewGroup(input.ResolvedTarget, cfg.Groups, c.groupPaletteOptions())
 			if err := config.AddGroup(cfg, newGroup); err != nil {
 				exec.Command("tmux", "display-message", fmt.Sprintf("Error: %v", err)).Run()
 				return false
@@ -16926,6 +17215,103 @@ func (c *Coordinator) handleSemanticAction(clientID string, input *daemon.InputP
 		}
 		return true
 
+	case "group_run":
+		// "Run in All Windows..." from the group menu (tabby hook group-run).
+		if input.ResolvedTarget == "" {
+			return false
+		}
+		if _, err := c.runInGroup(input.ResolvedTarget, input.PickerValue); err != nil {
+			exec.Command("tmux", "display-message", fmt.Sprintf("Error: %v", err)).Run()
+		}
+		return false
+
+	case "toggle_group_sync":
+		if input.ResolvedTarget == "" {
+			return false
+		}
+		on, err := c.toggleGroupSync(input.ResolvedTarget)
+		if err != nil {
+			exec.Command("tmux", "display-message", fmt.Sprintf("Error: %v", err)).Run()
+			return false
+		}
+		if on {
+			exec.Command("tmux", "display-message", "-d", "1500", fmt.Sprintf("Synchronizing panes in %s", input.ResolvedTarget)).Run()
+		} else {
+			exec.Command("tmux", "display-message", "-d", "1500", fmt.Sprintf("Stopped synchronizing panes in %s", input.ResolvedTarget)).Run()
+		}
+		return true
+
+	case "archive_group", "unarchive_group":
+		if input.ResolvedTarget == "" {
+			return false
+		}
+		var n int
+		var err error
+		verb := "Archived"
+		if input.ResolvedAction == "archive_group" {
+			n, err = c.archiveGroup(input.ResolvedTarget)
+		} else {
+			verb = "Restored"
+			n, err = c.unarchiveGroup(input.ResolvedTarget)
+		}
+		if err != nil {
+			exec.Command("tmux", "display-message", fmt.Sprintf("Error: %v", err)).Run()
+			return false
+		}
+		exec.Command("tmux", "display-message", "-d", "1500", fmt.Sprintf("%s %d window(s) in %s", verb, n, input.ResolvedTarget)).Run()
+		// Re-list now: the windows left (or rejoined) the session.
+		c.RefreshWindows()
+		return true
+
+	case "show_notifications":
+		c.showNotificationsMenu(clientID, menuPosition{})
+		return false
+
+	case "agent_log":
+		// "Agent Log..." in the window context menu.
+		if input.ResolvedTarget == "" {
+			return false
+		}
+		c.showAgentLogPopup(input.ResolvedTarget)
+		return true
+
+	case "mirror_pane":
+		// "Mirror in Sidebar" / "Stop Mirroring" in the pane menu.
+		if input.ResolvedTarget == "" {
+			return false
+		}
+		c.toggleMirrorPane(input.ResolvedTarget)
+		return true
+
+	case "view_crash":
+		// "View Crash Output" in the pane and alert menus.
+		if input.ResolvedTarget == "" {
+			return false
+		}
+		c.showCrashOutput(input.ResolvedTarget)
+		return true
+
+	case "agent_reply":
+		// Reply button on a window row, or "Quick Reply..." in a menu.
+		if input.ResolvedTarget == "" {
+			return false
+		}
+		pos := menuPosition{PaneID: input.PaneID, X: input.MouseX, Y: input.MouseY}
+		c.showQuickReplyMenu(clientID, input.ResolvedTarget, pos)
+		return true
+
+	case "project_menu":
+		// A command from the window's .tabby.yaml menu (showWindowContextMenu).
+		idx, err := strconv.Atoi(input.PickerValue)
+		if input.ResolvedTarget == "" || err != nil {
+			return false
+		}
+		if err := c.runProjectMenuItem(input.ResolvedTarget, idx); err != nil {
+			exec.Command("tmux", "display-message", fmt.Sprintf("Error: %v", err)).Run()
+			return false
+		}
+		return true
+
 	case "group_menu":
 		// Hamburger menu on group header -> show group context menu
 		pos := menuPosition{PaneID: input.PaneID, X: input.MouseX, Y: input.MouseY}
@@ -17969,8 +18355,9 @@ parseItems:
 // Pane-header clients (clientID starts with "header:") always use tmux display-menu
 // since the 1-line pane is too small for overlay menus.
 func (c *Coordinator) executeOrSendMenu(clientID string, args []string, pos menuPosition) {
-	// Pane-header clients can't show overlay menus - use tmux display-menu
-	isHeaderClient := isHeaderClient(clientID)
+	// Pane-header clients can't show overlay menus, and `tabby hook` has no
+	// renderer at all - use tmux display-menu
+	isHeaderClient := isHeaderClient(clientID) || daemon.KindOf(clientID) == daemon.TargetHook
 
 	if c.OnSendMenu != nil && !isHeaderClient {
 		title, items := parseTmuxMenuArgs(args)
@@ -18177,7 +18564,7 @@ func (c *Coordinator) setGroupMarkerExact(groupName, marker string) bool {
 	c.stateMu.Lock()
 	c.config = cfg
 	applyContrastConfig(cfg)
-	c.grouped = grouping.GroupWindowsWithOptions(c.windows, c.config.Groups, c.config.Sidebar.ShowEmptyGroups)
+	c.grouped = c.buildGroups(c.windows)
 	c.stateMu.Unlock()
 	return true
 }
@@ -18388,6 +18775,29 @@ func (c *Coordinator) showWindowContextMenu(clientID string, windowTarget string
 	openFinderCmd := "run-shell 'open \"#{pane_current_path}\"'"
 	args = append(args, "Open in Finder", "o", openFinderCmd)
 
+	// Project commands from the window's .tabby.yaml (see project_config.go).
+	// The item index is passed back instead of the command text, so commands
+	// never reach the menu string. Labels and the root name are repo-written
+	// and tmux format-expands menu item names, so their '#' are escaped.
+	items, root, pending := c.projectMenuItems(*win)
+	if root != "" {
+		rootName := strings.ReplaceAll(filepath.Base(root), "#", "##")
+		if pending > 0 {
+			args = append(args, fmt.Sprintf("-%s: %d untrusted (tabby project trust)", rootName, pending), "", "")
+		} else {
+			args = append(args, "-"+rootName, "", "")
+		}
+	}
+	projHook := c.getHookPath()
+	for i, item := range items {
+		runCmd := fmt.Sprintf("run-shell '%s project-menu %s %d'", projHook, wid, i)
+		args = append(args, "  "+strings.ReplaceAll(item.Label, "#", "##"), item.Key, runCmd)
+	}
+
+	if c.agentLog.hasWindow(win.ID) {
+		args = append(args, "Agent Log...", "l", fmt.Sprintf("run-shell '%s agent-log %s'", c.getHookPath(), wid))
+	}
+
 	// --- Destructive ---
 	args = append(args, "", "", "")
 
@@ -18544,6 +18954,17 @@ func (c *Coordinator) showPaneContextMenu(clientID string, paneID string, pos me
 	// Focus this pane
 	focusCmd := fmt.Sprintf("select-window -t :%d ; select-pane -t %s", windowIdx, pane.ID)
 	args = append(args, "Focus", "f", focusCmd)
+	if headerBoolDefault(c.config.AI.QuickReply.Enabled) && quickReplyWaiting(*pane) {
+		args = append(args, "Quick Reply...", "y", fmt.Sprintf("run-shell '%s agent-reply %s'", c.getHookPath(), pane.ID))
+	}
+	args = append(args, c.crashMenuItems(*window, *pane, false)...)
+	if headerBoolDefault(c.config.Widgets.Mirror.Enabled) {
+		mirrorLabel := "Mirror in Sidebar"
+		if c.mirror.paneID == pane.ID {
+			mirrorLabel = "Stop Mirroring"
+		}
+		args = append(args, mirrorLabel, "m", fmt.Sprintf("run-shell '%s mirror-pane %s'", c.getHookPath(), pane.ID))
+	}
 
 	// Break pane to new window (preserving group assignment)
 	breakCmd := fmt.Sprintf("break-pane -s %s", pane.ID)
@@ -18618,7 +19039,10 @@ func (c *Coordinator) showGroupContextMenu(clientID string, groupName string, po
 			break
 		}
 	}
-	if group == nil {
+	if group == nil || (len(group.Windows) == 0 && c.isArchivedGroupLocked(groupName)) {
+		if c.isArchivedGroupLocked(groupName) {
+			c.showArchivedGroupMenuLocked(clientID, groupName, pos)
+		}
 		return
 	}
 
@@ -18672,6 +19096,23 @@ func (c *Coordinator) showGroupContextMenu(clientID string, groupName string, po
 		args = append(args, "Collapse Group", "c", collapseCmd)
 	}
 
+	// --- Group-wide actions (see group_actions.go) ---
+	if len(group.Windows) > 0 {
+		args = append(args, "Run in All Windows...", "R", groupRunPromptCommand(c.getCtlPath(), group.Name))
+		syncLabel := "Synchronize Panes"
+		if c.groupSync[group.Name] {
+			syncLabel = "Stop Synchronizing Panes"
+		}
+		syncCmd := fmt.Sprintf("run-shell '%s toggle-group-sync \\\"%s\\\"'", hookPath, group.Name)
+		args = append(args, syncLabel, "s", syncCmd)
+		archiveCmd := fmt.Sprintf("run-shell '%s archive-group \\\"%s\\\"'", hookPath, group.Name)
+		args = append(args, "Archive Group", "A", archiveCmd)
+	}
+	if c.isArchivedGroupLocked(group.Name) {
+		unarchiveCmd := fmt.Sprintf("run-shell '%s unarchive-group \\\"%s\\\"'", hookPath, group.Name)
+		args = append(args, fmt.Sprintf("Unarchive %d Windows", c.archivedGroups[group.Name]), "u", unarchiveCmd)
+	}
+
 	// --- Group settings section ---
 	args = append(args, "", "", "")
 
@@ -19044,12 +19485,37 @@ func (c *Coordinator) showIndicatorContextMenu(clientID string, windowTarget str
 		args = append(args, "Set Silence", "s", setSilenceCmd)
 	}
 
+	// Agent states reported by `tabby hook agent-state`
+	if items := agentMenuItems(*win, time.Now()); len(items) > 0 {
+		args = append(args, "", "", "")
+		args = append(args, items...)
+	}
+	// Dead panes (crash.go)
+	var crashItems []string
+	for _, p := range win.Panes {
+		crashItems = append(crashItems, c.crashMenuItems(*win, p, true)...)
+	}
+	if len(crashItems) > 0 {
+		args = append(args, "", "", "")
+		args = append(args, crashItems...)
+	}
+
+	// Long-running commands from the tabby-cmd shell integration
+	if items := cmdMenuItems(*win); len(items) > 0 {
+		args = append(args, "", "", "")
+		args = append(args, items...)
+	}
+	if replyPane := c.quickReplyPane(*win); replyPane != "" {
+		args = append(args, "Quick Reply...", "r", fmt.Sprintf("run-shell '%s agent-reply %s'", c.getHookPath(), replyPane))
+	}
+
 	// Separator
 	args = append(args, "", "", "")
 
 	// Clear all indicators
-	clearAllCmd := fmt.Sprintf("set-window-option -t :%d -u @tabby_busy ; set-window-option -t :%d -u @tabby_input ; set-window-option -t :%d -u @tabby_bell ; set-window-option -t :%d -u @tabby_activity ; set-window-option -t :%d -u @tabby_silence", win.Index, win.Index, win.Index, win.Index, win.Index)
+	clearAllCmd := fmt.Sprintf("set-window-option -t :%d -u @tabby_busy ; set-window-option -t :%d -u @tabby_input ; set-window-option -t :%d -u @tabby_bell ; set-window-option -t :%d -u @tabby_activity ; set-window-option -t :%d -u @tabby_silence ; set-window-option -t :%d -u %s", win.Index, win.Index, win.Index, win.Index, win.Index, win.Index, cmdtrack.AlertOption)
 	args = append(args, "Clear All Alerts", "c", clearAllCmd)
+	args = append(args, "Notifications...", "n", fmt.Sprintf("run-shell '%s show-notifications'", c.getHookPath()))
 
 	c.executeOrSendMenu(clientID, args, pos)
 }
@@ -19065,15 +19531,26 @@ func (c *Coordinator) getToggleScript() string {
 	return filepath.Join(filepath.Dir(exe), "tabby") + " toggle"
 }
 
+// getTabbyPath returns the tabby binary next to the running daemon, or plain
+// "tabby" when that can't be resolved. Callers append the subcommand.
+func (c *Coordinator) getTabbyPath() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "tabby"
+	}
+	return filepath.Join(filepath.Dir(exe), "tabby")
+}
+
 // getHookPath returns the `tabby hook` invocation (binary path + subcommand).
 // The standalone tabby-hook binary no longer exists; everything is one binary.
 // Callers append the hook subcommand, e.g. fmt.Sprintf("...'%s kill-pane'", hookPath).
 func (c *Coordinator) getHookPath() string {
-	exe, err := os.Executable()
-	if err != nil {
-		return "tabby hook"
-	}
-	return filepath.Join(filepath.Dir(exe), "tabby") + " hook"
+	return c.getTabbyPath() + " hook"
+}
+
+// getCtlPath is getHookPath for `tabby ctl`.
+func (c *Coordinator) getCtlPath() string {
+	return c.getTabbyPath() + " ctl"
 }
 
 func (c *Coordinator) getScriptPath(name string) string {
@@ -19102,6 +19579,9 @@ func (c *Coordinator) showSidebarSettingsMenu(clientID string, pos menuPosition)
 		"-T", "Sidebar Settings",
 	}, pos.args()...)
 
+	args = append(args, "Notifications...", "n", fmt.Sprintf("run-shell '%s show-notifications'", c.getHookPath()))
+	args = append(args, "", "", "")
+
 	// Position options (restart sidebar to move it)
 	args = append(args, "Position: Left", "l", restartCmd("set-option -g @tabby_sidebar_position left"))
 	args = append(args, "Position: Right", "r", restartCmd("set-option -g @tabby_sidebar_position right"))
@@ -19182,7 +19662,7 @@ func (c *Coordinator) handleKeyInput(clientID string, input *daemon.InputPayload
 			c.stateMu.Lock()
 			c.config = cfg
 			applyContrastConfig(cfg)
-			c.grouped = grouping.GroupWindowsWithOptions(c.windows, c.config.Groups, c.config.Sidebar.ShowEmptyGroups)
+			c.grouped = c.buildGroups(c.windows)
 			c.computeVisualPositions()
 			moves := c.syncWindowIndices()
 			c.stateMu.Unlock()
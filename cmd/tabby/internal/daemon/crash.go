@@ -0,0 +1,283 @@
+package daemon
+
+// crash.go handles panes whose process died. With indicators.crash.keep_failed
+// (off by default) the daemon sets tmux's remain-on-exit to "failed", so a
+// pane that exits non-zero (or is killed by a signal) stays on screen as a
+// dead pane instead of closing; turning it off again restores "off". A user
+// who sets remain-on-exit themselves gets the same handling for their dead
+// panes. With neither, tmux closes a failed pane at once and there is
+// nothing to capture; the README and config.yaml say so.
+//
+// RefreshWindows calls scan before taking stateMu. Each new crashed pane's
+// last lines are saved under the state dir (the path is kept on the pane as
+// @tabby_crash_log) and its window is flagged with @tabby_crash, which the
+// sidebar draws and the notification center records as a crash. A pane that
+// exited cleanly (status 0, no signal) is not a crash. The pane and alert
+// menus offer "View Crash Output" and "Respawn Pane"; the flag is dropped
+// once the window has no crashed pane left.
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/paths"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// crashLogKeep is how many crash output files are kept.
+const crashLogKeep = 50
+
+// crashCapture captures a pane's last lines; a variable for tests.
+var crashCapture = capturePaneText
+
+// crashTmux runs a tmux command; a variable for tests.
+var crashTmux = func(args ...string) ([]byte, error) {
+	return exec.Command("tmux", args...).Output()
+}
+
+// crashState remembers the windows the daemon flagged, so the flag can be
+// dropped once their dead panes are respawned or closed. Own mutex: scan
+// runs outside stateMu.
+type crashState struct {
+	mu         sync.Mutex
+	cfg        *config.Config  // the config remain-on-exit was applied for
+	keptFailed bool            // remain-on-exit "failed" was set by the daemon
+	flagged    map[string]bool // window ID -> @tabby_crash set by the daemon
+}
+
+// crashDir is where crash output is saved.
+func crashDir() string {
+	return paths.StatePath("crashes")
+}
+
+// scan saves the output of newly dead panes and flags or unflags their
+// windows, updating windows in place so this refresh draws the result.
+func (s *crashState) scan(cfg *config.Config, windows []tmux.Window) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cfg == nil {
+		return
+	}
+	ind := cfg.Indicators.Crash
+	if cfg != s.cfg {
+		s.cfg = cfg
+		keep := keepFailedEnabled(cfg)
+		switch {
+		case keep && !s.keptFailed:
+			s.keptFailed = keepFailedPanes()
+		case !keep && s.keptFailed:
+			restoreRemainOnExit()
+			s.keptFailed = false
+		}
+	}
+	if !headerBoolDefault(ind.Enabled) {
+		return
+	}
+	if s.flagged == nil {
+		s.flagged = make(map[string]bool)
+	}
+
+	for i := range windows {
+		win := &windows[i]
+		dead := false
+		for j := range win.Panes {
+			p := &win.Panes[j]
+			if !paneCrashed(*p) {
+				continue
+			}
+			dead = true
+			if p.CrashLog != "" {
+				continue
+			}
+			path, err := saveCrashOutput(*p, ind.Lines, time.Now())
+			if err != nil {
+				coordinatorDebugLog.Printf("crash: save %s: %v", p.ID, err)
+				continue
+			}
+			logEvent("PANE_CRASHED window=%s pane=%s status=%d signal=%d log=%s", win.ID, p.ID, p.DeadStatus, p.DeadSignal, path)
+			crashTmux("set-option", "-p", "-t", p.ID, "@tabby_crash_log", path)
+			crashTmux("set-option", "-w", "-t", win.ID, "@tabby_crash", "1")
+			p.CrashLog = path
+			win.Crash = true
+			s.flagged[win.ID] = true
+		}
+		if !dead && s.flagged[win.ID] {
+			crashTmux("set-option", "-w", "-t", win.ID, "-u", "@tabby_crash")
+			win.Crash = false
+			delete(s.flagged, win.ID)
+		}
+	}
+}
+
+// keepFailedPanes sets the global remain-on-exit to "failed" unless the user
+// already chose a value other than the default, and reports whether it did.
+func keepFailedPanes() bool {
+	out, err := crashTmux("show-options", "-gv", "remain-on-exit")
+	if err != nil || strings.TrimSpace(string(out)) != "off" {
+		return false
+	}
+	_, err = crashTmux("set-option", "-g", "remain-on-exit", "failed")
+	return err == nil
+}
+
+// restoreRemainOnExit undoes keepFailedPanes, unless the user has since
+// changed remain-on-exit themselves.
+func restoreRemainOnExit() {
+	out, err := crashTmux("show-options", "-gv", "remain-on-exit")
+	if err != nil || strings.TrimSpace(string(out)) != "failed" {
+		return
+	}
+	crashTmux("set-option", "-g", "remain-on-exit", "off")
+}
+
+// keepFailedEnabled reports whether indicators.crash.keep_failed is on.
+func keepFailedEnabled(cfg *config.Config) bool {
+	ind := cfg.Indicators.Crash
+	return headerBoolDefault(ind.Enabled) && ind.KeepFailed != nil && *ind.KeepFailed
+}
+
+// keepsFailedPanes reports whether keep_failed is on, so a dead pane still
+// counts as window content. Safe on a nil Coordinator.
+func (c *Coordinator) keepsFailedPanes() bool {
+	if c == nil {
+		return false
+	}
+	c.crash.mu.Lock()
+	defer c.crash.mu.Unlock()
+	return c.crash.cfg != nil && keepFailedEnabled(c.crash.cfg)
+}
+
+// paneCrashed reports whether p is dead from a failure: a non-zero exit
+// status or a signal. A pane kept after a clean exit (remain-on-exit on) is
+// not a crash.
+func paneCrashed(p tmux.Pane) bool {
+	return p.Dead && (p.DeadStatus != 0 || p.DeadSignal != 0)
+}
+
+// crashExitText describes how a dead pane's process ended.
+func crashExitText(p tmux.Pane) string {
+	if p.DeadSignal != 0 {
+		return fmt.Sprintf("killed by signal %d", p.DeadSignal)
+	}
+	return fmt.Sprintf("exited with status %d", p.DeadStatus)
+}
+
+// saveCrashOutput writes a dead pane's last lines, after a short header, to a
+// new file in crashDir and returns its path. Old files beyond crashLogKeep
+// are removed.
+func saveCrashOutput(p tmux.Pane, lines int, now time.Time) (string, error) {
+	dir := crashDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	command := p.StartCommand
+	if command == "" {
+		command = p.Command
+	}
+	name := fmt.Sprintf("%s-pane%s.log", now.Format("20060102-150405"), strings.TrimPrefix(p.ID, "%"))
+	path := filepath.Join(dir, name)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# pane %s: %s\n", p.ID, command)
+	fmt.Fprintf(&b, "# %s at %s\n", crashExitText(p), now.Format(time.RFC3339))
+	if p.CurrentPath != "" {
+		fmt.Fprintf(&b, "# cwd %s\n", p.CurrentPath)
+	}
+	b.WriteString("\n")
+	b.WriteString(strings.TrimRight(crashCapture(p.ID, lines), "\n"))
+	b.WriteString("\n")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return "", err
+	}
+	pruneCrashLogs(dir, crashLogKeep)
+	return path, nil
+}
+
+// pruneCrashLogs removes all but the newest keep files in dir. The names
+// start with a timestamp, so they sort oldest first.
+func pruneCrashLogs(dir string, keep int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".log") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	for len(names) > keep {
+		os.Remove(filepath.Join(dir, names[0]))
+		names = names[1:]
+	}
+}
+
+// crashAlertIcon renders the sidebar mark for a crashed window, or "".
+func (c *Coordinator) crashAlertIcon(win tmux.Window) string {
+	ind := c.config.Indicators.Crash
+	if !headerBoolDefault(ind.Enabled) || ind.Icon == "" || !windowCrashed(win) {
+		return ""
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(ind.Color)).Bold(true).Render(ind.Icon)
+}
+
+// windowCrashed reports whether win is flagged or still has a crashed pane.
+func windowCrashed(win tmux.Window) bool {
+	if win.Crash {
+		return true
+	}
+	for _, p := range win.Panes {
+		if paneCrashed(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// crashMenuItems returns the menu rows for a dead pane: a disabled status
+// line (withStatus), "View Crash Output" when its output was saved, and
+// "Respawn Pane", which restarts the pane's command and clears the flag.
+func (c *Coordinator) crashMenuItems(win tmux.Window, p tmux.Pane, withStatus bool) []string {
+	if !p.Dead {
+		return nil
+	}
+	var items []string
+	if withStatus {
+		line := "Pane " + p.ID + " " + crashExitText(p)
+		items = append(items, "-"+strings.ReplaceAll(line, "#", "##"), "", "")
+	}
+	if p.CrashLog != "" {
+		items = append(items, "View Crash Output", "v", fmt.Sprintf("run-shell '%s view-crash %s'", c.getHookPath(), p.ID))
+	}
+	items = append(items, "Respawn Pane", "R",
+		fmt.Sprintf("respawn-pane -k -t %s ; set-option -p -t %s -u @tabby_crash_log ; set-window-option -t %s -u @tabby_crash", p.ID, p.ID, win.ID))
+	return items
+}
+
+// showCrashOutput opens a dead pane's saved output in a display-popup pager.
+func (c *Coordinator) showCrashOutput(paneID string) {
+	c.stateMu.RLock()
+	path := ""
+	for _, win := range c.windows {
+		for _, p := range win.Panes {
+			if p.ID == paneID {
+				path = p.CrashLog
+			}
+		}
+	}
+	c.stateMu.RUnlock()
+	if path == "" {
+		return
+	}
+	go exec.Command("tmux", "display-popup", "-E", "-w", "80%", "-h", "70%",
+		"-T", " Crash output: "+paneID+" ", "--", "less -R +G "+shellQuote(path)).Run()
+}
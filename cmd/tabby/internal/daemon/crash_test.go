@@ -0,0 +1,175 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/notifications"
+	"github.com/brendandebeasi/tabby/pkg/paths"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// stubCrash points the crash dir at a temp dir and records tmux calls.
+func stubCrash(t *testing.T) *[]string {
+	t.Helper()
+	t.Setenv("TABBY_STATE_DIR", t.TempDir())
+	paths.ResetForTest()
+	t.Cleanup(paths.ResetForTest)
+
+	var calls []string
+	origCapture, origTmux := crashCapture, crashTmux
+	crashCapture = func(paneID string, lines int) string { return "panic: boom\n" }
+	crashTmux = func(args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		if len(args) > 0 && args[0] == "show-options" {
+			return []byte("off\n"), nil
+		}
+		return nil, nil
+	}
+	t.Cleanup(func() { crashCapture, crashTmux = origCapture, origTmux })
+	return &calls
+}
+
+func TestCrashScanFlagsAndUnflags(t *testing.T) {
+	calls := stubCrash(t)
+	c := newTestCoordinator(t)
+	c.config.Indicators.Crash.Lines = 50
+
+	windows := []tmux.Window{testWindow("a", false, "make"), testWindow("b", false, "zsh")}
+	windows[0].Panes[0].Dead = true
+	windows[0].Panes[0].DeadStatus = 2
+	// Kept by the user's own remain-on-exit after a clean exit: no crash.
+	windows[1].Panes[0].Dead = true
+	c.crash.scan(c.config, windows)
+
+	assert.NotContains(t, *calls, "set-option -g remain-on-exit failed", "keep_failed is opt-in")
+	assert.False(t, c.keepsFailedPanes())
+	assert.True(t, windows[0].Crash)
+	assert.False(t, windows[1].Crash)
+	assert.Empty(t, windows[1].Panes[0].CrashLog)
+	assert.False(t, windowCrashed(windows[1]))
+	logPath := windows[0].Panes[0].CrashLog
+	require.NotEmpty(t, logPath)
+	data, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "exited with status 2")
+	assert.Contains(t, string(data), "panic: boom")
+	assert.Contains(t, *calls, "set-option -w -t @a @tabby_crash 1")
+
+	// Already saved: no second capture.
+	n := len(*calls)
+	c.crash.scan(c.config, windows)
+	assert.Len(t, *calls, n)
+
+	// Respawned: the flag is dropped.
+	windows = []tmux.Window{testWindow("a", false, "make")}
+	windows[0].Crash = true
+	c.crash.scan(c.config, windows)
+	assert.False(t, windows[0].Crash)
+	assert.Contains(t, *calls, "set-option -w -t @a -u @tabby_crash")
+}
+
+func TestCrashKeepFailed(t *testing.T) {
+	stubCrash(t)
+	remain := "off"
+	crashTmux = func(args ...string) ([]byte, error) {
+		switch {
+		case args[0] == "show-options":
+			return []byte(remain + "\n"), nil
+		case len(args) == 4 && args[2] == "remain-on-exit":
+			remain = args[3]
+		}
+		return nil, nil
+	}
+	c := newTestCoordinator(t)
+	on := true
+	c.config.Indicators.Crash.KeepFailed = &on
+	c.crash.scan(c.config, nil)
+	assert.Equal(t, "failed", remain)
+	assert.True(t, c.keepsFailedPanes())
+
+	// Turned off again: the daemon's value is reverted.
+	cfg := *c.config
+	off := false
+	cfg.Indicators.Crash.KeepFailed = &off
+	c.crash.scan(&cfg, nil)
+	assert.Equal(t, "off", remain)
+	assert.False(t, c.keepsFailedPanes())
+	assert.False(t, (*Coordinator)(nil).keepsFailedPanes())
+
+	// A value the user chose is left alone.
+	remain = "on"
+	c.crash.scan(c.config, nil)
+	assert.Equal(t, "on", remain)
+}
+
+func TestCrashScanDisabled(t *testing.T) {
+	calls := stubCrash(t)
+	c := newTestCoordinator(t)
+	off := false
+	c.config.Indicators.Crash.Enabled = &off
+
+	windows := []tmux.Window{testWindow("a", false, "make")}
+	windows[0].Panes[0].Dead = true
+	c.crash.scan(c.config, windows)
+	assert.Empty(t, *calls)
+	assert.Empty(t, windows[0].Panes[0].CrashLog)
+}
+
+func TestPruneCrashLogs(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("2026010%d-000000-pane1.log", i)), nil, 0644))
+	}
+	pruneCrashLogs(dir, 2)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "20260103-000000-pane1.log", entries[0].Name())
+}
+
+func TestCrashAlertIconAndMenu(t *testing.T) {
+	c := newTestCoordinator(t)
+	c.config.Indicators.Crash.Icon = "X"
+	win := testWindow("a", false, "make")
+	assert.Empty(t, c.crashAlertIcon(win))
+	assert.Nil(t, c.crashMenuItems(win, win.Panes[0], true))
+
+	win.Panes[0].Dead = true
+	win.Panes[0].DeadSignal = 9
+	assert.Contains(t, c.crashAlertIcon(win), "X")
+
+	items := c.crashMenuItems(win, win.Panes[0], true)
+	require.Len(t, items, 6, "status and respawn; no saved output yet")
+	assert.Equal(t, "-Pane %a-0 killed by signal 9", items[0])
+	assert.Equal(t, "Respawn Pane", items[3])
+	assert.Contains(t, items[5], "respawn-pane -k -t %a-0")
+
+	win.Panes[0].CrashLog = "/tmp/x.log"
+	items = c.crashMenuItems(win, win.Panes[0], false)
+	require.Len(t, items, 6)
+	assert.Equal(t, "View Crash Output", items[0])
+	assert.Contains(t, items[2], "view-crash %a-0")
+}
+
+func TestIndicatorTransitionsRecordCrash(t *testing.T) {
+	c := notifyTestCoordinator(t)
+	now := time.Now()
+	c.windows = []tmux.Window{testWindow("a", false, "make")}
+	c.indicatorTransitionsLocked(now)
+
+	c.windows[0].Panes[0].Dead = true
+	c.windows[0].Panes[0].DeadStatus = 1
+	events := c.indicatorTransitionsLocked(now)
+	require.Len(t, events, 1)
+	assert.Equal(t, notifications.KindCrash, events[0].Kind)
+	assert.Equal(t, "%a-0", events[0].PaneID)
+	assert.Empty(t, c.indicatorTransitionsLocked(now))
+}
@@ -0,0 +1,148 @@
+package daemon
+
+// ctl_handler.go wires `tabby ctl` MsgCtl socket requests onto coordinator
+// operations. Like HandlePetQA it runs synchronously on the server's
+// connection goroutine, so every operation it calls takes stateMu itself and
+// never assumes it is on the event loop.
+
+import (
+	"fmt"
+
+	"github.com/brendandebeasi/tabby/pkg/daemon"
+)
+
+// HandleCtl is the synchronous entry point invoked by server.OnCtl. It always
+// returns a populated response; nil is reserved for "no handler wired".
+func (c *Coordinator) HandleCtl(req *daemon.CtlRequest) *daemon.CtlResponse {
+	if req == nil {
+		return &daemon.CtlResponse{OK: false, Error: "nil request"}
+	}
+	switch req.Op {
+	case "group-run":
+		if len(req.Args) != 2 {
+			return &daemon.CtlResponse{OK: false, Error: "usage: group-run <group> <cmd>"}
+		}
+		n, err := c.runInGroup(req.Args[0], req.Args[1])
+		if err != nil {
+			return &daemon.CtlResponse{OK: false, Error: err.Error()}
+		}
+		return &daemon.CtlResponse{OK: true, Output: fmt.Sprintf("sent to %d window(s) in %s", n, req.Args[0])}
+
+	case "group-run-prompted":
+		if len(req.Args) != 1 {
+			return &daemon.CtlResponse{OK: false, Error: "usage: group-run-prompted <group>"}
+		}
+		n, err := c.runPromptedInGroup(req.Args[0])
+		if err != nil {
+			return &daemon.CtlResponse{OK: false, Error: err.Error()}
+		}
+		return &daemon.CtlResponse{OK: true, Output: fmt.Sprintf("sent to %d window(s) in %s", n, req.Args[0])}
+
+	case "group-sync":
+		if len(req.Args) < 1 {
+			return &daemon.CtlResponse{OK: false, Error: "usage: group-sync <group> [on|off]"}
+		}
+		name := req.Args[0]
+		var on bool
+		var err error
+		switch {
+		case len(req.Args) == 1 || req.Args[1] == "toggle":
+			on, err = c.toggleGroupSync(name)
+		case req.Args[1] == "on" || req.Args[1] == "off":
+			on = req.Args[1] == "on"
+			err = c.setGroupSync(name, on)
+		default:
+			return &daemon.CtlResponse{OK: false, Error: "expected on, off or toggle, got " + req.Args[1]}
+		}
+		if err != nil {
+			return &daemon.CtlResponse{OK: false, Error: err.Error()}
+		}
+		state := "off"
+		if on {
+			state = "on"
+		}
+		return &daemon.CtlResponse{OK: true, Output: fmt.Sprintf("synchronize-panes %s for %s", state, name)}
+
+	case "group-archive", "group-unarchive":
+		if len(req.Args) != 1 {
+			return &daemon.CtlResponse{OK: false, Error: "usage: " + req.Op + " <group>"}
+		}
+		var n int
+		var err error
+		verb := "archived"
+		if req.Op == "group-archive" {
+			n, err = c.archiveGroup(req.Args[0])
+		} else {
+			verb = "restored"
+			n, err = c.unarchiveGroup(req.Args[0])
+		}
+		if err != nil {
+			return &daemon.CtlResponse{OK: false, Error: err.Error()}
+		}
+		return &daemon.CtlResponse{OK: true, Output: fmt.Sprintf("%s %d window(s) in %s", verb, n, req.Args[0])}
+
+	case "agent-log":
+		if len(req.Args) != 1 {
+			return &daemon.CtlResponse{OK: false, Error: "usage: agent-log <window>"}
+		}
+		out, err := c.agentLogText(req.Args[0])
+		if err != nil {
+			return &daemon.CtlResponse{OK: false, Error: err.Error()}
+		}
+		return &daemon.CtlResponse{OK: true, Output: out}
+
+	case "notify":
+		if len(req.Args) < 1 || len(req.Args) > 2 {
+			return &daemon.CtlResponse{OK: false, Error: "usage: notify <message> [window]"}
+		}
+		window := ""
+		if len(req.Args) == 2 {
+			window = req.Args[1]
+		}
+		if err := c.postNotification(req.Args[0], window); err != nil {
+			return &daemon.CtlResponse{OK: false, Error: err.Error()}
+		}
+		return &daemon.CtlResponse{OK: true}
+
+	case "focus":
+		out, err := c.handleFocusCtl(req.Args)
+		if err != nil {
+			return &daemon.CtlResponse{OK: false, Error: err.Error()}
+		}
+		return &daemon.CtlResponse{OK: true, Output: out}
+
+	case "todo":
+		out, err := c.handleTodoCtl(req.Args)
+		if err != nil {
+			return &daemon.CtlResponse{OK: false, Error: err.Error()}
+		}
+		return &daemon.CtlResponse{OK: true, Output: out}
+
+	case "appearance-export":
+		data, err := c.exportAppearance()
+		if err != nil {
+			return &daemon.CtlResponse{OK: false, Error: err.Error()}
+		}
+		return &daemon.CtlResponse{OK: true, Output: string(data)}
+
+	case "appearance-import":
+		if len(req.Args) != 1 {
+			return &daemon.CtlResponse{OK: false, Error: "usage: appearance-import <json>"}
+		}
+		added, updated, err := c.importAppearance([]byte(req.Args[0]))
+		if err != nil {
+			return &daemon.CtlResponse{OK: false, Error: err.Error()}
+		}
+		return &daemon.CtlResponse{OK: true, Output: fmt.Sprintf("imported: %d added, %d updated", added, updated)}
+
+	case "appearance-forget":
+		if len(req.Args) != 1 {
+			return &daemon.CtlResponse{OK: false, Error: "usage: appearance-forget <key>"}
+		}
+		if err := c.forgetAppearance(req.Args[0]); err != nil {
+			return &daemon.CtlResponse{OK: false, Error: err.Error()}
+		}
+		return &daemon.CtlResponse{OK: true, Output: "forgot " + req.Args[0]}
+	}
+	return &daemon.CtlResponse{OK: false, Error: "unknown ctl op: " + req.Op}
+}
@@ -0,0 +1,390 @@
+package daemon
+
+// custom_widgets.go runs the widgets.custom commands and renders their output
+// in the sidebar zones alongside the built-in widgets. RefreshWidgets
+// (widgets.go) calls RefreshCustomWidgets every second; a widget is run when
+// its interval has passed, when one of its on: events fired (the active
+// window or its pane's cwd changed) or when its command changed. Each run is
+// a detached goroutine bounded by the widget's timeout, so a slow command
+// never blocks the loop; a changed result triggers a render.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/daemon"
+)
+
+// customWidgetLine is one line of a widget's JSON output.
+type customWidgetLine struct {
+	Text   string `json:"text"`
+	Fg     string `json:"fg"`
+	Bg     string `json:"bg"`
+	Bold   bool   `json:"bold"`
+	Action string `json:"action"` // shell command run when the line is clicked
+}
+
+// customWidgetRun is the last run of one widget and the context it ran in.
+type customWidgetRun struct {
+	command  string
+	windowID string
+	cwd      string
+	env      []string
+	ranAt    time.Time
+	running  bool
+	output   string
+	err      error
+}
+
+// customWidgetState holds the runs by widget name. Own mutex: runs finish on
+// their own goroutines, and rendering reads them under stateMu.
+type customWidgetState struct {
+	mu   sync.Mutex
+	runs map[string]*customWidgetRun
+}
+
+// widgetZone is a clickable zone.Mark inside a widget's content that is not
+// one of renderWidgetZone's fixed zones.
+type widgetZone struct {
+	id     string
+	action string
+	target string
+}
+
+// customWidgetWaitDelay bounds how long a timed-out command's output is
+// still read after its process group was killed.
+const customWidgetWaitDelay = time.Second
+
+// customWidgetExec runs a widget command; a variable for tests. The command
+// gets its own process group and a timeout kills the whole group: killing
+// only sh would leave a child it started holding stdout, and Output would
+// wait for that child.
+var customWidgetExec = func(ctx context.Context, dir, command string, env []string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+	cmd.WaitDelay = customWidgetWaitDelay
+	return cmd.Output()
+}
+
+// widgetEscapeRe matches terminal escape sequences: CSI, OSC, and two-byte
+// escapes. widgetSGRRe matches the CSI sequences that only set colors.
+var (
+	widgetEscapeRe = regexp.MustCompile(`\x1b(?:\[[0-9;:?<=>]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[@-Z\\-_])`)
+	widgetSGRRe    = regexp.MustCompile(`^\x1b\[[0-9;:]*m$`)
+)
+
+// sanitizeWidgetText removes escape sequences and control characters from s,
+// keeping colors (SGR) when keepSGR is set; anything else would move the
+// cursor or retitle the terminal.
+func sanitizeWidgetText(s string, keepSGR bool) string {
+	dropControls := func(text string) string {
+		return strings.Map(func(r rune) rune {
+			if r < ' ' && r != '\t' || r == 0x7f {
+				return -1
+			}
+			return r
+		}, text)
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range widgetEscapeRe.FindAllStringIndex(s, -1) {
+		b.WriteString(dropControls(s[last:m[0]]))
+		if seq := s[m[0]:m[1]]; keepSGR && widgetSGRRe.MatchString(seq) {
+			b.WriteString(seq)
+		}
+		last = m[1]
+	}
+	b.WriteString(dropControls(s[last:]))
+	return b.String()
+}
+
+// customWidgetContextLocked returns the active window, its content pane and
+// that pane's cwd, which widget commands run in. Caller must hold stateMu.
+func (c *Coordinator) customWidgetContextLocked() (windowID, paneID, cwd string) {
+	for _, win := range c.windows {
+		if !win.Active {
+			continue
+		}
+		for _, p := range win.Panes {
+			if isAuxiliaryPane(p) {
+				continue
+			}
+			if paneID == "" || p.Active {
+				paneID, cwd = p.ID, p.CurrentPath
+			}
+			if p.Active {
+				break
+			}
+		}
+		return win.ID, paneID, cwd
+	}
+	return "", "", ""
+}
+
+// customWidgetDue reports whether w should run now.
+func customWidgetDue(w config.CustomWidget, run *customWidgetRun, windowID, cwd string, now time.Time) bool {
+	if run.ranAt.IsZero() || run.command != w.Command {
+		return true
+	}
+	if w.Interval > 0 && now.Sub(run.ranAt) >= time.Duration(w.Interval)*time.Second {
+		return true
+	}
+	for _, ev := range w.On {
+		switch ev {
+		case "window_change":
+			if windowID != run.windowID {
+				return true
+			}
+		case "cwd_change":
+			if cwd != run.cwd {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RefreshCustomWidgets starts a run of each enabled custom widget that is due
+// and not already running. Called from the loop goroutine.
+func (c *Coordinator) RefreshCustomWidgets() {
+	widgets := c.config.Widgets.Custom
+	if len(widgets) == 0 {
+		return
+	}
+	c.stateMu.RLock()
+	windowID, paneID, cwd := c.customWidgetContextLocked()
+	width := c.lastWidth
+	c.stateMu.RUnlock()
+
+	now := time.Now()
+	s := &c.customWidgets
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.runs == nil {
+		s.runs = make(map[string]*customWidgetRun)
+	}
+	for _, w := range widgets {
+		if !headerBoolDefault(w.Enabled) || strings.TrimSpace(w.Command) == "" {
+			continue
+		}
+		run := s.runs[w.Name]
+		if run == nil {
+			run = &customWidgetRun{}
+			s.runs[w.Name] = run
+		}
+		if run.running || !customWidgetDue(w, run, windowID, cwd, now) {
+			continue
+		}
+		run.running = true
+		run.command, run.windowID, run.cwd, run.ranAt = w.Command, windowID, cwd, now
+		run.env = []string{
+			"TABBY_WIDGET=" + w.Name,
+			"TABBY_WINDOW_ID=" + windowID,
+			"TABBY_PANE_ID=" + paneID,
+			"TABBY_PANE_PATH=" + cwd,
+			"TABBY_SIDEBAR_WIDTH=" + strconv.Itoa(width),
+		}
+		go c.runCustomWidget(w, run, customWidgetDir(cwd), run.env)
+	}
+}
+
+// customWidgetDir is cwd when it still exists, else the home directory.
+func customWidgetDir(cwd string) string {
+	if cwd != "" {
+		if st, err := os.Stat(cwd); err == nil && st.IsDir() {
+			return cwd
+		}
+	}
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// runCustomWidget runs w's command and stores its output, rendering when the
+// output changed.
+func (c *Coordinator) runCustomWidget(w config.CustomWidget, run *customWidgetRun, dir string, env []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(w.Timeout)*time.Second)
+	defer cancel()
+	out, err := customWidgetExec(ctx, dir, w.Command, env)
+	if ctx.Err() != nil {
+		err = fmt.Errorf("timed out after %ds", w.Timeout)
+	}
+	if err != nil {
+		coordinatorDebugLog.Printf("custom widget %s: %v", w.Name, err)
+	}
+
+	s := &c.customWidgets
+	s.mu.Lock()
+	changed := run.output != string(out) || (run.err == nil) != (err == nil)
+	run.output, run.err, run.running = string(out), err, false
+	s.mu.Unlock()
+
+	if changed && c.OnRefreshLayout != nil {
+		c.OnRefreshLayout()
+	}
+}
+
+// customWidgetLines turns a run's output into lines for format. JSON that
+// does not parse is an error.
+func customWidgetLines(format, output string) ([]customWidgetLine, error) {
+	if strings.TrimSpace(output) == "" {
+		return nil, nil
+	}
+	if format == "json" {
+		var doc struct {
+			Lines []customWidgetLine `json:"lines"`
+		}
+		if err := json.Unmarshal([]byte(output), &doc); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+		for i := range doc.Lines {
+			doc.Lines[i].Text = sanitizeWidgetText(doc.Lines[i].Text, false)
+		}
+		return doc.Lines, nil
+	}
+	raw := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	lines := make([]customWidgetLine, len(raw))
+	for i, text := range raw {
+		lines[i].Text = sanitizeWidgetText(strings.TrimRight(text, "\r"), format == "ansi")
+	}
+	return lines, nil
+}
+
+// renderCustomWidget renders w's last output and returns the zones of its
+// clickable lines. A widget that has not run yet, or printed nothing, is
+// hidden; a failed run with no output shows the error.
+func (c *Coordinator) renderCustomWidget(w config.CustomWidget, width int) (string, []widgetZone) {
+	s := &c.customWidgets
+	s.mu.Lock()
+	run := s.runs[w.Name]
+	var output string
+	var runErr error
+	if run != nil {
+		output, runErr = run.output, run.err
+	}
+	s.mu.Unlock()
+
+	lines, err := customWidgetLines(w.Format, output)
+	if err == nil && len(lines) == 0 && runErr != nil {
+		err = runErr
+	}
+	if err != nil {
+		lines = []customWidgetLine{{Text: "⚠ " + w.Name + ": " + err.Error(), Fg: c.getInactiveTextColorWithFallback("")}}
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	if len(lines) > w.MaxLines {
+		lines = lines[:w.MaxLines]
+	}
+
+	fg := c.getInactiveTextColorWithFallback(w.Fg)
+	var zones []widgetZone
+	var result strings.Builder
+	for i, line := range lines {
+		text := line.Text
+		if w.Format == "ansi" {
+			text += "\x1b[0m"
+		} else {
+			style := lipgloss.NewStyle().Bold(line.Bold)
+			if color := firstNonEmpty(line.Fg, fg); color != "" {
+				style = style.Foreground(lipgloss.Color(color))
+			}
+			if color := firstNonEmpty(line.Bg, w.Bg); color != "" {
+				style = style.Background(lipgloss.Color(color))
+			}
+			text = style.Render(text)
+		}
+		if line.Action != "" {
+			id := fmt.Sprintf("custom_widget:%s#%d", w.Name, i)
+			text = zone.Mark(id, text)
+			zones = append(zones, widgetZone{id: id, action: "custom_widget_action", target: fmt.Sprintf("%d:%s", i, w.Name)})
+		}
+		result.WriteString(text + "\n")
+	}
+
+	return c.wrapWidgetChrome(widgetLayout{
+		Divider: w.Divider, DividerFg: w.DividerFg,
+		MarginTop: w.MarginTop, PaddingTop: w.PaddingTop, PaddingBot: w.PaddingBot, MarginBot: w.MarginBot,
+	}, result.String(), width), zones
+}
+
+// runCustomWidgetAction runs the action of the clicked line, target
+// "<line>:<widget name>", in the directory and environment of the run that
+// produced it, then re-runs the widget on the next tick.
+func (c *Coordinator) runCustomWidgetAction(target string) {
+	idxText, name, ok := strings.Cut(target, ":")
+	idx, err := strconv.Atoi(idxText)
+	if !ok || err != nil {
+		return
+	}
+	var w config.CustomWidget
+	found := false
+	for _, cw := range c.config.Widgets.Custom {
+		if cw.Name == name {
+			w, found = cw, true
+			break
+		}
+	}
+	if !found {
+		return
+	}
+
+	s := &c.customWidgets
+	s.mu.Lock()
+	run := s.runs[name]
+	if run == nil {
+		s.mu.Unlock()
+		return
+	}
+	lines, _ := customWidgetLines(w.Format, run.output)
+	dir, env := customWidgetDir(run.cwd), run.env
+	run.ranAt = time.Time{}
+	s.mu.Unlock()
+	if idx < 0 || idx >= len(lines) || lines[idx].Action == "" {
+		return
+	}
+
+	action := lines[idx].Action
+	logEvent("CUSTOM_WIDGET_ACTION widget=%s line=%d", name, idx)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(w.Timeout)*time.Second)
+		defer cancel()
+		if _, err := customWidgetExec(ctx, dir, action, env); err != nil {
+			coordinatorDebugLog.Printf("custom widget %s action: %v", name, err)
+		}
+	}()
+}
+
+// customWidgetRegions converts zones found by zone.Scan into click regions.
+func customWidgetRegions(zones []widgetZone) []daemon.ClickableRegion {
+	var regions []daemon.ClickableRegion
+	for _, z := range zones {
+		if info := zone.Get(z.id); info != nil && !info.IsZero() {
+			regions = append(regions, daemon.ClickableRegion{
+				StartLine: info.StartY,
+				EndLine:   info.EndY,
+				StartCol:  info.StartX,
+				EndCol:    info.EndX + 1,
+				Action:    z.action,
+				Target:    z.target,
+			})
+		}
+	}
+	return regions
+}
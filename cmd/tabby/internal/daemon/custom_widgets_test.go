@@ -0,0 +1,181 @@
+package daemon
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// stubCustomWidgetExec replaces the command runner; out maps a command to its
+// output. Returns the commands run, in order.
+func stubCustomWidgetExec(t *testing.T, out map[string]string) func() []string {
+	t.Helper()
+	var mu sync.Mutex
+	var ran []string
+	orig := customWidgetExec
+	customWidgetExec = func(ctx context.Context, dir, command string, env []string) ([]byte, error) {
+		mu.Lock()
+		ran = append(ran, command)
+		mu.Unlock()
+		return []byte(out[command]), nil
+	}
+	t.Cleanup(func() { customWidgetExec = orig })
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ran...)
+	}
+}
+
+func testCustomWidget(name, command string) config.CustomWidget {
+	return config.CustomWidget{Name: name, Command: command, Interval: 30, Timeout: 5, Format: "text", MaxLines: 10}
+}
+
+func TestSanitizeWidgetText(t *testing.T) {
+	in := "\x1b[31mred\x1b[0m \x1b[2Jcleared\x1b]0;title\x07\tok\r"
+	assert.Equal(t, "red cleared\tok", sanitizeWidgetText(in, false))
+	assert.Equal(t, "\x1b[31mred\x1b[0m cleared\tok", sanitizeWidgetText(in, true))
+}
+
+func TestCustomWidgetExecTimeoutKillsChildren(t *testing.T) {
+	// sh exits right away, but the backgrounded sleep keeps stdout open.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	out, err := customWidgetExec(ctx, t.TempDir(), "sleep 30 & echo started", nil)
+	assert.Error(t, err)
+	assert.Equal(t, "started\n", string(out))
+	assert.Less(t, time.Since(start), 200*time.Millisecond+customWidgetWaitDelay+2*time.Second,
+		"the sleeping child is killed with its group")
+}
+
+func TestCustomWidgetLines(t *testing.T) {
+	lines, err := customWidgetLines("text", "one\n\x1b[1mtwo\x1b[0m\n")
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "two", lines[1].Text)
+
+	lines, err = customWidgetLines("json", `{"lines":[{"text":"build ok","fg":"#6bcb77","action":"make"}]}`)
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.Equal(t, customWidgetLine{Text: "build ok", Fg: "#6bcb77", Action: "make"}, lines[0])
+
+	_, err = customWidgetLines("json", "not json")
+	assert.Error(t, err)
+
+	lines, err = customWidgetLines("json", "\n")
+	assert.NoError(t, err)
+	assert.Empty(t, lines)
+}
+
+func TestCustomWidgetDue(t *testing.T) {
+	now := time.Now()
+	w := testCustomWidget("w", "date")
+	run := &customWidgetRun{}
+	assert.True(t, customWidgetDue(w, run, "@1", "/a", now), "never ran")
+
+	run = &customWidgetRun{command: "date", windowID: "@1", cwd: "/a", ranAt: now}
+	assert.False(t, customWidgetDue(w, run, "@2", "/b", now.Add(time.Second)))
+	assert.True(t, customWidgetDue(w, run, "@1", "/a", now.Add(30*time.Second)), "interval passed")
+
+	w.Interval = 0
+	w.On = []string{"window_change"}
+	assert.False(t, customWidgetDue(w, run, "@1", "/b", now.Add(time.Hour)), "events only")
+	assert.True(t, customWidgetDue(w, run, "@2", "/a", now.Add(time.Second)))
+
+	w.On = []string{"cwd_change"}
+	assert.True(t, customWidgetDue(w, run, "@1", "/b", now.Add(time.Second)))
+
+	w.Command = "uptime"
+	assert.True(t, customWidgetDue(w, run, "@1", "/a", now), "command changed")
+}
+
+func TestRefreshCustomWidgetsRunsInActivePane(t *testing.T) {
+	ran := stubCustomWidgetExec(t, map[string]string{"date": "Mon\n"})
+	c := newTestCoordinator(t)
+	c.config.Widgets.Custom = []config.CustomWidget{testCustomWidget("when", "date")}
+	win := testWindow("a", true, "zsh", "vim")
+	win.Panes[0].CurrentPath = "/tmp"
+	win.Panes[0].Active = false
+	win.Panes[1].Active = true
+	win.Panes[1].CurrentPath = "/nonexistent-dir"
+	c.windows = []tmux.Window{win}
+
+	rendered := make(chan struct{}, 1)
+	c.OnRefreshLayout = func() { rendered <- struct{}{} }
+	c.RefreshCustomWidgets()
+	select {
+	case <-rendered:
+	case <-time.After(2 * time.Second):
+		t.Fatal("widget output did not trigger a render")
+	}
+	assert.Equal(t, []string{"date"}, ran())
+
+	c.customWidgets.mu.Lock()
+	run := c.customWidgets.runs["when"]
+	assert.Equal(t, "%a-1", strings.TrimPrefix(run.env[2], "TABBY_PANE_ID="))
+	assert.Equal(t, "/nonexistent-dir", run.cwd)
+	c.customWidgets.mu.Unlock()
+
+	c.RefreshCustomWidgets()
+	assert.Len(t, ran(), 1, "not due again yet")
+
+	content, zones := c.renderCustomWidget(c.config.Widgets.Custom[0], 25)
+	assert.Equal(t, "Mon\n", stripAnsi(content))
+	assert.Empty(t, zones)
+}
+
+func TestRenderCustomWidgetJSONActionsAndErrors(t *testing.T) {
+	ran := stubCustomWidgetExec(t, nil)
+	c := newTestCoordinator(t)
+	w := testCustomWidget("ci", "ci-status")
+	w.Format = "json"
+	w.MaxLines = 2
+	w.Divider = "-"
+	c.config.Widgets.Custom = []config.CustomWidget{w}
+
+	content, _ := c.renderCustomWidget(w, 10)
+	assert.Empty(t, content, "hidden until the first run")
+
+	c.customWidgets.runs = map[string]*customWidgetRun{"ci": {
+		output: `{"lines":[{"text":"main ✓"},{"text":"open","action":"xdg-open https://ci"},{"text":"dropped"}]}`,
+		env:    []string{"TABBY_WIDGET=ci"},
+	}}
+	content, zones := c.renderCustomWidget(w, 10)
+	lines := strings.Split(strings.TrimRight(stripAnsi(content), "\n"), "\n")
+	assert.Equal(t, []string{"----------", "main ✓", "open"}, stripZoneMarks(lines))
+	require.Len(t, zones, 1)
+	assert.Equal(t, "custom_widget_action", zones[0].action)
+	assert.Equal(t, "1:ci", zones[0].target)
+
+	c.runCustomWidgetAction("1:ci")
+	require.Eventually(t, func() bool { return len(ran()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "xdg-open https://ci", ran()[0])
+	assert.True(t, c.customWidgets.runs["ci"].ranAt.IsZero(), "re-run after an action")
+
+	c.runCustomWidgetAction("0:ci")
+	c.runCustomWidgetAction("9:other")
+	assert.Len(t, ran(), 1, "lines without an action and unknown widgets are ignored")
+
+	c.customWidgets.runs["ci"].output = "oops"
+	content, _ = c.renderCustomWidget(w, 40)
+	assert.Contains(t, stripAnsi(content), "⚠ ci: invalid JSON")
+}
+
+// stripZoneMarks removes bubblezone markers, which renderCustomWidget leaves
+// for renderWidgetZone's zone.Scan.
+func stripZoneMarks(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = sanitizeWidgetText(l, false)
+	}
+	return out
+}
@@ -785,9 +785,13 @@ func (c *Coordinator) applyNativeBorders(winID, groupName string) {
 	// The border colour tracks the TAB colour: a window's own custom @tabby_color
 	// wins, exactly as it does for the sidebar row and window header. Only when the
 	// window carries no custom colour do we fall back to its group theme (then
-	// Default, then any group, then config / hardcoded).
-	activeBg := ""
+	// Default, then any group, then config / hardcoded). Ahead of all of them, a
+	// context badge rule with pane_border (e.g. a prod cluster) paints the border.
+	activeBg := c.contextBorderColor(winID)
 	for _, g := range c.grouped {
+		if activeBg != "" {
+			break
+		}
 		for _, w := range g.Windows {
 			if w.ID == winID {
 				if cc := strings.TrimSpace(w.CustomColor); cc != "" && cc != "transparent" {
@@ -0,0 +1,335 @@
+package daemon
+
+// focus.go is the focus (pomodoro) widget: a countdown with start/pause and
+// reset buttons, also driven by `tabby ctl focus`. The timer itself lives in
+// pkg/focus and is persisted to focus.json in the state dir, so it survives
+// a daemon restart and is shared with the other sessions' daemons; each
+// refresh picks up changes another process wrote.
+//
+// When a phase ends every attached client hears a bell and a notification is
+// posted. Only the daemon whose refresh moves the shared timer on sees the
+// phase end (the others load the advanced file), so it rings the clients of
+// every session, not just its own. While a work period runs, events whose kind is not in
+// widgets.focus.allow are recorded in the history but not sent to sinks or
+// toasts, and the pet keeps its thoughts to itself.
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/focus"
+	"github.com/brendandebeasi/tabby/pkg/notifications"
+)
+
+// focusBell rings the terminal bell on every client attached to any
+// session; a variable for tests.
+var focusBell = func() {
+	out, err := exec.Command("tmux", "list-clients", "-F", "#{client_tty}").Output()
+	if err != nil {
+		return
+	}
+	for _, tty := range strings.Fields(string(out)) {
+		if f, err := os.OpenFile(tty, os.O_WRONLY, 0); err == nil {
+			f.WriteString("\a")
+			f.Close()
+		}
+	}
+}
+
+// focusState is the timer as last read from or written to focus.json. Own
+// mutex; never take stateMu while holding it.
+type focusState struct {
+	mu      sync.Mutex
+	loaded  bool
+	modTime time.Time
+	timer   focus.Timer
+}
+
+// focusSettings converts the widget config into timer settings.
+func focusSettings(cfg config.FocusWidget) focus.Settings {
+	return focus.Settings{
+		Work:           time.Duration(cfg.Work) * time.Minute,
+		Break:          time.Duration(cfg.Break) * time.Minute,
+		LongBreak:      time.Duration(cfg.LongBreak) * time.Minute,
+		LongBreakEvery: max(cfg.LongBreakEvery, 0),
+		AutoStartWork:  cfg.AutoStartWork,
+	}
+}
+
+// syncFocusLocked rereads focus.json when it changed on disk since it was
+// last read or written. Caller holds focus.mu.
+func (c *Coordinator) syncFocusLocked() {
+	path := focus.Path()
+	info, err := os.Stat(path)
+	if err != nil {
+		if !c.focus.loaded {
+			c.focus.loaded = true
+		}
+		return
+	}
+	if c.focus.loaded && info.ModTime().Equal(c.focus.modTime) {
+		return
+	}
+	t, err := focus.Load(path)
+	if err != nil {
+		coordinatorDebugLog.Printf("focus: %v", err)
+	} else {
+		c.focus.timer = t
+	}
+	c.focus.loaded, c.focus.modTime = true, info.ModTime()
+}
+
+// saveFocusLocked writes the timer to focus.json. Caller holds focus.mu.
+func (c *Coordinator) saveFocusLocked() {
+	path := focus.Path()
+	if err := focus.Save(path, c.focus.timer); err != nil {
+		coordinatorDebugLog.Printf("focus: save: %v", err)
+		return
+	}
+	if info, err := os.Stat(path); err == nil {
+		c.focus.modTime = info.ModTime()
+	}
+}
+
+// updateFocus applies fn to the up-to-date timer, saves it and returns the
+// result.
+func (c *Coordinator) updateFocus(fn func(t *focus.Timer, s focus.Settings)) focus.Timer {
+	s := focusSettings(c.config.Widgets.Focus)
+	c.focus.mu.Lock()
+	defer c.focus.mu.Unlock()
+	c.syncFocusLocked()
+	fn(&c.focus.timer, s)
+	c.saveFocusLocked()
+	return c.focus.timer
+}
+
+// focusTimer returns the timer as last synced.
+func (c *Coordinator) focusTimer() focus.Timer {
+	c.focus.mu.Lock()
+	defer c.focus.mu.Unlock()
+	return c.focus.timer
+}
+
+// refreshFocus syncs the timer and moves it on when its phase is over.
+func (c *Coordinator) refreshFocus(now time.Time) {
+	cfg := c.config.Widgets.Focus
+	c.focus.mu.Lock()
+	c.syncFocusLocked()
+	ended := c.focus.timer.Tick(now, focusSettings(cfg))
+	if ended != focus.Idle {
+		c.saveFocusLocked()
+	}
+	next := c.focus.timer.Phase
+	c.focus.mu.Unlock()
+	if ended != focus.Idle {
+		c.announceFocusPhase(cfg, ended, next)
+	}
+}
+
+// announceFocusPhase rings the bell and posts a notification when a phase
+// ends.
+func (c *Coordinator) announceFocusPhase(cfg config.FocusWidget, ended, next focus.Phase) {
+	if headerBoolDefault(cfg.Bell) {
+		focusBell()
+	}
+	if !headerBoolDefault(cfg.Toast) {
+		return
+	}
+	msg := "Break over"
+	switch {
+	case ended == focus.Work && next == focus.LongBreak:
+		msg = fmt.Sprintf("Focus done: take %dm (long break)", cfg.LongBreak)
+	case ended == focus.Work:
+		msg = fmt.Sprintf("Focus done: take %dm", cfg.Break)
+	case next == focus.Work:
+		msg = "Break over: focusing"
+	}
+	if err := c.postNotification(msg, ""); err != nil {
+		coordinatorDebugLog.Printf("focus: notify: %v", err)
+	}
+}
+
+// focusFilter drops the events do-not-disturb holds back while a work
+// period runs. A timer left in focus.json holds nothing once the widget is
+// disabled.
+func (c *Coordinator) focusFilter(cfg config.FocusWidget, events []notifications.Event) []notifications.Event {
+	if len(events) == 0 || !cfg.Enabled || !headerBoolDefault(cfg.DoNotDisturb) || !c.focusTimer().Focusing() {
+		return events
+	}
+	var kept []notifications.Event
+	for _, e := range events {
+		if containsFold(cfg.Allow, e.Kind) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// focusQuietPet reports whether the pet should keep quiet.
+func (c *Coordinator) focusQuietPet() bool {
+	cfg := c.config.Widgets.Focus
+	return cfg.Enabled && headerBoolDefault(cfg.QuietPet) && c.focusTimer().Focusing()
+}
+
+// formatFocusLeft formats a countdown as mm:ss.
+func formatFocusLeft(d time.Duration) string {
+	secs := int((d + time.Second - 1) / time.Second)
+	return fmt.Sprintf("%02d:%02d", secs/60, secs%60)
+}
+
+// focusStatus describes the timer for `tabby ctl focus status`.
+func focusStatus(t focus.Timer, now time.Time) string {
+	if t.Phase == focus.Idle {
+		return fmt.Sprintf("idle (%d done)", t.Completed)
+	}
+	phase := strings.ReplaceAll(string(t.Phase), "_", " ")
+	s := fmt.Sprintf("%s %s left (%d done)", phase, formatFocusLeft(t.Left(now)), t.Completed)
+	if t.Paused {
+		s = "paused: " + s
+	}
+	return s
+}
+
+// renderFocusWidget renders the countdown and its buttons.
+func (c *Coordinator) renderFocusWidget(width int) string {
+	cfg := c.config.Widgets.Focus
+	if !cfg.Enabled {
+		return ""
+	}
+	t := c.focusTimer()
+
+	fg := c.getInactiveTextColorWithFallback(cfg.Fg)
+	style := lipgloss.NewStyle()
+	if fg != "" {
+		style = style.Foreground(lipgloss.Color(fg))
+	}
+	if cfg.Bg != "" {
+		style = style.Background(lipgloss.Color(cfg.Bg))
+	}
+	timeStyle := style.Bold(true)
+
+	var result strings.Builder
+	// Line 1: what is counting down and how long is left.
+	label, left := "🍅 Focus", time.Duration(cfg.Work)*time.Minute
+	switch t.Phase {
+	case focus.Work:
+		left = t.Left(time.Now())
+		if cfg.WorkFg != "" {
+			timeStyle = timeStyle.Foreground(lipgloss.Color(cfg.WorkFg))
+		}
+	case focus.Break, focus.LongBreak:
+		label, left = "☕ Break", t.Left(time.Now())
+		if cfg.BreakFg != "" {
+			timeStyle = timeStyle.Foreground(lipgloss.Color(cfg.BreakFg))
+		}
+	}
+	result.WriteString(style.Render(label+" ") + timeStyle.Render(formatFocusLeft(left)) + "\n")
+
+	// Line 2: start or pause, reset, and a dot per finished work period.
+	toggle := zone.Mark("focus:start", style.Render("[▶]"))
+	if t.Phase != focus.Idle && !t.Paused {
+		toggle = zone.Mark("focus:pause", style.Render("[⏸]"))
+	}
+	line := toggle + style.Render(" ") + zone.Mark("focus:reset", style.Render("[↺]"))
+	if dots := min(t.Completed, max(width-9, 0)); dots > 0 {
+		line += style.Render(" " + strings.Repeat("•", dots))
+	}
+	result.WriteString(line + "\n")
+
+	return c.wrapWidgetChrome(widgetLayout{
+		Divider: cfg.Divider, DividerFg: cfg.DividerFg,
+		MarginTop: cfg.MarginTop, PaddingTop: cfg.PaddingTop, PaddingBot: cfg.PaddingBot, MarginBot: cfg.MarginBot,
+	}, result.String(), width)
+}
+
+// focusWidget is the registry entry for the focus timer.
+type focusWidget struct{ widgetBase }
+
+func (focusWidget) Name() string { return "focus" }
+
+func (focusWidget) Config(cfg *config.Config) WidgetConfig {
+	w := cfg.Widgets.Focus
+	return WidgetConfig{Enabled: w.Enabled, Position: widgetPosition(w.Position, "top"), Priority: w.Priority}
+}
+
+// Interval is one second while the widget is enabled, so a running timer
+// ends its phases on time. A disabled widget has no timer to run: `tabby ctl
+// focus` refuses to start one.
+func (focusWidget) Interval(cfg *config.Config) time.Duration {
+	if !cfg.Widgets.Focus.Enabled {
+		return 0
+	}
+	return time.Second
+}
+
+func (focusWidget) Refresh(c *Coordinator) { c.refreshFocus(time.Now()) }
+
+func (focusWidget) StateHash(c *Coordinator) string {
+	t := c.focusTimer()
+	return fmt.Sprintf("%s|%t|%d|%s", t.Phase, t.Paused, t.Completed, formatFocusLeft(t.Left(time.Now())))
+}
+
+func (focusWidget) Render(c *Coordinator, width int) string { return c.renderFocusWidget(width) }
+
+func (focusWidget) Actions() []string { return []string{"start", "pause", "reset"} }
+
+func (focusWidget) Click(c *Coordinator, clientID, action string) bool {
+	now := time.Now()
+	c.updateFocus(func(t *focus.Timer, s focus.Settings) {
+		switch action {
+		case "start":
+			if t.Phase == focus.Idle || t.Paused {
+				t.Start(now, 0, s)
+			}
+		case "pause":
+			t.Pause(now)
+		case "reset":
+			t.Reset()
+		}
+	})
+	return true
+}
+
+// handleFocusCtl runs `tabby ctl focus <start [duration]|pause|resume|reset|status>`.
+func (c *Coordinator) handleFocusCtl(args []string) (string, error) {
+	const usage = "usage: focus start [duration] | pause | resume | reset | status"
+	if len(args) == 0 {
+		return "", fmt.Errorf(usage)
+	}
+	if !c.config.Widgets.Focus.Enabled {
+		return "", fmt.Errorf("the focus widget is off (widgets.focus.enabled)")
+	}
+	now := time.Now()
+	var t focus.Timer
+	switch args[0] {
+	case "start":
+		var d time.Duration
+		if len(args) > 1 {
+			var err error
+			if d, err = focus.ParseDuration(args[1]); err != nil {
+				return "", err
+			}
+		}
+		t = c.updateFocus(func(t *focus.Timer, s focus.Settings) { t.Start(now, d, s) })
+	case "pause":
+		t = c.updateFocus(func(t *focus.Timer, _ focus.Settings) { t.Pause(now) })
+	case "resume":
+		t = c.updateFocus(func(t *focus.Timer, _ focus.Settings) { t.Resume(now) })
+	case "reset":
+		t = c.updateFocus(func(t *focus.Timer, _ focus.Settings) { t.Reset() })
+	case "status":
+		c.refreshFocus(now)
+		t = c.focusTimer()
+	default:
+		return "", fmt.Errorf(usage)
+	}
+	return focusStatus(t, now), nil
+}
@@ -0,0 +1,122 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/focus"
+	"github.com/brendandebeasi/tabby/pkg/notifications"
+	"github.com/brendandebeasi/tabby/pkg/paths"
+)
+
+// stubFocus points focus.json at a temp dir and counts bells.
+func stubFocus(t *testing.T) *int {
+	t.Helper()
+	t.Setenv("TABBY_STATE_DIR", t.TempDir())
+	paths.ResetForTest()
+	t.Cleanup(paths.ResetForTest)
+	bells := 0
+	orig := focusBell
+	focusBell = func() { bells++ }
+	t.Cleanup(func() { focusBell = orig })
+	return &bells
+}
+
+func focusTestCoordinator(t *testing.T) *Coordinator {
+	c := newTestCoordinator(t)
+	c.config.Widgets.Focus.Enabled = true
+	c.config.Widgets.Focus.Work = 25
+	c.config.Widgets.Focus.Break = 5
+	c.config.Widgets.Focus.LongBreak = 15
+	c.config.Widgets.Focus.Allow = []string{"crash", "notify"}
+	off := false
+	c.config.Widgets.Focus.Toast = &off
+	return c
+}
+
+func TestFocusCtlPersists(t *testing.T) {
+	stubFocus(t)
+	c := focusTestCoordinator(t)
+
+	out, err := c.handleFocusCtl([]string{"start", "10m"})
+	require.NoError(t, err)
+	assert.Equal(t, "work 10:00 left (0 done)", out)
+	_, err = c.handleFocusCtl([]string{"start", "soon"})
+	assert.Error(t, err)
+	_, err = c.handleFocusCtl([]string{"nap"})
+	assert.Error(t, err)
+
+	// A restarted daemon picks the timer up from focus.json.
+	restarted := focusTestCoordinator(t)
+	out, err = restarted.handleFocusCtl([]string{"status"})
+	require.NoError(t, err)
+	assert.Regexp(t, `^work (10:00|09:[0-9]{2}) left`, out)
+
+	out, err = restarted.handleFocusCtl([]string{"pause"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "paused: work"), out)
+	stored, err := focus.Load(focus.Path())
+	require.NoError(t, err)
+	assert.True(t, stored.Paused)
+
+	out, _ = restarted.handleFocusCtl([]string{"reset"})
+	assert.Equal(t, "idle (0 done)", out)
+
+	// Disabled, nothing would end the phases: no ticks and no timer.
+	restarted.config.Widgets.Focus.Enabled = false
+	assert.Zero(t, focusWidget{}.Interval(restarted.config))
+	_, err = restarted.handleFocusCtl([]string{"start"})
+	assert.Error(t, err)
+}
+
+func TestRefreshFocusEndsPhase(t *testing.T) {
+	bells := stubFocus(t)
+	c := focusTestCoordinator(t)
+	now := time.Now()
+	c.updateFocus(func(tm *focus.Timer, s focus.Settings) { tm.Start(now, time.Minute, s) })
+
+	c.refreshFocus(now.Add(30 * time.Second))
+	assert.Zero(t, *bells)
+	c.refreshFocus(now.Add(time.Minute))
+	assert.Equal(t, 1, *bells)
+	tm := c.focusTimer()
+	assert.Equal(t, focus.Break, tm.Phase)
+	assert.Equal(t, 1, tm.Completed)
+
+	lines := strings.Split(stripWidget(c.renderFocusWidget(20)), "\n")
+	assert.True(t, strings.HasPrefix(lines[0], "☕ Break 0"), lines[0])
+	assert.Equal(t, "[⏸] [↺] •", lines[1])
+}
+
+func TestFocusFilterAndQuietPet(t *testing.T) {
+	stubFocus(t)
+	c := focusTestCoordinator(t)
+	events := []notifications.Event{
+		{Kind: notifications.KindBell},
+		{Kind: notifications.KindCrash},
+		{Kind: notifications.KindDone},
+	}
+	cfg := c.config.Widgets.Focus
+	assert.Len(t, c.focusFilter(cfg, events), 3, "idle")
+	assert.False(t, c.focusQuietPet())
+
+	handled, changed := c.handleWidgetClick("client", "start", "focus")
+	require.True(t, handled)
+	assert.True(t, changed)
+	assert.Equal(t, []notifications.Event{{Kind: notifications.KindCrash}}, c.focusFilter(cfg, events))
+	assert.True(t, c.focusQuietPet())
+	assert.Contains(t, stripWidget(c.renderFocusWidget(20)), "[⏸] [↺]")
+
+	off := false
+	cfg.DoNotDisturb = &off
+	assert.Len(t, c.focusFilter(cfg, events), 3, "do_not_disturb off")
+
+	c.handleWidgetClick("client", "pause", "focus")
+	assert.Len(t, c.focusFilter(c.config.Widgets.Focus, events), 3, "paused")
+	assert.False(t, c.focusQuietPet())
+	assert.Contains(t, stripWidget(c.renderFocusWidget(20)), "[▶] [↺]")
+}
@@ -0,0 +1,311 @@
+package daemon
+
+// group_actions.go implements operations that fan out across every window of
+// a group: "run in all windows" (send a command line to each window's content
+// pane) and the synchronize-panes toggle. Both are reachable from the group
+// context menu and from `tabby ctl group-run|group-sync` (via HandleCtl).
+// The menu's "Run in All Windows..." prompt stores the typed command verbatim
+// in a tmux option that `tabby ctl group-run-prompted` reads, so the command
+// never passes through tmux or shell quoting.
+//
+// synchronize-panes is set per PANE (tmux >= 3.2) and only on content panes:
+// the sidebar and header renderers in the same window are bubbletea programs,
+// and mirroring keystrokes into them would fire sidebar shortcuts. tmux only
+// mirrors input between synchronized panes of the SAME window, so for
+// cross-window broadcast use group-run. The flag belongs to the group, not to
+// the windows it had when toggled: RefreshWindows reconciles it after
+// regrouping (groupSyncChangesLocked), so a window that joins a synced group
+// or a pane split in one is synced too, and one that leaves is unsynced.
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// groupSyncBadge is appended to a group header while synchronize-panes is on
+// for that group.
+const groupSyncBadge = "⇉"
+
+// groupRunPromptOption holds the "Run in All Windows..." answer until `tabby
+// ctl group-run-prompted` reads it.
+const groupRunPromptOption = "@tabby_group_run"
+
+// groupTmux runs a tmux command; a variable for tests.
+var groupTmux = func(args ...string) ([]byte, error) {
+	return exec.Command("tmux", args...).Output()
+}
+
+// groupContentPane finds the pane of a window that group-run types into; a
+// variable for tests.
+var groupContentPane = findContentPane
+
+// groupSyncOptionName is the session option that persists a group's sync
+// state across daemon restarts (same naming scheme as the collapsed-group
+// @tabby_grp_collapsed_<name> options).
+func groupSyncOptionName(group string) string {
+	return "@tabby_grp_sync_" + strings.ReplaceAll(group, " ", "_")
+}
+
+// groupWindows returns a snapshot of the windows currently filed under group
+// name. ok is false when no such group is displayed.
+func (c *Coordinator) groupWindows(name string) ([]tmux.Window, bool) {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	for _, g := range c.grouped {
+		if g.Name == name {
+			return append([]tmux.Window(nil), g.Windows...), true
+		}
+	}
+	return nil, false
+}
+
+// runInGroup types command followed by Enter into the content pane of every
+// window in the group. Returns how many windows received it.
+func (c *Coordinator) runInGroup(name, command string) (int, error) {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return 0, fmt.Errorf("empty command")
+	}
+	wins, ok := c.groupWindows(name)
+	if !ok {
+		return 0, fmt.Errorf("no such group: %s", name)
+	}
+	sent := 0
+	for _, win := range wins {
+		pane := groupContentPane(win.ID, "")
+		if pane == "" {
+			continue
+		}
+		// -l sends the text literally so words like "Enter" or "C-c" inside
+		// the command aren't interpreted as key names.
+		if _, err := groupTmux("send-keys", "-t", pane, "-l", command); err != nil {
+			logEvent("GROUP_RUN_SEND_ERR group=%s window=%s pane=%s err=%v", name, win.ID, pane, err)
+			continue
+		}
+		groupTmux("send-keys", "-t", pane, "Enter")
+		sent++
+	}
+	logEvent("GROUP_RUN group=%s windows=%d sent=%d", name, len(wins), sent)
+	return sent, nil
+}
+
+// runPromptedInGroup runs the answer of the group menu's "Run in All
+// Windows..." prompt in every window of the group, and clears it.
+func (c *Coordinator) runPromptedInGroup(name string) (int, error) {
+	out, _ := groupTmux("show-options", "-gqv", groupRunPromptOption)
+	groupTmux("set-option", "-gqu", groupRunPromptOption)
+	return c.runInGroup(name, strings.TrimSuffix(string(out), "\n"))
+}
+
+// groupRunPromptCommand is the group menu's "Run in All Windows..." item: a
+// command-prompt whose answer goes verbatim into groupRunPromptOption (%%%
+// escapes it for the double-quoted value) before `tabby ctl
+// group-run-prompted` runs it. The group name only reaches the shell through
+// shellQuote. tmux expands formats in menu commands, prompts and run-shell,
+// so each of those layers gets its '#' doubled.
+func groupRunPromptCommand(ctlPath, name string) string {
+	runCmd := fmt.Sprintf("%s group-run-prompted %s", ctlPath, shellQuote(name))
+	template := fmt.Sprintf(`set-option -g %s "%%%%%%" ; run-shell %s`, groupRunPromptOption, tmuxQuote(escapeFormat(runCmd)))
+	prompt := escapeFormat(fmt.Sprintf("Run in all %s windows:", name))
+	return escapeFormat(fmt.Sprintf("command-prompt -p %s %s", tmuxQuote(prompt), tmuxQuote(template)))
+}
+
+// tmuxQuote quotes s as one double-quoted argument of a tmux command string.
+func tmuxQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// escapeFormat doubles '#' so tmux's format expansion leaves s as is.
+func escapeFormat(s string) string {
+	return strings.ReplaceAll(s, "#", "##")
+}
+
+// syncablePanes returns the IDs of the content panes in panes — everything
+// except sidebar and header renderers.
+func syncablePanes(panes []tmux.Pane) []string {
+	var ids []string
+	for _, p := range panes {
+		if isAuxiliaryPane(p) {
+			continue
+		}
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+// isGroupSynced reports whether synchronize-panes is on for the group.
+func (c *Coordinator) isGroupSynced(name string) bool {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.groupSync[name]
+}
+
+// setGroupSync turns synchronize-panes on or off for every content pane of
+// the group's windows and records the state for the header badge.
+func (c *Coordinator) setGroupSync(name string, on bool) error {
+	wins, ok := c.groupWindows(name)
+	if !ok {
+		return fmt.Errorf("no such group: %s", name)
+	}
+	value := "off"
+	if on {
+		value = "on"
+	}
+	var panes []string
+	for _, win := range wins {
+		panes = append(panes, syncablePanes(win.Panes)...)
+	}
+	applyPaneSync(panes, value)
+
+	c.stateMu.Lock()
+	if c.groupSync == nil {
+		c.groupSync = make(map[string]bool)
+	}
+	if c.groupSyncPanes == nil {
+		c.groupSyncPanes = make(map[string]bool)
+	}
+	if on {
+		c.groupSync[name] = true
+	} else {
+		delete(c.groupSync, name)
+	}
+	for _, id := range panes {
+		if on {
+			c.groupSyncPanes[id] = true
+		} else {
+			delete(c.groupSyncPanes, id)
+		}
+	}
+	c.stateMu.Unlock()
+
+	if on {
+		groupTmux("set-option", groupSyncOptionName(name), "1")
+	} else {
+		groupTmux("set-option", "-u", groupSyncOptionName(name))
+	}
+	logEvent("GROUP_SYNC group=%s on=%v windows=%d", name, on, len(wins))
+	return nil
+}
+
+// applyPaneSync sets synchronize-panes to value ("on" or "off") on each pane.
+func applyPaneSync(panes []string, value string) {
+	for _, id := range panes {
+		groupTmux("set-option", "-p", "-t", id, "synchronize-panes", value)
+	}
+}
+
+// groupSyncChangesLocked reconciles synchronize-panes with the group flags
+// after regrouping. It returns the content panes of synced groups that are
+// not on yet (a window that joined the group, a new split) and the panes
+// turned on earlier that are no longer in a synced group, and records the
+// result in groupSyncPanes. Panes that are gone are just forgotten. Caller
+// holds stateMu.
+func (c *Coordinator) groupSyncChangesLocked() (on, off []string) {
+	if len(c.groupSync) == 0 && len(c.groupSyncPanes) == 0 {
+		return nil, nil
+	}
+	if c.groupSyncPanes == nil {
+		c.groupSyncPanes = make(map[string]bool)
+	}
+	want := make(map[string]bool)
+	for _, g := range c.grouped {
+		if !c.groupSync[g.Name] {
+			continue
+		}
+		for _, win := range g.Windows {
+			for _, id := range syncablePanes(win.Panes) {
+				want[id] = true
+			}
+		}
+	}
+	live := make(map[string]bool)
+	for _, win := range c.windows {
+		for _, p := range win.Panes {
+			live[p.ID] = true
+		}
+	}
+	for id := range want {
+		if !c.groupSyncPanes[id] {
+			c.groupSyncPanes[id] = true
+			on = append(on, id)
+		}
+	}
+	for id := range c.groupSyncPanes {
+		if want[id] {
+			continue
+		}
+		delete(c.groupSyncPanes, id)
+		if live[id] {
+			off = append(off, id)
+		}
+	}
+	sort.Strings(on)
+	sort.Strings(off)
+	return on, off
+}
+
+// toggleGroupSync flips the group's synchronize-panes state and returns the
+// new value.
+func (c *Coordinator) toggleGroupSync(name string) (bool, error) {
+	on := !c.isGroupSynced(name)
+	if err := c.setGroupSync(name, on); err != nil {
+		return false, err
+	}
+	return on, nil
+}
+
+// loadGroupSync restores the per-group sync flags from the session options
+// written by setGroupSync. Runs the tmux query before taking stateMu.
+func (c *Coordinator) loadGroupSync() {
+	out, err := tmuxOutputCtx("show-options", "-q")
+	if err != nil {
+		return
+	}
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	c.groupSync = parseGroupSyncOptions(string(out), c.knownGroupNamesLocked())
+}
+
+// parseGroupSyncOptions maps `show-options` output back to group names. The
+// option name flattens spaces to underscores, so it is matched against the
+// known group names rather than decoded.
+func parseGroupSyncOptions(out string, known []string) map[string]bool {
+	synced := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[1] != "1" {
+			continue
+		}
+		for _, name := range known {
+			if fields[0] == groupSyncOptionName(name) {
+				synced[name] = true
+			}
+		}
+	}
+	return synced
+}
+
+// knownGroupNamesLocked lists configured and currently displayed group names.
+// Caller must hold stateMu.
+func (c *Coordinator) knownGroupNamesLocked() []string {
+	seen := map[string]bool{"Default": true}
+	names := []string{"Default"}
+	for _, g := range c.config.Groups {
+		if !seen[g.Name] {
+			seen[g.Name] = true
+			names = append(names, g.Name)
+		}
+	}
+	for _, g := range c.grouped {
+		if !seen[g.Name] {
+			seen[g.Name] = true
+			names = append(names, g.Name)
+		}
+	}
+	return names
+}
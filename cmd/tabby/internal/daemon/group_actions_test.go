@@ -0,0 +1,147 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/daemon"
+	"github.com/brendandebeasi/tabby/pkg/grouping"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupSyncOptionName(t *testing.T) {
+	assert.Equal(t, "@tabby_grp_sync_Backend", groupSyncOptionName("Backend"))
+	assert.Equal(t, "@tabby_grp_sync_My_Project", groupSyncOptionName("My Project"))
+}
+
+func TestParseGroupSyncOptions(t *testing.T) {
+	out := "@tabby_grp_collapsed_Default 1\n" +
+		"@tabby_grp_sync_My_Project 1\n" +
+		"@tabby_grp_sync_Backend 0\n" +
+		"status on\n"
+	got := parseGroupSyncOptions(out, []string{"Default", "My Project", "Backend"})
+	assert.Equal(t, map[string]bool{"My Project": true}, got)
+}
+
+func TestSyncablePanesSkipsRenderers(t *testing.T) {
+	panes := []tmux.Pane{
+		{ID: "%1", Command: "tabby", StartCommand: "exec -a sidebar-renderer tabby render sidebar"},
+		{ID: "%2", Command: "zsh"},
+		{ID: "%3", Command: "tabby", StartCommand: "tabby render pane-header -pane '%2'"},
+		{ID: "%4", Command: "vim"},
+	}
+	assert.Equal(t, []string{"%2", "%4"}, syncablePanes(panes))
+}
+
+func TestHandleCtlValidation(t *testing.T) {
+	c := newTestCoordinator(t)
+
+	resp := c.HandleCtl(&daemon.CtlRequest{Op: "nope"})
+	assert.False(t, resp.OK)
+	assert.Contains(t, resp.Error, "unknown ctl op")
+
+	resp = c.HandleCtl(&daemon.CtlRequest{Op: "group-run", Args: []string{"Backend"}})
+	assert.False(t, resp.OK)
+	assert.Contains(t, resp.Error, "usage")
+
+	resp = c.HandleCtl(&daemon.CtlRequest{Op: "group-run", Args: []string{"Missing", "make"}})
+	assert.False(t, resp.OK)
+	assert.Contains(t, resp.Error, "no such group")
+
+	resp = c.HandleCtl(&daemon.CtlRequest{Op: "group-sync", Args: []string{"Missing", "sideways"}})
+	assert.False(t, resp.OK)
+	assert.Contains(t, resp.Error, "expected on, off or toggle")
+}
+
+func TestRunPromptedInGroupKeepsQuotes(t *testing.T) {
+	const typed = `git commit -m 'fix: it' && echo "done $USER"`
+	var ran []string
+	origTmux, origPane := groupTmux, groupContentPane
+	groupTmux = func(args ...string) ([]byte, error) {
+		ran = append(ran, strings.Join(args, " "))
+		if args[0] == "show-options" {
+			return []byte(typed + "\n"), nil
+		}
+		return nil, nil
+	}
+	groupContentPane = func(windowID, fallback string) string { return "%" + windowID }
+	t.Cleanup(func() { groupTmux, groupContentPane = origTmux, origPane })
+
+	c := newTestCoordinator(t)
+	c.grouped = []grouping.GroupedWindows{{Name: "Backend", Windows: []tmux.Window{{ID: "@1"}, {ID: "@2"}}}}
+	resp := c.HandleCtl(&daemon.CtlRequest{Op: "group-run-prompted", Args: []string{"Backend"}})
+	assert.True(t, resp.OK, resp.Error)
+	assert.Equal(t, "sent to 2 window(s) in Backend", resp.Output)
+	assert.Equal(t, []string{
+		"show-options -gqv @tabby_group_run",
+		"set-option -gqu @tabby_group_run",
+		"send-keys -t %@1 -l " + typed,
+		"send-keys -t %@1 Enter",
+		"send-keys -t %@2 -l " + typed,
+		"send-keys -t %@2 Enter",
+	}, ran)
+}
+
+func TestGroupRunPromptCommand(t *testing.T) {
+	// The typed command only ever lands in the option (%%%); the group name
+	// reaches the shell single-quoted, with tmux's quoting and format
+	// expansion escaped around it.
+	got := groupRunPromptCommand("/bin/tabby ctl", `Sam's "api" $HOME #1`)
+	want := `command-prompt -p "Run in all Sam's \"api\" \$HOME ####1 windows:" ` +
+		`"set-option -g @tabby_group_run \"%%%\" ; ` +
+		`run-shell \"/bin/tabby ctl group-run-prompted 'Sam'\\\\''s \\\"api\\\" \\\$HOME ####1'\""`
+	assert.Equal(t, want, got)
+}
+
+func TestGroupSyncFollowsMembership(t *testing.T) {
+	var ran []string
+	orig := groupTmux
+	groupTmux = func(args ...string) ([]byte, error) {
+		if args[1] == "-p" {
+			ran = append(ran, args[3]+" "+args[5])
+		}
+		return nil, nil
+	}
+	t.Cleanup(func() { groupTmux = orig })
+
+	c := newTestCoordinator(t)
+	c.config.Groups = []config.Group{{Name: "Backend"}}
+	api := testWindow("api", true, "zsh")
+	api.Group = "Backend"
+	web := testWindow("web", false, "zsh", "vim")
+	c.windows = []tmux.Window{api, web}
+	c.grouped = c.buildGroups(c.windows)
+	reconcile := func() {
+		ran = nil
+		on, off := c.groupSyncChangesLocked()
+		applyPaneSync(on, "on")
+		applyPaneSync(off, "off")
+	}
+
+	_, err := c.toggleGroupSync("Backend")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"%api-0 on"}, ran)
+	reconcile()
+	assert.Empty(t, ran, "nothing changed")
+
+	// web joins the synced group after the toggle.
+	c.windows[1].Group = "Backend"
+	c.grouped = c.buildGroups(c.windows)
+	reconcile()
+	assert.Equal(t, []string{"%web-0 on", "%web-1 on"}, ran)
+
+	// api leaves it; web's second pane is closed.
+	c.windows[0].Group = ""
+	c.windows[1].Panes = c.windows[1].Panes[:1]
+	c.grouped = c.buildGroups(c.windows)
+	reconcile()
+	assert.Equal(t, []string{"%api-0 off"}, ran)
+
+	_, err = c.toggleGroupSync("Backend")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"%web-0 off"}, ran[len(ran)-1:])
+	reconcile()
+	assert.Empty(t, ran)
+}
@@ -0,0 +1,272 @@
+package daemon
+
+// group_archive.go hibernates a whole group: every window of the group is
+// moved into the minimized holding session (see parkWindow), which takes it out
+// of native next/prev cycling, choose-tree and the dashboard in one go. The
+// sidebar keeps a single collapsed "archived (N)" header for the group, whose
+// menu offers "Unarchive Group".
+//
+// Archived windows are tagged, per window, with:
+//
+//	@tabby_archived        the group name the window was archived under
+//	@tabby_archive_order   its position in the group at archive time
+//	@tabby_archive_layout  its window_layout at archive time
+//
+// plus the usual @tabby_min_origin, so cleanupMinimizedSessionIfEmpty keeps the
+// holding session alive and other user sessions ignore them. They are NOT
+// flagged @tabby_minimized: an archived window never peeks and never shows in
+// the Minimized section. Unarchiving moves the windows back in archive order
+// and re-applies the saved layout, since the trip through the 80x24 holding
+// session squashes pane proportions.
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/grouping"
+)
+
+// archivedWindow is one window parked by archiveGroup.
+type archivedWindow struct {
+	ID     string
+	Group  string
+	Order  int
+	Layout string
+}
+
+// archiveWindowOptions are the window options archiving sets, unset again
+// on unarchive or when parking fails.
+var archiveWindowOptions = []string{"@tabby_archived", "@tabby_archive_order", "@tabby_archive_layout", "@tabby_min_origin", "@tabby_min_dir", "@tabby_min_host"}
+
+// archiveTmux runs a tmux command; a variable for tests.
+var archiveTmux = tmuxRun
+
+// archivePark parks a window for archiveGroup and reports whether it is in
+// the holding session afterwards; a variable for tests.
+var archivePark = func(c *Coordinator, windowID string) bool {
+	return c.parkWindow(windowID, false) || isParkedWindow(windowID)
+}
+
+// archivedWindowCacheTTL bounds how long a cached listing is trusted, for
+// archived windows closed behind tabby's back.
+const archivedWindowCacheTTL = 30 * time.Second
+
+// archivedWindowCache memoizes listArchivedWindows for RefreshWindows, which
+// would otherwise run its tmux execs on every refresh. archiveGroup and
+// unarchiveGroup invalidate it. Own mutex; never take stateMu while holding
+// it.
+type archivedWindowCache struct {
+	mu   sync.Mutex
+	at   time.Time // zero when invalid
+	wins []archivedWindow
+}
+
+// cachedArchivedWindows is listArchivedWindows through the cache. Runs tmux
+// when the cache is stale; call without stateMu held.
+func (c *Coordinator) cachedArchivedWindows() []archivedWindow {
+	s := &c.archivedCache
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.at.IsZero() || time.Since(s.at) >= archivedWindowCacheTTL {
+		s.wins = c.listArchivedWindows()
+		s.at = time.Now()
+	}
+	return s.wins
+}
+
+// invalidateArchivedWindows makes the next cachedArchivedWindows list again.
+func (c *Coordinator) invalidateArchivedWindows() {
+	s := &c.archivedCache
+	s.mu.Lock()
+	s.at = time.Time{}
+	s.mu.Unlock()
+}
+
+// archiveGroup parks every window of the group in the holding session.
+// Returns how many windows were archived.
+func (c *Coordinator) archiveGroup(name string) (int, error) {
+	defer c.invalidateArchivedWindows()
+	wins, ok := c.groupWindows(name)
+	if !ok || len(wins) == 0 {
+		return 0, fmt.Errorf("no such group: %s", name)
+	}
+	origin := c.dashboardSession()
+	archived := 0
+	for i, win := range wins {
+		// A minimized window of the group is archived too; drop its minimized
+		// state so it comes back as an ordinary window on unarchive.
+		c.clearPeekIf(win.ID)
+		archiveTmux("set-window-option", "-t", win.ID, "-u", "@tabby_minimized")
+		archiveTmux("set-window-option", "-t", win.ID, "@tabby_archived", name)
+		archiveTmux("set-window-option", "-t", win.ID, "@tabby_archive_order", strconv.Itoa(i))
+		if win.Layout != "" {
+			archiveTmux("set-window-option", "-t", win.ID, "@tabby_archive_layout", win.Layout)
+		}
+		archiveTmux("set-window-option", "-t", win.ID, "@tabby_min_origin", origin)
+		if archivePark(c, win.ID) {
+			archived++
+			continue
+		}
+		// Still in the user session: untag it, or the sidebar would hide a
+		// live window as archived.
+		coordinatorDebugLog.Printf("archiveGroup: parking %s failed", win.ID)
+		for _, opt := range archiveWindowOptions {
+			archiveTmux("set-window-option", "-t", win.ID, "-u", opt)
+		}
+	}
+	logEvent("GROUP_ARCHIVE group=%s windows=%d archived=%d", name, len(wins), archived)
+	return archived, nil
+}
+
+// unarchiveGroup moves the group's archived windows back into this session in
+// their original order and restores each one's layout.
+func (c *Coordinator) unarchiveGroup(name string) (int, error) {
+	defer c.invalidateArchivedWindows()
+	var wins []archivedWindow
+	for _, aw := range c.listArchivedWindows() {
+		if aw.Group == name {
+			wins = append(wins, aw)
+		}
+	}
+	if len(wins) == 0 {
+		return 0, fmt.Errorf("group %s is not archived", name)
+	}
+	sortArchivedWindows(wins)
+	origin := c.dashboardSession()
+	restored := 0
+	for _, aw := range wins {
+		// move-window without an index appends, so moving in archive order
+		// rebuilds the group's original left-to-right order.
+		if err := exec.Command("tmux", "move-window", "-d", "-s", aw.ID, "-t", origin+":").Run(); err != nil {
+			coordinatorDebugLog.Printf("unarchiveGroup: move-window failed for %s: %v", aw.ID, err)
+			continue
+		}
+		if aw.Layout != "" {
+			tmuxRun("select-layout", "-t", aw.ID, aw.Layout)
+		}
+		for _, opt := range archiveWindowOptions {
+			tmuxRun("set-window-option", "-t", aw.ID, "-u", opt)
+		}
+		restored++
+	}
+	cleanupMinimizedSessionIfEmpty()
+	logEvent("GROUP_UNARCHIVE group=%s windows=%d restored=%d", name, len(wins), restored)
+	return restored, nil
+}
+
+// listArchivedWindows returns this session's archived windows from the
+// holding session. Runs tmux; call without stateMu held.
+func (c *Coordinator) listArchivedWindows() []archivedWindow {
+	if err := exec.Command("tmux", "has-session", "-t", minimizedHoldingSession).Run(); err != nil {
+		return nil
+	}
+	out, err := exec.Command("tmux", "list-windows", "-t", minimizedHoldingSession, "-F",
+		"#{window_id}\t#{@tabby_min_origin}\t#{@tabby_archived}\t#{@tabby_archive_order}\t#{@tabby_archive_layout}").Output()
+	if err != nil {
+		return nil
+	}
+	return parseArchivedWindows(string(out), c.dashboardSession())
+}
+
+// parseArchivedWindows parses listArchivedWindows' list-windows output,
+// keeping archived windows that belong to origin.
+func parseArchivedWindows(out, origin string) []archivedWindow {
+	var wins []archivedWindow
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		f := strings.Split(line, "\t")
+		if len(f) < 5 {
+			continue
+		}
+		group := strings.TrimSpace(f[2])
+		if group == "" || strings.TrimSpace(f[1]) != origin {
+			continue
+		}
+		order, _ := strconv.Atoi(strings.TrimSpace(f[3]))
+		wins = append(wins, archivedWindow{
+			ID:     strings.TrimSpace(f[0]),
+			Group:  group,
+			Order:  order,
+			Layout: strings.TrimSpace(f[4]),
+		})
+	}
+	return wins
+}
+
+// sortArchivedWindows orders windows by archive position, falling back to the
+// stable window ID.
+func sortArchivedWindows(wins []archivedWindow) {
+	sort.SliceStable(wins, func(i, j int) bool {
+		if wins[i].Order != wins[j].Order {
+			return wins[i].Order < wins[j].Order
+		}
+		return grouping.WindowIDNum(wins[i].ID) < grouping.WindowIDNum(wins[j].ID)
+	})
+}
+
+// archivedGroupCounts tallies archived windows per group name.
+func archivedGroupCounts(wins []archivedWindow) map[string]int {
+	counts := make(map[string]int)
+	for _, aw := range wins {
+		counts[aw.Group]++
+	}
+	return counts
+}
+
+// isArchivedGroupLocked reports whether name has archived windows. Caller
+// must hold stateMu.
+func (c *Coordinator) isArchivedGroupLocked(name string) bool {
+	return c.archivedGroups[name] > 0
+}
+
+// showArchivedGroupMenuLocked is the context menu for an archived group's
+// header row (the group has no live windows, so showGroupContextMenu finds
+// nothing in c.grouped). Caller must hold stateMu.
+func (c *Coordinator) showArchivedGroupMenuLocked(clientID, name string, pos menuPosition) {
+	hookPath := c.getHookPath()
+	args := append([]string{
+		"display-menu",
+		"-O",
+		"-T", fmt.Sprintf("Group: %s (archived, %d windows)", name, c.archivedGroups[name]),
+	}, pos.args()...)
+	unarchiveCmd := fmt.Sprintf("run-shell '%s unarchive-group \\\"%s\\\"'", hookPath, name)
+	args = append(args, "Unarchive Group", "u", unarchiveCmd)
+	c.executeOrSendMenu(clientID, args, pos)
+}
+
+// appendArchivedGroupRows adds an empty header row, themed from config, for
+// every archived group not already in groups, so the sidebar shows
+// "<name> archived (N)". Rows are added in name order to keep the sidebar
+// stable across refreshes.
+func appendArchivedGroupRows(groups []grouping.GroupedWindows, archived map[string]int, cfgGroups []config.Group) []grouping.GroupedWindows {
+	if len(archived) == 0 {
+		return groups
+	}
+	present := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		present[g.Name] = true
+	}
+	names := make([]string, 0, len(archived))
+	for name, n := range archived {
+		if n > 0 && !present[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		row := grouping.GroupedWindows{Name: name}
+		for _, cg := range cfgGroups {
+			if cg.Name == name {
+				row.Theme = cg.Theme
+				break
+			}
+		}
+		groups = append(groups, row)
+	}
+	return groups
+}
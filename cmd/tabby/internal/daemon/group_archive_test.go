@@ -0,0 +1,107 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/daemon"
+	"github.com/brendandebeasi/tabby/pkg/grouping"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseArchivedWindows(t *testing.T) {
+	out := "@1\t\t\t\t\n" + // new-session placeholder
+		"@7\t$1\tBackend\t1\tabc1,80x24,0,0,7\n" +
+		"@5\t$1\tBackend\t0\t\n" +
+		"@9\t$2\tBackend\t0\t\n" + // another session's archive
+		"@4\t$1\t\t\t\n" // plain minimized window
+	got := parseArchivedWindows(out, "$1")
+	assert.Equal(t, []archivedWindow{
+		{ID: "@7", Group: "Backend", Order: 1, Layout: "abc1,80x24,0,0,7"},
+		{ID: "@5", Group: "Backend", Order: 0},
+	}, got)
+}
+
+func TestSortArchivedWindows(t *testing.T) {
+	wins := []archivedWindow{
+		{ID: "@12", Order: 2},
+		{ID: "@10", Order: 0},
+		{ID: "@9", Order: 1},
+		{ID: "@3", Order: 1},
+	}
+	sortArchivedWindows(wins)
+	var ids []string
+	for _, w := range wins {
+		ids = append(ids, w.ID)
+	}
+	assert.Equal(t, []string{"@10", "@3", "@9", "@12"}, ids)
+}
+
+func TestAppendArchivedGroupRows(t *testing.T) {
+	groups := []grouping.GroupedWindows{{Name: "Default"}, {Name: "Docs"}}
+	cfg := []config.Group{{Name: "Backend", Theme: config.Theme{Bg: "#3498db"}}}
+	got := appendArchivedGroupRows(groups, map[string]int{"Docs": 2, "Backend": 7, "Alpha": 1}, cfg)
+	var names []string
+	for _, g := range got {
+		names = append(names, g.Name)
+	}
+	// Docs still has live windows, so it is annotated in place, not duplicated.
+	assert.Equal(t, []string{"Default", "Docs", "Alpha", "Backend"}, names)
+	assert.Equal(t, "#3498db", got[3].Theme.Bg)
+	assert.Empty(t, got[3].Windows)
+}
+
+func TestHandleCtlArchiveValidation(t *testing.T) {
+	c := newTestCoordinator(t)
+
+	resp := c.HandleCtl(&daemon.CtlRequest{Op: "group-archive"})
+	assert.False(t, resp.OK)
+	assert.Contains(t, resp.Error, "usage")
+
+	resp = c.HandleCtl(&daemon.CtlRequest{Op: "group-archive", Args: []string{"Missing"}})
+	assert.False(t, resp.OK)
+	assert.Contains(t, resp.Error, "no such group")
+}
+
+func TestArchivedWindowCache(t *testing.T) {
+	c := newTestCoordinator(t)
+	cached := []archivedWindow{{ID: "@7", Group: "Backend"}}
+	c.archivedCache.wins, c.archivedCache.at = cached, time.Now()
+
+	// A fresh cache is served without asking tmux.
+	assert.Equal(t, cached, c.cachedArchivedWindows())
+
+	// Archiving (even a failed attempt) drops it.
+	_, err := c.archiveGroup("Missing")
+	assert.Error(t, err)
+	assert.True(t, c.archivedCache.at.IsZero())
+}
+
+func TestArchiveGroupUntagsWindowThatFailedToPark(t *testing.T) {
+	options := map[string]map[string]string{"@1": {}, "@2": {}}
+	origTmux, origPark := archiveTmux, archivePark
+	archiveTmux = func(args ...string) error {
+		// set-window-option -t <id> [-u] <option> [value]
+		id := args[2]
+		if args[3] == "-u" {
+			delete(options[id], args[4])
+		} else {
+			options[id][args[3]] = args[4]
+		}
+		return nil
+	}
+	archivePark = func(_ *Coordinator, id string) bool { return id == "@1" }
+	t.Cleanup(func() { archiveTmux, archivePark = origTmux, origPark })
+
+	c := newTestCoordinator(t)
+	c.sessionID = "$1"
+	c.grouped = []grouping.GroupedWindows{{Name: "Backend", Windows: []tmux.Window{{ID: "@1"}, {ID: "@2", Layout: "abc1,80x24,0,0,2"}}}}
+
+	n, err := c.archiveGroup("Backend")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, map[string]string{"@tabby_archived": "Backend", "@tabby_archive_order": "0", "@tabby_min_origin": "$1"}, options["@1"])
+	assert.Empty(t, options["@2"], "a window left in the session must not stay tagged as archived")
+}
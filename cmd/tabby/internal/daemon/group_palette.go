@@ -0,0 +1,41 @@
+package daemon
+
+// group_palette.go fills in colors for groups configured without a bg. The
+// allocator (colors.AllocateGroupColors) is keyed by group name, so a group's
+// color no longer depends on its position in the config or the sidebar, and
+// it is kept perceptually distinct from every other group, explicit or not.
+// Colors are allocated over every configured group, not just the ones on
+// screen, so groups appearing or emptying never recolor the others, and the
+// archived rows in sidebarRenderGroups get the same colors as live ones.
+
+import (
+	"github.com/brendandebeasi/tabby/pkg/colors"
+	"github.com/brendandebeasi/tabby/pkg/grouping"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// buildGroups groups windows against the configured groups and assigns
+// palette colors to the ones without an explicit bg. Caller must hold stateMu.
+func (c *Coordinator) buildGroups(windows []tmux.Window) []grouping.GroupedWindows {
+	grouped := grouping.GroupWindowsWithOptions(windows, c.config.Groups, c.config.Sidebar.ShowEmptyGroups)
+	grouping.AssignDefaultColors(grouped, c.config.Groups, c.groupPaletteOptions())
+	return grouped
+}
+
+// groupPaletteOptions matches the palette to the active theme: its dark/light
+// mode, the lightness of its default group color, and a contrast floor
+// against the terminal background so a tab never melts into the sidebar.
+func (c *Coordinator) groupPaletteOptions() colors.PaletteOptions {
+	opts := colors.PaletteOptions{}
+	if c.bgDetector != nil {
+		opts.Dark = c.bgDetector.IsDarkBackground()
+	}
+	if c.theme != nil {
+		opts.Dark = c.theme.Dark
+		opts.Lightness = colors.PerceptualLightness(c.theme.DefaultGroupBg)
+	}
+	if c.config.PaneHeader.TerminalBg != "" || c.theme != nil || c.bgDetector != nil {
+		opts.Background = c.GetTerminalBg()
+	}
+	return opts
+}
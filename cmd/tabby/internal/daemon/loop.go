@@ -36,8 +36,7 @@ type ClientGeomTickEvent struct{}
 type WindowCheckTickEvent struct{}
 type AnimationTickEvent struct{}
 type RefreshTickEvent struct{}
-type GitTickEvent struct{}
-type TeamClaudeTickEvent struct{}
+type WidgetTickEvent struct{}
 type AutoThemeTickEvent struct{}
 type WatchdogTickEvent struct{}
 type IdleTickEvent struct{}
@@ -56,8 +55,7 @@ func (ClientGeomTickEvent) kind() string  { return "tick:client_geom" }
 func (WindowCheckTickEvent) kind() string { return "tick:window_check" }
 func (AnimationTickEvent) kind() string   { return "tick:animation" }
 func (RefreshTickEvent) kind() string     { return "tick:refresh" }
-func (GitTickEvent) kind() string         { return "tick:git" }
-func (TeamClaudeTickEvent) kind() string  { return "tick:teamclaude" }
+func (WidgetTickEvent) kind() string      { return "tick:widget" }
 func (AutoThemeTickEvent) kind() string   { return "tick:auto_theme" }
 func (WatchdogTickEvent) kind() string    { return "tick:watchdog" }
 func (IdleTickEvent) kind() string        { return "tick:idle" }
@@ -153,7 +151,6 @@ type Loop struct {
 	activeWindowID     string
 	lastWindowsHash    string
 	lastStructuralHash string
-	lastGitState       string
 	lastAutoTheme      string
 	lastClientGeom     string
 	lastResizeKey      string
@@ -323,10 +320,8 @@ func (l *Loop) dispatch(ev Event) {
 		l.handleAnimationTick()
 	case RefreshTickEvent:
 		l.handleRefreshTick()
-	case GitTickEvent:
-		l.handleGitTick()
-	case TeamClaudeTickEvent:
-		l.handleTeamClaudeTick()
+	case WidgetTickEvent:
+		l.handleWidgetTick()
 	case AutoThemeTickEvent:
 		l.handleAutoThemeTick()
 	case WatchdogTickEvent:
@@ -947,31 +942,21 @@ func (l *Loop) handleAnimationTick() {
 	})
 }
 
-// handleGitTick is the migrated body of the gitTicker case.
-func (l *Loop) handleGitTick() {
-	l.flags.git.Store(false)
-	l.deps.RunLoopTask("git_tick", 6*time.Second, func() {
-		// Only broadcast if git state changed
-		currentGitState := l.coord.GetGitStateHash()
-		if currentGitState != l.lastGitState {
-			perf.Log("gitTick (changed)")
-			l.coord.RefreshGit()
-			l.coord.RefreshSession()
+// handleWidgetTick refreshes the sidebar widgets that are due (widgets.go).
+// Each widget declares its own cadence; synchronous refreshes (git, session,
+// stats) run inline, slow ones (teamclaude, claude usage, widgets.custom)
+// start detached goroutines that trigger their own render. The sidebar
+// re-renders when a refreshed widget's state hash changed.
+func (l *Loop) handleWidgetTick() {
+	l.flags.widget.Store(false)
+	l.deps.RunLoopTaskNonFatal("widget_tick", 6*time.Second, func() {
+		if l.coord.RefreshWidgets(time.Now()) {
+			perf.Log("widgetTick (changed)")
 			l.server.BroadcastRender()
-			l.lastGitState = currentGitState
 		}
 	})
 }
 
-// handleTeamClaudeTick kicks off a (throttled, coalesced) teamclaude quota
-// refresh. RefreshTeamClaude returns immediately and does the HTTP fetch in a
-// detached goroutine that triggers its own render on change, so this handler
-// never blocks the event loop — no RunLoopTask wrapper needed.
-func (l *Loop) handleTeamClaudeTick() {
-	l.flags.teamClaude.Store(false)
-	l.coord.RefreshTeamClaude()
-}
-
 // handleTabSummaryTick triggers auto tab-summary generation. Like the TeamClaude
 // handler, RefreshTabSummaries returns immediately and does the capture + LLM
 // work in a coalesced goroutine, so the event loop never blocks.
@@ -14,8 +14,7 @@ type tickFlags struct {
 	window     atomic.Bool
 	anim       atomic.Bool
 	refresh    atomic.Bool
-	git        atomic.Bool
-	teamClaude atomic.Bool
+	widget     atomic.Bool
 	autoTheme  atomic.Bool
 	watchdog   atomic.Bool
 	idle       atomic.Bool
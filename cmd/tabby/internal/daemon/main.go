@@ -650,7 +650,9 @@ func cleanupOrphanedSidebars(windows []tmux.Window, coordinator *Coordinator) {
 					liveSidebars = append(liveSidebars, paneID)
 				}
 			}
-			if dead {
+			// With indicators.crash.keep_failed a dead content pane still
+			// holds the window open, so its crash output can be read (crash.go).
+			if dead && !coordinator.keepsFailedPanes() {
 				continue
 			}
 			if !paneIsSystemPane(cmd, startCmd) {
@@ -817,7 +819,9 @@ func cleanupOrphanWindowsByTmux(sessionID string, coordinator *Coordinator) {
 			if strings.Contains(cmd, "sidebar") || strings.Contains(startCmd, "sidebar") {
 				hasSidebar = true
 			}
-			if dead {
+			// With indicators.crash.keep_failed a dead content pane still
+			// holds the window open, so its crash output can be read (crash.go).
+			if dead && !coordinator.keepsFailedPanes() {
 				continue
 			}
 			if !paneIsSystemPane(cmd, startCmd) {
@@ -861,7 +865,9 @@ func cleanupOrphanWindowsByTmux(sessionID string, coordinator *Coordinator) {
 			if strings.Contains(cmd, "sidebar") || strings.Contains(startCmd, "sidebar") {
 				confirmHasSidebar = true
 			}
-			if dead {
+			// With indicators.crash.keep_failed a dead content pane still
+			// holds the window open, so its crash output can be read (crash.go).
+			if dead && !coordinator.keepsFailedPanes() {
 				continue
 			}
 			if !paneIsSystemPane(cmd, startCmd) {
@@ -2576,6 +2582,19 @@ func Run(args []string) int {
 		return coordinator.HandlePetQA(req)
 	}
 
+	// OnCtl: `tabby ctl` requests follow the same synchronous contract as
+	// OnPetQA. A successful op may have changed something the sidebar shows
+	// (a group's sync badge, windows archived out of the session), so queue a
+	// refresh on the loop and repaint.
+	server.OnCtl = func(req *daemon.CtlRequest) *daemon.CtlResponse {
+		resp := coordinator.HandleCtl(req)
+		if resp != nil && resp.OK {
+			loop.SubmitRefresh()
+			server.BroadcastRender()
+		}
+		return resp
+	}
+
 	// Set up menu send callback for in-renderer context menus
 	coordinator.OnSendMenu = func(clientID string, menu *daemon.MenuPayload) {
 		server.SendMenuToClient(clientID, menu)
@@ -2857,8 +2876,8 @@ func Run(args []string) int {
 		go runTicker(loopCtx, 100*time.Millisecond, func() { loop.submitCoalesced(&loop.flags.anim, AnimationTickEvent{}) })
 		go runTicker(loopCtx, 3*time.Second, func() { loop.submitCoalesced(&loop.flags.window, WindowCheckTickEvent{}) })
 		go runTicker(loopCtx, 30*time.Second, func() { loop.submitCoalesced(&loop.flags.refresh, RefreshTickEvent{}) })
-		go runTicker(loopCtx, 5*time.Second, func() { loop.submitCoalesced(&loop.flags.git, GitTickEvent{}) })
-		go runTicker(loopCtx, 15*time.Second, func() { loop.submitCoalesced(&loop.flags.teamClaude, TeamClaudeTickEvent{}) })
+		// Sidebar widgets: RefreshWidgets runs each one on its own interval.
+		go runTicker(loopCtx, time.Second, func() { loop.submitCoalesced(&loop.flags.widget, WidgetTickEvent{}) })
 		go runTicker(loopCtx, 5*time.Second, func() { loop.submitCoalesced(&loop.flags.watchdog, WatchdogTickEvent{}) })
 		go runTicker(loopCtx, 60*time.Second, func() { loop.submitCoalesced(&loop.flags.autoTheme, AutoThemeTickEvent{}) })
 		go runTicker(loopCtx, 3*time.Second, func() { loop.submitCoalesced(&loop.flags.socket, SocketCheckTickEvent{}) })
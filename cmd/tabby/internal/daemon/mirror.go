@@ -0,0 +1,300 @@
+package daemon
+
+// mirror.go is the pane-mirror widget: a pane pinned from its context menu
+// ("Mirror in Sidebar") is captured with capture-pane -e about once a second
+// and its last widgets.mirror.lines lines are shown in the sidebar, cut or
+// wrapped to the sidebar width. The pane's colors are kept unless
+// widgets.mirror.colors is false; tmux converts them for each client's
+// terminal. Clicking the lines jumps to the pane, the ✕ unpins it.
+//
+// The pinned pane is kept in the session option @tabby_mirror_pane so it
+// survives a daemon restart. When a capture fails the pane is unpinned only
+// if tmux no longer lists it (it was closed); any other failure keeps the pin
+// and the last lines until the next capture.
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+)
+
+// mirrorTmux runs a tmux command; a variable for tests.
+var mirrorTmux = func(args ...string) ([]byte, error) {
+	return exec.Command("tmux", args...).Output()
+}
+
+// mirrorState is the pinned pane and its last capture, guarded by stateMu.
+type mirrorState struct {
+	paneID string
+	loaded bool // @tabby_mirror_pane read back after a restart
+	lines  []string
+}
+
+// mirrorSessionArgs targets this daemon's session with a session option.
+func (c *Coordinator) mirrorSessionArgs(args ...string) []string {
+	if c.sessionID != "" {
+		args = append(args, "-t", c.sessionID)
+	}
+	return args
+}
+
+// setMirrorPane pins paneID to the mirror widget, or unpins it when empty,
+// and records the choice in @tabby_mirror_pane.
+func (c *Coordinator) setMirrorPane(paneID string) {
+	c.stateMu.Lock()
+	c.mirror = mirrorState{paneID: paneID, loaded: true}
+	c.stateMu.Unlock()
+	if paneID == "" {
+		mirrorTmux(append(c.mirrorSessionArgs("set-option", "-qu"), "@tabby_mirror_pane")...)
+		return
+	}
+	mirrorTmux(append(c.mirrorSessionArgs("set-option", "-q"), "@tabby_mirror_pane", paneID)...)
+}
+
+// toggleMirrorPane pins paneID, or unpins it when it is already pinned.
+func (c *Coordinator) toggleMirrorPane(paneID string) {
+	c.stateMu.RLock()
+	pinned := c.mirror.paneID
+	c.stateMu.RUnlock()
+	if pinned == paneID {
+		paneID = ""
+	}
+	c.setMirrorPane(paneID)
+}
+
+// refreshMirror captures the pinned pane's screen.
+func (c *Coordinator) refreshMirror() {
+	cfg := c.config.Widgets.Mirror
+	c.stateMu.RLock()
+	paneID, loaded := c.mirror.paneID, c.mirror.loaded
+	c.stateMu.RUnlock()
+	if !loaded {
+		out, _ := mirrorTmux(append(c.mirrorSessionArgs("show-options", "-qv"), "@tabby_mirror_pane")...)
+		paneID = strings.TrimSpace(string(out))
+		c.stateMu.Lock()
+		if !c.mirror.loaded {
+			c.mirror = mirrorState{paneID: paneID, loaded: true}
+		}
+		c.stateMu.Unlock()
+	}
+	if paneID == "" {
+		return
+	}
+
+	out, err := mirrorTmux("capture-pane", "-p", "-e", "-t", paneID)
+	if err != nil {
+		if mirrorPaneGone(paneID) {
+			coordinatorDebugLog.Printf("mirror: capture %s: %v, pane closed, unpinning", paneID, err)
+			c.setMirrorPane("")
+			return
+		}
+		coordinatorDebugLog.Printf("mirror: capture %s: %v", paneID, err)
+		return
+	}
+	lines := mirrorLines(string(out), cfg.Lines, headerBoolDefault(cfg.Colors))
+	c.stateMu.Lock()
+	if c.mirror.paneID == paneID {
+		c.mirror.lines = lines
+	}
+	c.stateMu.Unlock()
+}
+
+// mirrorPaneGone reports whether tmux lists every pane of the server and
+// paneID is not among them. A failed listing says nothing about the pane.
+func mirrorPaneGone(paneID string) bool {
+	out, err := mirrorTmux("list-panes", "-a", "-F", "#{pane_id}")
+	if err != nil {
+		return false
+	}
+	for _, id := range strings.Fields(string(out)) {
+		if id == paneID {
+			return false
+		}
+	}
+	return true
+}
+
+// mirrorLines keeps the last n lines of a capture, without the blank rows
+// below the cursor, sanitized to text and (with colors) SGR sequences.
+func mirrorLines(capture string, n int, colors bool) []string {
+	lines := strings.Split(strings.TrimRight(capture, "\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(sanitizeWidgetText(lines[len(lines)-1], false)) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(sanitizeWidgetText(l, colors), " ")
+	}
+	return lines
+}
+
+// fitMirrorLine cuts a line with SGR sequences into rows of width cells,
+// padded with spaces; without wrap only the first row is kept. Colors carry
+// over to continuation rows and every colored row ends with a reset.
+func fitMirrorLine(line string, width int, wrap bool) []string {
+	if width < 1 {
+		return nil
+	}
+	var rows []string
+	var row strings.Builder
+	var sgr string // SGR sequences in effect
+	cells := 0
+	flush := func() {
+		if sgr != "" {
+			row.WriteString("\x1b[0m")
+		}
+		row.WriteString(strings.Repeat(" ", width-cells))
+		rows = append(rows, row.String())
+		row.Reset()
+		row.WriteString(sgr)
+		cells = 0
+	}
+	for i := 0; i < len(line); {
+		if line[i] == 0x1b {
+			end := strings.IndexByte(line[i:], 'm')
+			if end < 0 {
+				break
+			}
+			seq := line[i : i+end+1]
+			if seq == "\x1b[0m" || seq == "\x1b[m" {
+				sgr = ""
+			} else {
+				sgr += seq
+			}
+			row.WriteString(seq)
+			i += end + 1
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		w := runewidth.RuneWidth(r)
+		if cells+w > width {
+			if !wrap {
+				break
+			}
+			flush()
+		}
+		row.WriteRune(r)
+		cells += w
+		i += size
+	}
+	flush()
+	return rows
+}
+
+// renderMirrorWidget renders the pinned pane's last lines under a header
+// naming the pane.
+func (c *Coordinator) renderMirrorWidget(width int) string {
+	cfg := c.config.Widgets.Mirror
+	paneID := c.mirror.paneID
+	if !headerBoolDefault(cfg.Enabled) || paneID == "" {
+		return ""
+	}
+	label := paneID
+	for _, win := range c.windows {
+		for _, p := range win.Panes {
+			if p.ID == paneID {
+				label = fmt.Sprintf("%s %s", win.Name, p.Command)
+			}
+		}
+	}
+
+	fg := c.getInactiveTextColorWithFallback(cfg.Fg)
+	style := lipgloss.NewStyle()
+	if fg != "" {
+		style = style.Foreground(lipgloss.Color(fg))
+	}
+	if cfg.Bg != "" {
+		style = style.Background(lipgloss.Color(cfg.Bg))
+	}
+
+	var result strings.Builder
+	// Header: "⧉ <window> <command>" and an unpin button at the right edge.
+	head := runewidth.Truncate("⧉ "+label, max(width-2, 1), "…")
+	gap := max(width-runewidth.StringWidth(head)-1, 1)
+	result.WriteString(style.Render(head+strings.Repeat(" ", gap)) + zone.Mark("mirror:unpin", style.Render("✕")) + "\n")
+
+	// Body: the last lines. Rows are full width, so the block is one click
+	// target.
+	var rows []string
+	for _, l := range c.mirror.lines {
+		rows = append(rows, fitMirrorLine(l, width, cfg.Wrap)...)
+	}
+	if len(rows) > cfg.Lines {
+		rows = rows[len(rows)-cfg.Lines:]
+	}
+	if len(rows) > 0 {
+		result.WriteString(zone.Mark("mirror:jump", strings.Join(rows, "\n")) + "\n")
+	}
+
+	return c.wrapWidgetChrome(widgetLayout{
+		Divider: cfg.Divider, DividerFg: cfg.DividerFg,
+		MarginTop: cfg.MarginTop, PaddingTop: cfg.PaddingTop, PaddingBot: cfg.PaddingBot, MarginBot: cfg.MarginBot,
+	}, result.String(), width)
+}
+
+// jumpToMirrorPane focuses the pinned pane's window and the pane.
+func (c *Coordinator) jumpToMirrorPane() bool {
+	c.stateMu.RLock()
+	paneID := c.mirror.paneID
+	windowID := ""
+	for _, win := range c.windows {
+		for _, p := range win.Panes {
+			if p.ID == paneID {
+				windowID = win.ID
+			}
+		}
+	}
+	c.stateMu.RUnlock()
+	if windowID == "" {
+		return false
+	}
+	mirrorTmux("select-window", "-t", windowID, ";", "select-pane", "-t", paneID)
+	return true
+}
+
+// mirrorWidget is the registry entry for the pane mirror.
+type mirrorWidget struct{ widgetBase }
+
+func (mirrorWidget) Name() string { return "mirror" }
+
+func (mirrorWidget) Config(cfg *config.Config) WidgetConfig {
+	w := cfg.Widgets.Mirror
+	return WidgetConfig{Enabled: headerBoolDefault(w.Enabled), Position: widgetPosition(w.Position, "bottom"), Priority: w.Priority}
+}
+
+func (mirrorWidget) Interval(cfg *config.Config) time.Duration {
+	if !headerBoolDefault(cfg.Widgets.Mirror.Enabled) {
+		return 0
+	}
+	return secondsOr(cfg.Widgets.Mirror.UpdateInterval, time.Second)
+}
+
+func (mirrorWidget) Refresh(c *Coordinator) { c.refreshMirror() }
+
+func (mirrorWidget) StateHash(c *Coordinator) string {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.mirror.paneID + "\n" + strings.Join(c.mirror.lines, "\n")
+}
+
+func (mirrorWidget) Render(c *Coordinator, width int) string { return c.renderMirrorWidget(width) }
+
+func (mirrorWidget) Actions() []string { return []string{"jump", "unpin"} }
+
+func (mirrorWidget) Click(c *Coordinator, clientID, action string) bool {
+	if action == "unpin" {
+		c.setMirrorPane("")
+		return true
+	}
+	return c.jumpToMirrorPane()
+}
@@ -0,0 +1,131 @@
+package daemon
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// zoneMarkRegex matches the bubblezone markers around a clickable region.
+var zoneMarkRegex = regexp.MustCompile(`\x1b\[[0-9]+z`)
+
+// stripWidget removes colors and zone markers from a rendered widget.
+func stripWidget(s string) string {
+	return zoneMarkRegex.ReplaceAllString(stripAnsi(s), "")
+}
+
+// stubMirrorTmux replaces the tmux runner. capture is the capture-pane
+// output and panes the list-panes output, each an error when nil. Returns the
+// commands run, joined by spaces.
+func stubMirrorTmux(t *testing.T, option string, capture, panes *string) func() []string {
+	t.Helper()
+	var mu sync.Mutex
+	var ran []string
+	orig := mirrorTmux
+	mirrorTmux = func(args ...string) ([]byte, error) {
+		mu.Lock()
+		ran = append(ran, strings.Join(args, " "))
+		mu.Unlock()
+		switch args[0] {
+		case "show-options":
+			return []byte(option + "\n"), nil
+		case "capture-pane":
+			if capture == nil {
+				return nil, errors.New("can't find pane")
+			}
+			return []byte(*capture), nil
+		case "list-panes":
+			if panes == nil {
+				return nil, errors.New("server busy")
+			}
+			return []byte(*panes), nil
+		}
+		return nil, nil
+	}
+	t.Cleanup(func() { mirrorTmux = orig })
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ran...)
+	}
+}
+
+func TestFitMirrorLine(t *testing.T) {
+	assert.Equal(t, []string{"hello"}, fitMirrorLine("hello world", 5, false))
+	assert.Equal(t, []string{"hi   "}, fitMirrorLine("hi", 5, false), "padded to width")
+	assert.Equal(t, []string{"hello", " worl", "d    "}, fitMirrorLine("hello world", 5, true))
+
+	rows := fitMirrorLine("\x1b[31mabcdef\x1b[0mgh", 4, true)
+	assert.Equal(t, []string{"\x1b[31mabcd\x1b[0m", "\x1b[31mef\x1b[0mgh"}, rows, "colors carry over")
+	assert.Equal(t, []string{"日本", "語  "}, fitMirrorLine("日本語", 4, true), "wide runes")
+}
+
+func TestMirrorLines(t *testing.T) {
+	capture := "one\ntwo\n\x1b[32mthree\x1b[0m   \n\n   \n"
+	assert.Equal(t, []string{"two", "\x1b[32mthree\x1b[0m"}, mirrorLines(capture, 2, true))
+	assert.Equal(t, []string{"one", "two", "three"}, mirrorLines(capture, 8, false))
+}
+
+func TestRefreshMirrorLoadsCapturesAndUnpins(t *testing.T) {
+	capture := "$ make\nok\n"
+	ran := stubMirrorTmux(t, "%a-0", &capture, nil)
+	c := newTestCoordinator(t)
+	c.config.Widgets.Mirror.Lines = 8
+	c.windows = []tmux.Window{testWindow("a", true, "make")}
+
+	c.refreshMirror()
+	assert.Equal(t, "%a-0", c.mirror.paneID, "read back from @tabby_mirror_pane")
+	assert.Equal(t, []string{"$ make", "ok"}, c.mirror.lines)
+
+	lines := strings.Split(stripWidget(c.renderMirrorWidget(10)), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "⧉ a make ✕", lines[0])
+	assert.Equal(t, "ok        ", lines[2])
+
+	assert.True(t, c.jumpToMirrorPane())
+	assert.Contains(t, ran(), "select-window -t @a ; select-pane -t %a-0")
+
+	// A failed capture of a pane tmux still lists, or with tmux not
+	// answering at all, keeps the pin and the last lines.
+	listed := "%0\n%a-0\n"
+	stubMirrorTmux(t, "", nil, &listed)
+	c.refreshMirror()
+	assert.Equal(t, "%a-0", c.mirror.paneID)
+	assert.Equal(t, []string{"$ make", "ok"}, c.mirror.lines)
+	stubMirrorTmux(t, "", nil, nil)
+	c.refreshMirror()
+	assert.Equal(t, "%a-0", c.mirror.paneID)
+
+	others := "%0\n"
+	stubMirrorTmux(t, "", nil, &others)
+	c.refreshMirror()
+	assert.Empty(t, c.mirror.paneID, "a closed pane is unpinned")
+	assert.Empty(t, c.renderMirrorWidget(10))
+}
+
+func TestToggleMirrorPane(t *testing.T) {
+	ran := stubMirrorTmux(t, "", nil, nil)
+	c := newTestCoordinator(t)
+	c.toggleMirrorPane("%1")
+	assert.Equal(t, "%1", c.mirror.paneID)
+	c.toggleMirrorPane("%2")
+	assert.Equal(t, "%2", c.mirror.paneID)
+	c.toggleMirrorPane("%2")
+	assert.Empty(t, c.mirror.paneID)
+	assert.Equal(t, []string{
+		"set-option -q -t test-session @tabby_mirror_pane %1",
+		"set-option -q -t test-session @tabby_mirror_pane %2",
+		"set-option -qu -t test-session @tabby_mirror_pane",
+	}, ran())
+
+	handled, changed := c.handleWidgetClick("client", "unpin", "mirror")
+	assert.True(t, handled)
+	assert.True(t, changed)
+}
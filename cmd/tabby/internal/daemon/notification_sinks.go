@@ -0,0 +1,282 @@
+package daemon
+
+// notification_sinks.go fires the `notifications.sinks` from config.yaml for
+// the indicator events recorded by notifications.go: run a command, POST JSON
+// to a webhook, or show a freedesktop notification over D-Bus. Each sink
+// filters by event kind and group; quiet hours and the rate limits apply to
+// every sink. Sinks run off the refresh path, in their own goroutine.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/notifications"
+)
+
+// sinkTimeout bounds one sink invocation.
+const sinkTimeout = 10 * time.Second
+
+// notificationDispatcher holds the rate-limit state. Own mutex.
+type notificationDispatcher struct {
+	mu     sync.Mutex
+	last   map[string]time.Time // window ID + kind -> last fired
+	recent []time.Time          // fire times in the last minute
+}
+
+// allow reports whether an event for key may fire at now, and records it when
+// it may.
+func (d *notificationDispatcher) allow(key string, now time.Time, cfg config.Notifications) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.last == nil {
+		d.last = make(map[string]time.Time)
+	}
+	if cfg.RateLimitSeconds > 0 {
+		if t, ok := d.last[key]; ok && now.Sub(t) < time.Duration(cfg.RateLimitSeconds)*time.Second {
+			return false
+		}
+	}
+	if cfg.MaxPerMinute > 0 {
+		kept := d.recent[:0]
+		for _, t := range d.recent {
+			if now.Sub(t) < time.Minute {
+				kept = append(kept, t)
+			}
+		}
+		d.recent = kept
+		if len(d.recent) >= cfg.MaxPerMinute {
+			return false
+		}
+	}
+	d.last[key] = now
+	d.recent = append(d.recent, now)
+	return true
+}
+
+// inQuietHours reports whether now falls inside qh. An unset or empty window
+// (start == end) is never quiet.
+func inQuietHours(qh config.QuietHours, now time.Time) bool {
+	start := parseHHMM(strings.TrimSpace(qh.Start), now)
+	end := parseHHMM(strings.TrimSpace(qh.End), now)
+	if start.IsZero() || end.IsZero() {
+		return false
+	}
+	cur, s, e := timeOfDay(now), timeOfDay(start), timeOfDay(end)
+	if s == e {
+		return false
+	}
+	if s < e {
+		return cur >= s && cur < e
+	}
+	return cur >= s || cur < e
+}
+
+// sinkMatches reports whether sink wants event e.
+func sinkMatches(sink config.NotificationSink, e notifications.Event) bool {
+	if !containsFold(sink.On, e.Kind) {
+		return false
+	}
+	if len(sink.Groups) > 0 && !containsFold(sink.Groups, e.Group) {
+		return false
+	}
+	return !containsFold(sink.ExcludeGroups, e.Group)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(strings.TrimSpace(v), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// sinkPayload is what a sink receives: the event plus ready-made text and the
+// deep link back to the pane.
+type sinkPayload struct {
+	notifications.Event
+	Message string `json:"message"`
+	Target  string `json:"target"`        // session:window[.pane], for `tabby hook focus-pane`
+	Focus   string `json:"focus_command"` // shell command that jumps to the pane
+}
+
+// eventPhrases completes "<window> ..." for each event kind.
+var eventPhrases = map[string]string{
+	notifications.KindBell:     "rang the bell",
+	notifications.KindInput:    "needs input",
+	notifications.KindBusy:     "started working",
+	notifications.KindDone:     "finished",
+	notifications.KindActivity: "has activity",
+	notifications.KindSilence:  "went silent",
+	notifications.KindCrash:    "crashed",
+}
+
+func (c *Coordinator) newSinkPayload(e notifications.Event) sinkPayload {
+	name := strings.TrimSpace(e.Label())
+	if name == "" {
+		name = "window " + strconv.Itoa(e.WindowIndex)
+	}
+	phrase := eventPhrases[e.Kind]
+	if phrase == "" {
+		phrase = e.Kind
+	}
+	message := name + " " + phrase
+	if e.Message != "" {
+		message = e.Message
+	}
+	target := e.Session + ":" + e.WindowID
+	if e.PaneID != "" {
+		target += "." + e.PaneID
+	}
+	return sinkPayload{
+		Event:   e,
+		Message: message,
+		Target:  target,
+		Focus:   c.getHookPath() + " focus-pane " + shellQuote(target),
+	}
+}
+
+// expandSinkCommand substitutes the {placeholders} of a command sink. Values
+// are shell-quoted, so templates must not add their own quotes.
+func expandSinkCommand(tmpl string, p sinkPayload) string {
+	r := strings.NewReplacer(
+		"{kind}", shellQuote(p.Kind),
+		"{message}", shellQuote(p.Message),
+		"{title}", shellQuote(p.Label()),
+		"{window}", shellQuote(strconv.Itoa(p.WindowIndex)),
+		"{window_id}", shellQuote(p.WindowID),
+		"{pane}", shellQuote(p.PaneID),
+		"{group}", shellQuote(p.Group),
+		"{session}", shellQuote(p.Session),
+		"{target}", shellQuote(p.Target),
+		"{focus}", shellQuote(p.Focus),
+	)
+	return r.Replace(tmpl)
+}
+
+// shellQuote wraps s in single quotes for sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// dispatchNotifications fires the configured sinks for events. The config is
+// read by the caller (under stateMu) and passed in; the sinks themselves run
+// in a goroutine.
+func (c *Coordinator) dispatchNotifications(cfg config.Notifications, events []notifications.Event) {
+	if len(cfg.Sinks) == 0 || len(events) == 0 {
+		return
+	}
+	now := time.Now()
+	quiet := inQuietHours(cfg.QuietHours, now)
+	type job struct {
+		sink    config.NotificationSink
+		payload sinkPayload
+	}
+	var jobs []job
+	for _, e := range events {
+		var sinks []config.NotificationSink
+		for _, s := range cfg.Sinks {
+			if sinkMatches(s, e) && (!quiet || s.IgnoreQuietHours) {
+				sinks = append(sinks, s)
+			}
+		}
+		if len(sinks) == 0 {
+			continue
+		}
+		if !c.notifySinks.allow(e.WindowID+"|"+e.Kind, now, cfg) {
+			logEvent("NOTIFY_RATE_LIMITED kind=%s window=%s", e.Kind, e.WindowID)
+			continue
+		}
+		p := c.newSinkPayload(e)
+		for _, s := range sinks {
+			jobs = append(jobs, job{s, p})
+		}
+	}
+	if len(jobs) == 0 {
+		return
+	}
+	go func() {
+		for _, j := range jobs {
+			if err := runSink(j.sink, j.payload); err != nil {
+				logEvent("NOTIFY_SINK_ERROR type=%s kind=%s window=%s err=%v", j.sink.Type, j.payload.Kind, j.payload.WindowID, err)
+				continue
+			}
+			logEvent("NOTIFY_SINK_OK type=%s kind=%s window=%s", j.sink.Type, j.payload.Kind, j.payload.WindowID)
+		}
+	}()
+}
+
+// runSink delivers one notification.
+func runSink(sink config.NotificationSink, p sinkPayload) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+	defer cancel()
+	switch strings.ToLower(strings.TrimSpace(sink.Type)) {
+	case "command":
+		if strings.TrimSpace(sink.Command) == "" {
+			return fmt.Errorf("command sink without command")
+		}
+		return exec.CommandContext(ctx, "sh", "-c", expandSinkCommand(sink.Command, p)).Run()
+	case "webhook":
+		if strings.TrimSpace(sink.URL) == "" {
+			return fmt.Errorf("webhook sink without url")
+		}
+		body, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, sink.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook returned %s", resp.Status)
+		}
+		return nil
+	case "dbus":
+		return notifyDBus(ctx, "tabby: "+p.Label(), p.Message)
+	default:
+		return fmt.Errorf("unknown sink type %q", sink.Type)
+	}
+}
+
+// notifyDBus sends org.freedesktop.Notifications.Notify via gdbus, falling
+// back to notify-send. Both ship with most Linux desktops; neither needs a Go
+// D-Bus binding.
+func notifyDBus(ctx context.Context, summary, body string) error {
+	if _, err := exec.LookPath("gdbus"); err == nil {
+		return exec.CommandContext(ctx, "gdbus", "call", "--session",
+			"--dest", "org.freedesktop.Notifications",
+			"--object-path", "/org/freedesktop/Notifications",
+			"--method", "org.freedesktop.Notifications.Notify",
+			gvariantString("tabby"), "0", gvariantString(""),
+			gvariantString(summary), gvariantString(body),
+			"[]", "{}", "5000").Run()
+	}
+	if _, err := exec.LookPath("notify-send"); err == nil {
+		return exec.CommandContext(ctx, "notify-send", "--app-name=tabby", summary, body).Run()
+	}
+	return fmt.Errorf("neither gdbus nor notify-send found")
+}
+
+// gvariantString renders s as a GVariant text-format string literal, which is
+// how gdbus parses its arguments.
+func gvariantString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
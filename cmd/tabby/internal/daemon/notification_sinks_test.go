@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/notifications"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInQuietHours(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 4, h, m, 0, 0, time.Local) }
+	night := config.QuietHours{Start: "22:00", End: "07:00"}
+	assert.True(t, inQuietHours(night, at(23, 30)))
+	assert.True(t, inQuietHours(night, at(6, 59)))
+	assert.False(t, inQuietHours(night, at(7, 0)))
+	assert.False(t, inQuietHours(night, at(12, 0)))
+
+	lunch := config.QuietHours{Start: "12:00", End: "13:00"}
+	assert.True(t, inQuietHours(lunch, at(12, 30)))
+	assert.False(t, inQuietHours(lunch, at(13, 30)))
+
+	assert.False(t, inQuietHours(config.QuietHours{}, at(3, 0)), "unset is never quiet")
+}
+
+func TestSinkMatches(t *testing.T) {
+	sink := config.NotificationSink{On: []string{"input", "bell"}, Groups: []string{"work"}}
+	assert.True(t, sinkMatches(sink, notifications.Event{Kind: "input", Group: "Work"}))
+	assert.False(t, sinkMatches(sink, notifications.Event{Kind: "busy", Group: "Work"}))
+	assert.False(t, sinkMatches(sink, notifications.Event{Kind: "input", Group: "Home"}))
+
+	sink = config.NotificationSink{On: []string{"bell"}, ExcludeGroups: []string{"Noisy"}}
+	assert.True(t, sinkMatches(sink, notifications.Event{Kind: "bell", Group: "Work"}))
+	assert.False(t, sinkMatches(sink, notifications.Event{Kind: "bell", Group: "Noisy"}))
+}
+
+func TestNotificationDispatcherRateLimits(t *testing.T) {
+	var d notificationDispatcher
+	cfg := config.Notifications{RateLimitSeconds: 30, MaxPerMinute: 2}
+	now := time.Now()
+	assert.True(t, d.allow("@1|input", now, cfg))
+	assert.False(t, d.allow("@1|input", now.Add(10*time.Second), cfg), "same window+kind inside the cooldown")
+	assert.True(t, d.allow("@2|input", now.Add(10*time.Second), cfg))
+	assert.False(t, d.allow("@3|input", now.Add(20*time.Second), cfg), "per-minute cap")
+	assert.True(t, d.allow("@1|input", now.Add(61*time.Second), cfg))
+
+	var open notificationDispatcher
+	unlimited := config.Notifications{RateLimitSeconds: -1, MaxPerMinute: -1}
+	for i := 0; i < 20; i++ {
+		assert.True(t, open.allow("@1|bell", now, unlimited))
+	}
+}
+
+func TestExpandSinkCommandQuotes(t *testing.T) {
+	p := sinkPayload{
+		Event:   notifications.Event{Kind: "input", WindowIndex: 3, Title: "it's done; rm -rf /"},
+		Message: "x",
+		Target:  "$0:@3.%5",
+	}
+	got := expandSinkCommand("notify {title} {window} {target}", p)
+	assert.Equal(t, `notify 'it'\''s done; rm -rf /' '3' '$0:@3.%5'`, got)
+}
+
+func TestRunSinkCommandAndWebhook(t *testing.T) {
+	c := newTestCoordinator(t)
+	p := c.newSinkPayload(notifications.Event{Kind: "input", Session: "$0", WindowID: "@3", PaneID: "%5", WindowName: "api"})
+	assert.Equal(t, "api needs input", p.Message)
+	assert.Equal(t, "$0:@3.%5", p.Target)
+	assert.Contains(t, p.Focus, "focus-pane '$0:@3.%5'")
+
+	out := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, runSink(config.NotificationSink{Type: "command", Command: "printf %s {message} > " + shellQuote(out)}, p))
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "api needs input", string(data))
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.True(t, strings.HasPrefix(r.Header.Get("Content-Type"), "application/json"))
+		assert.NoError(t, json.Unmarshal(body, &got))
+	}))
+	defer srv.Close()
+	require.NoError(t, runSink(config.NotificationSink{Type: "webhook", URL: srv.URL}, p))
+	assert.Equal(t, "input", got["kind"])
+	assert.Equal(t, "@3", got["window_id"])
+	assert.Equal(t, "api needs input", got["message"])
+
+	assert.Error(t, runSink(config.NotificationSink{Type: "pager"}, p))
+}
@@ -0,0 +1,224 @@
+package daemon
+
+// notifications.go is the notification center: every indicator transition the
+// daemon observes in RefreshWindows (bell, input, busy, busy -> done,
+// activity, silence, crash) is appended to the persistent history in
+// pkg/notifications, so it survives the window being viewed (which clears the
+// indicator) and daemon restarts. The history is browsable from the
+// "Notifications" menu (sidebar settings and alert menus) and from
+// `tabby notifications`.
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/notifications"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// notificationMenuLimit caps the rows in the notifications menu; the CLI
+// shows the full history.
+const notificationMenuLimit = 20
+
+// indicatorFlags is the indicator state of one window at one refresh, with
+// the pane each AI indicator came from.
+type indicatorFlags struct {
+	Bell, Input, Busy, Activity, Silence, Crash bool
+	InputPane, BusyPane, CrashPane              string
+}
+
+// notificationCenter holds the history and the previous refresh's indicator
+// state. It has its own mutex: it is read from menu handlers (under
+// stateMu.RLock) and written from RefreshWindows.
+type notificationCenter struct {
+	openOnce sync.Once
+	log      *notifications.Log
+
+	mu   sync.Mutex
+	prev map[string]indicatorFlags // window ID -> flags; nil until seeded
+}
+
+// history returns the notification log, loading it on first use.
+func (n *notificationCenter) history() *notifications.Log {
+	n.openOnce.Do(func() {
+		n.log = notifications.Open(notifications.Path(), notifications.Max)
+	})
+	return n.log
+}
+
+// windowIndicatorFlags reads a window's current indicators, attributing input
+// and busy to the first AI pane showing them.
+func windowIndicatorFlags(win tmux.Window) indicatorFlags {
+	f := indicatorFlags{
+		Bell:     win.Bell,
+		Input:    win.Input,
+		Busy:     win.Busy,
+		Activity: win.Activity,
+		Silence:  win.Silence,
+		Crash:    win.Crash,
+	}
+	for _, p := range win.Panes {
+		if paneCrashed(p) {
+			f.Crash = true
+			if f.CrashPane == "" {
+				f.CrashPane = p.ID
+			}
+		}
+		if p.AIInput {
+			f.Input = true
+			if f.InputPane == "" {
+				f.InputPane = p.ID
+			}
+		}
+		if p.AIBusy {
+			f.Busy = true
+			if f.BusyPane == "" {
+				f.BusyPane = p.ID
+			}
+		}
+	}
+	return f
+}
+
+// indicatorTransitionsLocked diffs every window's indicators against the
+// previous refresh and returns the transitions as events. The first call only
+// records the baseline, so a daemon restart doesn't replay every lit
+// indicator. Caller must hold stateMu (reads c.windows, c.grouped and
+// c.config).
+func (c *Coordinator) indicatorTransitionsLocked(now time.Time) []notifications.Event {
+	ind := c.config.Indicators
+	next := make(map[string]indicatorFlags, len(c.windows))
+	for _, win := range c.windows {
+		next[win.ID] = windowIndicatorFlags(win)
+	}
+
+	c.notify.mu.Lock()
+	prev := c.notify.prev
+	c.notify.prev = next
+	c.notify.mu.Unlock()
+	if prev == nil {
+		return nil
+	}
+
+	groupOf := make(map[string]string, len(c.windows))
+	for _, g := range c.grouped {
+		for _, w := range g.Windows {
+			groupOf[w.ID] = g.Name
+		}
+	}
+
+	var events []notifications.Event
+	for _, win := range c.windows {
+		cur, was := next[win.ID], prev[win.ID]
+		group := groupOf[win.ID]
+		if group == "" {
+			group = win.Group
+		}
+		add := func(kind, pane string) {
+			events = append(events, notifications.Event{
+				Time:        now,
+				Session:     c.sessionID,
+				WindowID:    win.ID,
+				WindowIndex: win.Index,
+				WindowName:  win.Name,
+				PaneID:      pane,
+				Group:       group,
+				Kind:        kind,
+				Title:       win.AITitle,
+			})
+		}
+		if ind.Bell.Enabled && cur.Bell && !was.Bell {
+			add(notifications.KindBell, "")
+		}
+		if ind.Input.Enabled && cur.Input && !was.Input {
+			add(notifications.KindInput, cur.InputPane)
+		}
+		if ind.Busy.Enabled {
+			if cur.Busy && !was.Busy {
+				add(notifications.KindBusy, cur.BusyPane)
+			}
+			// Busy -> input is already recorded as input.
+			if was.Busy && !cur.Busy && !cur.Input {
+				add(notifications.KindDone, was.BusyPane)
+			}
+		}
+		if ind.Activity.Enabled && cur.Activity && !was.Activity {
+			add(notifications.KindActivity, "")
+		}
+		if ind.Silence.Enabled && cur.Silence && !was.Silence {
+			add(notifications.KindSilence, "")
+		}
+		if headerBoolDefault(ind.Crash.Enabled) && cur.Crash && !was.Crash {
+			add(notifications.KindCrash, cur.CrashPane)
+		}
+	}
+	return events
+}
+
+// recordNotifications appends events to the history. Does file I/O; call
+// without stateMu held.
+func (c *Coordinator) recordNotifications(events []notifications.Event) {
+	if len(events) == 0 {
+		return
+	}
+	for _, e := range events {
+		logEvent("NOTIFICATION kind=%s window=%s pane=%s", e.Kind, e.WindowID, e.PaneID)
+	}
+	if err := c.notify.history().Append(events...); err != nil {
+		coordinatorDebugLog.Printf("recordNotifications: %v", err)
+	}
+}
+
+// sessionNotifications returns up to n of this session's events, newest first.
+func (c *Coordinator) sessionNotifications(n int) []notifications.Event {
+	var out []notifications.Event
+	for _, e := range c.notify.history().Recent(0) {
+		if e.Session != c.sessionID {
+			continue
+		}
+		out = append(out, e)
+		if len(out) == n {
+			break
+		}
+	}
+	return out
+}
+
+// notificationMenuLabel renders one menu row: age, kind, window and title.
+func notificationMenuLabel(e notifications.Event, now time.Time) string {
+	label := fmt.Sprintf("%-4s %-8s #%d", notifications.Ago(e.Time, now), e.Kind, e.WindowIndex)
+	if name := strings.TrimSpace(e.Label()); name != "" {
+		label += " " + truncate(name, 40)
+	}
+	// '#' starts a tmux format in menu labels.
+	return strings.ReplaceAll(label, "#", "##")
+}
+
+// showNotificationsMenu lists this session's recent indicator events; picking
+// one jumps to its window (and pane, when known).
+func (c *Coordinator) showNotificationsMenu(clientID string, pos menuPosition) {
+	events := c.sessionNotifications(notificationMenuLimit)
+	args := []string{"display-menu", "-O", "-T", "Notifications"}
+	if pos.PaneID != "" {
+		args = append(args, pos.args()...)
+	} else {
+		// Opened from a menu item or key binding: no click to anchor to.
+		args = append(args, "-x", "C", "-y", "C")
+	}
+	if len(events) == 0 {
+		args = append(args, "-No notifications yet", "", "")
+		c.executeOrSendMenu(clientID, args, pos)
+		return
+	}
+	now := time.Now()
+	for _, e := range events {
+		jump := fmt.Sprintf("select-window -t %s", e.WindowID)
+		if e.PaneID != "" {
+			jump += fmt.Sprintf(" ; select-pane -t %s", e.PaneID)
+		}
+		args = append(args, notificationMenuLabel(e, now), "", jump)
+	}
+	c.executeOrSendMenu(clientID, args, pos)
+}
@@ -0,0 +1,70 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/notifications"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func notifyTestCoordinator(t *testing.T) *Coordinator {
+	c := newTestCoordinator(t)
+	c.config.Indicators.Bell.Enabled = true
+	c.config.Indicators.Input.Enabled = true
+	c.config.Indicators.Busy.Enabled = true
+	return c
+}
+
+func TestIndicatorTransitionsSeedsThenDiffs(t *testing.T) {
+	c := notifyTestCoordinator(t)
+	now := time.Now()
+	win := testWindow("a", true, "claude")
+	win.Bell = true
+	c.windows = []tmux.Window{win}
+
+	assert.Empty(t, c.indicatorTransitionsLocked(now), "first pass only records the baseline")
+	assert.Empty(t, c.indicatorTransitionsLocked(now), "no change, no event")
+
+	c.windows[0].Panes[0].AIBusy = true
+	c.windows[0].AITitle = "Fix login"
+	events := c.indicatorTransitionsLocked(now)
+	require.Len(t, events, 1)
+	assert.Equal(t, notifications.KindBusy, events[0].Kind)
+	assert.Equal(t, "@a", events[0].WindowID)
+	assert.Equal(t, "%a-0", events[0].PaneID)
+	assert.Equal(t, "Fix login", events[0].Title)
+	assert.Equal(t, "test-session", events[0].Session)
+
+	c.windows[0].Panes[0].AIBusy = false
+	c.windows[0].Panes[0].AIInput = true
+	events = c.indicatorTransitionsLocked(now)
+	require.Len(t, events, 1, "busy -> input records input only")
+	assert.Equal(t, notifications.KindInput, events[0].Kind)
+
+	c.windows[0].Panes[0].AIInput = false
+	c.windows[0].Busy = true
+	c.indicatorTransitionsLocked(now)
+	c.windows[0].Busy = false
+	events = c.indicatorTransitionsLocked(now)
+	require.Len(t, events, 1)
+	assert.Equal(t, notifications.KindDone, events[0].Kind)
+}
+
+func TestIndicatorTransitionsRespectDisabledIndicators(t *testing.T) {
+	c := notifyTestCoordinator(t)
+	c.config.Indicators.Bell.Enabled = false
+	c.windows = []tmux.Window{testWindow("a", true, "zsh")}
+	c.indicatorTransitionsLocked(time.Now())
+
+	c.windows[0].Bell = true
+	assert.Empty(t, c.indicatorTransitionsLocked(time.Now()))
+}
+
+func TestNotificationMenuLabel(t *testing.T) {
+	now := time.Now()
+	e := notifications.Event{Time: now.Add(-3 * time.Minute), Kind: notifications.KindInput, WindowIndex: 4, WindowName: "api", Title: "Review #12"}
+	assert.Equal(t, "3m   input    ##4 Review ##12", notificationMenuLabel(e, now))
+}
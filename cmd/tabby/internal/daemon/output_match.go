@@ -0,0 +1,178 @@
+package daemon
+
+// output_match.go applies busy_detection.output_matchers (pkg/outputmatch)
+// to AI tool panes. RefreshWindows hands the panes to refreshOutputMatch,
+// which captures them off the refresh path: a pane is captured only when it
+// produced output since its last check, so an idle pane costs nothing.
+// pane_last_activity only has whole seconds, so a capture taken in the same
+// second as the pane's last output may have missed the rest of that second
+// (a prompt printed right after a busy line): such a pane is captured once
+// more on the next refresh.
+// processAIToolStates then lets the rule that fired set the pane's
+// input/busy state ahead of the title/CPU/timer heuristics.
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/outputmatch"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// outputMatchState holds the compiled rules and the last result per pane.
+// Own mutex, never held across a capture: result is read under stateMu.
+type outputMatchState struct {
+	mu       sync.Mutex
+	cfg      *config.Config // the config the rules were compiled from
+	rules    []outputmatch.Rule
+	checked  map[string]outputMatchCheck
+	results  map[string]outputmatch.Result
+	scanning atomic.Bool
+}
+
+// outputMatchCheck is a pane's last capture: its LastActivity then, and the
+// second the capture was planned in.
+type outputMatchCheck struct {
+	activity int64
+	at       int64
+}
+
+// outputMatchJob is one pane to capture and evaluate.
+type outputMatchJob struct {
+	paneID  string
+	command string
+	lines   int
+}
+
+// outputMatchCapture captures a pane's last lines; a variable for tests.
+var outputMatchCapture = capturePaneText
+
+// refreshOutputMatch picks the AI panes with new output and captures them in
+// the background, one scan at a time. When a result changed it asks for
+// another refresh, which applies it (processAIToolStates).
+func (c *Coordinator) refreshOutputMatch(cfg *config.Config, windows []tmux.Window) {
+	s := &c.outputMatch
+	if !s.scanning.CompareAndSwap(false, true) {
+		return
+	}
+	rules, jobs := s.plan(cfg, windows, time.Now())
+	if len(jobs) == 0 {
+		s.scanning.Store(false)
+		return
+	}
+	go func() {
+		defer s.scanning.Store(false)
+		if s.evaluate(rules, jobs) && c.OnRefreshLayout != nil {
+			c.OnRefreshLayout()
+		}
+	}()
+}
+
+// plan recompiles the rules when the config changed and returns the panes
+// due for a capture at now: AI panes whose output changed since their last
+// check, or whose last check fell in the second of their last output.
+func (s *outputMatchState) plan(cfg *config.Config, windows []tmux.Window, now time.Time) ([]outputmatch.Rule, []outputMatchJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cfg != nil && cfg != s.cfg {
+		rules, err := outputmatch.Compile(cfg.BusyDetection.OutputMatchers)
+		if err != nil {
+			logEvent("OUTPUT_MATCHERS_INVALID err=%v", err)
+		}
+		s.cfg, s.rules = cfg, rules
+		// New rules: every pane gets re-checked.
+		s.checked = make(map[string]outputMatchCheck)
+		s.results = make(map[string]outputmatch.Result)
+	}
+	if s.checked == nil {
+		s.checked = make(map[string]outputMatchCheck)
+		s.results = make(map[string]outputmatch.Result)
+	}
+
+	var jobs []outputMatchJob
+	seen := make(map[string]bool)
+	for _, win := range windows {
+		for _, p := range win.Panes {
+			if isAuxiliaryPane(p) || p.AgentState != "" || !tmux.IsAITool(p.Command) {
+				continue
+			}
+			lines := outputmatch.LinesFor(s.rules, p.Command)
+			if lines == 0 {
+				continue
+			}
+			seen[p.ID] = true
+			if last, ok := s.checked[p.ID]; ok && last.activity == p.LastActivity && last.at > last.activity {
+				continue
+			}
+			s.checked[p.ID] = outputMatchCheck{activity: p.LastActivity, at: now.Unix()}
+			jobs = append(jobs, outputMatchJob{paneID: p.ID, command: p.Command, lines: lines})
+		}
+	}
+	for id := range s.checked {
+		if !seen[id] {
+			delete(s.checked, id)
+			delete(s.results, id)
+		}
+	}
+	return s.rules, jobs
+}
+
+// evaluate captures each job's pane and stores which rule fired, reporting
+// whether any result changed.
+func (s *outputMatchState) evaluate(rules []outputmatch.Rule, jobs []outputMatchJob) bool {
+	changed := false
+	for _, j := range jobs {
+		// A few extra rows: prompts often end in blank lines.
+		text := outputMatchCapture(j.paneID, j.lines+10)
+		res, ok := outputmatch.Evaluate(rules, j.command, text)
+
+		s.mu.Lock()
+		prev, had := s.results[j.paneID]
+		if ok {
+			if !had || prev.Rule.Name != res.Rule.Name {
+				coordinatorDebugLog.Printf("[AI] Pane %s (%s): output matcher %q -> %s (%q)",
+					j.paneID, j.command, res.Rule.Name, res.Rule.State, res.Line)
+				changed = true
+			}
+			s.results[j.paneID] = res
+		} else if had {
+			delete(s.results, j.paneID)
+			changed = true
+		}
+		s.mu.Unlock()
+	}
+	return changed
+}
+
+// result returns the rule that fired for a pane at its last check.
+func (s *outputMatchState) result(paneID string) (outputmatch.Result, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, ok := s.results[paneID]
+	return res, ok
+}
+
+// applyOutputMatch sets pane's AI state from its output-matcher result and
+// reports whether it did. A busy match goes stale once the pane has been
+// silent for the idle timeout, so a frozen "working" line cannot pin a pane
+// busy forever. Caller must hold stateMu.
+func (c *Coordinator) applyOutputMatch(pane *tmux.Pane, now int64) bool {
+	res, ok := c.outputMatch.result(pane.ID)
+	if !ok {
+		return false
+	}
+	switch res.Rule.State {
+	case outputmatch.Busy:
+		if now-pane.LastActivity > tmux.AIIdleTimeout() {
+			return false
+		}
+		pane.AIBusy, pane.AIInput = true, false
+	case outputmatch.Input:
+		pane.AIBusy, pane.AIInput = false, true
+	default:
+		pane.AIBusy, pane.AIInput = false, false
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+func stubOutputMatchCapture(t *testing.T, text *string) *int {
+	t.Helper()
+	calls := 0
+	orig := outputMatchCapture
+	outputMatchCapture = func(string, int) string {
+		calls++
+		return *text
+	}
+	t.Cleanup(func() { outputMatchCapture = orig })
+	return &calls
+}
+
+func TestOutputMatchCapturesOnActivity(t *testing.T) {
+	text := " Do you want to proceed?\n ❯ 1. Yes\n"
+	calls := stubOutputMatchCapture(t, &text)
+	cfg := testConfig()
+	cfg.BusyDetection.OutputMatchers = []config.OutputMatcher{
+		{Name: "permission", Tool: "claude", Pattern: `Do you want to proceed\?`, State: "input"},
+		{Name: "working", Tool: "claude", Pattern: `esc to interrupt`, State: "busy"},
+	}
+	win := testWindow("a", false, "2.1.17", "zsh")
+	win.Panes[0].LastActivity = 100
+
+	var s outputMatchState
+	s.evaluate(s.plan(cfg, []tmux.Window{win}, time.Now()))
+	assert.Equal(t, 1, *calls, "only the AI pane is captured")
+	res, ok := s.result("%a-0")
+	require.True(t, ok)
+	assert.Equal(t, "permission", res.Rule.Name)
+
+	s.evaluate(s.plan(cfg, []tmux.Window{win}, time.Now()))
+	assert.Equal(t, 1, *calls, "no new output, no capture")
+
+	text = "✻ Working… (esc to interrupt)\n"
+	win.Panes[0].LastActivity = 101
+	s.evaluate(s.plan(cfg, []tmux.Window{win}, time.Now()))
+	res, _ = s.result("%a-0")
+	assert.Equal(t, "working", res.Rule.Name)
+
+	text = "all done\n"
+	win.Panes[0].LastActivity = 102
+	s.evaluate(s.plan(cfg, []tmux.Window{win}, time.Now()))
+	_, ok = s.result("%a-0")
+	assert.False(t, ok)
+}
+
+func TestOutputMatchRecapturesWithinActivitySecond(t *testing.T) {
+	// Busy output at 100.2 is captured at 100.4; the permission prompt
+	// prints at 100.9 and the pane goes quiet, still at LastActivity 100.
+	text := "✻ Working… (esc to interrupt)\n"
+	calls := stubOutputMatchCapture(t, &text)
+	cfg := testConfig()
+	cfg.BusyDetection.OutputMatchers = []config.OutputMatcher{
+		{Name: "permission", Tool: "claude", Pattern: `Do you want to proceed\?`, State: "input"},
+		{Name: "working", Tool: "claude", Pattern: `esc to interrupt`, State: "busy"},
+	}
+	win := testWindow("a", false, "2.1.17", "zsh")
+	win.Panes[0].LastActivity = 100
+	windows := []tmux.Window{win}
+
+	var s outputMatchState
+	s.evaluate(s.plan(cfg, windows, time.Unix(100, 400e6)))
+	res, _ := s.result("%a-0")
+	assert.Equal(t, "working", res.Rule.Name)
+
+	text = " Do you want to proceed?\n ❯ 1. Yes\n"
+	s.evaluate(s.plan(cfg, windows, time.Unix(101, 0)))
+	assert.Equal(t, 2, *calls, "the capture in second 100 may have missed the rest of it")
+	res, _ = s.result("%a-0")
+	assert.Equal(t, "permission", res.Rule.Name)
+
+	s.evaluate(s.plan(cfg, windows, time.Unix(102, 0)))
+	assert.Equal(t, 2, *calls, "captured after second 100, nothing new since")
+}
+
+func TestProcessAIToolStatesAppliesOutputMatch(t *testing.T) {
+	text := " Do you want to proceed?\n"
+	stubOutputMatchCapture(t, &text)
+	c := newTestCoordinator(t)
+	c.config.Indicators.Busy.Enabled = true
+	c.config.Indicators.Input.Enabled = true
+	c.config.BusyDetection.OutputMatchers = []config.OutputMatcher{
+		{Name: "permission", Tool: "claude", Pattern: `proceed\?`, State: "input"},
+		{Name: "working", Tool: "claude", Pattern: `interrupt`, State: "busy"},
+	}
+	win := testWindow("a", false, "2.1.17")
+	win.Panes[0].LastActivity = time.Now().Unix()
+	c.windows = []tmux.Window{win}
+
+	c.outputMatch.evaluate(c.outputMatch.plan(c.config, c.windows, time.Now()))
+	c.processAIToolStates(nil)
+	assert.True(t, c.windows[0].Panes[0].AIInput)
+	assert.True(t, c.windows[0].Input, "input shows right away, without waiting for the idle timer")
+
+	text = "(esc to interrupt)\n"
+	c.windows[0].Input = false // as re-listed from tmux
+	c.windows[0].Panes[0].LastActivity = time.Now().Unix() + 1
+	c.outputMatch.evaluate(c.outputMatch.plan(c.config, c.windows, time.Now()))
+	c.processAIToolStates(nil)
+	assert.True(t, c.windows[0].Panes[0].AIBusy)
+
+	// A busy line that stopped updating no longer holds the pane busy.
+	c.windows[0].Panes[0].LastActivity = time.Now().Unix() - 3600
+	assert.False(t, c.applyOutputMatch(&c.windows[0].Panes[0], time.Now().Unix()))
+}
+
+func TestRefreshOutputMatchRunsInBackground(t *testing.T) {
+	text := " Do you want to proceed?\n"
+	stubOutputMatchCapture(t, &text)
+	c := newTestCoordinator(t)
+	c.config.BusyDetection.OutputMatchers = []config.OutputMatcher{
+		{Name: "permission", Tool: "claude", Pattern: `proceed\?`, State: "input"},
+	}
+	refreshed := make(chan struct{}, 1)
+	c.OnRefreshLayout = func() { refreshed <- struct{}{} }
+	win := testWindow("a", false, "2.1.17")
+	win.Panes[0].LastActivity = 100
+
+	c.refreshOutputMatch(c.config, []tmux.Window{win})
+	select {
+	case <-refreshed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("a new match asks for a refresh")
+	}
+	res, ok := c.outputMatch.result("%a-0")
+	require.True(t, ok)
+	assert.Equal(t, "permission", res.Rule.Name)
+
+	win.Panes[0].LastActivity = 101
+	calls := 0
+	c.OnRefreshLayout = func() { calls++ }
+	c.refreshOutputMatch(c.config, []tmux.Window{win})
+	require.Eventually(t, func() bool { return !c.outputMatch.scanning.Load() }, 2*time.Second, 10*time.Millisecond)
+	assert.Zero(t, calls, "same rule, no change, no refresh")
+}
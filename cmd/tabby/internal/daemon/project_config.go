@@ -0,0 +1,158 @@
+package daemon
+
+// project_config.go reads the committed .tabby.yaml at a project's git
+// toplevel (config.ProjectConfig) and applies it on top of config.yaml:
+// appearance seeds (appearanceRecordFor), the group a new window joins
+// (seedWindowAppearance), tab-label codes (windowDirCode) and extra window
+// context-menu commands. Lookups happen every window refresh and render, so
+// parsed files are cached per toplevel and only re-read when the file's mtime
+// changes; a missing file is cached too. Menu commands are only offered
+// once the user trusted the file (config.ProjectTrusted).
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// projectConfigEntry is one cached .tabby.yaml lookup. ok is false when the
+// file does not exist or failed to parse.
+type projectConfigEntry struct {
+	modTime time.Time
+	cfg     config.ProjectConfig
+	ok      bool
+	hash    string // sha256 of the file, for the trust check
+
+	// Parsed "CODE>Folder" lists, keyed by lower-cased folder.
+	abbrevs   map[string]string
+	projNames map[string]string
+}
+
+// projectConfig returns the .tabby.yaml for a local project key (a git
+// toplevel path). Remote keys never have one.
+func (c *Coordinator) projectConfig(key string) (config.ProjectConfig, bool) {
+	e := c.projectConfigEntry(key)
+	return e.cfg, e.ok
+}
+
+// projectConfigEntry returns the cached lookup for a project key, refreshing
+// it when the file's mtime changed.
+func (c *Coordinator) projectConfigEntry(key string) projectConfigEntry {
+	if key == "" || strings.Contains(key, "://") || !filepath.IsAbs(key) {
+		return projectConfigEntry{}
+	}
+	var modTime time.Time
+	if fi, err := os.Stat(config.ProjectConfigPath(key)); err == nil {
+		modTime = fi.ModTime()
+	}
+
+	c.projectConfigMu.Lock()
+	defer c.projectConfigMu.Unlock()
+	if e, hit := c.projectConfigs[key]; hit && e.modTime.Equal(modTime) {
+		return e
+	}
+	var e projectConfigEntry
+	e.modTime = modTime
+	if !modTime.IsZero() {
+		cfg, ok, err := config.LoadProjectConfig(key)
+		if err != nil {
+			logEvent("PROJECT_CONFIG_ERR dir=%s err=%v", key, err)
+		}
+		e.cfg, e.ok = cfg, ok && err == nil
+		if e.ok {
+			e.abbrevs = parseAbbreviations(cfg.TabNames.Abbreviations)
+			e.projNames = parseAbbreviations(cfg.AI.TabSummary.ProjectNames)
+			e.hash, _ = config.ProjectConfigHash(key)
+		}
+	}
+	if c.projectConfigs == nil {
+		c.projectConfigs = make(map[string]projectConfigEntry)
+	}
+	c.projectConfigs[key] = e
+	return e
+}
+
+// windowProjectConfig returns the project config for a window's git
+// toplevel. Remote windows and windows without a content cwd have none.
+func (c *Coordinator) windowProjectConfig(win tmux.Window) (projectConfigEntry, string) {
+	key, ok := c.windowNameKey(win)
+	if !ok {
+		return projectConfigEntry{}, ""
+	}
+	return c.projectConfigEntry(key), key
+}
+
+// projectAppearanceSeed returns the color/icon/group a project's .tabby.yaml
+// seeds onto new windows, if it sets any of them.
+func (c *Coordinator) projectAppearanceSeed(key string) (CWDColorMapping, bool) {
+	pc, ok := c.projectConfig(key)
+	if !ok {
+		return CWDColorMapping{}, false
+	}
+	seed := CWDColorMapping{Color: pc.Color, Icon: pc.Icon, Group: pc.Group}
+	return seed, !seed.Empty()
+}
+
+// projectDirCodes returns a window's .tabby.yaml overrides for the AI-summary
+// project name and the tab-name abbreviation of folder. Either may be "".
+func (c *Coordinator) projectDirCodes(win tmux.Window, folder string) (projName, abbrev string) {
+	e, _ := c.windowProjectConfig(win)
+	if !e.ok {
+		return "", ""
+	}
+	folder = strings.ToLower(strings.TrimSpace(folder))
+	return e.projNames[folder], e.abbrevs[folder]
+}
+
+// projectMenuItems returns the extra window context-menu commands from the
+// window's .tabby.yaml, plus the project root they run in. A file that was
+// not trusted with `tabby project trust` (or changed since) returns no items;
+// pending is then how many it holds.
+func (c *Coordinator) projectMenuItems(win tmux.Window) (items []config.ProjectMenuItem, root string, pending int) {
+	e, key := c.windowProjectConfig(win)
+	if !e.ok || len(e.cfg.Menu) == 0 {
+		return nil, "", 0
+	}
+	if !config.ProjectTrusted(key, e.hash) {
+		return nil, key, len(e.cfg.Menu)
+	}
+	return e.cfg.Menu, key, 0
+}
+
+// runProjectMenuItem runs menu item idx of windowID's .tabby.yaml: typed into
+// the window's content pane ("send"), or in a new pane at the project root
+// ("split").
+func (c *Coordinator) runProjectMenuItem(windowID string, idx int) error {
+	c.stateMu.RLock()
+	win := findWindowByTarget(c.windows, windowID)
+	var snapshot tmux.Window
+	if win != nil {
+		snapshot = *win
+	}
+	c.stateMu.RUnlock()
+	if win == nil {
+		return fmt.Errorf("no such window: %s", windowID)
+	}
+	items, root, _ := c.projectMenuItems(snapshot)
+	if idx < 0 || idx >= len(items) {
+		return fmt.Errorf("no project command %d for %s", idx, windowID)
+	}
+	item := items[idx]
+	logEvent("PROJECT_MENU_RUN window=%s label=%q mode=%s", windowID, item.Label, item.Mode)
+	if item.Mode == "split" {
+		return tmuxRun("split-window", "-v", "-t", windowID, "-c", root, item.Command)
+	}
+	pane := findContentPane(windowID, "")
+	if pane == "" {
+		return fmt.Errorf("no content pane in %s", windowID)
+	}
+	if err := tmuxRun("send-keys", "-t", pane, "-l", item.Command); err != nil {
+		return err
+	}
+	return tmuxRun("send-keys", "-t", pane, "Enter")
+}
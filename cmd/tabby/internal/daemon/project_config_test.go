@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/paths"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectAppearanceSeed(t *testing.T) {
+	c := newTestCoordinator(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, config.ProjectFileName)
+
+	_, ok := c.projectAppearanceSeed(dir)
+	assert.False(t, ok)
+
+	assert.NoError(t, os.WriteFile(path, []byte("color: \"#e74c3c\"\ngroup: Tabby\n"), 0644))
+	// Bump the mtime so the cached "missing" entry is invalidated even on
+	// filesystems with coarse timestamps.
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(path, future, future))
+	seed, ok := c.projectAppearanceSeed(dir)
+	assert.True(t, ok)
+	assert.Equal(t, CWDColorMapping{Color: "#e74c3c", Group: "Tabby"}, seed)
+
+	_, ok = c.projectAppearanceSeed("ssh://devbox/srv")
+	assert.False(t, ok, "remote keys have no project file")
+}
+
+func TestAppearanceRecordForPersonalWinsOverProject(t *testing.T) {
+	c := newTestCoordinator(t)
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, config.ProjectFileName), []byte("color: \"#e74c3c\"\nicon: x\n"), 0644))
+	c.cwdColors[dir] = CWDColorMapping{Color: "#000000"}
+
+	rec, ok := c.appearanceRecordFor(dir, testWindow("app", true))
+	assert.True(t, ok)
+	assert.Equal(t, "#000000", rec.Color)
+	assert.Equal(t, "x", rec.Icon)
+}
+
+func TestWindowDirCodeProjectPrecedence(t *testing.T) {
+	c := newTestCoordinator(t)
+	c.config = testConfig()
+	c.config.TabNames.Abbreviations = []string{"GLB>widget"}
+	dir := filepath.Join(t.TempDir(), "widget")
+	assert.NoError(t, os.Mkdir(dir, 0755))
+	c.gitTopCache[dir] = dir
+
+	win := testWindow("w", true, "zsh")
+	win.Panes[0].CurrentPath = dir
+	assert.Equal(t, "GLB", c.windowDirCode(win), "no project file: config.yaml applies")
+
+	path := filepath.Join(dir, config.ProjectFileName)
+	assert.NoError(t, os.WriteFile(path, []byte("tab_names:\n  abbreviations: [\"WDG>widget\"]\n"), 0644))
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(path, future, future))
+	assert.Equal(t, "WDG", c.windowDirCode(win), "project abbreviation beats the global one")
+
+	c.config.AI.TabSummary.ProjectNames = []string{"gpn>widget"}
+	c.tabAbbrevCfg = nil
+	assert.Equal(t, "gpn", c.windowDirCode(win), "any project_names entry beats abbreviations")
+
+	assert.NoError(t, os.WriteFile(path, []byte("ai:\n  tab_summary:\n    project_names: [\"ppn>widget\"]\n"), 0644))
+	future = future.Add(time.Minute)
+	assert.NoError(t, os.Chtimes(path, future, future))
+	assert.Equal(t, "ppn", c.windowDirCode(win))
+}
+
+func TestProjectMenuItems(t *testing.T) {
+	c := newTestCoordinator(t)
+	dir := t.TempDir()
+	c.gitTopCache[dir] = dir
+	win := testWindow("w", true, "zsh")
+	win.Panes[0].CurrentPath = dir
+
+	c.windows = append(c.windows, win)
+	t.Setenv("TABBY_STATE_DIR", t.TempDir())
+	paths.ResetForTest()
+	t.Cleanup(paths.ResetForTest)
+
+	items, root, _ := c.projectMenuItems(win)
+	assert.Empty(t, items)
+	assert.Empty(t, root)
+
+	yaml := "menu:\n  - label: Test\n    key: t\n    command: make test\n  - label: Broken\n  - label: Dev\n    command: npm run dev\n    mode: Split\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, config.ProjectFileName), []byte(yaml), 0644))
+	c.projectConfigs = nil
+	items, root, pending := c.projectMenuItems(win)
+	assert.Empty(t, items, "not shown until trusted")
+	assert.Equal(t, dir, root)
+	assert.Equal(t, 2, pending)
+	assert.Error(t, c.runProjectMenuItem(win.ID, 0), "nor run")
+
+	_, err := config.TrustProject(dir)
+	require.NoError(t, err)
+	items, root, pending = c.projectMenuItems(win)
+	assert.Equal(t, dir, root)
+	assert.Zero(t, pending)
+	assert.Equal(t, []config.ProjectMenuItem{
+		{Label: "Test", Key: "t", Command: "make test"},
+		{Label: "Dev", Command: "npm run dev", Mode: "split"},
+	}, items)
+}
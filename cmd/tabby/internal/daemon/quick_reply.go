@@ -0,0 +1,135 @@
+package daemon
+
+// quick_reply.go answers agent permission prompts from the sidebar. A window
+// whose agent pane is waiting for input gets a reply button (↩) next to its
+// menu button; the button, the alerts menu and the pane menu open a menu
+// showing the prompt's last lines with one item per configured reply
+// (ai.quick_reply), which types the reply's keys into the pane.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brendandebeasi/tabby/pkg/agentstate"
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/outputmatch"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// quickReplyButton is drawn before the window menu button.
+const quickReplyButton = " ↩"
+
+// quickReplyWaiting reports whether p is an agent pane waiting for an answer.
+func quickReplyWaiting(p tmux.Pane) bool {
+	if p.AIInput {
+		return true
+	}
+	st, ok := agentstate.Parse(p.AgentState)
+	return ok && st.NeedsInput()
+}
+
+// quickReplyPane returns the first content pane of win waiting for an
+// answer, or "" when there is none or quick replies are off.
+func (c *Coordinator) quickReplyPane(win tmux.Window) string {
+	if !headerBoolDefault(c.config.AI.QuickReply.Enabled) {
+		return ""
+	}
+	for _, p := range win.Panes {
+		if !isAuxiliaryPane(p) && quickReplyWaiting(p) {
+			return p.ID
+		}
+	}
+	return ""
+}
+
+// quickReplyTool is the ai.quick_reply.tools key for a pane command.
+func quickReplyTool(command string) string {
+	if tmux.IsClaudeCommand(command) {
+		return "claude"
+	}
+	return command
+}
+
+// quickReplyOptions returns the replies configured for a pane command.
+func (c *Coordinator) quickReplyOptions(command string) []config.QuickReplyOption {
+	tools := c.config.AI.QuickReply.Tools
+	if opts, ok := tools[quickReplyTool(command)]; ok {
+		return opts
+	}
+	return tools["default"]
+}
+
+// quickReplyMenuArgs builds the display-menu items: the prompt lines as
+// disabled rows, then one item per reply, then a jump to the pane.
+func quickReplyMenuArgs(paneID string, windowIndex int, prompt []string, opts []config.QuickReplyOption) []string {
+	var args []string
+	for _, l := range prompt {
+		args = append(args, "-"+strings.ReplaceAll(truncate(l, 60), "#", "##"), "", "")
+	}
+	if len(prompt) > 0 {
+		args = append(args, "", "", "")
+	}
+	for i, o := range opts {
+		if len(o.Keys) == 0 {
+			continue
+		}
+		key := ""
+		if i < 9 {
+			key = fmt.Sprintf("%d", i+1)
+		}
+		keys := make([]string, len(o.Keys))
+		for j, k := range o.Keys {
+			keys[j] = shellQuote(k)
+		}
+		args = append(args, strings.ReplaceAll(o.Label, "#", "##"), key,
+			fmt.Sprintf("send-keys -t %s %s", paneID, strings.Join(keys, " ")))
+	}
+	args = append(args, "", "", "")
+	args = append(args, "Jump to Pane", "j", fmt.Sprintf("select-window -t :%d ; select-pane -t %s", windowIndex, paneID))
+	return args
+}
+
+// showQuickReplyMenu opens the reply menu for an agent pane. target is a pane
+// ID, or a window target whose waiting pane is used.
+func (c *Coordinator) showQuickReplyMenu(clientID, target string, pos menuPosition) {
+	c.stateMu.RLock()
+	var pane *tmux.Pane
+	var win *tmux.Window
+	if strings.HasPrefix(target, "%") {
+		for i := range c.windows {
+			for j := range c.windows[i].Panes {
+				if c.windows[i].Panes[j].ID == target {
+					win, pane = &c.windows[i], &c.windows[i].Panes[j]
+				}
+			}
+		}
+	} else if win = findWindowByTarget(c.windows, target); win != nil {
+		id := c.quickReplyPane(*win)
+		for j := range win.Panes {
+			if win.Panes[j].ID == id {
+				pane = &win.Panes[j]
+			}
+		}
+	}
+	if pane == nil {
+		c.stateMu.RUnlock()
+		return
+	}
+	paneID, command, windowIndex := pane.ID, pane.Command, win.Index
+	opts := c.quickReplyOptions(command)
+	lines := c.config.AI.QuickReply.Lines
+	c.stateMu.RUnlock()
+	if lines <= 0 {
+		lines = 8
+	}
+
+	// Capture a few extra rows: the prompt's box often ends in blank lines.
+	prompt := outputmatch.LastLines(capturePaneText(paneID, lines+10), lines)
+	args := append([]string{
+		"display-menu",
+		"-O",
+		"-T", fmt.Sprintf(" Reply: %s ", strings.ReplaceAll(quickReplyTool(command), "#", "##")),
+	}, pos.args()...)
+	args = append(args, quickReplyMenuArgs(paneID, windowIndex, prompt, opts)...)
+	c.executeOrSendMenu(clientID, args, pos)
+}
@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/agentstate"
+	"github.com/brendandebeasi/tabby/pkg/config"
+)
+
+func TestQuickReplyPane(t *testing.T) {
+	c := newTestCoordinator(t)
+	win := testWindow("a", false, "zsh", "claude")
+	assert.Empty(t, c.quickReplyPane(win))
+
+	win.Panes[1].AgentState = encodedState(agentstate.Permission, time.Now())
+	assert.Equal(t, "%a-1", c.quickReplyPane(win))
+
+	win.Panes[1].AgentState = ""
+	win.Panes[0].AIInput = true
+	assert.Equal(t, "%a-0", c.quickReplyPane(win), "heuristic input detection counts too")
+
+	off := false
+	c.config.AI.QuickReply.Enabled = &off
+	assert.Empty(t, c.quickReplyPane(win))
+}
+
+func TestQuickReplyOptions(t *testing.T) {
+	c := newTestCoordinator(t)
+	c.config.AI.QuickReply.Tools = map[string][]config.QuickReplyOption{
+		"claude":  {{Label: "Yes", Keys: []string{"1"}}},
+		"default": {{Label: "Yes", Keys: []string{"y", "Enter"}}},
+	}
+	assert.Equal(t, []string{"1"}, c.quickReplyOptions("2.1.17")[0].Keys, "Claude Code by version title")
+	assert.Equal(t, []string{"y", "Enter"}, c.quickReplyOptions("aider")[0].Keys)
+}
+
+func TestQuickReplyMenuArgs(t *testing.T) {
+	opts := []config.QuickReplyOption{
+		{Label: "Yes", Keys: []string{"1"}},
+		{Label: "Skip"}, // no keys: left out
+		{Label: "No", Keys: []string{"Escape"}},
+	}
+	args := quickReplyMenuArgs("%5", 2, []string{"Run rm #tmp?"}, opts)
+	require.Len(t, args, 18)
+	assert.Equal(t, "-Run rm ##tmp?", args[0])
+	assert.Equal(t, []string{"Yes", "1", "send-keys -t %5 '1'"}, args[6:9])
+	assert.Equal(t, []string{"No", "3", "send-keys -t %5 'Escape'"}, args[9:12])
+	assert.Equal(t, "select-window -t :2 ; select-pane -t %5", args[17])
+}
@@ -0,0 +1,171 @@
+package daemon
+
+// stats.go renders the stats widget: CPU, memory and battery sampled by
+// pkg/sysstats every update_interval (statsWidget in widgets.go), each drawn
+// as a bar in the configured bar_style.
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/brendandebeasi/tabby/pkg/daemon"
+	"github.com/brendandebeasi/tabby/pkg/sysstats"
+)
+
+// statsIconsByStyle are the row labels per widget style.
+var statsIconsByStyle = map[string]struct{ CPU, Memory, Battery, Charging string }{
+	"nerd":    {CPU: "\uf4bc", Memory: "\uf2db", Battery: "\uf240", Charging: "\uf0e7"}, // nf-oct-cpu, nf-fa-microchip, nf-fa-battery_full, nf-fa-bolt
+	"emoji":   {CPU: "💻", Memory: "🧠", Battery: "🔋", Charging: "⚡"},
+	"ascii":   {CPU: "CPU", Memory: "MEM", Battery: "BAT", Charging: "CHG"},
+	"minimal": {CPU: "cpu", Memory: "mem", Battery: "bat", Charging: "chg"},
+}
+
+// statsBarChars are the filled and empty cells per bar_style.
+var statsBarChars = map[string]struct{ Filled, Empty string }{
+	"block":   {Filled: "█", Empty: "░"},
+	"braille": {Filled: "⣿", Empty: "⣀"},
+	"dots":    {Filled: "●", Empty: "○"},
+	"ascii":   {Filled: "#", Empty: "-"},
+}
+
+// RefreshStats samples the system when the widget is enabled and reports
+// whether the rounded values changed. The widget registry schedules it every
+// update_interval (default 2s), from the loop goroutine only.
+func (c *Coordinator) RefreshStats() bool {
+	if !c.config.Widgets.Stats.Enabled {
+		return false
+	}
+	c.stateMu.RLock()
+	prev := c.stats
+	c.stateMu.RUnlock()
+	if c.statsReader == nil {
+		c.statsReader = sysstats.NewReader()
+	}
+	next := statsState(c.statsReader.Read(), time.Now())
+
+	c.stateMu.Lock()
+	c.stats = next
+	c.stateMu.Unlock()
+	return statsDisplayKey(next) != statsDisplayKey(prev)
+}
+
+// statsState converts a sample for the widget. Unavailable values are
+// negative (CPU, memory) or have an empty BatteryStatus.
+func statsState(s sysstats.Sample, now time.Time) daemon.StatsState {
+	st := daemon.StatsState{CPUPercent: -1, MemoryPercent: -1, LastUpdate: now}
+	if s.CPUOK {
+		st.CPUPercent = s.CPUPercent
+	}
+	if s.MemOK {
+		const gib = 1 << 30
+		st.MemoryUsed = float64(s.MemUsed) / gib
+		st.MemoryTotal = float64(s.MemTotal) / gib
+		st.MemoryPercent = s.MemPercent
+	}
+	if s.BatteryOK {
+		st.BatteryPercent = s.BatteryPercent
+		st.BatteryStatus = s.BatteryStatus
+		if st.BatteryStatus == "" {
+			st.BatteryStatus = "Unknown"
+		}
+	}
+	return st
+}
+
+// statsDisplayKey is what the widget shows, for change detection.
+func statsDisplayKey(st daemon.StatsState) string {
+	return fmt.Sprintf("%d|%d|%d|%s", int(math.Round(st.CPUPercent)), int(math.Round(st.MemoryPercent)), st.BatteryPercent, st.BatteryStatus)
+}
+
+// renderStatsBar draws pct (0-100) as a bar of width cells.
+func renderStatsBar(style string, pct float64, width int) string {
+	chars, ok := statsBarChars[style]
+	if !ok {
+		chars = statsBarChars["block"]
+	}
+	pct = math.Max(0, math.Min(100, pct))
+	filled := int(math.Round(pct * float64(width) / 100))
+	bar := strings.Repeat(chars.Filled, filled) + strings.Repeat(chars.Empty, width-filled)
+	if style == "ascii" {
+		bar = "[" + bar + "]"
+	}
+	return bar
+}
+
+// renderStatsWidget renders the stats widget. Rows whose value could not be
+// read (no battery, no /proc) are left out.
+func (c *Coordinator) renderStatsWidget(width int) string {
+	cfg := c.config.Widgets.Stats
+	if !cfg.Enabled {
+		return ""
+	}
+	st := c.stats
+	if st.LastUpdate.IsZero() {
+		return ""
+	}
+
+	icons, ok := statsIconsByStyle[cfg.Style]
+	if !ok {
+		icons = statsIconsByStyle["emoji"]
+	}
+	barWidth := cfg.BarWidth
+	if barWidth <= 0 {
+		barWidth = 5
+	}
+	fg := c.getInactiveTextColorWithFallback(cfg.Fg)
+	styleFor := func(color string) lipgloss.Style {
+		if color == "" {
+			color = fg
+		}
+		s := lipgloss.NewStyle()
+		if color != "" {
+			s = s.Foreground(lipgloss.Color(color))
+		}
+		return s
+	}
+
+	var rows []string
+	row := func(color, icon string, pct float64) {
+		label := "  " + icon + " "
+		value := fmt.Sprintf(" %3.0f%%", pct)
+		w := min(barWidth, width-lipgloss.Width(label)-lipgloss.Width(value))
+		if cfg.BarStyle == "ascii" {
+			w -= 2
+		}
+		bar := ""
+		if w > 0 {
+			bar = renderStatsBar(cfg.BarStyle, pct, w)
+		}
+		rows = append(rows, styleFor(color).Render(label+bar+value))
+	}
+	if cfg.ShowCPU && st.CPUPercent >= 0 {
+		row(cfg.CPUFg, icons.CPU, st.CPUPercent)
+	}
+	if cfg.ShowMemory && st.MemoryPercent >= 0 {
+		row(cfg.MemoryFg, icons.Memory, st.MemoryPercent)
+	}
+	if cfg.ShowBattery && st.BatteryStatus != "" {
+		icon := icons.Battery
+		if st.BatteryStatus == "Charging" {
+			icon = icons.Charging
+		}
+		row(cfg.BatteryFg, icon, float64(st.BatteryPercent))
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+
+	var result strings.Builder
+	for _, r := range rows {
+		result.WriteString(r + "\n")
+	}
+
+	return c.wrapWidgetChrome(widgetLayout{
+		Divider: cfg.Divider, DividerFg: cfg.DividerFg,
+		MarginTop: cfg.MarginTop, PaddingTop: cfg.PaddingTop, PaddingBot: cfg.PaddingBot, MarginBot: cfg.MarginBot,
+	}, result.String(), width)
+}
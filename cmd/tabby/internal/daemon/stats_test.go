@@ -0,0 +1,82 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/sysstats"
+)
+
+func TestRenderStatsBar(t *testing.T) {
+	assert.Equal(t, "███░░", renderStatsBar("block", 60, 5))
+	assert.Equal(t, "⣿⣀⣀⣀", renderStatsBar("braille", 25, 4))
+	assert.Equal(t, "●●●●●", renderStatsBar("dots", 140, 5))
+	assert.Equal(t, "[--]", renderStatsBar("ascii", -5, 2))
+	assert.Equal(t, "██░░", renderStatsBar("unknown", 50, 4))
+}
+
+func TestStatsStateMarksUnavailable(t *testing.T) {
+	now := time.Now()
+	st := statsState(sysstats.Sample{MemOK: true, MemUsed: 3 << 30, MemTotal: 4 << 30, MemPercent: 75}, now)
+	assert.Equal(t, -1.0, st.CPUPercent)
+	assert.Equal(t, 75.0, st.MemoryPercent)
+	assert.Equal(t, 3.0, st.MemoryUsed)
+	assert.Empty(t, st.BatteryStatus)
+
+	st = statsState(sysstats.Sample{BatteryOK: true, BatteryPercent: 40}, now)
+	assert.Equal(t, "Unknown", st.BatteryStatus)
+}
+
+func TestRenderStatsWidget(t *testing.T) {
+	c := newTestCoordinator(t)
+	cfg := &c.config.Widgets.Stats
+	cfg.Enabled = true
+	cfg.ShowCPU = true
+	cfg.ShowMemory = true
+	cfg.ShowBattery = true
+	cfg.Style = "ascii"
+	cfg.BarStyle = "block"
+	cfg.BarWidth = 4
+	assert.Empty(t, c.renderStatsWidget(25), "nothing sampled yet")
+
+	c.stats = statsState(sysstats.Sample{
+		CPUOK: true, CPUPercent: 50,
+		MemOK: true, MemPercent: 25,
+		BatteryOK: true, BatteryPercent: 100, BatteryStatus: "Charging",
+	}, time.Now())
+	lines := strings.Split(strings.TrimRight(stripAnsi(c.renderStatsWidget(25)), "\n"), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "  CPU ██░░  50%", lines[0])
+	assert.Equal(t, "  MEM █░░░  25%", lines[1])
+	assert.Equal(t, "  CHG ████ 100%", lines[2])
+
+	// A narrow sidebar shrinks the bar.
+	lines = strings.Split(stripAnsi(c.renderStatsWidget(12)), "\n")
+	assert.Equal(t, "  CPU █  50%", lines[0])
+
+	cfg.ShowBattery = false
+	c.stats.CPUPercent = -1
+	lines = strings.Split(strings.TrimRight(stripAnsi(c.renderStatsWidget(25)), "\n"), "\n")
+	assert.Equal(t, []string{"  MEM █░░░  25%"}, lines)
+}
+
+func TestRefreshStatsReportsChanges(t *testing.T) {
+	c := newTestCoordinator(t)
+	c.config.Widgets.Stats.Enabled = true
+	c.statsReader = &sysstats.Reader{Proc: t.TempDir(), Sys: t.TempDir()}
+
+	assert.True(t, c.RefreshStats(), "the first sample renders")
+	first := c.stats.LastUpdate
+	require.False(t, first.IsZero())
+
+	c.stats.LastUpdate = first.Add(-time.Minute)
+	assert.False(t, c.RefreshStats(), "resampled, nothing shown changed")
+	assert.True(t, c.stats.LastUpdate.After(first))
+
+	c.config.Widgets.Stats.Enabled = false
+	assert.False(t, c.RefreshStats())
+}
@@ -0,0 +1,159 @@
+package daemon
+
+// toast.go shows notification toasts: a small display-popup running
+// `tabby render toast` in a corner of the client, for the indicator events
+// selected by notifications.toast and for `tabby notify`. Toasts for a window
+// an attached client is already looking at are skipped, and at most one
+// toast is shown per refresh (tmux shows one popup per client at a time).
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/notifications"
+)
+
+// toastHeight is the popup height: title, two message lines, hint.
+const toastHeight = 4
+
+// toastPopupArgs builds the display-popup argv for a toast running popupCmd.
+func toastPopupArgs(cfg config.Toast, popupCmd string) []string {
+	x, y := "R", "0"
+	switch strings.ToLower(strings.TrimSpace(cfg.Position)) {
+	case "top-left":
+		x = "0"
+	case "bottom-right":
+		y = "S"
+	case "bottom-left":
+		x, y = "0", "S"
+	}
+	width := cfg.Width
+	if width <= 0 {
+		width = 44
+	}
+	// -B: the renderer paints its own card; -s bg matches it so nothing
+	// flashes while it starts.
+	return []string{"display-popup", "-E", "-B",
+		"-x", x, "-y", y,
+		"-w", strconv.Itoa(width), "-h", strconv.Itoa(toastHeight),
+		"-s", "bg=#1e293b",
+		"--", popupCmd}
+}
+
+// toastCommand renders the `tabby render toast` invocation for an event.
+func (c *Coordinator) toastCommand(cfg config.Toast, e notifications.Event) string {
+	bin := rendererExecPrefix("tabby-toast", "toast")
+	if bin == "" {
+		return ""
+	}
+	p := c.newSinkPayload(e)
+	title := strings.TrimSpace(e.Label())
+	if title == "" {
+		title = "tabby"
+	}
+	if e.WindowID != "" {
+		title = fmt.Sprintf("%d: %s", e.WindowIndex, title)
+	}
+	duration := cfg.Duration
+	if duration <= 0 {
+		duration = 5
+	}
+	return fmt.Sprintf("%s --kind %s --title %s --message %s --window %s --pane %s --timeout %d",
+		bin, shellQuote(e.Kind), shellQuote(title), shellQuote(p.Message),
+		shellQuote(e.WindowID), shellQuote(e.PaneID), duration)
+}
+
+// launchToast shows one toast. Fire-and-forget.
+func (c *Coordinator) launchToast(cfg config.Toast, e notifications.Event) {
+	popupCmd := c.toastCommand(cfg, e)
+	if popupCmd == "" {
+		return
+	}
+	go exec.Command("tmux", toastPopupArgs(cfg, popupCmd)...).Run()
+	logEvent("TOAST kind=%s window=%s", e.Kind, e.WindowID)
+}
+
+// pickToast returns the newest event that should be toasted: a kind listed in
+// cfg.On (KindNotify always qualifies) for a window nobody is viewing.
+func pickToast(cfg config.Toast, events []notifications.Event, viewed map[string]bool) (notifications.Event, bool) {
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		if e.Kind != notifications.KindNotify && !containsFold(cfg.On, e.Kind) {
+			continue
+		}
+		if e.WindowID != "" && viewed[e.WindowID] {
+			continue
+		}
+		return e, true
+	}
+	return notifications.Event{}, false
+}
+
+// toastNotifications shows a toast for the newest eligible event, if toasts
+// are enabled. Runs tmux; call without stateMu held.
+func (c *Coordinator) toastNotifications(cfg config.Notifications, events []notifications.Event) {
+	if !cfg.Toast.Enabled || len(events) == 0 {
+		return
+	}
+	e, ok := pickToast(cfg.Toast, events, attachedClientWindows())
+	if !ok {
+		return
+	}
+	if !c.toastLimiter.allow(e.WindowID+"|"+e.Kind, time.Now(), cfg) {
+		return
+	}
+	c.launchToast(cfg.Toast, e)
+}
+
+// postNotification handles `tabby notify`: records a KindNotify event for windowTarget
+// (optional), fires the sinks that subscribe to "notify", and shows a toast
+// unless that window is already on screen. Toasts for `tabby notify` do not
+// need notifications.toast.enabled. While focusing, do-not-disturb can hold
+// back everything but the history entry.
+func (c *Coordinator) postNotification(message, windowTarget string) error {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return fmt.Errorf("empty message")
+	}
+	e := notifications.Event{
+		Time:    time.Now(),
+		Session: c.sessionID,
+		Kind:    notifications.KindNotify,
+		Message: message,
+	}
+	c.stateMu.RLock()
+	cfg := c.config.Notifications
+	focusCfg := c.config.Widgets.Focus
+	if windowTarget != "" {
+		win := findWindowByTarget(c.windows, windowTarget)
+		if win == nil {
+			c.stateMu.RUnlock()
+			return fmt.Errorf("no such window: %s", windowTarget)
+		}
+		e.WindowID, e.WindowIndex, e.WindowName, e.Title = win.ID, win.Index, win.Name, win.AITitle
+		e.Group = win.Group
+		for _, g := range c.grouped {
+			for _, w := range g.Windows {
+				if w.ID == win.ID {
+					e.Group = g.Name
+				}
+			}
+		}
+	}
+	c.stateMu.RUnlock()
+
+	events := []notifications.Event{e}
+	c.recordNotifications(events)
+	if events = c.focusFilter(focusCfg, events); len(events) == 0 {
+		return nil
+	}
+	c.dispatchNotifications(cfg, events)
+	if _, ok := pickToast(cfg.Toast, events, attachedClientWindows()); ok {
+		c.launchToast(cfg.Toast, e)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/notifications"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToastPopupArgsPositions(t *testing.T) {
+	pos := func(p string) (string, string) {
+		args := toastPopupArgs(config.Toast{Position: p, Width: 40}, "cmd")
+		var x, y string
+		for i := 0; i+1 < len(args); i++ {
+			switch args[i] {
+			case "-x":
+				x = args[i+1]
+			case "-y":
+				y = args[i+1]
+			}
+		}
+		assert.Equal(t, "cmd", args[len(args)-1])
+		return x, y
+	}
+	x, y := pos("top-right")
+	assert.Equal(t, []string{"R", "0"}, []string{x, y})
+	x, y = pos("top-left")
+	assert.Equal(t, []string{"0", "0"}, []string{x, y})
+	x, y = pos("bottom-right")
+	assert.Equal(t, []string{"R", "S"}, []string{x, y})
+	x, y = pos("Bottom-Left")
+	assert.Equal(t, []string{"0", "S"}, []string{x, y})
+	x, y = pos("")
+	assert.Equal(t, []string{"R", "0"}, []string{x, y}, "default is top-right")
+}
+
+func TestPickToast(t *testing.T) {
+	cfg := config.Toast{On: []string{"input", "crash"}}
+	events := []notifications.Event{
+		{Kind: notifications.KindInput, WindowID: "@1"},
+		{Kind: notifications.KindBusy, WindowID: "@2"},
+		{Kind: notifications.KindInput, WindowID: "@3"},
+	}
+
+	e, ok := pickToast(cfg, events, nil)
+	assert.True(t, ok)
+	assert.Equal(t, "@3", e.WindowID, "newest eligible event wins")
+
+	e, ok = pickToast(cfg, events, map[string]bool{"@3": true})
+	assert.True(t, ok)
+	assert.Equal(t, "@1", e.WindowID, "windows on screen are skipped")
+
+	_, ok = pickToast(cfg, events, map[string]bool{"@1": true, "@3": true})
+	assert.False(t, ok)
+
+	_, ok = pickToast(config.Toast{}, []notifications.Event{{Kind: notifications.KindNotify}}, nil)
+	assert.True(t, ok, "tabby notify always toasts")
+}
+
+func TestPostNotificationValidates(t *testing.T) {
+	c := newTestCoordinator(t)
+	assert.Error(t, c.postNotification("  ", ""))
+	assert.Error(t, c.postNotification("hi", "@404"))
+}
@@ -0,0 +1,282 @@
+package daemon
+
+// todo.go is the todo widget: the open items of a markdown task file
+// (pkg/todo), widgets.todo.path or, while a window of a group listed under
+// widgets.todo.groups is active, that group's file. Clicking an item ticks
+// it in the file and "+" prompts for a new one: the answer is stored
+// verbatim in a tmux option that `tabby ctl todo add-prompted` reads, so it
+// never passes through a shell. The file's size and modification time are
+// checked every update_interval seconds, so edits made in an editor show up
+// without any reload.
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/paths"
+	"github.com/brendandebeasi/tabby/pkg/todo"
+)
+
+// todoWidgetMaxRows caps the listed items; each row is its own click zone
+// ("todo:tick1" ...).
+const todoWidgetMaxRows = 20
+
+// todoPromptOption holds the add prompt's answer until `tabby ctl todo
+// add-prompted` reads it.
+const todoPromptOption = "@tabby_todo_new"
+
+// todoPrompt asks for a new item with tmux's command prompt and runs
+// template with the answer; a variable for tests.
+var todoPrompt = func(template string) {
+	go exec.Command("tmux", "command-prompt", "-p", "New todo:", template).Run()
+}
+
+// todoPromptAnswer reads and clears the add prompt's answer; a variable for
+// tests.
+var todoPromptAnswer = func() string {
+	out, _ := exec.Command("tmux", "show-options", "-gqv", todoPromptOption).Output()
+	exec.Command("tmux", "set-option", "-gqu", todoPromptOption).Run()
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+// todoState is the task file shown and its items, guarded by stateMu.
+type todoState struct {
+	path      string
+	signature string // size and mtime of path when last read
+	items     []todo.Item
+}
+
+// todoPathLocked is the task file for the active window's group. Caller
+// holds stateMu.
+func (c *Coordinator) todoPathLocked() string {
+	cfg := c.config.Widgets.Todo
+	path := cfg.Path
+	if len(cfg.Groups) > 0 {
+		for _, g := range c.grouped {
+			for _, win := range g.Windows {
+				if win.Active && cfg.Groups[g.Name] != "" {
+					path = cfg.Groups[g.Name]
+				}
+			}
+		}
+	}
+	if path == "" {
+		return filepath.Join(paths.ConfigDir(), "todo.md")
+	}
+	return expandWorkingDir(path)
+}
+
+// refreshTodo rereads the task file when it changed, or when the active
+// group switched files. force rereads it regardless.
+func (c *Coordinator) refreshTodo(force bool) {
+	c.stateMu.RLock()
+	path := c.todoPathLocked()
+	same := path == c.todo.path
+	signature := c.todo.signature
+	c.stateMu.RUnlock()
+
+	sig := "missing"
+	if info, err := os.Stat(path); err == nil {
+		sig = fmt.Sprintf("%d|%d", info.Size(), info.ModTime().UnixNano())
+	}
+	if same && sig == signature && !force {
+		return
+	}
+	items, err := todo.Load(path)
+	if err != nil {
+		coordinatorDebugLog.Printf("todo: %v", err)
+	}
+
+	c.stateMu.Lock()
+	c.todo.path, c.todo.signature, c.todo.items = path, sig, items
+	c.stateMu.Unlock()
+}
+
+// openTodos returns the open items, at most n. Caller holds stateMu.
+func (c *Coordinator) openTodos(n int) []todo.Item {
+	var open []todo.Item
+	for _, it := range c.todo.items {
+		if !it.Done {
+			open = append(open, it)
+		}
+	}
+	if len(open) > n {
+		open = open[:n]
+	}
+	return open
+}
+
+// renderTodoWidget renders a "Todo (3)" header with an add button, then one
+// clickable "☐ item" line per open item.
+func (c *Coordinator) renderTodoWidget(width int) string {
+	cfg := c.config.Widgets.Todo
+	if !cfg.Enabled {
+		return ""
+	}
+
+	fg := c.getInactiveTextColorWithFallback(cfg.Fg)
+	style := lipgloss.NewStyle()
+	if fg != "" {
+		style = style.Foreground(lipgloss.Color(fg))
+	}
+	if cfg.Bg != "" {
+		style = style.Background(lipgloss.Color(cfg.Bg))
+	}
+
+	var result strings.Builder
+	all := c.openTodos(len(c.todo.items))
+	shown := c.openTodos(min(cfg.Count, todoWidgetMaxRows))
+
+	// Header: "Todo (3)" and an add button at the right edge.
+	head := "Todo"
+	if len(all) > 0 {
+		head += fmt.Sprintf(" (%d)", len(all))
+	}
+	head = runewidth.Truncate(head, max(width-2, 1), "…")
+	gap := max(width-runewidth.StringWidth(head)-1, 1)
+	result.WriteString(style.Render(head+strings.Repeat(" ", gap)) + zone.Mark("todo:add", style.Render("+")) + "\n")
+
+	if len(all) == 0 {
+		result.WriteString(style.Render(runewidth.Truncate("Nothing to do", width, "…")) + "\n")
+	}
+	for i, it := range shown {
+		row := style.Render(runewidth.Truncate("☐ "+it.Text, width, "…"))
+		result.WriteString(zone.Mark("todo:tick"+strconv.Itoa(i+1), row) + "\n")
+	}
+	if more := len(all) - len(shown); more > 0 {
+		result.WriteString(style.Render(fmt.Sprintf("… %d more", more)) + "\n")
+	}
+
+	return c.wrapWidgetChrome(widgetLayout{
+		Divider: cfg.Divider, DividerFg: cfg.DividerFg,
+		MarginTop: cfg.MarginTop, PaddingTop: cfg.PaddingTop, PaddingBot: cfg.PaddingBot, MarginBot: cfg.MarginBot,
+	}, result.String(), width)
+}
+
+// tickTodo marks the nth (1-based) open item done in the file.
+func (c *Coordinator) tickTodo(n int) error {
+	c.stateMu.RLock()
+	path := c.todo.path
+	open := c.openTodos(len(c.todo.items))
+	c.stateMu.RUnlock()
+	if n < 1 || n > len(open) {
+		return fmt.Errorf("no open item %d", n)
+	}
+	if err := todo.SetDone(path, open[n-1], true); err != nil {
+		return err
+	}
+	c.refreshTodo(true)
+	return nil
+}
+
+// handleTodoCtl runs `tabby ctl todo <add <text>|done <n>|list>`, and
+// add-prompted for the widget's add prompt.
+func (c *Coordinator) handleTodoCtl(args []string) (string, error) {
+	const usage = "usage: todo add <text> | done <n> | list"
+	if len(args) == 0 {
+		return "", fmt.Errorf(usage)
+	}
+	c.refreshTodo(false)
+	switch args[0] {
+	case "add", "add-prompted":
+		text := strings.Join(args[1:], " ")
+		if args[0] == "add-prompted" {
+			text = todoPromptAnswer()
+		}
+		c.stateMu.RLock()
+		path := c.todo.path
+		c.stateMu.RUnlock()
+		if err := todo.Add(path, text); err != nil {
+			return "", err
+		}
+		c.refreshTodo(true)
+	case "done":
+		if len(args) != 2 {
+			return "", fmt.Errorf(usage)
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return "", fmt.Errorf("not an item number: %s", args[1])
+		}
+		if err := c.tickTodo(n); err != nil {
+			return "", err
+		}
+	case "list":
+	default:
+		return "", fmt.Errorf(usage)
+	}
+	if args[0] != "list" && c.OnRefreshLayout != nil {
+		c.OnRefreshLayout()
+	}
+
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	var out strings.Builder
+	for i, it := range c.openTodos(len(c.todo.items)) {
+		fmt.Fprintf(&out, "%d. %s\n", i+1, it.Text)
+	}
+	return out.String(), nil
+}
+
+// todoWidget is the registry entry for the checklist.
+type todoWidget struct{ widgetBase }
+
+func (todoWidget) Name() string { return "todo" }
+
+func (todoWidget) Config(cfg *config.Config) WidgetConfig {
+	w := cfg.Widgets.Todo
+	return WidgetConfig{Enabled: w.Enabled, Position: widgetPosition(w.Position, "bottom"), Priority: w.Priority}
+}
+
+func (todoWidget) Interval(cfg *config.Config) time.Duration {
+	if !cfg.Widgets.Todo.Enabled {
+		return 0
+	}
+	return secondsOr(cfg.Widgets.Todo.UpdateInterval, 2*time.Second)
+}
+
+func (todoWidget) Refresh(c *Coordinator) { c.refreshTodo(false) }
+
+func (todoWidget) StateHash(c *Coordinator) string {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.todo.path + "|" + fmt.Sprint(c.todo.items)
+}
+
+func (todoWidget) Render(c *Coordinator, width int) string { return c.renderTodoWidget(width) }
+
+func (todoWidget) Actions() []string {
+	actions := []string{"add"}
+	for i := 1; i <= todoWidgetMaxRows; i++ {
+		actions = append(actions, "tick"+strconv.Itoa(i))
+	}
+	return actions
+}
+
+// Click ticks the clicked item, or prompts for a new one. tmux's %%% escapes
+// the answer for the double-quoted option value, which keeps it verbatim.
+func (todoWidget) Click(c *Coordinator, clientID, action string) bool {
+	if action == "add" {
+		todoPrompt(fmt.Sprintf(`set-option -g %s "%%%%%%" ; run-shell '%s todo add-prompted'`, todoPromptOption, c.getCtlPath()))
+		return false
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(action, "tick"))
+	if err != nil {
+		return false
+	}
+	if err := c.tickTodo(n); err != nil {
+		coordinatorDebugLog.Printf("todo: tick %d: %v", n, err)
+		return false
+	}
+	return true
+}
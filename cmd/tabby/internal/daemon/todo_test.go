@@ -0,0 +1,86 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/grouping"
+)
+
+func TestTodoWidget(t *testing.T) {
+	dir := t.TempDir()
+	global := filepath.Join(dir, "todo.md")
+	work := filepath.Join(dir, "work.md")
+	require.NoError(t, os.WriteFile(global, []byte("# Inbox\n- [ ] water plants\n- [x] pay rent\n- [ ] book flights\n- [ ] renew passport\n"), 0644))
+
+	var prompted string
+	orig := todoPrompt
+	todoPrompt = func(template string) { prompted = template }
+	t.Cleanup(func() { todoPrompt = orig })
+
+	c := newTestCoordinator(t)
+	c.config.Widgets.Todo = config.TodoWidget{
+		Enabled: true, Path: global, Count: 2, UpdateInterval: 2,
+		Groups: map[string]string{"Work": work},
+	}
+	home := testWindow("home", true, "zsh")
+	job := testWindow("job", false, "zsh")
+	job.ID = "@2"
+	c.windows = append(c.windows, home, job)
+	c.grouped = []grouping.GroupedWindows{{Name: "Default", Windows: c.windows[:1]}, {Name: "Work", Windows: c.windows[1:]}}
+
+	c.refreshTodo(false)
+	lines := strings.Split(stripWidget(c.renderTodoWidget(20)), "\n")
+	assert.Equal(t, []string{"Todo (3)           +", "☐ water plants", "☐ book flights", "… 1 more", ""}, lines)
+
+	// Ticking the second row writes the file and drops the item.
+	handled, changed := c.handleWidgetClick("client", "tick2", "todo")
+	require.True(t, handled)
+	assert.True(t, changed)
+	data, err := os.ReadFile(global)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "- [x] book flights\n")
+	assert.Len(t, c.openTodos(10), 2)
+
+	// An edit in an editor is picked up by its size/mtime.
+	require.NoError(t, os.WriteFile(global, []byte("- [ ] one thing\n"), 0644))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(global, future, future))
+	c.refreshTodo(false)
+	assert.Equal(t, "one thing", c.openTodos(10)[0].Text)
+
+	// The prompt's answer reaches the file without a shell in between.
+	c.handleWidgetClick("client", "add", "todo")
+	assert.Contains(t, prompted, `set-option -g @tabby_todo_new "%%%" ; run-shell '`)
+	assert.Contains(t, prompted, ` todo add-prompted'`)
+	origAnswer := todoPromptAnswer
+	todoPromptAnswer = func() string { return "fix `make` in Sam's repo" }
+	t.Cleanup(func() { todoPromptAnswer = origAnswer })
+	out, err := c.handleTodoCtl([]string{"add-prompted"})
+	require.NoError(t, err)
+	assert.Equal(t, "1. one thing\n2. fix `make` in Sam's repo\n", out)
+
+	// The active window's group picks its own file; add creates it.
+	c.windows[0].Active, c.windows[1].Active = false, true
+	c.grouped[1].Windows[0].Active = true
+	out, err = c.handleTodoCtl([]string{"add", "standup", "notes"})
+	require.NoError(t, err)
+	assert.Equal(t, "1. standup notes\n", out)
+	data, err = os.ReadFile(work)
+	require.NoError(t, err)
+	assert.Equal(t, "- [ ] standup notes\n", string(data))
+
+	_, err = c.handleTodoCtl([]string{"done", "2"})
+	assert.Error(t, err)
+	out, err = c.handleTodoCtl([]string{"done", "1"})
+	require.NoError(t, err)
+	assert.Empty(t, out)
+	assert.Contains(t, stripWidget(c.renderTodoWidget(20)), "Nothing to do")
+}
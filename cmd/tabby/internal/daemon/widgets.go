@@ -0,0 +1,403 @@
+package daemon
+
+// widgets.go is the registry of built-in sidebar widgets. Each widget
+// implements Widget: it decodes its layout from its section of
+// config.Widgets, declares how often it refreshes, fingerprints its state and
+// renders itself. The loop's WidgetTickEvent calls RefreshWidgets once a
+// second, which refreshes whichever widgets are due and reports whether any
+// of them changed; collectWidgetEntries renders the enabled ones in registry
+// order. A new built-in widget is a type here plus a registerWidget call, no
+// new tick event or collectWidgetEntries branch.
+//
+// The pet (its own animation tick and click hit-testing) and widgets.custom
+// (one entry per configured command) stay outside the registry.
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+)
+
+// Widget is a built-in sidebar widget.
+type Widget interface {
+	// Name is the widget's key under widgets: in config.yaml and the target
+	// of its click zones.
+	Name() string
+	// Config decodes the widget's layout settings from cfg.
+	Config(cfg *config.Config) WidgetConfig
+	// Interval is how often Refresh runs; 0 means never (e.g. disabled).
+	Interval(cfg *config.Config) time.Duration
+	// Refresh updates the widget's cached state. It runs on the loop
+	// goroutine, so slow work belongs in a goroutine that calls
+	// OnRefreshLayout itself.
+	Refresh(c *Coordinator)
+	// StateHash fingerprints what Render shows; a change since the previous
+	// refresh triggers a render.
+	StateHash(c *Coordinator) string
+	// Render returns the widget's content, called with stateMu read-locked.
+	Render(c *Coordinator, width int) string
+	// Actions lists the actions Render marks as "<name>:<action>" zones.
+	Actions() []string
+	// Click handles a click on one of Actions and reports whether tmux
+	// state changed.
+	Click(c *Coordinator, clientID, action string) bool
+}
+
+// WidgetConfig is the layout every widget decodes from its config section.
+type WidgetConfig struct {
+	Enabled  bool
+	Position string // "top" or "bottom"
+	Priority int
+}
+
+// widgetLayout is the spacing and divider around a widget's rows, from the
+// margin_*, padding_*, divider and divider_fg keys of its config section.
+type widgetLayout struct {
+	Divider    string
+	DividerFg  string
+	MarginTop  int
+	PaddingTop int
+	PaddingBot int
+	MarginBot  int
+}
+
+// wrapWidgetChrome puts a widget's rendered rows (each ending in "\n")
+// between its top margin, divider and padding and its bottom padding and
+// margin.
+func (c *Coordinator) wrapWidgetChrome(layout widgetLayout, body string, width int) string {
+	var result strings.Builder
+	result.WriteString(strings.Repeat("\n", max(layout.MarginTop, 0)))
+	if layout.Divider != "" {
+		dividerWidth := lipgloss.Width(layout.Divider)
+		if dividerWidth == 0 {
+			dividerWidth = 1
+		}
+		dividerStyle := lipgloss.NewStyle()
+		if dividerFg := c.getInactiveTextColorWithFallback(layout.DividerFg); dividerFg != "" {
+			dividerStyle = dividerStyle.Foreground(lipgloss.Color(dividerFg))
+		}
+		result.WriteString(dividerStyle.Render(strings.Repeat(layout.Divider, width/dividerWidth)) + "\n")
+	}
+	result.WriteString(strings.Repeat("\n", max(layout.PaddingTop, 0)))
+	result.WriteString(body)
+	result.WriteString(strings.Repeat("\n", max(layout.PaddingBot, 0)))
+	result.WriteString(strings.Repeat("\n", max(layout.MarginBot, 0)))
+	return result.String()
+}
+
+// widgetBase provides the optional parts of Widget: no refresh, no clicks.
+type widgetBase struct{}
+
+func (widgetBase) Refresh(*Coordinator)                    {}
+func (widgetBase) Actions() []string                       { return nil }
+func (widgetBase) Click(*Coordinator, string, string) bool { return false }
+func (widgetBase) Interval(*config.Config) time.Duration   { return 0 }
+func (widgetBase) StateHash(*Coordinator) string           { return "" }
+
+// widgetRegistry holds the built-in widgets in render order; equal
+// priorities keep this order.
+var widgetRegistry []Widget
+
+func registerWidget(w Widget) {
+	widgetRegistry = append(widgetRegistry, w)
+}
+
+// lookupWidget returns the registered widget called name, or nil.
+func lookupWidget(name string) Widget {
+	for _, w := range widgetRegistry {
+		if w.Name() == name {
+			return w
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerWidget(clockWidget{})
+	registerWidget(statsWidget{})
+	registerWidget(gitWidget{})
+	registerWidget(sessionWidget{})
+	registerWidget(claudeWidget{})
+	registerWidget(teamClaudeWidget{})
+	registerWidget(mirrorWidget{})
+	registerWidget(focusWidget{})
+	registerWidget(calendarWidget{})
+	registerWidget(topWidget{})
+	registerWidget(todoWidget{})
+}
+
+// widgetTickSlack absorbs ticker jitter so a widget due every N seconds is
+// refreshed on the Nth one-second tick rather than the one after.
+const widgetTickSlack = 250 * time.Millisecond
+
+// widgetScheduler tracks when each widget last refreshed and the state hash
+// it had then. The mutex also keeps a refresh that outlived its loop task
+// from overlapping the next.
+type widgetScheduler struct {
+	mu          sync.Mutex
+	refreshedAt map[string]time.Time
+	hashes      map[string]string
+}
+
+// RefreshWidgets refreshes the registered widgets whose interval has passed
+// and starts the widgets.custom commands that are due. It reports whether a
+// refreshed widget's state hash differs from its previous refresh, i.e. the
+// sidebar needs a render.
+func (c *Coordinator) RefreshWidgets(now time.Time) bool {
+	s := &c.widgetSched
+	if !s.mu.TryLock() {
+		return false
+	}
+	defer s.mu.Unlock()
+	if s.refreshedAt == nil {
+		s.refreshedAt = make(map[string]time.Time)
+		s.hashes = make(map[string]string)
+	}
+
+	changed := false
+	for _, w := range widgetRegistry {
+		interval := w.Interval(c.config)
+		if interval <= 0 {
+			continue
+		}
+		name := w.Name()
+		if last, ok := s.refreshedAt[name]; ok && now.Sub(last) < interval-widgetTickSlack {
+			continue
+		}
+		s.refreshedAt[name] = now
+		w.Refresh(c)
+		hash := w.StateHash(c)
+		if prev, ok := s.hashes[name]; !ok || prev != hash {
+			changed = true
+		}
+		s.hashes[name] = hash
+	}
+	c.RefreshCustomWidgets()
+	return changed
+}
+
+// handleWidgetClick routes a click on a "<name>:<action>" zone to the widget
+// that marked it. handled is false when no registered widget owns it.
+func (c *Coordinator) handleWidgetClick(clientID string, action, target string) (handled, changed bool) {
+	w := lookupWidget(target)
+	if w == nil || !slices.Contains(w.Actions(), action) {
+		return false, false
+	}
+	return true, w.Click(c, clientID, action)
+}
+
+// widgetZoneIDs are the zone marks of every registered widget's actions.
+func widgetZoneIDs() []string {
+	var ids []string
+	for _, w := range widgetRegistry {
+		for _, a := range w.Actions() {
+			ids = append(ids, w.Name()+":"+a)
+		}
+	}
+	return ids
+}
+
+// widgetPosition defaults an unset position to def.
+func widgetPosition(pos, def string) string {
+	if pos == "" {
+		return def
+	}
+	return pos
+}
+
+// secondsOr converts a config interval in seconds, falling back to def.
+func secondsOr(secs int, def time.Duration) time.Duration {
+	if secs <= 0 {
+		return def
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// clockWidget shows the time and optionally the date. It has nothing to
+// fetch; refreshing every second lets the hash notice the time has moved on,
+// and the hash only moves every second when the formats print seconds.
+type clockWidget struct{ widgetBase }
+
+func (clockWidget) Name() string { return "clock" }
+
+func (clockWidget) Config(cfg *config.Config) WidgetConfig {
+	w := cfg.Widgets.Clock
+	return WidgetConfig{Enabled: w.Enabled, Position: widgetPosition(w.Position, "top"), Priority: w.Priority}
+}
+
+func (clockWidget) Interval(cfg *config.Config) time.Duration {
+	if !cfg.Widgets.Clock.Enabled {
+		return 0
+	}
+	return time.Second
+}
+
+func (clockWidget) StateHash(c *Coordinator) string {
+	return clockStateHash(c.config.Widgets.Clock, time.Now())
+}
+
+// clockStateHash is the time the clock shows at now: to the second when its
+// time or date format prints seconds, else to the minute.
+func clockStateHash(clock config.ClockWidget, now time.Time) string {
+	layout := clock.Format
+	if layout == "" {
+		layout = "15:04:05"
+	}
+	if clock.ShowDate {
+		dateLayout := clock.DateFmt
+		if dateLayout == "" {
+			dateLayout = "Mon Jan 2"
+		}
+		layout += "|" + dateLayout
+	}
+	if !layoutShowsSeconds(layout) {
+		now = now.Truncate(time.Minute)
+	}
+	return now.Format("2006-01-02 15:04:05 MST")
+}
+
+// layoutShowsSeconds reports whether a Go time layout prints seconds (or a
+// fraction of one), i.e. two instants a second apart format differently.
+func layoutShowsSeconds(layout string) bool {
+	ref := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	return ref.Format(layout) != ref.Add(time.Second).Format(layout)
+}
+
+func (clockWidget) Render(c *Coordinator, width int) string { return c.renderClockWidget(width) }
+
+// statsWidget shows CPU, memory and battery bars (stats.go).
+type statsWidget struct{ widgetBase }
+
+func (statsWidget) Name() string { return "stats" }
+
+func (statsWidget) Config(cfg *config.Config) WidgetConfig {
+	w := cfg.Widgets.Stats
+	return WidgetConfig{Enabled: w.Enabled, Position: widgetPosition(w.Position, "bottom"), Priority: w.Priority}
+}
+
+func (statsWidget) Interval(cfg *config.Config) time.Duration {
+	if !cfg.Widgets.Stats.Enabled {
+		return 0
+	}
+	return secondsOr(cfg.Widgets.Stats.UpdateInterval, 2*time.Second)
+}
+
+func (statsWidget) Refresh(c *Coordinator) { c.RefreshStats() }
+
+func (statsWidget) StateHash(c *Coordinator) string {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return statsDisplayKey(c.stats)
+}
+
+func (statsWidget) Render(c *Coordinator, width int) string { return c.renderStatsWidget(width) }
+
+// gitWidget shows the daemon's working tree branch and status.
+type gitWidget struct{ widgetBase }
+
+func (gitWidget) Name() string { return "git" }
+
+func (gitWidget) Config(cfg *config.Config) WidgetConfig {
+	w := cfg.Widgets.Git
+	return WidgetConfig{Enabled: w.Enabled, Position: widgetPosition(w.Position, "bottom"), Priority: w.Priority}
+}
+
+func (gitWidget) Interval(cfg *config.Config) time.Duration {
+	if !cfg.Widgets.Git.Enabled {
+		return 0
+	}
+	return secondsOr(cfg.Widgets.Git.UpdateInterval, 5*time.Second)
+}
+
+func (gitWidget) Refresh(c *Coordinator)                  { c.RefreshGit() }
+func (gitWidget) StateHash(c *Coordinator) string         { return c.GetGitStateHash() }
+func (gitWidget) Render(c *Coordinator, width int) string { return c.renderGitWidget(width) }
+
+// sessionWidget shows the tmux session name, clients and window count.
+type sessionWidget struct{ widgetBase }
+
+func (sessionWidget) Name() string { return "session" }
+
+func (sessionWidget) Config(cfg *config.Config) WidgetConfig {
+	w := cfg.Widgets.Session
+	return WidgetConfig{Enabled: w.Enabled, Position: widgetPosition(w.Position, "bottom"), Priority: w.Priority}
+}
+
+func (sessionWidget) Interval(cfg *config.Config) time.Duration {
+	if !cfg.Widgets.Session.Enabled {
+		return 0
+	}
+	return 5 * time.Second
+}
+
+func (sessionWidget) Refresh(c *Coordinator) { c.RefreshSession() }
+
+func (sessionWidget) StateHash(c *Coordinator) string {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return fmt.Sprintf("%s:%d:%d", c.sessionName, c.sessionClients, c.windowCount)
+}
+
+func (sessionWidget) Render(c *Coordinator, width int) string { return c.renderSessionWidget(width) }
+
+// claudeWidget shows Claude Code token usage and cost (claude_usage.go).
+// RefreshClaudeUsage scans in the background, throttled to update_interval,
+// and renders on change itself; the 10s interval only polls that throttle.
+// It also runs with the widget hidden when show_window_cost is on.
+type claudeWidget struct{ widgetBase }
+
+func (claudeWidget) Name() string { return "claude" }
+
+func (claudeWidget) Config(cfg *config.Config) WidgetConfig {
+	w := cfg.Widgets.Claude
+	return WidgetConfig{Enabled: w.Enabled, Position: widgetPosition(w.Position, "bottom"), Priority: w.Priority}
+}
+
+func (claudeWidget) Interval(cfg *config.Config) time.Duration {
+	if !cfg.Widgets.Claude.Enabled && !cfg.Widgets.Claude.ShowWindowCost {
+		return 0
+	}
+	return 10 * time.Second
+}
+
+func (claudeWidget) Refresh(c *Coordinator)                  { c.RefreshClaudeUsage() }
+func (claudeWidget) Render(c *Coordinator, width int) string { return c.renderClaudeWidget(width) }
+
+// teamClaudeWidget shows per-account quota from a teamclaude proxy. Like
+// claudeWidget, the fetch runs in the background throttled to
+// update_interval; the 15s interval polls that throttle.
+type teamClaudeWidget struct{ widgetBase }
+
+func (teamClaudeWidget) Name() string { return "teamclaude" }
+
+func (teamClaudeWidget) Config(cfg *config.Config) WidgetConfig {
+	w := cfg.Widgets.TeamClaude
+	return WidgetConfig{Enabled: w.Enabled, Position: widgetPosition(w.Position, "bottom"), Priority: w.Priority}
+}
+
+func (teamClaudeWidget) Interval(cfg *config.Config) time.Duration {
+	if !cfg.Widgets.TeamClaude.Enabled || cfg.Widgets.TeamClaude.URL == "" {
+		return 0
+	}
+	return 15 * time.Second
+}
+
+func (teamClaudeWidget) Refresh(c *Coordinator)          { c.RefreshTeamClaude() }
+func (teamClaudeWidget) StateHash(c *Coordinator) string { return c.GetTeamClaudeStateHash() }
+func (teamClaudeWidget) Render(c *Coordinator, width int) string {
+	return c.renderTeamClaudeWidget(width)
+}
+
+// Actions: the header's degraded-model warning icon.
+func (teamClaudeWidget) Actions() []string { return []string{"open_degraded"} }
+
+func (teamClaudeWidget) Click(c *Coordinator, clientID, action string) bool {
+	c.launchDegradedModelsPopup(clientID)
+	return true
+}
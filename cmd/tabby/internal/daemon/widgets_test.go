@@ -0,0 +1,133 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+)
+
+// fakeWidget is a registry widget whose schedule, state and clicks the test
+// controls.
+type fakeWidget struct {
+	widgetBase
+	interval  time.Duration
+	hash      string
+	refreshes int
+	clicks    []string
+}
+
+func (w *fakeWidget) Name() string { return "fake" }
+func (w *fakeWidget) Config(*config.Config) WidgetConfig {
+	return WidgetConfig{Enabled: true, Position: "top", Priority: 5}
+}
+func (w *fakeWidget) Interval(*config.Config) time.Duration   { return w.interval }
+func (w *fakeWidget) Refresh(*Coordinator)                    { w.refreshes++ }
+func (w *fakeWidget) StateHash(*Coordinator) string           { return w.hash }
+func (w *fakeWidget) Render(c *Coordinator, width int) string { return "fake\n" }
+func (w *fakeWidget) Actions() []string                       { return []string{"poke"} }
+func (w *fakeWidget) Click(c *Coordinator, clientID, action string) bool {
+	w.clicks = append(w.clicks, clientID+":"+action)
+	return true
+}
+
+// withWidgets swaps the registry for the test.
+func withWidgets(t *testing.T, ws ...Widget) {
+	t.Helper()
+	orig := widgetRegistry
+	widgetRegistry = ws
+	t.Cleanup(func() { widgetRegistry = orig })
+}
+
+func TestWidgetRegistry(t *testing.T) {
+	var names []string
+	for _, w := range widgetRegistry {
+		names = append(names, w.Name())
+	}
+	assert.Equal(t, []string{"clock", "stats", "git", "session", "claude", "teamclaude", "mirror", "focus", "calendar", "top", "todo"}, names)
+	assert.Nil(t, lookupWidget("pet"))
+	assert.Contains(t, widgetZoneIDs(), "teamclaude:open_degraded")
+
+	cfg := testConfig()
+	cfg.Widgets.Git.Enabled = true
+	cfg.Widgets.Git.UpdateInterval = 30
+	assert.Equal(t, 30*time.Second, lookupWidget("git").Interval(cfg))
+	assert.Equal(t, WidgetConfig{Enabled: true, Position: "bottom"}, lookupWidget("git").Config(cfg))
+	cfg.Widgets.Git.Enabled = false
+	assert.Zero(t, lookupWidget("git").Interval(cfg), "disabled widgets never refresh")
+
+	cfg.Widgets.Claude.ShowWindowCost = true
+	assert.NotZero(t, lookupWidget("claude").Interval(cfg), "window cost needs usage with the widget hidden")
+}
+
+func TestRefreshWidgetsSchedulesByInterval(t *testing.T) {
+	w := &fakeWidget{interval: 5 * time.Second, hash: "a"}
+	off := &fakeWidget{}
+	withWidgets(t, w, off)
+	c := newTestCoordinator(t)
+	now := time.Now()
+
+	assert.True(t, c.RefreshWidgets(now), "first refresh renders")
+	assert.Equal(t, 1, w.refreshes)
+	assert.False(t, c.RefreshWidgets(now.Add(time.Second)), "not due")
+	assert.Equal(t, 1, w.refreshes)
+
+	// A tick a little early still counts as due.
+	assert.False(t, c.RefreshWidgets(now.Add(5*time.Second-100*time.Millisecond)), "due, unchanged")
+	assert.Equal(t, 2, w.refreshes)
+
+	w.hash = "b"
+	assert.True(t, c.RefreshWidgets(now.Add(10*time.Second)))
+	assert.Zero(t, off.refreshes, "interval 0 never refreshes")
+}
+
+func TestWidgetEntriesAndClicks(t *testing.T) {
+	w := &fakeWidget{}
+	withWidgets(t, w)
+	c := newTestCoordinator(t)
+
+	var got *widgetEntry
+	for _, e := range c.collectWidgetEntries(25, true, true) {
+		if e.name == "fake" {
+			got = &e
+		}
+	}
+	require.NotNil(t, got)
+	assert.Equal(t, "top", got.zone)
+	assert.Equal(t, 5, got.priority)
+	assert.Equal(t, "fake\n", got.content)
+
+	handled, changed := c.handleWidgetClick("client", "poke", "fake")
+	assert.True(t, handled)
+	assert.True(t, changed)
+	handled, _ = c.handleWidgetClick("client", "other", "fake")
+	assert.False(t, handled, "not one of the widget's actions")
+	handled, _ = c.handleWidgetClick("client", "poke", "sidebar")
+	assert.False(t, handled)
+	assert.Equal(t, []string{"client:poke"}, w.clicks)
+}
+
+func TestClockStateHash(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 10, 0, time.UTC)
+	later := now.Add(20 * time.Second)
+
+	minutes := config.ClockWidget{Format: "15:04", ShowDate: true}
+	assert.Equal(t, clockStateHash(minutes, now), clockStateHash(minutes, later), "no seconds shown")
+	assert.NotEqual(t, clockStateHash(minutes, now), clockStateHash(minutes, now.Add(time.Minute)))
+
+	assert.NotEqual(t, clockStateHash(config.ClockWidget{}, now), clockStateHash(config.ClockWidget{}, later), "default format shows seconds")
+	withDate := config.ClockWidget{Format: "15:04", ShowDate: true, DateFmt: "Jan 2 :05"}
+	assert.NotEqual(t, clockStateHash(withDate, now), clockStateHash(withDate, later), "date format shows seconds")
+}
+
+func TestWrapWidgetChrome(t *testing.T) {
+	c := newTestCoordinator(t)
+	assert.Equal(t, "a\n", c.wrapWidgetChrome(widgetLayout{}, "a\n", 10))
+
+	layout := widgetLayout{Divider: "─", MarginTop: 1, PaddingTop: 1, PaddingBot: 2, MarginBot: 1}
+	got := stripAnsi(c.wrapWidgetChrome(layout, "a\nb\n", 4))
+	assert.Equal(t, "\n────\n\na\nb\n\n\n\n", got)
+}
@@ -0,0 +1,281 @@
+package daemon
+
+// window_git.go adds compact git status to each tab row
+// (widgets.git.show_window_status): " ⎇main*↑1↓2" for the branch, a dirty
+// mark and the commits ahead of and behind upstream, for the repo of the
+// window's active pane.
+//
+// Unlike RefreshGit, which polls the active pane's repo on the git widget's
+// interval, this is driven by RefreshWindows: a window is looked up again
+// when its pane's cwd changes or when it becomes the active window, and the
+// active window at most every gitActiveRecheck while RefreshWindows runs, so
+// editing, committing or pushing in the window in front of the user shows
+// without leaving it. Lookups
+// run on a small worker pool, never on the loop goroutine, and results are
+// cached per repository (keyed by gitToplevel), so windows in the same repo
+// share one `git status`.
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// gitWindowWorkers bounds the git processes run at once for tab status.
+const gitWindowWorkers = 4
+
+// gitRepoFresh is how long a repo's status is reused when another window
+// asks for it, so a burst of windows in one repo runs git once.
+const gitRepoFresh = 2 * time.Second
+
+// gitActiveRecheck is how old the active window's status may get before
+// RefreshWindows queues it again with its cwd unchanged.
+const gitActiveRecheck = 5 * time.Second
+
+// gitWindowStatusRun runs `git status` in a repo; a variable for tests.
+var gitWindowStatusRun = func(ctx context.Context, top string) ([]byte, error) {
+	return exec.CommandContext(ctx, "git", "-C", top, "status", "--porcelain=v2", "--branch").Output()
+}
+
+// gitRepoStatus is one repository's tab status.
+type gitRepoStatus struct {
+	Branch string
+	Dirty  bool
+	Ahead  int
+	Behind int
+}
+
+// gitRepoEntry is a cached status.
+type gitRepoEntry struct {
+	status    gitRepoStatus
+	checkedAt time.Time
+}
+
+// windowGitState is the per-window git status. Own mutex; never take stateMu
+// while holding it.
+type windowGitState struct {
+	mu       sync.Mutex
+	cwds     map[string]string       // window ID -> cwd last queued
+	repos    map[string]string       // window ID -> repo toplevel ("" outside a repo)
+	byRepo   map[string]gitRepoEntry // repo toplevel -> status
+	active   string                  // active window last seen
+	queue    []string                // cwds waiting for a worker
+	queued   map[string]bool
+	workers  int
+	inflight sync.WaitGroup // for tests
+}
+
+// windowGitCWD is the cwd the tab status follows: the window's active
+// content pane, else its first one.
+func windowGitCWD(win tmux.Window) string {
+	for _, p := range win.Panes {
+		if p.Active && !isAuxiliaryPane(p) {
+			if cwd := normalizeCWD(p.CurrentPath); cwd != "" {
+				return cwd
+			}
+		}
+	}
+	return firstPaneCWD(win)
+}
+
+// windowGitCWDsLocked snapshots each local window's cwd and the active
+// window for scheduleWindowGit. Caller holds stateMu.
+func (c *Coordinator) windowGitCWDsLocked() (cwds map[string]string, active string) {
+	if !c.config.Widgets.Git.ShowWindowStatus {
+		return nil, ""
+	}
+	cwds = make(map[string]string, len(c.windows))
+	for _, win := range c.windows {
+		if win.RemoteHost != "" || hasRemoteContentPane(win) {
+			continue
+		}
+		cwds[win.ID] = windowGitCWD(win)
+		if win.Active {
+			active = win.ID
+		}
+	}
+	return cwds, active
+}
+
+// scheduleWindowGit queues a status lookup for every window whose cwd
+// changed, for the window that just became active, and for the active window
+// once its status is gitActiveRecheck old. Call without stateMu.
+func (c *Coordinator) scheduleWindowGit(cwds map[string]string, active string) {
+	if cwds == nil {
+		return
+	}
+	s := &c.windowGit
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cwds == nil {
+		s.cwds, s.repos, s.byRepo, s.queued = map[string]string{}, map[string]string{}, map[string]gitRepoEntry{}, map[string]bool{}
+	}
+	for id := range s.cwds {
+		if _, ok := cwds[id]; !ok {
+			delete(s.cwds, id)
+			delete(s.repos, id)
+		}
+	}
+	for id, cwd := range cwds {
+		prev, seen := s.cwds[id]
+		if seen && prev == cwd && !(id == active && active != s.active) && !s.staleActiveLocked(id, active) {
+			continue
+		}
+		s.cwds[id] = cwd
+		if cwd == "" {
+			delete(s.repos, id)
+			continue
+		}
+		if id == active && active != s.active {
+			// Coming back to a window: its repo is looked at again even if a
+			// cached status is fresh. The old status shows until then.
+			if entry, ok := s.byRepo[s.repos[id]]; ok {
+				entry.checkedAt = time.Time{}
+				s.byRepo[s.repos[id]] = entry
+			}
+		}
+		if !s.queued[cwd] {
+			s.queued[cwd] = true
+			s.queue = append(s.queue, cwd)
+		}
+	}
+	s.active = active
+	for s.workers < gitWindowWorkers && s.workers < len(s.queue) {
+		s.workers++
+		s.inflight.Add(1)
+		go c.windowGitWorker()
+	}
+}
+
+// staleActiveLocked reports whether id is the active window and its repo's
+// status was last checked gitActiveRecheck ago or more. Caller holds s.mu.
+func (s *windowGitState) staleActiveLocked(id, active string) bool {
+	if id != active {
+		return false
+	}
+	entry, ok := s.byRepo[s.repos[id]]
+	return ok && time.Since(entry.checkedAt) >= gitActiveRecheck
+}
+
+// windowGitWorker looks up queued cwds until the queue is empty.
+func (c *Coordinator) windowGitWorker() {
+	s := &c.windowGit
+	defer s.inflight.Done()
+	changed := false
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.workers--
+			s.mu.Unlock()
+			break
+		}
+		cwd := s.queue[0]
+		s.queue = s.queue[1:]
+		delete(s.queued, cwd)
+		s.mu.Unlock()
+
+		top := c.gitToplevel(cwd)
+		var status gitRepoStatus
+		fresh := false
+		if top != "" {
+			s.mu.Lock()
+			entry, ok := s.byRepo[top]
+			s.mu.Unlock()
+			if ok && time.Since(entry.checkedAt) < gitRepoFresh {
+				status, fresh = entry.status, true
+			} else {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				out, err := gitWindowStatusRun(ctx, top)
+				cancel()
+				if err != nil {
+					coordinatorDebugLog.Printf("window git: %s: %v", top, err)
+					top = ""
+				} else {
+					status = parseGitStatusV2(string(out))
+				}
+			}
+		}
+
+		s.mu.Lock()
+		if top != "" && !fresh {
+			if prev, ok := s.byRepo[top]; !ok || prev.status != status {
+				changed = true
+			}
+			s.byRepo[top] = gitRepoEntry{status: status, checkedAt: time.Now()}
+		}
+		for id, wcwd := range s.cwds {
+			if wcwd == cwd && s.repos[id] != top {
+				s.repos[id] = top
+				changed = true
+			}
+		}
+		s.mu.Unlock()
+	}
+	if changed && c.OnRefreshLayout != nil {
+		c.OnRefreshLayout()
+	}
+}
+
+// parseGitStatusV2 reads `git status --porcelain=v2 --branch`.
+func parseGitStatusV2(out string) gitRepoStatus {
+	var st gitRepoStatus
+	var oid string
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "# branch.oid "):
+			oid = strings.TrimPrefix(line, "# branch.oid ")
+		case strings.HasPrefix(line, "# branch.head "):
+			st.Branch = strings.TrimPrefix(line, "# branch.head ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			for _, f := range strings.Fields(strings.TrimPrefix(line, "# branch.ab ")) {
+				n, _ := strconv.Atoi(f[1:])
+				if f[0] == '+' {
+					st.Ahead = n
+				} else {
+					st.Behind = n
+				}
+			}
+		case line != "" && !strings.HasPrefix(line, "#"):
+			st.Dirty = true
+		}
+	}
+	if st.Branch == "(detached)" && len(oid) >= 7 {
+		st.Branch = oid[:7]
+	}
+	return st
+}
+
+// gitTabSuffix returns " ⎇main*↑1↓2" for the tab label, or "" when the
+// option is off or the window is not in a repo.
+func (c *Coordinator) gitTabSuffix(win tmux.Window) string {
+	if !c.config.Widgets.Git.ShowWindowStatus {
+		return ""
+	}
+	s := &c.windowGit
+	s.mu.Lock()
+	entry, ok := s.byRepo[s.repos[win.ID]]
+	s.mu.Unlock()
+	if !ok || entry.status.Branch == "" {
+		return ""
+	}
+	st := entry.status
+	out := " ⎇" + runewidth.Truncate(st.Branch, 16, "…")
+	if st.Dirty {
+		out += "*"
+	}
+	if st.Ahead > 0 {
+		out += fmt.Sprintf("↑%d", st.Ahead)
+	}
+	if st.Behind > 0 {
+		out += fmt.Sprintf("↓%d", st.Behind)
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+func TestParseGitStatusV2(t *testing.T) {
+	st := parseGitStatusV2(`# branch.oid 1a2b3c4d5e6f
+# branch.head main
+# branch.upstream origin/main
+# branch.ab +2 -1
+1 .M N... 100644 100644 100644 aaa bbb README.md
+`)
+	assert.Equal(t, gitRepoStatus{Branch: "main", Dirty: true, Ahead: 2, Behind: 1}, st)
+
+	st = parseGitStatusV2("# branch.oid 1a2b3c4d5e6f\n# branch.head (detached)\n")
+	assert.Equal(t, gitRepoStatus{Branch: "1a2b3c4"}, st)
+}
+
+func TestWindowGitStatus(t *testing.T) {
+	var mu sync.Mutex
+	runs := map[string]int{}
+	status := "# branch.head main\n# branch.ab +1 -0\n"
+	orig := gitWindowStatusRun
+	gitWindowStatusRun = func(_ context.Context, top string) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		runs[top]++
+		return []byte(status), nil
+	}
+	t.Cleanup(func() { gitWindowStatusRun = orig })
+
+	c := newTestCoordinator(t)
+	c.config.Widgets.Git.ShowWindowStatus = true
+	c.gitTopMu.Lock()
+	c.gitTopCache["/src/app"] = "/src/app"
+	c.gitTopCache["/src/app/web"] = "/src/app"
+	c.gitTopCache["/tmp"] = ""
+	c.gitTopMu.Unlock()
+
+	win := func(name string, active bool, cwd string) tmux.Window {
+		w := testWindow(name, active, "zsh")
+		w.Panes[0].CurrentPath = cwd
+		return w
+	}
+	c.windows = []tmux.Window{win("a", true, "/src/app"), win("b", false, "/src/app/web"), win("c", false, "/tmp")}
+	schedule := func() {
+		c.scheduleWindowGit(c.windowGitCWDsLocked())
+		c.windowGit.inflight.Wait()
+	}
+
+	schedule()
+	assert.Equal(t, " ⎇main↑1", c.gitTabSuffix(c.windows[0]))
+	assert.Equal(t, " ⎇main↑1", c.gitTabSuffix(c.windows[1]), "same repo, shared status")
+	assert.Empty(t, c.gitTabSuffix(c.windows[2]), "not a repo")
+	assert.Equal(t, 1, runs["/src/app"], "one git status per repo")
+
+	// Nothing changed: no lookups, even after the freshness window.
+	schedule()
+	assert.Equal(t, 1, runs["/src/app"])
+
+	// Switching windows rechecks the newly active window's repo.
+	status = "# branch.head main\n1 .M N... 100644 100644 100644 aaa bbb x\n"
+	c.windows[0].Active, c.windows[1].Active = false, true
+	schedule()
+	assert.Equal(t, 2, runs["/src/app"])
+	assert.Equal(t, " ⎇main*", c.gitTabSuffix(c.windows[0]))
+
+	// A cwd change out of the repo clears the window's status.
+	c.windows[0].Panes[0].CurrentPath = "/tmp"
+	schedule()
+	assert.Empty(t, c.gitTabSuffix(c.windows[0]))
+
+	c.config.Widgets.Git.ShowWindowStatus = false
+	assert.Empty(t, c.gitTabSuffix(c.windows[1]))
+}
+
+func TestWindowGitRechecksActiveWindow(t *testing.T) {
+	var mu sync.Mutex
+	runs := 0
+	status := "# branch.head main\n"
+	orig := gitWindowStatusRun
+	gitWindowStatusRun = func(_ context.Context, top string) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		runs++
+		return []byte(status), nil
+	}
+	t.Cleanup(func() { gitWindowStatusRun = orig })
+
+	c := newTestCoordinator(t)
+	c.config.Widgets.Git.ShowWindowStatus = true
+	c.gitTopMu.Lock()
+	c.gitTopCache["/src/app"] = "/src/app"
+	c.gitTopMu.Unlock()
+	w := testWindow("a", true, "zsh")
+	w.Panes[0].CurrentPath = "/src/app"
+	c.windows = []tmux.Window{w}
+	schedule := func() {
+		c.scheduleWindowGit(c.windowGitCWDsLocked())
+		c.windowGit.inflight.Wait()
+	}
+	age := func() {
+		c.windowGit.mu.Lock()
+		entry := c.windowGit.byRepo["/src/app"]
+		entry.checkedAt = entry.checkedAt.Add(-gitActiveRecheck)
+		c.windowGit.byRepo["/src/app"] = entry
+		c.windowGit.mu.Unlock()
+	}
+
+	schedule()
+	assert.Equal(t, " ⎇main", c.gitTabSuffix(w))
+
+	// The user edits and commits in the same window, cwd unchanged.
+	status = "# branch.head main\n# branch.ab +1 -0\n1 .M N... 100644 100644 100644 aaa bbb x\n"
+	schedule()
+	assert.Equal(t, 1, runs, "a fresh status is not looked up again")
+	age()
+	schedule()
+	assert.Equal(t, 2, runs)
+	assert.Equal(t, " ⎇main*↑1", c.gitTabSuffix(w))
+
+	// An inactive window keeps its status until it changes or is selected.
+	c.windows[0].Active = false
+	age()
+	schedule()
+	assert.Equal(t, 2, runs)
+}
@@ -0,0 +1,285 @@
+package daemon
+
+// window_usage.go is the resource usage of each window's processes: the CPU%
+// and resident memory of every content pane's shell and all its descendants,
+// summed per window every widgets.top.update_interval seconds. The top widget
+// lists the heaviest windows, and with widgets.top.show_window_usage each tab
+// row ends with its window's usage, e.g. " 85% 1.2G".
+//
+// On Linux the sample comes from /proc (sysstats.ProcessReader): CPU% is the
+// CPU time each process used since the previous sample over the time between
+// them, so it is current load and one busy core is 100%. Elsewhere (macOS) it
+// falls back to one ps run (loadProcessTree). ps's %cpu is a decaying average
+// there, but on Linux procps it is CPU time over the process's whole life,
+// which would show an idle shell whose child just started spinning near 0%.
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/sysstats"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// topWidgetMaxRows caps the top widget's list; each row is its own click
+// zone ("top:jump1" ...).
+const topWidgetMaxRows = 10
+
+// windowUsageProcessTree reads the process table with ps where /proc is
+// unavailable; a variable for tests.
+var windowUsageProcessTree = loadProcessTree
+
+// windowUsage is one window's summed process usage.
+type windowUsage struct {
+	cpu float64 // percent
+	rss int64   // KiB
+}
+
+// windowUsageState is the latest sample per window ID, guarded by stateMu.
+// procs keeps the previous /proc CPU times and is only used by
+// refreshWindowUsage, which the widget scheduler never runs concurrently.
+type windowUsageState struct {
+	byWindow map[string]windowUsage
+	procs    *sysstats.ProcessReader
+}
+
+// refreshWindowUsage samples the process table and sums it per window.
+func (c *Coordinator) refreshWindowUsage() {
+	cfg := c.config.Widgets.Top
+	if !cfg.Enabled && !cfg.ShowWindowUsage {
+		return
+	}
+	pt := c.windowUsageTree()
+	if pt == nil {
+		return
+	}
+
+	c.stateMu.RLock()
+	pids := make(map[string][]int, len(c.windows))
+	for _, win := range c.windows {
+		for _, p := range win.Panes {
+			if !isAuxiliaryPane(p) && p.PID > 0 {
+				pids[win.ID] = append(pids[win.ID], p.PID)
+			}
+		}
+	}
+	c.stateMu.RUnlock()
+
+	byWindow := make(map[string]windowUsage, len(pids))
+	for id, roots := range pids {
+		// One set per window so a process is counted once even if panes
+		// share descendants.
+		visited := make(map[int]bool)
+		for _, pid := range roots {
+			pt.subtree(pid, visited)
+		}
+		var u windowUsage
+		for p := range visited {
+			u.cpu += pt.cpuByPID[p]
+			u.rss += pt.rssByPID[p]
+		}
+		byWindow[id] = u
+	}
+
+	c.stateMu.Lock()
+	c.windowUsage.byWindow = byWindow
+	c.stateMu.Unlock()
+}
+
+// windowUsageTree samples every process from /proc, or with ps where /proc
+// is unavailable. Returns nil when neither works.
+func (c *Coordinator) windowUsageTree() *processTree {
+	if c.windowUsage.procs == nil {
+		c.windowUsage.procs = sysstats.NewProcessReader()
+	}
+	procs, ok := c.windowUsage.procs.Read()
+	if !ok {
+		return windowUsageProcessTree()
+	}
+	pt := &processTree{
+		children: make(map[int][]int),
+		cpuByPID: make(map[int]float64, len(procs)),
+		rssByPID: make(map[int]int64, len(procs)),
+	}
+	for _, p := range procs {
+		pt.children[p.PPID] = append(pt.children[p.PPID], p.PID)
+		pt.cpuByPID[p.PID] = p.CPUPercent
+		pt.rssByPID[p.PID] = p.RSS
+	}
+	return pt
+}
+
+// formatRSS formats KiB as "512K", "340M" or "1.2G".
+func formatRSS(kib int64) string {
+	switch {
+	case kib < 1024:
+		return fmt.Sprintf("%dK", kib)
+	case kib < 1024*1024:
+		return fmt.Sprintf("%dM", kib/1024)
+	}
+	return fmt.Sprintf("%.1fG", float64(kib)/(1024*1024))
+}
+
+// formatWindowUsage formats a window's usage as "85% 1.2G".
+func formatWindowUsage(u windowUsage) string {
+	return fmt.Sprintf("%.0f%% %s", u.cpu, formatRSS(u.rss))
+}
+
+// windowUsageSuffix returns " 85% 1.2G" for the tab label, or "" when
+// show_window_usage is off or the window has no sample yet. Caller holds
+// stateMu.
+func (c *Coordinator) windowUsageSuffix(win tmux.Window) string {
+	if !c.config.Widgets.Top.ShowWindowUsage {
+		return ""
+	}
+	u, ok := c.windowUsage.byWindow[win.ID]
+	if !ok {
+		return ""
+	}
+	return " " + formatWindowUsage(u)
+}
+
+// topWindows returns the windows using the most CPU (or memory with sort:
+// rss), heaviest first, at most count. Caller holds stateMu.
+func (c *Coordinator) topWindows(count int) []tmux.Window {
+	byRSS := c.config.Widgets.Top.Sort == "rss"
+	var wins []tmux.Window
+	for _, win := range c.windows {
+		if _, ok := c.windowUsage.byWindow[win.ID]; ok {
+			wins = append(wins, win)
+		}
+	}
+	slices.SortStableFunc(wins, func(a, b tmux.Window) int {
+		ua, ub := c.windowUsage.byWindow[a.ID], c.windowUsage.byWindow[b.ID]
+		if byRSS && ua.rss != ub.rss {
+			return compareDesc(ua.rss, ub.rss)
+		}
+		if ua.cpu != ub.cpu {
+			return compareDesc(ua.cpu, ub.cpu)
+		}
+		return compareDesc(ua.rss, ub.rss)
+	})
+	if len(wins) > count {
+		wins = wins[:count]
+	}
+	return wins
+}
+
+// compareDesc orders larger values first.
+func compareDesc[T int64 | float64](a, b T) int {
+	switch {
+	case a > b:
+		return -1
+	case a < b:
+		return 1
+	}
+	return 0
+}
+
+// renderTopWidget renders one line per window, "<name>  85% 1.2G" with the
+// usage right-aligned, each clickable to switch to the window.
+func (c *Coordinator) renderTopWidget(width int) string {
+	cfg := c.config.Widgets.Top
+	if !cfg.Enabled {
+		return ""
+	}
+
+	fg := c.getInactiveTextColorWithFallback(cfg.Fg)
+	style := lipgloss.NewStyle()
+	if fg != "" {
+		style = style.Foreground(lipgloss.Color(fg))
+	}
+	if cfg.Bg != "" {
+		style = style.Background(lipgloss.Color(cfg.Bg))
+	}
+
+	var result strings.Builder
+	wins := c.topWindows(min(cfg.Count, topWidgetMaxRows))
+	if len(wins) == 0 {
+		result.WriteString(style.Render(runewidth.Truncate("⚙ No usage yet", width, "…")) + "\n")
+	}
+	for i, win := range wins {
+		usage := formatWindowUsage(c.windowUsage.byWindow[win.ID])
+		room := max(width-runewidth.StringWidth(usage)-1, 1)
+		name := runewidth.Truncate(win.Name, room, "…")
+		gap := max(width-runewidth.StringWidth(name)-runewidth.StringWidth(usage), 1)
+		row := style.Render(name + strings.Repeat(" ", gap) + usage)
+		result.WriteString(zone.Mark("top:jump"+strconv.Itoa(i+1), row) + "\n")
+	}
+
+	return c.wrapWidgetChrome(widgetLayout{
+		Divider: cfg.Divider, DividerFg: cfg.DividerFg,
+		MarginTop: cfg.MarginTop, PaddingTop: cfg.PaddingTop, PaddingBot: cfg.PaddingBot, MarginBot: cfg.MarginBot,
+	}, result.String(), width)
+}
+
+// topWidget is the registry entry for the heaviest windows. It also samples
+// with the widget hidden when show_window_usage is on.
+type topWidget struct{ widgetBase }
+
+func (topWidget) Name() string { return "top" }
+
+func (topWidget) Config(cfg *config.Config) WidgetConfig {
+	w := cfg.Widgets.Top
+	return WidgetConfig{Enabled: w.Enabled, Position: widgetPosition(w.Position, "bottom"), Priority: w.Priority}
+}
+
+func (topWidget) Interval(cfg *config.Config) time.Duration {
+	if !cfg.Widgets.Top.Enabled && !cfg.Widgets.Top.ShowWindowUsage {
+		return 0
+	}
+	return secondsOr(cfg.Widgets.Top.UpdateInterval, 3*time.Second)
+}
+
+func (topWidget) Refresh(c *Coordinator) { c.refreshWindowUsage() }
+
+// StateHash covers every window's displayed usage, which both the widget
+// and the tab suffixes show.
+func (topWidget) StateHash(c *Coordinator) string {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	var b strings.Builder
+	for _, win := range c.windows {
+		if u, ok := c.windowUsage.byWindow[win.ID]; ok {
+			fmt.Fprintf(&b, "%s %s %s\n", win.ID, win.Name, formatWindowUsage(u))
+		}
+	}
+	return b.String()
+}
+
+func (topWidget) Render(c *Coordinator, width int) string { return c.renderTopWidget(width) }
+
+func (topWidget) Actions() []string {
+	actions := make([]string, topWidgetMaxRows)
+	for i := range actions {
+		actions[i] = "jump" + strconv.Itoa(i+1)
+	}
+	return actions
+}
+
+// Click switches to the window on the clicked row.
+func (topWidget) Click(c *Coordinator, clientID, action string) bool {
+	n, err := strconv.Atoi(strings.TrimPrefix(action, "jump"))
+	if err != nil {
+		return false
+	}
+	c.stateMu.RLock()
+	wins := c.topWindows(min(c.config.Widgets.Top.Count, topWidgetMaxRows))
+	c.stateMu.RUnlock()
+	if n < 1 || n > len(wins) {
+		return false
+	}
+	if err := c.SelectWindow(wins[n-1].ID, "top_widget", clientID); err != nil {
+		coordinatorDebugLog.Printf("top: select %s: %v", wins[n-1].ID, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/sysstats"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+func TestParseProcessTable(t *testing.T) {
+	pt := parseProcessTable("  100     1  2.5  2048\n  101   100 10.0 10240\nbogus\n  102   100  1.0\n")
+	assert.Equal(t, []int{101, 102}, pt.children[100])
+	assert.Equal(t, 10.0, pt.cpuByPID[101])
+	assert.Equal(t, int64(10240), pt.rssByPID[101])
+	assert.Zero(t, pt.rssByPID[102], "rss column missing")
+	assert.InDelta(t, 13.5, pt.treeCPU(100), 0.001)
+}
+
+func TestFormatRSS(t *testing.T) {
+	assert.Equal(t, "512K", formatRSS(512))
+	assert.Equal(t, "340M", formatRSS(340*1024))
+	assert.Equal(t, "1.2G", formatRSS(1258291))
+}
+
+func TestWindowUsage(t *testing.T) {
+	// Window a: two panes whose shells (100, 200) run a build and an editor.
+	// Window b: one idle shell holding a lot of memory.
+	table := `100 1 0.0 4096
+101 100 90.0 1048576
+200 1 0.0 4096
+201 200 5.0 102400
+300 1 1.0 3145728
+`
+	orig := windowUsageProcessTree
+	windowUsageProcessTree = func() *processTree { return parseProcessTable(table) }
+	t.Cleanup(func() { windowUsageProcessTree = orig })
+
+	c := newTestCoordinator(t)
+	c.config.Widgets.Top.Enabled = true
+	c.config.Widgets.Top.ShowWindowUsage = true
+	c.config.Widgets.Top.Count = 5
+	c.config.Widgets.Top.Sort = "cpu"
+	// No procfs, as on macOS: ps is used.
+	c.windowUsage.procs = &sysstats.ProcessReader{Proc: filepath.Join(t.TempDir(), "missing")}
+
+	a := testWindow("build", true, "zsh", "vim")
+	a.ID = "@1"
+	a.Panes[0].PID, a.Panes[1].PID = 100, 200
+	b := testWindow("db", false, "zsh")
+	b.ID = "@2"
+	b.Panes[0].PID = 300
+	c.windows = []tmux.Window{b, a}
+
+	c.refreshWindowUsage()
+	assert.Equal(t, " 95% 1.1G", c.windowUsageSuffix(a))
+	assert.Equal(t, " 1% 3.0G", c.windowUsageSuffix(b))
+
+	top := c.topWindows(5)
+	require.Len(t, top, 2)
+	assert.Equal(t, "@1", top[0].ID, "busiest first")
+	c.config.Widgets.Top.Sort = "rss"
+	assert.Equal(t, "@2", c.topWindows(5)[0].ID, "largest first")
+	assert.Len(t, c.topWindows(1), 1)
+
+	lines := strings.Split(stripWidget(c.renderTopWidget(20)), "\n")
+	assert.Equal(t, "db           1% 3.0G", lines[0])
+	assert.Equal(t, "build       95% 1.1G", lines[1])
+
+	c.config.Widgets.Top.ShowWindowUsage = false
+	assert.Empty(t, c.windowUsageSuffix(a))
+}
+
+func TestWindowUsageFromProc(t *testing.T) {
+	orig := windowUsageProcessTree
+	windowUsageProcessTree = func() *processTree {
+		t.Fatal("ps must not run when /proc is readable")
+		return nil
+	}
+	t.Cleanup(func() { windowUsageProcessTree = orig })
+
+	proc := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(proc, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(proc, name), []byte(content), 0644))
+	}
+	// stat writes /proc/<pid>/stat with utime (field 14) and starttime
+	// (field 22) set; the rest are zero.
+	stat := func(pid, ppid, utime int) {
+		fields := make([]string, 22)
+		for i := range fields {
+			fields[i] = "0"
+		}
+		fields[0], fields[1], fields[11] = "S", fmt.Sprint(ppid), fmt.Sprint(utime)
+		write(fmt.Sprintf("%d/stat", pid), fmt.Sprintf("%d (sh) %s\n", pid, strings.Join(fields, " ")))
+	}
+
+	c := newTestCoordinator(t)
+	c.config.Widgets.Top.ShowWindowUsage = true
+	c.windowUsage.procs = &sysstats.ProcessReader{Proc: proc}
+	a := testWindow("build", true, "zsh")
+	a.ID = "@1"
+	a.Panes[0].PID = 100
+	c.windows = []tmux.Window{a}
+
+	// A shell that used a lot of CPU long ago and a child that just started.
+	write("uptime", "1000.00 0\n")
+	stat(100, 1, 50000)
+	stat(101, 100, 0)
+	c.refreshWindowUsage()
+
+	// Over the next 10s the shell idles and the child spins a full core.
+	write("uptime", "1010.00 0\n")
+	stat(101, 100, 1000)
+	c.refreshWindowUsage()
+	assert.Equal(t, " 100% 0K", c.windowUsageSuffix(a))
+
+	// Then both go quiet.
+	write("uptime", "1020.00 0\n")
+	c.refreshWindowUsage()
+	assert.Equal(t, " 0% 0K", c.windowUsageSuffix(a))
+}
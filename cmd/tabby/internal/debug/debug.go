@@ -0,0 +1,129 @@
+// Package debug implements the `tabby debug` subcommand: diagnostics that
+// show why the daemon reads a pane the way it does.
+//
+//	tabby debug match-pane [pane]    which busy_detection.output_matchers rule fires
+//
+// [pane] defaults to the current pane. The config file is read directly, so
+// this works with no daemon running.
+package debug
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/brendandebeasi/tabby/pkg/agentstate"
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/outputmatch"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// Run dispatches `tabby debug <op> ...`. Returns the exit code main should
+// propagate.
+func Run(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return 2
+	}
+	switch args[0] {
+	case "match-pane":
+		pane := os.Getenv("TMUX_PANE")
+		if len(args) > 1 {
+			pane = args[1]
+		}
+		if pane == "" || len(args) > 2 {
+			fmt.Fprintln(os.Stderr, "usage: tabby debug match-pane [pane]")
+			return 2
+		}
+		return matchPane(os.Stdout, pane)
+	case "-h", "--help", "help":
+		usage(os.Stdout)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "tabby debug: unknown command %q\n", args[0])
+		usage(os.Stderr)
+		return 2
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: tabby debug <command>")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "  match-pane [pane]   show which output matcher fires for a pane (default: this pane)")
+}
+
+// matchPane captures the pane and reports every rule's verdict.
+func matchPane(w io.Writer, pane string) int {
+	cfg, err := config.LoadConfig(config.DefaultConfigPath())
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	rules, compileErr := outputmatch.Compile(cfg.BusyDetection.OutputMatchers)
+
+	out, err := exec.Command("tmux", "display-message", "-p", "-t", pane,
+		"#{pane_id}\t#{pane_current_command}\t#{"+agentstate.Option+"}").Output()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tabby debug: no such pane: %s\n", pane)
+		return 1
+	}
+	fields := strings.SplitN(strings.TrimRight(string(out), "\n"), "\t", 3)
+	for len(fields) < 3 {
+		fields = append(fields, "")
+	}
+	id, command, agentState := fields[0], fields[1], fields[2]
+	lines := outputmatch.LinesFor(rules, command)
+	text := ""
+	if lines > 0 {
+		text = capture(id, lines+10)
+	}
+
+	fmt.Fprintf(w, "pane %s  command %s\n", id, command)
+	tmux.ConfigureBusyDetection(cfg.BusyDetection.ExtraIdle, cfg.BusyDetection.AITools, cfg.BusyDetection.IdleTimeout)
+	if !tmux.IsAITool(command) {
+		fmt.Fprintf(w, "note: %q is not in busy_detection.ai_tools, so the daemon does not check it\n", command)
+	}
+	if st, ok := agentstate.Parse(agentState); ok {
+		fmt.Fprintf(w, "note: the agent reported %q, which takes precedence over output matchers\n", st.Name)
+	}
+	if compileErr != nil {
+		fmt.Fprintf(w, "invalid rules (skipped):\n  %s\n", strings.ReplaceAll(compileErr.Error(), "\n", "\n  "))
+	}
+	if len(rules) == 0 {
+		fmt.Fprintln(w, "no output matchers configured")
+		return 0
+	}
+
+	fired := false
+	for _, r := range rules {
+		var verdict string
+		switch line, ok := r.Match(text); {
+		case !r.AppliesTo(command):
+			verdict = "skipped (tool " + r.Tool + ")"
+		case ok && !fired:
+			verdict = fmt.Sprintf("FIRED  %q", line)
+			fired = true
+		case ok:
+			verdict = fmt.Sprintf("matches %q, but an earlier rule fired", line)
+		default:
+			verdict = fmt.Sprintf("no match in the last %d lines", r.Lines)
+		}
+		fmt.Fprintf(w, "  %-20s %-6s %s\n", r.Name, r.State, verdict)
+	}
+	if res, ok := outputmatch.Evaluate(rules, command, text); ok {
+		fmt.Fprintf(w, "result: %s (rule %s)\n", res.Rule.State, res.Rule.Name)
+	} else {
+		fmt.Fprintln(w, "result: no rule fired; the timer heuristics decide")
+	}
+	return 0
+}
+
+// capture returns the pane's last rows.
+func capture(pane string, lines int) string {
+	out, err := exec.Command("tmux", "capture-pane", "-p", "-t", pane, "-S", fmt.Sprintf("-%d", lines)).Output()
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
@@ -0,0 +1,147 @@
+package hook
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/agentstate"
+)
+
+// doAgentState records what the AI agent in this pane is doing, for the
+// sidebar's per-state indicators and time-in-state.
+// Usage: tabby hook agent-state <state> [--detail text]
+//
+// States: thinking, tool, permission, prompt, error, done (or clear). The
+// state is stored on the pane as @tabby_agent_state. When the local tmux is
+// not reachable (the agent runs on a remote host), an OSC 7700 tabby-agent
+// sequence is written to the tty instead, for the outer tmux's osc-handler.
+func doAgentState(args []string) {
+	state, detail, ok := parseAgentStateArgs(args)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Usage: tabby hook agent-state <%s|clear> [--detail text]\n",
+			strings.Join(agentstate.States, "|"))
+		return
+	}
+
+	pane := resolveAgentPane()
+	if pane == "" {
+		writeOSC(fmt.Sprintf("\x1b]7700;tabby-agent;%s;%s\x07", state, oscSafe(detail)))
+		return
+	}
+	setAgentState(pane, state, detail)
+	signalDaemon("USR1")
+}
+
+// parseAgentStateArgs reads "<state> [--detail text]". The state is returned
+// canonicalized, or "clear".
+func parseAgentStateArgs(args []string) (state, detail string, ok bool) {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--detail" && i+1 < len(args):
+			detail = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--detail="):
+			detail = strings.TrimPrefix(args[i], "--detail=")
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	if len(rest) != 1 {
+		return "", "", false
+	}
+	if agentstate.IsClear(rest[0]) {
+		return "clear", detail, true
+	}
+	state, ok = agentstate.Normalize(rest[0])
+	return state, detail, ok
+}
+
+// resolveAgentPane returns the pane ID the calling agent runs in: $TMUX_PANE
+// when it still exists, else the pane whose shell is an ancestor of this
+// process. Empty when the local tmux is not reachable.
+func resolveAgentPane() string {
+	if tmuxPane := os.Getenv("TMUX_PANE"); tmuxPane != "" {
+		out, err := exec.Command("tmux", "display-message", "-t", tmuxPane, "-p", "#{pane_id}").Output()
+		if err == nil {
+			if id := strings.TrimSpace(string(out)); id != "" {
+				return id
+			}
+		}
+	}
+
+	out, err := exec.Command("tmux", "list-panes", "-a", "-F", "#{pane_pid}|#{pane_id}").Output()
+	if err != nil {
+		return ""
+	}
+	paneByPID := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if parts := strings.SplitN(line, "|", 2); len(parts) == 2 {
+			paneByPID[parts[0]] = parts[1]
+		}
+	}
+	searchPID := os.Getpid()
+	for i := 0; i < 10; i++ {
+		ppidOut, err := exec.Command("ps", "-o", "ppid=", "-p", strconv.Itoa(searchPID)).Output()
+		if err != nil {
+			break
+		}
+		searchPID, err = strconv.Atoi(strings.TrimSpace(string(ppidOut)))
+		if err != nil || searchPID <= 1 {
+			break
+		}
+		if id, ok := paneByPID[strconv.Itoa(searchPID)]; ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// setAgentState writes state onto pane, keeping the start time when the
+// agent repeats its current state.
+func setAgentState(pane, state, detail string) {
+	if state == "clear" {
+		exec.Command("tmux", "set-option", "-p", "-t", pane, "-u", agentstate.Option).Run()
+		return
+	}
+	var prev agentstate.State
+	hasPrev := false
+	if cur, err := exec.Command("tmux", "show-options", "-pqv", "-t", pane, agentstate.Option).Output(); err == nil {
+		prev, hasPrev = agentstate.Parse(string(cur))
+	}
+	st := agentstate.Next(prev, hasPrev, state, detail, time.Now())
+	exec.Command("tmux", "set-option", "-p", "-t", pane, agentstate.Option, agentstate.Format(st)).Run()
+}
+
+// applyAgentPayload parses "state;detail" from an OSC 7700 tabby-agent
+// sequence and records it on this handler's source pane.
+func applyAgentPayload(payload string) {
+	parts := strings.SplitN(payload, ";", 2)
+	state, detail, ok := parseAgentStateArgs(parts[:1])
+	if !ok {
+		return
+	}
+	if len(parts) == 2 {
+		detail = parts[1]
+	}
+	pane := os.Getenv("TMUX_PANE")
+	if pane == "" {
+		return
+	}
+	setAgentState(pane, state, detail)
+	signalDaemon("USR1")
+}
+
+// oscSafe drops the bytes that would end or corrupt an OSC payload.
+func oscSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,58 @@
+package hook
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/cmdtrack"
+	"github.com/brendandebeasi/tabby/pkg/config"
+)
+
+// applyCmdPayload handles a tabby-cmd "start;<command>" or "end;<exit>"
+// sequence from this handler's source pane. The handler lives as long as the
+// pane, so t remembers the running command between the two. A command that
+// ran at least indicators.command.min_duration is recorded on the pane as
+// @tabby_cmd and, unless the user is looking at its window, flagged on the
+// window as @tabby_cmd_alert.
+func applyCmdPayload(t *cmdtrack.Tracker, payload string) {
+	kind, arg, _ := strings.Cut(payload, ";")
+	now := time.Now()
+	switch kind {
+	case "start":
+		t.Start(arg, now)
+		return
+	case "end":
+	default:
+		return
+	}
+	rec, ok := t.End(arg, now)
+	if !ok {
+		return
+	}
+	pane := os.Getenv("TMUX_PANE")
+	if pane == "" {
+		return
+	}
+
+	cfg, err := config.LoadConfig(config.DefaultConfigPath())
+	if err != nil || cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	ind := cfg.Indicators.Command
+	if ind.Enabled != nil && !*ind.Enabled {
+		return
+	}
+	if rec.Duration() < time.Duration(ind.MinDuration)*time.Second {
+		return
+	}
+
+	exec.Command("tmux", "set-option", "-p", "-t", pane, cmdtrack.Option, cmdtrack.Format(rec)).Run()
+	// No alert for a window the user is watching in an attached session.
+	out, _ := exec.Command("tmux", "display-message", "-p", "-t", pane, "#{window_active} #{session_attached}").Output()
+	if f := strings.Fields(string(out)); len(f) != 2 || f[0] != "1" || f[1] == "0" {
+		exec.Command("tmux", "set-option", "-w", "-t", pane, cmdtrack.AlertOption, rec.Alert()).Run()
+	}
+	signalDaemon("USR1")
+}
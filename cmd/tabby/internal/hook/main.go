@@ -62,6 +62,9 @@ func Run(allArgs []string) int {
 	case "set-title":
 		doSetTitle(args)
 		return 0
+	case "agent-state":
+		doAgentState(args)
+		return 0
 	case "osc-handler":
 		doOSCHandler()
 		return 0
@@ -136,6 +139,59 @@ func Run(allArgs []string) int {
 		target = args[0]
 		value = args[1]
 
+	case "group-run":
+		if len(args) < 2 {
+			fatal("Usage: tabby hook group-run <name> <cmd>")
+		}
+		target = args[0]
+		value = strings.Join(args[1:], " ")
+
+	case "toggle-group-sync":
+		if len(args) < 1 {
+			fatal("Usage: tabby hook toggle-group-sync <name>")
+		}
+		target = args[0]
+
+	case "archive-group", "unarchive-group":
+		if len(args) < 1 {
+			fatal("Usage: tabby hook " + action + " <name>")
+		}
+		target = args[0]
+
+	case "agent-log":
+		if len(args) < 1 {
+			fatal("Usage: tabby hook agent-log <window>")
+		}
+		target = args[0]
+
+	case "agent-reply":
+		if len(args) < 1 {
+			fatal("Usage: tabby hook agent-reply <pane|window>")
+		}
+		target = args[0]
+
+	case "view-crash":
+		if len(args) < 1 {
+			fatal("Usage: tabby hook view-crash <pane>")
+		}
+		target = args[0]
+
+	case "mirror-pane":
+		if len(args) < 1 {
+			fatal("Usage: tabby hook mirror-pane <pane>")
+		}
+		target = args[0]
+
+	case "project-menu":
+		if len(args) < 2 {
+			fatal("Usage: tabby hook project-menu <window> <index>")
+		}
+		target = args[0]
+		value = args[1]
+
+	case "show-notifications":
+		// No arguments: the daemon lists this session's history.
+
 	case "toggle-group-collapse":
 		if len(args) < 2 {
 			fatal("Usage: tabby hook toggle-group-collapse <name> <collapse|expand>")
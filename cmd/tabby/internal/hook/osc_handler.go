@@ -5,6 +5,8 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+
+	"github.com/brendandebeasi/tabby/pkg/cmdtrack"
 )
 
 // tabbyOSCPrefix is the raw OSC sequence emitted by emitOSCFallback.
@@ -23,12 +25,33 @@ const tabbyOSCPrefixDCS = "\x1b\x1b]7700;tabby-indicator;"
 const tabbyCWDPrefix = "\x1b]7700;tabby-cwd;"
 const tabbyCWDPrefixDCS = "\x1b\x1b]7700;tabby-cwd;"
 
+// tabbyAgentPrefix / tabbyAgentPrefixDCS carry an AI agent state from
+// `tabby hook agent-state` run on a remote host: "state;detail". It is
+// recorded on the LOCAL pane, like the remote cwd.
+const tabbyAgentPrefix = "\x1b]7700;tabby-agent;"
+const tabbyAgentPrefixDCS = "\x1b\x1b]7700;tabby-agent;"
+
+// tabbyCmdPrefix / tabbyCmdPrefixDCS carry "start;<command>" and
+// "end;<exit>" from the tabby-cmd shell integration (scripts/tabby-cmd.sh),
+// for long-running command tracking in this pane.
+const tabbyCmdPrefix = "\x1b]7700;tabby-cmd;"
+const tabbyCmdPrefixDCS = "\x1b\x1b]7700;tabby-cmd;"
+
+// tabbyEnvPrefix / tabbyEnvPrefixDCS carry the context variables (KUBECONFIG,
+// AWS_PROFILE, ...) the tabby-cmd shell integration reports at each prompt as
+// "NAME=value" pairs joined by \x1f. The context badges read them, since
+// /proc only has the shell's environment from when it started.
+const tabbyEnvPrefix = "\x1b]7700;tabby-env;"
+const tabbyEnvPrefixDCS = "\x1b\x1b]7700;tabby-env;"
+
 // doOSCHandler reads stdin (a tmux pipe-pane output stream) and calls
-// doSetIndicator whenever a tabby OSC 7700 indicator sequence is found.
+// doSetIndicator whenever a tabby OSC 7700 indicator sequence is found (and
+// the remote-cwd / agent-state / command / env handlers for theirs).
 // Runs until stdin is closed, which happens when the tmux pane exits.
 func doOSCHandler() {
 	r := bufio.NewReaderSize(os.Stdin, 65536)
 	var window []byte
+	var cmds cmdtrack.Tracker
 
 	for {
 		b, err := r.ReadByte()
@@ -65,6 +88,60 @@ func doOSCHandler() {
 			continue
 		}
 
+		// Agent state (DCS-wrapped, then raw).
+		if idx := strings.Index(ws, tabbyAgentPrefixDCS); idx >= 0 {
+			rest := ws[idx+len(tabbyAgentPrefixDCS):]
+			if end := strings.IndexByte(rest, '\x07'); end >= 0 {
+				applyAgentPayload(rest[:end])
+				window = window[:0]
+			}
+			continue
+		}
+		if idx := strings.Index(ws, tabbyAgentPrefix); idx >= 0 {
+			rest := ws[idx+len(tabbyAgentPrefix):]
+			if end := strings.IndexByte(rest, '\x07'); end >= 0 {
+				applyAgentPayload(rest[:end])
+				window = window[:0]
+			}
+			continue
+		}
+
+		// Shell command start/end (DCS-wrapped, then raw).
+		if idx := strings.Index(ws, tabbyCmdPrefixDCS); idx >= 0 {
+			rest := ws[idx+len(tabbyCmdPrefixDCS):]
+			if end := strings.IndexByte(rest, '\x07'); end >= 0 {
+				applyCmdPayload(&cmds, rest[:end])
+				window = window[:0]
+			}
+			continue
+		}
+		if idx := strings.Index(ws, tabbyCmdPrefix); idx >= 0 {
+			rest := ws[idx+len(tabbyCmdPrefix):]
+			if end := strings.IndexByte(rest, '\x07'); end >= 0 {
+				applyCmdPayload(&cmds, rest[:end])
+				window = window[:0]
+			}
+			continue
+		}
+
+		// Shell context variables (DCS-wrapped, then raw).
+		if idx := strings.Index(ws, tabbyEnvPrefixDCS); idx >= 0 {
+			rest := ws[idx+len(tabbyEnvPrefixDCS):]
+			if end := strings.IndexByte(rest, '\x07'); end >= 0 {
+				applyEnvPayload(rest[:end])
+				window = window[:0]
+			}
+			continue
+		}
+		if idx := strings.Index(ws, tabbyEnvPrefix); idx >= 0 {
+			rest := ws[idx+len(tabbyEnvPrefix):]
+			if end := strings.IndexByte(rest, '\x07'); end >= 0 {
+				applyEnvPayload(rest[:end])
+				window = window[:0]
+			}
+			continue
+		}
+
 		// Remote-cwd report (DCS-wrapped, when the remote shell ran inside an
 		// inner tmux on the remote host).
 		if idx := strings.Index(ws, tabbyCWDPrefixDCS); idx >= 0 {
@@ -111,6 +188,23 @@ func applyRemoteCWDPayload(payload string) {
 	exec.Command("tmux", "set-option", "-p", "-t", pane, "@tabby_remote_cwd", payload).Run()
 }
 
+// applyEnvPayload records the shell's reported context variables on this
+// handler's source pane as @tabby_env. The shell only reports a change, but
+// the write is still skipped when the option already holds the value.
+func applyEnvPayload(payload string) {
+	pane := os.Getenv("TMUX_PANE")
+	if pane == "" {
+		return
+	}
+	if cur, err := exec.Command("tmux", "show-options", "-pqv", "-t", pane, "@tabby_env").Output(); err == nil {
+		if strings.TrimSuffix(string(cur), "\n") == payload {
+			return
+		}
+	}
+	exec.Command("tmux", "set-option", "-p", "-t", pane, "@tabby_env", payload).Run()
+	signalDaemon("USR1")
+}
+
 // applyIndicatorPayload parses "indicator;value" and calls doSetIndicator.
 func applyIndicatorPayload(payload string) {
 	parts := strings.SplitN(payload, ";", 2)
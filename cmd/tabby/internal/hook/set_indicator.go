@@ -203,18 +203,20 @@ func windowExists(win string) bool {
 // session (e.g. a bastion host running tabby) can intercept it via pipe-pane
 // and apply the indicator locally. Used when the local tabby daemon is not
 // reachable (no tmux session, or running inside a remote SSH session).
-//
-// If $TMUX is set the sequence is wrapped in a DCS passthrough envelope so it
-// survives any inner tmux session and reaches the outer pane's raw output.
 func emitOSCFallback(indicator, value string) {
+	writeOSC(fmt.Sprintf("\x1b]7700;tabby-indicator;%s;%s\x07", indicator, value))
+}
+
+// writeOSC writes payload to /dev/tty. If $TMUX is set the sequence is
+// wrapped in a DCS passthrough envelope so it survives any inner tmux session
+// and reaches the outer pane's raw output.
+func writeOSC(payload string) {
 	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
 	if err != nil {
 		return
 	}
 	defer tty.Close()
 
-	payload := fmt.Sprintf("\x1b]7700;tabby-indicator;%s;%s\x07", indicator, value)
-
 	// Wrap in tmux DCS passthrough when running inside an inner tmux so the
 	// sequence is forwarded through to the outer pane verbatim.
 	if os.Getenv("TMUX") != "" {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/brendandebeasi/tabby/pkg/colors"
 	"github.com/brendandebeasi/tabby/pkg/config"
 )
 
@@ -95,7 +96,9 @@ func addGroup(configPath, name string) error {
 		return err
 	}
 
-	newGroup := config.DefaultGroupWithIndex(name, len(cfg.Groups))
+	// Outside the daemon there is no theme to match, so the palette uses its
+	// defaults; the color is still keyed by name and distinct from the others.
+	newGroup := config.NewGroup(name, cfg.Groups, colors.PaletteOptions{})
 	if err := config.AddGroup(cfg, newGroup); err != nil {
 		return err
 	}
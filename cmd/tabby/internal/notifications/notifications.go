@@ -0,0 +1,109 @@
+// Package notifications implements the `tabby notifications` subcommand: print
+// the indicator-transition history the daemon records (bell, input, busy,
+// done, activity, silence) in notifications.jsonl under the state dir.
+//
+//	tabby notifications                  this session's history, oldest first
+//	tabby notifications --since 1h       only the last hour
+//	tabby notifications --all            every session on this host
+//	tabby notifications --json           one JSON object per line
+//
+// The file is read directly, so this works with no daemon running. The
+// format is documented in pkg/notifications.
+package notifications
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	history "github.com/brendandebeasi/tabby/pkg/notifications"
+)
+
+// Run prints the history. Returns the exit code main should propagate.
+func Run(args []string) int {
+	fs := flag.NewFlagSet("tabby notifications", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	since := fs.Duration("since", 0, "only events newer than this (e.g. 30m, 1h, 24h)")
+	asJSON := fs.Bool("json", false, "print one JSON object per event")
+	all := fs.Bool("all", false, "include every tmux session, not just the current one")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	events, err := history.Load(history.Path())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tabby notifications:", err)
+		return 1
+	}
+	session := ""
+	if !*all {
+		session = currentSession()
+	}
+	var from time.Time
+	if *since > 0 {
+		from = time.Now().Add(-*since)
+	}
+	events = history.Filter(events, session, from)
+
+	if *asJSON {
+		err = printJSON(os.Stdout, events)
+	} else {
+		err = printTable(os.Stdout, events, time.Now())
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tabby notifications:", err)
+		return 1
+	}
+	return 0
+}
+
+// currentSession returns the tmux session ID this command runs in, or "" when
+// run outside tmux (then every session is shown).
+func currentSession() string {
+	if os.Getenv("TMUX") == "" {
+		return ""
+	}
+	out, err := exec.Command("tmux", "display-message", "-p", "#{session_id}").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func printJSON(w io.Writer, events []history.Event) error {
+	enc := json.NewEncoder(w)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printTable(w io.Writer, events []history.Event, now time.Time) error {
+	if len(events) == 0 {
+		fmt.Fprintln(w, "No notifications.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "AGO\tKIND\tWINDOW\tPANE\tGROUP\tTITLE")
+	for _, e := range events {
+		pane := e.PaneID
+		if pane == "" {
+			pane = "-"
+		}
+		group := e.Group
+		if group == "" {
+			group = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d:%s\t%s\t%s\t%s\n",
+			history.Ago(e.Time, now), e.Kind, e.WindowIndex, e.WindowID, pane, group, e.Label())
+	}
+	return tw.Flush()
+}
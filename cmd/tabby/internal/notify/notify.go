@@ -0,0 +1,61 @@
+// Package notify implements the `tabby notify` subcommand: post a message to
+// the running daemon, which records it in the notification history, fires the
+// sinks subscribed to "notify" and shows a toast.
+//
+//	tabby notify "deploy finished"
+//	tabby notify "tests failed" --window @3
+//
+// --window takes any target tmux understands for a window (@id or index);
+// the toast then jumps there on Enter/click and is skipped while that window
+// is on screen.
+package notify
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/brendandebeasi/tabby/cmd/tabby/internal/ctl"
+	"github.com/brendandebeasi/tabby/pkg/daemon"
+)
+
+// Run sends the notify op. Returns the exit code main should propagate.
+func Run(args []string) int {
+	fs := flag.NewFlagSet("tabby notify", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	window := fs.String("window", "", "window the message is about (@id or index)")
+
+	// Accept flags before or after the message.
+	var words []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return 2
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		words = append(words, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	message := strings.TrimSpace(strings.Join(words, " "))
+	if message == "" {
+		fmt.Fprintln(os.Stderr, `usage: tabby notify "message" [--window @3]`)
+		return 2
+	}
+
+	reqArgs := []string{message}
+	if *window != "" {
+		reqArgs = append(reqArgs, *window)
+	}
+	resp, err := ctl.Request(&daemon.CtlRequest{Op: "notify", Args: reqArgs})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tabby notify: daemon not reachable:", err)
+		return 1
+	}
+	if !resp.OK {
+		fmt.Fprintln(os.Stderr, "tabby notify:", resp.Error)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,131 @@
+// Package project implements the `tabby project` subcommand: review and trust
+// the menu commands of a project's committed .tabby.yaml.
+//
+// Subcommands:
+//
+//	tabby project show [dir]      print the file's commands and trust state
+//	tabby project trust [dir]     trust the file as it is now
+//	tabby project untrust [dir]   drop the trust
+//
+// The daemon only offers a .tabby.yaml's menu commands once the file is
+// trusted, and checks again before running one; any edit to the file needs
+// a new trust. The store is described in pkg/config (ProjectTrustPath).
+package project
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+)
+
+// Run dispatches `tabby project <op> [dir]`. Returns the exit code main
+// should propagate.
+func Run(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stdout)
+		return 0
+	}
+	dir := "."
+	if len(args) > 1 {
+		dir = args[1]
+	}
+	if len(args) > 2 {
+		usage(os.Stderr)
+		return 2
+	}
+	var err error
+	switch args[0] {
+	case "-h", "--help", "help":
+		usage(os.Stdout)
+		return 0
+	case "show":
+		err = show(os.Stdout, resolveRoot(dir))
+	case "trust":
+		err = trust(os.Stdout, resolveRoot(dir))
+	case "untrust":
+		root := resolveRoot(dir)
+		if err = config.UntrustProject(root); err == nil {
+			fmt.Println("untrusted " + root)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "tabby project: unknown subcommand %q\n\n", args[0])
+		usage(os.Stderr)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tabby project %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: tabby project <subcommand> [dir]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Subcommands:")
+	fmt.Fprintln(w, "  show [dir]      print the .tabby.yaml menu commands and whether they are trusted")
+	fmt.Fprintln(w, "  trust [dir]     offer those commands in the window menu (until the file changes)")
+	fmt.Fprintln(w, "  untrust [dir]   stop offering them")
+}
+
+// show prints the menu commands of root's .tabby.yaml.
+func show(w io.Writer, root string) error {
+	pc, ok, err := config.LoadProjectConfig(root)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no %s in %s", config.ProjectFileName, root)
+	}
+	hash, err := config.ProjectConfigHash(root)
+	if err != nil {
+		return err
+	}
+	state := "not trusted"
+	if config.ProjectTrusted(root, hash) {
+		state = "trusted"
+	}
+	fmt.Fprintf(w, "%s (%s)\n", config.ProjectConfigPath(root), state)
+	if len(pc.Menu) == 0 {
+		fmt.Fprintln(w, "  no menu commands")
+	}
+	for _, item := range pc.Menu {
+		mode := item.Mode
+		if mode == "" {
+			mode = "send"
+		}
+		fmt.Fprintf(w, "  %s [%s]: %s\n", item.Label, mode, item.Command)
+	}
+	return nil
+}
+
+// trust lists the commands, then records the file as trusted.
+func trust(w io.Writer, root string) error {
+	if err := show(w, root); err != nil {
+		return err
+	}
+	if _, err := config.TrustProject(root); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "trusted "+root)
+	return nil
+}
+
+// resolveRoot turns a directory argument into the project root the daemon
+// keys .tabby.yaml by: the git toplevel, else the absolute dir.
+func resolveRoot(dir string) string {
+	if out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output(); err == nil {
+		if top := strings.TrimSpace(string(out)); top != "" {
+			return filepath.Clean(top)
+		}
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return filepath.Clean(dir)
+}
@@ -1,5 +1,5 @@
 // Package renderdispatch handles the `tabby render <sidebar|window-header|
-// pane-header|sidebar-popup|pet-qa-popup>` second-level subcommand dispatch.
+// pane-header|sidebar-popup|pet-qa-popup|toast>` second-level subcommand dispatch.
 // Each renderer lives in its own package; this file routes by name.
 package renderdispatch
 
@@ -13,12 +13,13 @@ import (
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/petqapopup"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/sidebar"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/sidebarpopup"
+	"github.com/brendandebeasi/tabby/cmd/tabby/internal/toast"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/windowheader"
 )
 
 func Run(args []string) int {
 	if len(args) < 1 {
-		fmt.Fprintln(os.Stderr, "Usage: tabby render <sidebar|window-header|pane-header|sidebar-popup|pet-qa-popup|degraded-models-popup> [args...]")
+		fmt.Fprintln(os.Stderr, "Usage: tabby render <sidebar|window-header|pane-header|sidebar-popup|pet-qa-popup|degraded-models-popup|toast> [args...]")
 		return 2
 	}
 	rest := args[1:]
@@ -37,6 +38,8 @@ func Run(args []string) int {
 		return degradedmodelspopup.Run(rest)
 	case "close-confirm":
 		return closeconfirm.Run(rest)
+	case "toast":
+		return toast.Run(rest)
 	default:
 		fmt.Fprintf(os.Stderr, "tabby render: unknown renderer %q\n", args[0])
 		return 2
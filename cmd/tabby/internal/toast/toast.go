@@ -0,0 +1,229 @@
+// Package toast is the display-popup renderer for notification toasts.
+// Exported as the `tabby render toast` subcommand; the daemon launches it
+// (coordinator.launchToast) for configured indicator transitions and for
+// `tabby notify`.
+//
+// The toast is a small card in a corner of the client:
+//
+//	Enter / click       jump to the source window (and pane)
+//	any other key       dismiss
+//
+// It dismisses itself after --timeout seconds. The jump runs `tmux
+// select-window` / `select-pane` from the renderer, so the launcher is
+// fire-and-forget.
+package toast
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/termenv"
+
+	"github.com/brendandebeasi/tabby/pkg/renderer"
+)
+
+// ── styles ──────────────────────────────────────────────────────────────
+//
+// Dark card like the sister popups; the accent stripe and title take the
+// event kind's color.
+const (
+	cardBg  = "#1e293b"
+	textFg  = "#e2e8f0"
+	hintFg  = "#94a3b8"
+	defAcct = "#7aa2f7"
+)
+
+// kindAccents colors the stripe and title per event kind.
+var kindAccents = map[string]string{
+	"input":  "#ffd93d",
+	"bell":   "#ff8c69",
+	"crash":  "#f7768e",
+	"done":   "#6bcb77",
+	"notify": "#7aa2f7",
+}
+
+// kindIcons prefixes the title per event kind.
+var kindIcons = map[string]string{
+	"input":  "?",
+	"bell":   "◆",
+	"crash":  "✗",
+	"done":   "✓",
+	"notify": "●",
+}
+
+type tickMsg struct{}
+
+type model struct {
+	title, message, kind string
+	window, pane         string
+	remaining            int // seconds until auto-dismiss
+	width, height        int
+
+	// jump is read by Run() after the TUI exits.
+	jump bool
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return tickMsg{} })
+}
+
+func (m model) Init() tea.Cmd { return tick() }
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tickMsg:
+		m.remaining--
+		if m.remaining <= 0 {
+			return m, tea.Quit
+		}
+		return m, tick()
+	case tea.KeyMsg:
+		m.jump = msg.String() == "enter" && m.window != ""
+		return m, tea.Quit
+	case tea.MouseMsg:
+		if msg.Action == tea.MouseActionPress {
+			m.jump = m.window != ""
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m model) View() string {
+	w, h := m.width, m.height
+	if w < 6 || h < 3 {
+		return ""
+	}
+	accent := kindAccents[m.kind]
+	if accent == "" {
+		accent = defAcct
+	}
+	stripe := lipgloss.NewStyle().Background(lipgloss.Color(accent)).Render(" ")
+	fill := lipgloss.NewStyle().Background(lipgloss.Color(cardBg))
+	titleStyle := fill.Foreground(lipgloss.Color(accent)).Bold(true)
+	textStyle := fill.Foreground(lipgloss.Color(textFg))
+	hintStyle := fill.Foreground(lipgloss.Color(hintFg)).Faint(true)
+
+	inner := w - 3 // stripe + one space each side
+	line := func(s string, st lipgloss.Style) string {
+		s = runewidth.Truncate(s, inner, "…")
+		pad := inner - runewidth.StringWidth(s)
+		return stripe + fill.Render(" ") + st.Render(s) + fill.Render(strings.Repeat(" ", pad+1))
+	}
+
+	title := m.title
+	if icon := kindIcons[m.kind]; icon != "" {
+		title = icon + " " + title
+	}
+	hint := fmt.Sprintf("esc dismiss · %ds", m.remaining)
+	if m.window != "" {
+		hint = "⏎ jump · " + hint
+	}
+
+	rows := []string{line(title, titleStyle)}
+	for _, l := range wrap(m.message, inner, h-2) {
+		rows = append(rows, line(l, textStyle))
+	}
+	for len(rows) < h-1 {
+		rows = append(rows, line("", textStyle))
+	}
+	rows = append(rows, line(hint, hintStyle))
+	return strings.Join(rows[:h], "\n")
+}
+
+// wrap breaks s into at most maxLines lines of width columns, word by word.
+func wrap(s string, width, maxLines int) []string {
+	if maxLines <= 0 || width <= 0 {
+		return nil
+	}
+	var lines []string
+	cur := ""
+	for _, word := range strings.Fields(s) {
+		next := word
+		if cur != "" {
+			next = cur + " " + word
+		}
+		if runewidth.StringWidth(next) <= width || cur == "" {
+			cur = next
+			continue
+		}
+		lines = append(lines, cur)
+		cur = word
+	}
+	if cur != "" {
+		lines = append(lines, cur)
+	}
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		lines[maxLines-1] = runewidth.Truncate(lines[maxLines-1]+" …", width, "…")
+	}
+	return lines
+}
+
+// Run is the render-dispatch entry point.
+func Run(args []string) int {
+	fs := flag.NewFlagSet("toast", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	title := fs.String("title", "tabby", "first line, in the kind's color")
+	message := fs.String("message", "", "body text")
+	kind := fs.String("kind", "notify", "event kind: input, bell, crash, done, notify, ...")
+	window := fs.String("window", "", "tmux window id to jump to on Enter/click")
+	pane := fs.String("pane", "", "tmux pane id to select after jumping")
+	timeout := fs.Int("timeout", 5, "seconds before the toast dismisses itself")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *timeout <= 0 {
+		*timeout = 5
+	}
+
+	lipgloss.SetColorProfile(termenv.NewOutput(os.Stdout).ColorProfile())
+	resetTerminal := func() {
+		renderer.ResetTerminal()
+		fmt.Print("\033[0m\033[?25h")
+		os.Stdout.Sync()
+	}
+	resetTerminal()
+	defer resetTerminal()
+
+	m := model{
+		title: *title, message: *message, kind: *kind,
+		window: *window, pane: *pane,
+		remaining: *timeout, width: 44, height: 4,
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
+
+	// tmux sends SIGINT/SIGTERM on display-popup close.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		<-sigCh
+		p.Send(tea.Quit())
+	}()
+
+	final, err := p.Run()
+	if err != nil {
+		resetTerminal()
+		fmt.Fprintf(os.Stderr, "toast: %v\n", err)
+		return 1
+	}
+	if fm, ok := final.(model); ok && fm.jump {
+		exec.Command("tmux", "select-window", "-t", fm.window).Run()
+		if fm.pane != "" {
+			exec.Command("tmux", "select-pane", "-t", fm.pane).Run()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,40 @@
+package toast
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	assert.Equal(t, []string{"build finished", "in 3m"}, wrap("build finished in 3m", 14, 3))
+	assert.Equal(t, []string{"one two …"}, wrap("one two three four", 9, 1))
+	assert.Nil(t, wrap("x", 10, 0))
+}
+
+func TestUpdateJumpAndDismiss(t *testing.T) {
+	m := model{window: "@3", remaining: 2}
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.True(t, next.(model).jump)
+	assert.NotNil(t, cmd)
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.False(t, next.(model).jump)
+
+	next, _ = model{remaining: 2}.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, next.(model).jump, "nothing to jump to without a window")
+
+	next, cmd = m.Update(tickMsg{})
+	assert.Equal(t, 1, next.(model).remaining)
+	assert.NotNil(t, cmd)
+}
+
+func TestViewFitsPopup(t *testing.T) {
+	m := model{title: "api needs input", message: "Claude is waiting for permission", kind: "input", window: "@3", remaining: 5, width: 30, height: 4}
+	rows := strings.Split(m.View(), "\n")
+	assert.Len(t, rows, 4)
+	assert.Contains(t, m.View(), "jump")
+}
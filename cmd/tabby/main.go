@@ -20,15 +20,22 @@ import (
 	"os"
 	"sort"
 
+	"github.com/brendandebeasi/tabby/cmd/tabby/internal/agent"
+	"github.com/brendandebeasi/tabby/cmd/tabby/internal/appearance"
+	"github.com/brendandebeasi/tabby/cmd/tabby/internal/ctl"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/cyclepane"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/daemon"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/dashboard"
+	"github.com/brendandebeasi/tabby/cmd/tabby/internal/debug"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/dev"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/hook"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/managegroup"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/newwindow"
+	"github.com/brendandebeasi/tabby/cmd/tabby/internal/notifications"
+	"github.com/brendandebeasi/tabby/cmd/tabby/internal/notify"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/panepicker"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/pet"
+	"github.com/brendandebeasi/tabby/cmd/tabby/internal/project"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/renderdispatch"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/setup"
 	"github.com/brendandebeasi/tabby/cmd/tabby/internal/toggle"
@@ -45,16 +52,23 @@ type subcommand struct {
 }
 
 var subcommands = []subcommand{
+	{"agent", "inspect AI agents: log <window> prints the agent session timeline", agent.Run},
+	{"appearance", "manage remembered per-project colors/markers: list, export, import, forget", appearance.Run},
+	{"ctl", "control the running daemon: group-run, group-sync, group-archive", ctl.Run},
 	{"cycle-pane", "cycle the active content pane and dim inactive panes", cyclepane.Run},
 	{"daemon", "run the tabby daemon (socket server + coordinator)", daemon.Run},
 	{"dashboard", "toggle the all-panes dashboard (gather panes into a tiled grid)", dashboard.Run},
+	{"debug", "diagnostics: match-pane [pane] shows which output matcher fires", debug.Run},
 	{"dev", "developer commands: reload, status", dev.Run},
 	{"hook", "tmux hook dispatcher (split-pane, kill-pane, resize, etc.)", hook.Run},
 	{"manage-group", "edit window-group entries in the tabby config file", managegroup.Run},
 	{"new-window", "create a new tmux window with sidebar", newwindow.Run},
+	{"notifications", "print the indicator history (bell, input, busy, done): --since 1h, --json, --all", notifications.Run},
+	{"notify", "post a message to the notification history, sinks and a toast: tabby notify \"msg\" [--window @3]", notify.Run},
 	{"pane-picker", "interactive pane picker TUI", panepicker.Run},
 	{"pet", "interact with the cat: ask, traits, forget", pet.Run},
-	{"render", "spawn a renderer: sidebar | window-header | pane-header | sidebar-popup | pet-qa-popup", renderdispatch.Run},
+	{"project", "review and trust a project's .tabby.yaml menu commands: show, trust, untrust", project.Run},
+	{"render", "spawn a renderer: sidebar | window-header | pane-header | sidebar-popup | pet-qa-popup | toast", renderdispatch.Run},
 	{"setup", "interactive configuration wizard", setup.Run},
 	{"toggle", "enable or disable the tabby sidebar for this session", toggle.Run},
 	{"watchdog", "supervise the tabby daemon, restarting on crash", watchdog.Run},
@@ -0,0 +1,203 @@
+// Package agentstate models what an AI coding agent in a pane is doing, as
+// reported by the agent's own hooks through `tabby hook agent-state` (or the
+// OSC 7700 tabby-agent sequence from a remote host).
+//
+// The state lives on the pane as the @tabby_agent_state user option, so it
+// survives daemon restarts and is read with the rest of the pane list:
+//
+//	<state> US <unix seconds entered> US <detail>
+//
+// where US is the ASCII unit separator (0x1f). The daemon maps the states onto
+// the existing busy/input indicators and draws per-state icons from
+// `indicators.agent` in config.yaml.
+package agentstate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Option is the tmux pane option holding the encoded state.
+const Option = "@tabby_agent_state"
+
+// The states, in the order they usually occur.
+const (
+	Thinking   = "thinking"   // model is generating
+	Tool       = "tool"       // running a tool (shell, edit, search, ...)
+	Permission = "permission" // blocked on a permission prompt
+	Prompt     = "prompt"     // waiting for the next user prompt
+	Error      = "error"      // the turn failed
+	Done       = "done"       // the turn finished
+)
+
+// States lists every state, for usage text and validation.
+var States = []string{Thinking, Tool, Permission, Prompt, Error, Done}
+
+// aliases maps the spellings agent hooks tend to use onto the states.
+var aliases = map[string]string{
+	"working":            Thinking,
+	"running-tool":       Tool,
+	"tool-use":           Tool,
+	"waiting-permission": Permission,
+	"needs-permission":   Permission,
+	"waiting-prompt":     Prompt,
+	"waiting-input":      Prompt,
+	"idle":               Prompt,
+	"errored":            Error,
+	"failed":             Error,
+	"finished":           Done,
+	"stopped":            Done,
+}
+
+// maxDetail bounds the detail text kept on the pane.
+const maxDetail = 200
+
+// sep separates the encoded fields.
+const sep = "\x1f"
+
+// Normalize returns the canonical state for name (case-insensitive, with
+// "_" and "-" treated alike), or false when name is not a state.
+func Normalize(name string) (string, bool) {
+	n := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "_", "-")
+	for _, s := range States {
+		if n == s {
+			return s, true
+		}
+	}
+	if s, ok := aliases[n]; ok {
+		return s, true
+	}
+	return "", false
+}
+
+// IsClear reports whether name asks to drop the pane's state.
+func IsClear(name string) bool {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "clear", "none", "reset":
+		return true
+	}
+	return false
+}
+
+// State is one pane's decoded agent state.
+type State struct {
+	Name   string
+	Since  time.Time
+	Detail string
+}
+
+// Busy reports whether the agent is working (thinking or running a tool).
+func (s State) Busy() bool { return s.Name == Thinking || s.Name == Tool }
+
+// NeedsInput reports whether the agent is waiting on the user.
+func (s State) NeedsInput() bool { return s.Name == Permission || s.Name == Prompt }
+
+// Label is the human-readable state name.
+func (s State) Label() string {
+	switch s.Name {
+	case Tool:
+		return "running tool"
+	case Permission:
+		return "waiting for permission"
+	case Prompt:
+		return "waiting for prompt"
+	case Error:
+		return "errored"
+	case Done:
+		return "finished"
+	}
+	return s.Name
+}
+
+// Priority orders states by urgency, for picking one state to show for a
+// window with several agent panes. Higher is more urgent.
+func Priority(name string) int {
+	switch name {
+	case Permission:
+		return 6
+	case Error:
+		return 5
+	case Prompt:
+		return 4
+	case Tool:
+		return 3
+	case Thinking:
+		return 2
+	case Done:
+		return 1
+	}
+	return 0
+}
+
+// Format encodes s for the pane option.
+func Format(s State) string {
+	return s.Name + sep + strconv.FormatInt(s.Since.Unix(), 10) + sep + cleanDetail(s.Detail)
+}
+
+// Parse decodes a pane option value. It returns false for an empty or
+// unrecognized value.
+func Parse(raw string) (State, bool) {
+	parts := strings.SplitN(strings.TrimSpace(raw), sep, 3)
+	name, ok := Normalize(parts[0])
+	if !ok {
+		return State{}, false
+	}
+	st := State{Name: name}
+	if len(parts) > 1 {
+		if sec, err := strconv.ParseInt(parts[1], 10, 64); err == nil && sec > 0 {
+			st.Since = time.Unix(sec, 0)
+		}
+	}
+	if len(parts) > 2 {
+		st.Detail = parts[2]
+	}
+	return st, true
+}
+
+// Next returns the state to store when an agent reports name with detail,
+// given the pane's previous state. Repeating the current state keeps its
+// start time so time-in-state covers the whole stretch.
+func Next(prev State, hasPrev bool, name, detail string, now time.Time) State {
+	st := State{Name: name, Since: now, Detail: cleanDetail(detail)}
+	if hasPrev && prev.Name == name && !prev.Since.IsZero() {
+		st.Since = prev.Since
+	}
+	return st
+}
+
+// cleanDetail strips control characters (which would end an OSC sequence or
+// split the encoding) and bounds the length.
+func cleanDetail(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, s)
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > maxDetail {
+		s = string(r[:maxDetail-1]) + "…"
+	}
+	return s
+}
+
+// Elapsed renders a time-in-state duration compactly: "<1m", "12m", "1h5m",
+// "3d".
+func Elapsed(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		h, m := int(d/time.Hour), int(d%time.Hour/time.Minute)
+		if m == 0 {
+			return fmt.Sprintf("%dh", h)
+		}
+		return fmt.Sprintf("%dh%dm", h, m)
+	default:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	}
+}
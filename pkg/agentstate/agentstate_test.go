@@ -0,0 +1,78 @@
+package agentstate
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{
+		"thinking":     Thinking,
+		"TOOL":         Tool,
+		"running_tool": Tool,
+		"finished":     Done,
+		" permission ": Permission,
+	} {
+		got, ok := Normalize(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, ok := Normalize("dancing")
+	assert.False(t, ok)
+	assert.True(t, IsClear("clear"))
+	assert.False(t, IsClear("done"))
+}
+
+func TestFormatParseRoundTrip(t *testing.T) {
+	since := time.Unix(1_700_000_000, 0)
+	raw := Format(State{Name: Tool, Since: since, Detail: "Bash: go test\n./...\x07"})
+	st, ok := Parse(raw)
+	require.True(t, ok)
+	assert.Equal(t, Tool, st.Name)
+	assert.True(t, st.Since.Equal(since))
+	assert.Equal(t, "Bash: go test ./...", st.Detail)
+	assert.True(t, st.Busy())
+	assert.False(t, st.NeedsInput())
+
+	_, ok = Parse("")
+	assert.False(t, ok)
+	st, ok = Parse("prompt")
+	require.True(t, ok, "a bare state name still parses")
+	assert.True(t, st.NeedsInput())
+	assert.True(t, st.Since.IsZero())
+}
+
+func TestNextKeepsStartOfRepeatedState(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	prev := State{Name: Tool, Since: t0, Detail: "Read"}
+	st := Next(prev, true, Tool, "Edit", t0.Add(time.Minute))
+	assert.True(t, st.Since.Equal(t0))
+	assert.Equal(t, "Edit", st.Detail)
+
+	st = Next(prev, true, Thinking, "", t0.Add(time.Minute))
+	assert.True(t, st.Since.Equal(t0.Add(time.Minute)))
+}
+
+func TestDetailIsBounded(t *testing.T) {
+	st, _ := Parse(Format(State{Name: Done, Detail: strings.Repeat("x", 500)}))
+	assert.Equal(t, maxDetail, len([]rune(st.Detail)))
+}
+
+func TestElapsed(t *testing.T) {
+	assert.Equal(t, "<1m", Elapsed(30*time.Second))
+	assert.Equal(t, "12m", Elapsed(12*time.Minute+40*time.Second))
+	assert.Equal(t, "1h5m", Elapsed(65*time.Minute))
+	assert.Equal(t, "2h", Elapsed(2*time.Hour))
+	assert.Equal(t, "3d", Elapsed(75*time.Hour))
+}
+
+func TestPriority(t *testing.T) {
+	assert.Greater(t, Priority(Permission), Priority(Prompt))
+	assert.Greater(t, Priority(Error), Priority(Tool))
+	assert.Greater(t, Priority(Thinking), Priority(Done))
+	assert.Zero(t, Priority("nope"))
+}
@@ -0,0 +1,197 @@
+// Package appearance owns the per-directory appearance memory: the color,
+// marker icon, group and pinned state Tabby remembers for a project key (a
+// local git-toplevel path, or an "ssh://host/dir" / "sshhost://host" string)
+// and seeds onto new windows opened there.
+//
+// The memory lives in cwd-colors.json under paths.StateDir(), a flat JSON
+// object keyed by project key:
+//
+//	{
+//	  "/home/me/git/tabby": {"color": "#e74c3c", "icon": "🐱", "group": "Tabby"},
+//	  "ssh://devbox/srv":   {"pinned": true}
+//	}
+//
+// `tabby appearance export` wraps the same object in a versioned envelope,
+// which is also what `import` expects (a bare cwd-colors.json is accepted too):
+//
+//	{"version": 1, "entries": { <key>: <record>, ... }}
+//
+// Every record field is optional. Tab names are deliberately not part of the
+// schema (see the daemon's CWDColorMapping). Team-wide defaults for a project
+// live in its committed .tabby.yaml instead (config.ProjectConfig).
+package appearance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/brendandebeasi/tabby/pkg/paths"
+)
+
+// ExportVersion is the envelope version written by Encode.
+const ExportVersion = 1
+
+// Record is one remembered appearance. All fields use omitempty so old and
+// new files stay interchangeable.
+type Record struct {
+	Color  string `json:"color,omitempty"`
+	Icon   string `json:"icon,omitempty"`
+	Group  string `json:"group,omitempty"`  // saved @tabby_group
+	Pinned bool   `json:"pinned,omitempty"` // saved @tabby_pinned
+}
+
+// Empty reports whether r carries no remembered state.
+func (r Record) Empty() bool {
+	return strings.TrimSpace(r.Color) == "" &&
+		strings.TrimSpace(r.Icon) == "" &&
+		strings.TrimSpace(r.Group) == "" &&
+		!r.Pinned
+}
+
+// Export is the envelope written by `tabby appearance export`.
+type Export struct {
+	Version int               `json:"version"`
+	Entries map[string]Record `json:"entries"`
+}
+
+// Path returns the location of the appearance memory file, creating the
+// state directory if needed.
+func Path() string {
+	paths.EnsureStateDir()
+	return paths.StatePath("cwd-colors.json")
+}
+
+// NormalizeKey cleans a project key the way the daemon stores it. Remote keys
+// ("ssh://", "sshhost://") only have surrounding space trimmed.
+func NormalizeKey(key string) string {
+	key = strings.TrimSpace(key)
+	if key == "" || strings.Contains(key, "://") {
+		return key
+	}
+	return filepath.Clean(key)
+}
+
+// Load reads the memory file at path. A missing file is an empty memory.
+func Load(path string) (map[string]Record, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]Record{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]Record
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if m == nil {
+		m = map[string]Record{}
+	}
+	return m, nil
+}
+
+// Save writes m to path, dropping empty records.
+func Save(path string, m map[string]Record) error {
+	pruned := make(map[string]Record, len(m))
+	for k, r := range m {
+		if !r.Empty() {
+			pruned[k] = r
+		}
+	}
+	data, err := json.MarshalIndent(pruned, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Encode renders m as an indented export envelope.
+func Encode(m map[string]Record) ([]byte, error) {
+	if m == nil {
+		m = map[string]Record{}
+	}
+	return json.MarshalIndent(Export{Version: ExportVersion, Entries: m}, "", "  ")
+}
+
+// Decode parses an export envelope, or a bare cwd-colors.json object, into a
+// map of normalized keys.
+func Decode(data []byte) (map[string]Record, error) {
+	var env struct {
+		Version *int              `json:"version"`
+		Entries map[string]Record `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &env); err == nil && env.Version != nil {
+		if *env.Version > ExportVersion {
+			return nil, fmt.Errorf("unsupported appearance export version %d", *env.Version)
+		}
+		return normalizeKeys(env.Entries), nil
+	}
+	var bare map[string]Record
+	if err := json.Unmarshal(data, &bare); err != nil {
+		return nil, fmt.Errorf("not an appearance export: %w", err)
+	}
+	return normalizeKeys(bare), nil
+}
+
+func normalizeKeys(m map[string]Record) map[string]Record {
+	out := make(map[string]Record, len(m))
+	for k, r := range m {
+		if k = NormalizeKey(k); k != "" {
+			out[k] = r
+		}
+	}
+	return out
+}
+
+// Merge folds src into dst field by field: a non-empty incoming field
+// overwrites, an empty one keeps what dst already had. Returns how many keys
+// were added and how many existing ones changed.
+func Merge(dst, src map[string]Record) (added, updated int) {
+	for k, in := range src {
+		cur, ok := dst[k]
+		next := cur
+		if c := strings.TrimSpace(in.Color); c != "" {
+			next.Color = c
+		}
+		if i := strings.TrimSpace(in.Icon); i != "" {
+			next.Icon = i
+		}
+		if g := strings.TrimSpace(in.Group); g != "" {
+			next.Group = g
+		}
+		if in.Pinned {
+			next.Pinned = true
+		}
+		if next.Empty() {
+			continue
+		}
+		switch {
+		case !ok:
+			added++
+		case next != cur:
+			updated++
+		default:
+			continue
+		}
+		dst[k] = next
+	}
+	return added, updated
+}
+
+// FillFrom returns r with every empty field taken from seed. Pinned is never
+// seeded: pinning is a personal choice.
+func (r Record) FillFrom(seed Record) Record {
+	if strings.TrimSpace(r.Color) == "" {
+		r.Color = seed.Color
+	}
+	if strings.TrimSpace(r.Icon) == "" {
+		r.Icon = seed.Icon
+	}
+	if strings.TrimSpace(r.Group) == "" {
+		r.Group = seed.Group
+	}
+	return r
+}
@@ -0,0 +1,75 @@
+package appearance
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	m := map[string]Record{
+		"/home/me/git/tabby": {Color: "#e74c3c", Icon: "🐱", Group: "Tabby"},
+		"ssh://devbox/srv":   {Pinned: true},
+	}
+	data, err := Encode(m)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"version": 1`)
+
+	got, err := Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, m, got)
+}
+
+func TestDecodeAcceptsBareFileAndNormalizesKeys(t *testing.T) {
+	got, err := Decode([]byte(`{"/home/me/git/tabby/": {"color": "#fff000"}, " ssh://h/x ": {"icon": "x"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Record{
+		"/home/me/git/tabby": {Color: "#fff000"},
+		"ssh://h/x":          {Icon: "x"},
+	}, got)
+}
+
+func TestDecodeRejectsFutureVersionAndGarbage(t *testing.T) {
+	_, err := Decode([]byte(`{"version": 99, "entries": {}}`))
+	assert.Error(t, err)
+	_, err = Decode([]byte(`[1,2,3]`))
+	assert.Error(t, err)
+}
+
+func TestMerge(t *testing.T) {
+	dst := map[string]Record{
+		"/a": {Color: "#111111", Group: "A"},
+		"/b": {Icon: "b"},
+	}
+	added, updated := Merge(dst, map[string]Record{
+		"/a": {Color: "#222222"}, // color overwritten, group kept
+		"/b": {Icon: "b"},        // unchanged
+		"/c": {Pinned: true},     // new
+		"/d": {},                 // empty: ignored
+	})
+	assert.Equal(t, 1, added)
+	assert.Equal(t, 1, updated)
+	assert.Equal(t, Record{Color: "#222222", Group: "A"}, dst["/a"])
+	assert.Equal(t, Record{Pinned: true}, dst["/c"])
+	_, ok := dst["/d"]
+	assert.False(t, ok)
+}
+
+func TestSaveLoadPrunesEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cwd-colors.json")
+	m, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, m)
+
+	require.NoError(t, Save(path, map[string]Record{"/a": {Color: "#123456"}, "/b": {}}))
+	m, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Record{"/a": {Color: "#123456"}}, m)
+}
+
+func TestFillFrom(t *testing.T) {
+	r := Record{Color: "#111111", Pinned: true}.FillFrom(Record{Color: "#999999", Icon: "i", Group: "G", Pinned: false})
+	assert.Equal(t, Record{Color: "#111111", Icon: "i", Group: "G", Pinned: true}, r)
+}
@@ -0,0 +1,162 @@
+// Package claudeusage reads token usage out of Claude Code's JSONL session
+// transcripts, so tabby can show what each session cost without the sqlite3
+// binary or Claude Code's legacy __store.db.
+//
+// Claude Code writes one transcript per session under
+// ~/.claude/projects/<munged cwd>/<session id>.jsonl. Every assistant line
+// carries the model, the session's cwd and a usage block:
+//
+//	{"type":"assistant","sessionId":"…","cwd":"/src/api","timestamp":"…",
+//	 "message":{"id":"msg_…","model":"claude-sonnet-4-5-…","usage":{
+//	   "input_tokens":12,"output_tokens":420,
+//	   "cache_creation_input_tokens":3100,"cache_read_input_tokens":18000}}}
+//
+// A streamed reply is written as several lines sharing one message ID, so
+// lines are de-duplicated by message and request ID. Tracker tails the files
+// incrementally: each Scan only reads bytes appended since the last one.
+//
+// The package depends on the standard library only.
+package claudeusage
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Usage is the token counts and estimated cost of one or more messages.
+type Usage struct {
+	Input      int64   `json:"input"`
+	Output     int64   `json:"output"`
+	CacheWrite int64   `json:"cache_write"`
+	CacheRead  int64   `json:"cache_read"`
+	Cost       float64 `json:"cost"` // USD
+	Messages   int     `json:"messages"`
+}
+
+// Add accumulates o into u.
+func (u *Usage) Add(o Usage) {
+	u.Input += o.Input
+	u.Output += o.Output
+	u.CacheWrite += o.CacheWrite
+	u.CacheRead += o.CacheRead
+	u.Cost += o.Cost
+	u.Messages += o.Messages
+}
+
+// Tokens is the total token count, cache reads and writes included.
+func (u Usage) Tokens() int64 {
+	return u.Input + u.Output + u.CacheWrite + u.CacheRead
+}
+
+// Record is one assistant message's usage.
+type Record struct {
+	Time      time.Time
+	SessionID string
+	Cwd       string
+	Model     string
+	Key       string // de-duplication key: message ID + request ID
+	Usage     Usage
+}
+
+// line is the subset of a transcript line we read.
+type line struct {
+	Type      string  `json:"type"`
+	SessionID string  `json:"sessionId"`
+	Cwd       string  `json:"cwd"`
+	Timestamp string  `json:"timestamp"`
+	RequestID string  `json:"requestId"`
+	CostUSD   float64 `json:"costUSD"`
+	Message   struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage *struct {
+			InputTokens              int64 `json:"input_tokens"`
+			OutputTokens             int64 `json:"output_tokens"`
+			CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// ParseLine decodes one transcript line. ok is false for lines that carry no
+// assistant usage (user turns, summaries, tool results, malformed JSON).
+func ParseLine(b []byte) (Record, bool) {
+	var l line
+	if err := json.Unmarshal(b, &l); err != nil || l.Type != "assistant" || l.Message.Usage == nil {
+		return Record{}, false
+	}
+	u := l.Message.Usage
+	r := Record{
+		SessionID: l.SessionID,
+		Cwd:       l.Cwd,
+		Model:     l.Message.Model,
+		Usage: Usage{
+			Input:      u.InputTokens,
+			Output:     u.OutputTokens,
+			CacheWrite: u.CacheCreationInputTokens,
+			CacheRead:  u.CacheReadInputTokens,
+			Messages:   1,
+		},
+	}
+	if l.Message.ID != "" {
+		r.Key = l.Message.ID + ":" + l.RequestID
+	}
+	r.Time, _ = time.Parse(time.RFC3339Nano, l.Timestamp)
+	// Older transcripts recorded the cost themselves.
+	if l.CostUSD > 0 {
+		r.Usage.Cost = l.CostUSD
+	} else {
+		r.Usage.Cost = Cost(r.Model, r.Usage)
+	}
+	return r, true
+}
+
+// Price is a model's list price in USD per million tokens.
+type Price struct {
+	Input, Output, CacheWrite, CacheRead float64
+}
+
+// prices maps model-name fragments to list prices. The first fragment
+// contained in the model name wins, so specific versions come before their
+// family.
+var prices = []struct {
+	match string
+	price Price
+}{
+	{"opus-4-5", Price{5, 25, 6.25, 0.50}},
+	{"opus", Price{15, 75, 18.75, 1.50}},
+	{"haiku-4", Price{1, 5, 1.25, 0.10}},
+	{"3-5-haiku", Price{0.80, 4, 1, 0.08}},
+	{"haiku", Price{0.25, 1.25, 0.30, 0.03}},
+	{"sonnet", Price{3, 15, 3.75, 0.30}},
+}
+
+// PriceFor returns the list price for a model, and false for models it does
+// not know (including Claude Code's "<synthetic>" placeholder messages).
+func PriceFor(model string) (Price, bool) {
+	m := strings.ToLower(model)
+	for _, p := range prices {
+		if strings.Contains(m, p.match) {
+			return p.price, true
+		}
+	}
+	return Price{}, false
+}
+
+// Cost estimates u's cost at the model's list price. Unknown models cost 0.
+func Cost(model string, u Usage) float64 {
+	p, ok := PriceFor(model)
+	if !ok {
+		return 0
+	}
+	return (float64(u.Input)*p.Input +
+		float64(u.Output)*p.Output +
+		float64(u.CacheWrite)*p.CacheWrite +
+		float64(u.CacheRead)*p.CacheRead) / 1e6
+}
+
+// DayKey is the local calendar day of t, as used in Session.Days.
+func DayKey(t time.Time) string {
+	return t.Local().Format("2006-01-02")
+}
@@ -0,0 +1,95 @@
+package claudeusage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assistantLine(session, msgID, model, ts string, in, out, cw, cr int64) string {
+	return fmt.Sprintf(`{"type":"assistant","sessionId":%q,"cwd":"/src/api","timestamp":%q,"requestId":"req_1","message":{"id":%q,"model":%q,"usage":{"input_tokens":%d,"output_tokens":%d,"cache_creation_input_tokens":%d,"cache_read_input_tokens":%d}}}`+"\n",
+		session, ts, msgID, model, in, out, cw, cr)
+}
+
+func TestParseLine(t *testing.T) {
+	rec, ok := ParseLine([]byte(assistantLine("s1", "msg_1", "claude-sonnet-4-5-20250929", "2026-10-18T09:00:00.000Z", 1000, 2000, 1_000_000, 0)))
+	require.True(t, ok)
+	assert.Equal(t, "s1", rec.SessionID)
+	assert.Equal(t, "/src/api", rec.Cwd)
+	assert.Equal(t, "msg_1:req_1", rec.Key)
+	assert.Equal(t, int64(1_003_000), rec.Usage.Tokens())
+	assert.InDelta(t, 0.003+0.03+3.75, rec.Usage.Cost, 1e-9)
+
+	_, ok = ParseLine([]byte(`{"type":"user","message":{"role":"user","content":"hi"}}`))
+	assert.False(t, ok)
+	_, ok = ParseLine([]byte(`not json`))
+	assert.False(t, ok)
+
+	rec, ok = ParseLine([]byte(`{"type":"assistant","costUSD":0.42,"message":{"model":"claude-opus-4-1","usage":{"input_tokens":1}}}`))
+	require.True(t, ok)
+	assert.Equal(t, 0.42, rec.Usage.Cost, "a recorded cost wins over the estimate")
+}
+
+func TestPriceFor(t *testing.T) {
+	for model, want := range map[string]float64{
+		"claude-opus-4-1-20250805":   15,
+		"claude-opus-4-5-20251101":   5,
+		"claude-haiku-4-5-20251001":  1,
+		"claude-3-5-haiku-20241022":  0.80,
+		"claude-3-5-sonnet-20241022": 3,
+	} {
+		p, ok := PriceFor(model)
+		require.True(t, ok, model)
+		assert.Equal(t, want, p.Input, model)
+	}
+	_, ok := PriceFor("<synthetic>")
+	assert.False(t, ok)
+}
+
+func TestTrackerScansIncrementally(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "-src-api")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	path := filepath.Join(dir, "s1.jsonl")
+
+	first := assistantLine("s1", "msg_1", "claude-sonnet-4-5", "2026-10-17T09:00:00Z", 10, 20, 0, 0)
+	// The same streamed message written twice, then a partial line.
+	dup := assistantLine("s1", "msg_2", "claude-sonnet-4-5", "2026-10-18T09:00:00Z", 5, 5, 0, 0)
+	partial := assistantLine("s1", "msg_3", "claude-sonnet-4-5", "2026-10-18T10:00:00Z", 1, 1, 0, 0)
+	require.NoError(t, os.WriteFile(path, []byte(first+dup+dup+partial[:20]), 0o644))
+
+	tr := NewTracker(root)
+	changed, err := tr.Scan()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	sessions := tr.Sessions()
+	require.Len(t, sessions, 1)
+	assert.Equal(t, 2, sessions[0].Total.Messages)
+	assert.Equal(t, int64(40), sessions[0].Total.Tokens())
+	assert.Len(t, sessions[0].Days, 2)
+
+	changed, err = tr.Scan()
+	require.NoError(t, err)
+	assert.False(t, changed, "nothing new appended")
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(partial[20:])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	changed, err = tr.Scan()
+	require.NoError(t, err)
+	assert.True(t, changed, "the completed line is read")
+	assert.Equal(t, 3, tr.Sessions()[0].Total.Messages)
+}
+
+func TestTrackerMissingRoot(t *testing.T) {
+	changed, err := NewTracker(filepath.Join(t.TempDir(), "nope")).Scan()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+}
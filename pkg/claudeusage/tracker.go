@@ -0,0 +1,207 @@
+package claudeusage
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Session is the usage accumulated by one Claude Code session.
+type Session struct {
+	ID    string
+	Cwd   string // working directory of the latest message
+	Model string // model of the latest message
+	First time.Time
+	Last  time.Time
+	Total Usage
+	Days  map[string]Usage // DayKey -> usage that day
+}
+
+// fileState is how far a transcript has been read.
+type fileState struct {
+	offset int64
+}
+
+// Tracker incrementally ingests the transcripts under Root. It is not safe
+// for concurrent use; callers serialize Scan and Sessions.
+type Tracker struct {
+	Root string
+
+	files    map[string]*fileState
+	seen     map[string]struct{}
+	sessions map[string]*Session
+}
+
+// NewTracker returns a tracker for the transcripts under root (normally
+// ~/.claude/projects).
+func NewTracker(root string) *Tracker {
+	return &Tracker{
+		Root:     root,
+		files:    make(map[string]*fileState),
+		seen:     make(map[string]struct{}),
+		sessions: make(map[string]*Session),
+	}
+}
+
+// DefaultRoot returns ~/.claude/projects, honoring $CLAUDE_CONFIG_DIR.
+func DefaultRoot() string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "projects")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".claude", "projects")
+}
+
+// Scan reads whatever was appended to the transcripts since the last scan
+// and reports whether any usage was added. A missing root is not an error:
+// Claude Code may simply not have run yet.
+func (t *Tracker) Scan() (changed bool, err error) {
+	err = filepath.WalkDir(t.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == t.Root {
+				return err
+			}
+			return nil // unreadable subdirectory: skip it
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".jsonl") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		fst := t.files[path]
+		if fst == nil {
+			fst = &fileState{}
+			t.files[path] = fst
+		}
+		if info.Size() < fst.offset {
+			// Truncated or rewritten; message keys keep re-read lines
+			// from counting twice.
+			fst.offset = 0
+		}
+		if info.Size() == fst.offset {
+			return nil
+		}
+		n, err := t.readFrom(path, fst.offset)
+		if err == nil {
+			fst.offset += n.bytes
+		}
+		if n.records > 0 {
+			changed = true
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	return changed, err
+}
+
+type readResult struct {
+	bytes   int64 // complete lines consumed
+	records int   // usage records added
+}
+
+// readFrom ingests the complete lines of path after offset. A trailing line
+// without a newline is still being written and is left for the next scan.
+func (t *Tracker) readFrom(path string, offset int64) (readResult, error) {
+	var res readResult
+	f, err := os.Open(path)
+	if err != nil {
+		return res, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return res, err
+	}
+	fallbackID := strings.TrimSuffix(filepath.Base(path), ".jsonl")
+	r := bufio.NewReaderSize(f, 64*1024)
+	for {
+		b, err := r.ReadBytes('\n')
+		if err != nil {
+			// io.EOF with a partial line, or a read error: stop here.
+			return res, nil
+		}
+		res.bytes += int64(len(b))
+		// Tool results can be megabytes; only assistant lines with usage
+		// are worth decoding.
+		if !bytes.Contains(b, []byte(`"usage"`)) {
+			continue
+		}
+		rec, ok := ParseLine(b)
+		if !ok {
+			continue
+		}
+		if rec.SessionID == "" {
+			rec.SessionID = fallbackID
+		}
+		if t.add(rec) {
+			res.records++
+		}
+	}
+}
+
+// add folds rec into its session. It returns false for a duplicate.
+func (t *Tracker) add(rec Record) bool {
+	if rec.Key != "" {
+		if _, dup := t.seen[rec.Key]; dup {
+			return false
+		}
+		t.seen[rec.Key] = struct{}{}
+	}
+	s := t.sessions[rec.SessionID]
+	if s == nil {
+		s = &Session{ID: rec.SessionID, Days: make(map[string]Usage)}
+		t.sessions[rec.SessionID] = s
+	}
+	if rec.Cwd != "" {
+		s.Cwd = rec.Cwd
+	}
+	if rec.Model != "" && !strings.HasPrefix(rec.Model, "<") {
+		s.Model = rec.Model
+	}
+	if !rec.Time.IsZero() {
+		if s.First.IsZero() || rec.Time.Before(s.First) {
+			s.First = rec.Time
+		}
+		if rec.Time.After(s.Last) {
+			s.Last = rec.Time
+		}
+	}
+	s.Total.Add(rec.Usage)
+	day := DayKey(rec.Time)
+	if rec.Time.IsZero() {
+		day = DayKey(time.Now())
+	}
+	d := s.Days[day]
+	d.Add(rec.Usage)
+	s.Days[day] = d
+	return true
+}
+
+// Sessions returns copies of all sessions, most recently active first.
+func (t *Tracker) Sessions() []Session {
+	out := make([]Session, 0, len(t.sessions))
+	for _, s := range t.sessions {
+		cp := *s
+		cp.Days = make(map[string]Usage, len(s.Days))
+		for k, v := range s.Days {
+			cp.Days[k] = v
+		}
+		out = append(out, cp)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Last.Equal(out[j].Last) {
+			return out[i].Last.After(out[j].Last)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
@@ -0,0 +1,161 @@
+// Package cmdtrack records long-running shell commands reported by the
+// tabby-cmd shell integration (scripts/tabby-cmd.sh). The shell prints an
+// OSC 7700 tabby-cmd sequence when a command starts and when it ends:
+//
+//	ESC ] 7700 ; tabby-cmd ; start ; <command line> BEL
+//	ESC ] 7700 ; tabby-cmd ; end ; <exit status> BEL
+//
+// The pane's osc-handler times the command with a Tracker and, when it ran
+// for at least the configured minimum, stores it on the pane as the
+// @tabby_cmd user option:
+//
+//	<exit> US <unix start> US <unix end> US <command>
+//
+// where US is the ASCII unit separator (0x1f). The window also gets
+// @tabby_cmd_alert ("done" or "failed") until the user visits it.
+package cmdtrack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Option is the tmux pane option holding the last long command.
+const Option = "@tabby_cmd"
+
+// AlertOption is the tmux window option set when a long command finished
+// while the window was not being looked at.
+const AlertOption = "@tabby_cmd_alert"
+
+// Alert values.
+const (
+	Done   = "done"
+	Failed = "failed"
+)
+
+// maxCommand bounds the command text kept on the pane.
+const maxCommand = 200
+
+// sep separates the encoded fields.
+const sep = "\x1f"
+
+// Record is a finished command.
+type Record struct {
+	Command string
+	Exit    int
+	Start   time.Time
+	End     time.Time
+}
+
+// Duration is how long the command ran.
+func (r Record) Duration() time.Duration { return r.End.Sub(r.Start) }
+
+// Failed reports whether the command exited non-zero.
+func (r Record) Failed() bool { return r.Exit != 0 }
+
+// Alert is the window alert value for the record.
+func (r Record) Alert() string {
+	if r.Failed() {
+		return Failed
+	}
+	return Done
+}
+
+// Status renders "3m12s", with " · exit 2" appended on failure.
+func (r Record) Status() string {
+	s := FormatDuration(r.Duration())
+	if r.Failed() {
+		s += fmt.Sprintf(" · exit %d", r.Exit)
+	}
+	return s
+}
+
+// Label renders "make · 3m12s".
+func (r Record) Label() string { return r.Command + " · " + r.Status() }
+
+// Format encodes r for the pane option.
+func Format(r Record) string {
+	return strings.Join([]string{
+		strconv.Itoa(r.Exit),
+		strconv.FormatInt(r.Start.Unix(), 10),
+		strconv.FormatInt(r.End.Unix(), 10),
+		cleanCommand(r.Command),
+	}, sep)
+}
+
+// Parse decodes a pane option value. An empty or malformed value is not a
+// record.
+func Parse(raw string) (Record, bool) {
+	parts := strings.SplitN(strings.TrimRight(raw, "\n"), sep, 4)
+	if len(parts) != 4 || parts[3] == "" {
+		return Record{}, false
+	}
+	exit, err1 := strconv.Atoi(parts[0])
+	start, err2 := strconv.ParseInt(parts[1], 10, 64)
+	end, err3 := strconv.ParseInt(parts[2], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || end < start {
+		return Record{}, false
+	}
+	return Record{Command: parts[3], Exit: exit, Start: time.Unix(start, 0), End: time.Unix(end, 0)}, true
+}
+
+// Tracker times the command running in one pane from its start and end
+// sequences. The zero value is ready to use.
+type Tracker struct {
+	command string
+	start   time.Time
+}
+
+// Start records that command began at now.
+func (t *Tracker) Start(command string, now time.Time) {
+	t.command, t.start = cleanCommand(command), now
+}
+
+// End finishes the running command and returns its record, or false when no
+// command was started (the shell integration was sourced mid-command).
+func (t *Tracker) End(exitText string, now time.Time) (Record, bool) {
+	if t.start.IsZero() {
+		return Record{}, false
+	}
+	exit, err := strconv.Atoi(strings.TrimSpace(exitText))
+	if err != nil {
+		exit = 0
+	}
+	r := Record{Command: t.command, Exit: exit, Start: t.start, End: now}
+	*t = Tracker{}
+	if r.Command == "" {
+		return Record{}, false
+	}
+	return r, true
+}
+
+// FormatDuration renders d as "45s", "3m12s" or "2h05m". It is the format
+// for a finished span, a command here and an agent session in the agent log,
+// where seconds matter: most commands worth tracking run well under a
+// minute. agentstate.Elapsed stays coarser ("<1m", "12m") because it labels
+// a state that is still going and is redrawn only on refresh.
+func FormatDuration(d time.Duration) string {
+	s := int(max(d, 0).Round(time.Second) / time.Second)
+	switch {
+	case s < 60:
+		return fmt.Sprintf("%ds", s)
+	case s < 3600:
+		return fmt.Sprintf("%dm%02ds", s/60, s%60)
+	default:
+		return fmt.Sprintf("%dh%02dm", s/3600, s%3600/60)
+	}
+}
+
+// cleanCommand collapses whitespace and control characters (which would
+// corrupt the encoding) and bounds the length.
+func cleanCommand(s string) string {
+	s = strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return r <= ' ' || r == 0x7f
+	}), " ")
+	if r := []rune(s); len(r) > maxCommand {
+		s = string(r[:maxCommand])
+	}
+	return s
+}
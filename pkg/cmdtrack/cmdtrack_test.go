@@ -0,0 +1,61 @@
+package cmdtrack
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatParseRoundTrip(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	raw := Format(Record{Command: "make\tall\n\x07", Exit: 2, Start: start, End: start.Add(192 * time.Second)})
+	r, ok := Parse(raw)
+	require.True(t, ok)
+	assert.Equal(t, "make all", r.Command)
+	assert.Equal(t, 2, r.Exit)
+	assert.True(t, r.Start.Equal(start))
+	assert.Equal(t, 192*time.Second, r.Duration())
+	assert.True(t, r.Failed())
+	assert.Equal(t, Failed, r.Alert())
+	assert.Equal(t, "make all · 3m12s · exit 2", r.Label())
+}
+
+func TestParseRejectsMalformed(t *testing.T) {
+	for _, raw := range []string{"", "0\x1f1\x1f2", "x\x1f1\x1f2\x1fmake", "0\x1f5\x1f1\x1fmake", "0\x1f1\x1f2\x1f"} {
+		_, ok := Parse(raw)
+		assert.False(t, ok, "%q", raw)
+	}
+}
+
+func TestTracker(t *testing.T) {
+	var tr Tracker
+	now := time.Unix(1_700_000_000, 0)
+
+	_, ok := tr.End("0", now)
+	assert.False(t, ok, "an end without a start is ignored")
+
+	tr.Start("cargo build --release", now)
+	r, ok := tr.End("0\n", now.Add(45*time.Second))
+	require.True(t, ok)
+	assert.Equal(t, "cargo build --release", r.Command)
+	assert.False(t, r.Failed())
+	assert.Equal(t, Done, r.Alert())
+	assert.Equal(t, "cargo build --release · 45s", r.Label())
+
+	_, ok = tr.End("0", now.Add(time.Minute))
+	assert.False(t, ok, "End resets the tracker")
+
+	tr.Start(strings.Repeat("x", 300), now)
+	r, _ = tr.End("1", now)
+	assert.Len(t, r.Command, maxCommand)
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "9s", FormatDuration(9400*time.Millisecond))
+	assert.Equal(t, "1m05s", FormatDuration(65*time.Second))
+	assert.Equal(t, "2h05m", FormatDuration(2*time.Hour+5*time.Minute+30*time.Second))
+	assert.Equal(t, "0s", FormatDuration(-3*time.Second), "clock skew")
+}
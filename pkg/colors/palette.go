@@ -0,0 +1,244 @@
+package colors
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+// Group palette allocation.
+//
+// GetDefaultGroupColor picks from a fixed list by index, so two groups can land
+// on similar colors and every color shifts when a group is added or the list
+// is reordered. The allocator below instead works in OKLCH (the polar form of
+// OKLab, a perceptually uniform space): each group's preferred hue comes from a
+// hash of its NAME, every group is drawn at the same lightness and chroma, and
+// a hue that lands too close (by OKLab distance) to a color already taken is
+// walked around the wheel by the golden angle until it is distinct.
+
+// PaletteOptions controls AllocateGroupColors and GroupColorForName. Zero
+// values pick defaults suited to the terminal's dark/light mode.
+type PaletteOptions struct {
+	// Dark selects defaults for a dark terminal background.
+	Dark bool
+	// Lightness is the OKLCH lightness (0-1) every color is drawn at. Pass the
+	// active theme's group color through PerceptualLightness to match it.
+	Lightness float64
+	// Chroma is the OKLCH chroma (roughly 0-0.3). Out-of-gamut colors are
+	// desaturated until they fit in sRGB.
+	Chroma float64
+	// Background is the terminal background. When set, each color is pushed
+	// through EnsureContrast so it stays at least MinContrast away from it.
+	Background string
+	// MinContrast is the WCAG ratio against Background (default 1.5).
+	MinContrast float64
+	// MinDistance is the smallest OKLab distance allowed between two
+	// allocated colors (default 0.08).
+	MinDistance float64
+}
+
+const (
+	goldenAngle        = 137.50776405003785
+	paletteHueAttempts = 36
+)
+
+func (o PaletteOptions) withDefaults() PaletteOptions {
+	if o.Lightness <= 0 || o.Lightness >= 1 {
+		if o.Dark {
+			o.Lightness = 0.58
+		} else {
+			o.Lightness = 0.74
+		}
+	}
+	if o.Chroma <= 0 {
+		o.Chroma = 0.13
+	}
+	if o.MinContrast <= 0 {
+		o.MinContrast = 1.5
+	}
+	if o.MinDistance <= 0 {
+		o.MinDistance = 0.08
+	}
+	return o
+}
+
+// GroupColorForName returns the color a group named name prefers: its hashed
+// hue at the options' lightness and chroma. It ignores every other group; use
+// AllocateGroupColors when several groups need to be told apart.
+func GroupColorForName(name string, opts PaletteOptions) string {
+	opts = opts.withDefaults()
+	return paletteColor(opts.Lightness, opts.Chroma, nameHue(name), opts)
+}
+
+// AllocateGroupColors assigns a color to every name. Colors listed in reserved
+// (groups with an explicit color) count as taken. The result depends only on
+// the SET of names and reserved colors, never on their order, so groups keep
+// their colors when the sidebar is reordered. When the hue wheel runs out of
+// room at the chosen lightness, nearby lightness tiers are tried, and as a
+// last resort the candidate farthest from every taken color wins.
+func AllocateGroupColors(names []string, reserved []string, opts PaletteOptions) map[string]string {
+	opts = opts.withDefaults()
+
+	var taken [][3]float64
+	for _, hex := range reserved {
+		if lab, ok := hexToOKLab(hex); ok {
+			taken = append(taken, lab)
+		}
+	}
+
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+
+	result := make(map[string]string, len(sorted))
+	tiers := []float64{opts.Lightness, opts.Lightness - 0.1, opts.Lightness + 0.1}
+	for _, name := range sorted {
+		if _, done := result[name]; done {
+			continue
+		}
+		base := nameHue(name)
+		best, bestDist := "", -1.0
+		for _, l := range tiers {
+			for k := 0; k < paletteHueAttempts; k++ {
+				hex := paletteColor(l, opts.Chroma, base+float64(k)*goldenAngle, opts)
+				lab, _ := hexToOKLab(hex)
+				d := minOKLabDistance(lab, taken)
+				if d >= opts.MinDistance {
+					best, bestDist = hex, math.Inf(1)
+					break
+				}
+				if d > bestDist {
+					best, bestDist = hex, d
+				}
+			}
+			if math.IsInf(bestDist, 1) {
+				break
+			}
+		}
+		result[name] = best
+		if lab, ok := hexToOKLab(best); ok {
+			taken = append(taken, lab)
+		}
+	}
+	return result
+}
+
+// PerceptualLightness returns the OKLab lightness (0-1) of a hex color, or 0
+// for an invalid one.
+func PerceptualLightness(hexColor string) float64 {
+	lab, ok := hexToOKLab(hexColor)
+	if !ok {
+		return 0
+	}
+	return lab[0]
+}
+
+// OKLabDistance is the Euclidean distance between two colors in OKLab, or -1
+// if either is not a valid hex color.
+func OKLabDistance(a, b string) float64 {
+	la, okA := hexToOKLab(a)
+	lb, okB := hexToOKLab(b)
+	if !okA || !okB {
+		return -1
+	}
+	return labDistance(la, lb)
+}
+
+// nameHue hashes name onto the hue wheel (degrees).
+func nameHue(name string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return float64(h.Sum32()%3600) / 10
+}
+
+// paletteColor renders one OKLCH point as hex, then applies the background
+// contrast floor.
+func paletteColor(l, c, hue float64, opts PaletteOptions) string {
+	hex := oklchToHex(l, c, hue)
+	if opts.Background != "" {
+		hex = EnsureContrast(hex, opts.Background, opts.MinContrast)
+	}
+	return hex
+}
+
+func minOKLabDistance(lab [3]float64, taken [][3]float64) float64 {
+	best := math.Inf(1)
+	for _, t := range taken {
+		if d := labDistance(lab, t); d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+func labDistance(a, b [3]float64) float64 {
+	dl, da, db := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return math.Sqrt(dl*dl + da*da + db*db)
+}
+
+// oklchToHex converts OKLCH to sRGB hex, lowering chroma until the color is
+// inside the sRGB gamut so the hue is preserved.
+func oklchToHex(l, c, hueDeg float64) string {
+	hue := math.Mod(hueDeg, 360) * math.Pi / 180
+	for ; c > 0; c -= 0.005 {
+		r, g, b := okLabToLinearRGB(l, c*math.Cos(hue), c*math.Sin(hue))
+		if inUnit(r) && inUnit(g) && inUnit(b) {
+			return linearRGBToHex(r, g, b)
+		}
+	}
+	r, g, b := okLabToLinearRGB(l, 0, 0)
+	return linearRGBToHex(r, g, b)
+}
+
+func inUnit(v float64) bool { return v >= -1e-4 && v <= 1+1e-4 }
+
+// hexToOKLab converts a #rrggbb color to OKLab (L, a, b).
+func hexToOKLab(hexColor string) ([3]float64, bool) {
+	r, g, b := hexToRGB(hexColor)
+	if r < 0 {
+		return [3]float64{}, false
+	}
+	lr := srgbToLinear(float64(r) / 255)
+	lg := srgbToLinear(float64(g) / 255)
+	lb := srgbToLinear(float64(b) / 255)
+
+	lms1 := math.Cbrt(0.4122214708*lr + 0.5363325363*lg + 0.0514459929*lb)
+	lms2 := math.Cbrt(0.2119034982*lr + 0.6806995451*lg + 0.1073969566*lb)
+	lms3 := math.Cbrt(0.0883024619*lr + 0.2817188376*lg + 0.6299787005*lb)
+
+	return [3]float64{
+		0.2104542553*lms1 + 0.7936177850*lms2 - 0.0040720468*lms3,
+		1.9779984951*lms1 - 2.4285922050*lms2 + 0.4505937099*lms3,
+		0.0259040371*lms1 + 0.7827717662*lms2 - 0.8086757660*lms3,
+	}, true
+}
+
+func okLabToLinearRGB(l, a, b float64) (float64, float64, float64) {
+	l1 := l + 0.3963377774*a + 0.2158037573*b
+	m1 := l - 0.1055613458*a - 0.0638541728*b
+	s1 := l - 0.0894841775*a - 1.2914855480*b
+	l3, m3, s3 := l1*l1*l1, m1*m1*m1, s1*s1*s1
+	return 4.0767416621*l3 - 3.3077115913*m3 + 0.2309699292*s3,
+		-1.2684380046*l3 + 2.6097574011*m3 - 0.3413193965*s3,
+		-0.0041960863*l3 - 0.7034186147*m3 + 1.7076147010*s3
+}
+
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+func linearRGBToHex(r, g, b float64) string {
+	to8 := func(v float64) int64 {
+		return int64(math.Round(linearToSRGB(math.Max(0, math.Min(1, v))) * 255))
+	}
+	return rgbToHex(to8(r), to8(g), to8(b))
+}
@@ -0,0 +1,80 @@
+package colors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOKLabRoundTrip(t *testing.T) {
+	for _, hex := range []string{"#3498db", "#e74c3c", "#000000", "#ffffff", "#27ae60"} {
+		lab, ok := hexToOKLab(hex)
+		assert.True(t, ok)
+		r, g, b := okLabToLinearRGB(lab[0], lab[1], lab[2])
+		assert.Equal(t, hex, linearRGBToHex(r, g, b))
+	}
+	assert.InDelta(t, 1.0, PerceptualLightness("#ffffff"), 1e-3)
+	assert.InDelta(t, 0.0, PerceptualLightness("#000000"), 1e-3)
+	assert.Equal(t, 0.0, PerceptualLightness("nope"))
+	assert.Equal(t, -1.0, OKLabDistance("#ffffff", "nope"))
+}
+
+func TestGroupColorForName_StableAndValid(t *testing.T) {
+	opts := PaletteOptions{Dark: true}
+	a := GroupColorForName("Backend", opts)
+	assert.True(t, isValidHex(a), a)
+	assert.Equal(t, a, GroupColorForName("Backend", opts))
+	assert.NotEqual(t, a, GroupColorForName("Frontend", opts))
+}
+
+func TestAllocateGroupColors_OrderIndependent(t *testing.T) {
+	opts := PaletteOptions{Dark: true}
+	names := []string{"Default", "Backend", "Frontend", "Docs", "Infra"}
+	first := AllocateGroupColors(names, nil, opts)
+	reversed := AllocateGroupColors([]string{"Infra", "Docs", "Frontend", "Backend", "Default"}, nil, opts)
+	assert.Equal(t, first, reversed)
+	assert.Len(t, first, len(names))
+}
+
+func TestAllocateGroupColors_Distinct(t *testing.T) {
+	opts := PaletteOptions{Dark: true}
+	names := []string{"A", "B", "C", "D", "E", "F", "G", "H"}
+	got := AllocateGroupColors(names, []string{"#3498db"}, opts)
+	all := []string{"#3498db"}
+	for _, n := range names {
+		all = append(all, got[n])
+	}
+	for i := range all {
+		for j := i + 1; j < len(all); j++ {
+			assert.GreaterOrEqual(t, OKLabDistance(all[i], all[j]), 0.08,
+				"%s vs %s too close", all[i], all[j])
+		}
+	}
+}
+
+func TestAllocateGroupColors_HonorsLightness(t *testing.T) {
+	got := AllocateGroupColors([]string{"Backend"}, nil, PaletteOptions{Lightness: 0.45})
+	assert.InDelta(t, 0.45, PerceptualLightness(got["Backend"]), 0.02)
+}
+
+func TestAllocateGroupColors_MinContrast(t *testing.T) {
+	bg := "#191724"
+	opts := PaletteOptions{Dark: true, Lightness: 0.25, Background: bg, MinContrast: 2.0}
+	got := AllocateGroupColors([]string{"Backend", "Docs"}, nil, opts)
+	for _, hex := range got {
+		assert.GreaterOrEqual(t, GetContrastRatio(hex, bg), 2.0, hex)
+	}
+}
+
+func TestAllocateGroupColors_ManyGroupsStillAssigned(t *testing.T) {
+	var names []string
+	for i := 0; i < 40; i++ {
+		names = append(names, string(rune('a'+i%26))+string(rune('A'+i/26)))
+	}
+	got := AllocateGroupColors(names, nil, PaletteOptions{})
+	for _, n := range names {
+		assert.True(t, isValidHex(got[n]), "%s -> %q", n, got[n])
+	}
+	assert.False(t, math.IsNaN(PerceptualLightness(got[names[0]])))
+}
@@ -21,6 +21,67 @@ type Config struct {
 	AutoTheme     AutoTheme     `yaml:"auto_theme"`
 	AI            AIConfig      `yaml:"ai"`
 	TabNames      TabNames      `yaml:"tab_names"`
+	Notifications Notifications `yaml:"notifications"`
+}
+
+// Notifications configures the sinks the daemon fires when a window's
+// indicators change (see the notification history in pkg/notifications):
+//
+//	notifications:
+//	  quiet_hours: {start: "22:00", end: "07:00"}
+//	  rate_limit_seconds: 30
+//	  sinks:
+//	    - type: command
+//	      on: [input, bell]
+//	      command: terminal-notifier -title {title} -message {message} -execute {focus}
+//	    - type: webhook
+//	      url: http://127.0.0.1:9000/tabby
+//	      groups: [Work]
+//	    - type: dbus
+//
+// Placeholders in a command are shell-quoted when substituted.
+type Notifications struct {
+	Sinks      []NotificationSink `yaml:"sinks"`
+	QuietHours QuietHours         `yaml:"quiet_hours"`
+	// RateLimitSeconds is the minimum gap between two notifications for the
+	// same window and kind (default 30; negative disables).
+	RateLimitSeconds int `yaml:"rate_limit_seconds"`
+	// MaxPerMinute caps notifications across all windows (default 10;
+	// negative disables).
+	MaxPerMinute int `yaml:"max_per_minute"`
+	// Toast shows a small popup inside tmux for the same events.
+	Toast Toast `yaml:"toast"`
+}
+
+// Toast configures the in-tmux toast popup (`tabby render toast`). Toasts
+// for the window an attached client is already looking at are skipped.
+type Toast struct {
+	Enabled  bool     `yaml:"enabled"`
+	On       []string `yaml:"on"`       // event kinds (default: input, bell, crash)
+	Position string   `yaml:"position"` // top-right (default), top-left, bottom-right, bottom-left
+	Duration int      `yaml:"duration"` // seconds before it dismisses itself (default 5)
+	Width    int      `yaml:"width"`    // columns (default 44)
+}
+
+// QuietHours suppresses every sink between Start and End ("HH:MM", local
+// time). A window that wraps midnight (22:00-07:00) is allowed.
+type QuietHours struct {
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+}
+
+// NotificationSink is one destination for indicator notifications.
+type NotificationSink struct {
+	Type string `yaml:"type"` // "command", "webhook" or "dbus"
+	// On lists the event kinds that fire this sink (default: input, bell,
+	// crash). Any kind recorded in the notification history is accepted.
+	On            []string `yaml:"on"`
+	Groups        []string `yaml:"groups"`         // only windows in these groups (empty: all)
+	ExcludeGroups []string `yaml:"exclude_groups"` // never windows in these groups
+	Command       string   `yaml:"command"`        // type: command — run with sh -c
+	URL           string   `yaml:"url"`            // type: webhook — receives a JSON POST
+	// IgnoreQuietHours lets an urgent sink fire during quiet hours.
+	IgnoreQuietHours bool `yaml:"ignore_quiet_hours"`
 }
 
 // TabNames configures how Tabby composes the sidebar tab label for a window.
@@ -36,6 +97,32 @@ type TabNames struct {
 // AIConfig holds settings for AI-tool integrations (e.g. Claude Code, OpenCode hooks).
 type AIConfig struct {
 	TabSummary AITabSummary `yaml:"tab_summary"`
+	AgentLog   AIAgentLog   `yaml:"agent_log"`
+	QuickReply QuickReply   `yaml:"quick_reply"`
+}
+
+// QuickReply configures the sidebar's reply button for agent panes waiting on
+// a permission prompt: the prompt's last lines are shown in a menu with one
+// item per reply, which sends its keys to the pane.
+type QuickReply struct {
+	Enabled *bool `yaml:"enabled"` // default true
+	Lines   int   `yaml:"lines"`   // prompt lines shown (default 8)
+	// Replies per pane command (claude, codex, ...); "default" covers the
+	// rest. Claude Code panes are matched as "claude" whatever their title.
+	Tools map[string][]QuickReplyOption `yaml:"tools"`
+}
+
+// QuickReplyOption is one reply: a menu label and the tmux key names sent by
+// send-keys (e.g. ["1"], ["y", "Enter"], ["Escape"]).
+type QuickReplyOption struct {
+	Label string   `yaml:"label"`
+	Keys  []string `yaml:"keys"`
+}
+
+// AIAgentLog controls the per-pane agent session timeline shown by
+// `tabby agent log` and "Agent Log..." in the window menu.
+type AIAgentLog struct {
+	Sessions int `yaml:"sessions"` // ended sessions kept per pane (default 5)
 }
 
 // AITabSummary controls the 2-3 word work summary shown after a tab's directory
@@ -83,6 +170,20 @@ type BusyDetection struct {
 	ExtraIdle   []string `yaml:"extra_idle"`   // Additional commands to treat as idle (not busy)
 	AITools     []string `yaml:"ai_tools"`     // Interactive AI tools (busy when active, input when idle)
 	IdleTimeout int      `yaml:"idle_timeout"` // Seconds of no output before AI tool shows as "input needed" (default: 10)
+
+	// OutputMatchers are checked in order against the last lines of an AI
+	// pane whenever it produces output; the first match sets the pane's
+	// state directly, ahead of the timer heuristics.
+	OutputMatchers []OutputMatcher `yaml:"output_matchers"`
+}
+
+// OutputMatcher is one busy_detection.output_matchers rule.
+type OutputMatcher struct {
+	Name    string `yaml:"name"`    // shown by `tabby debug match-pane`
+	Tool    string `yaml:"tool"`    // pane command it applies to ("claude" covers Claude Code's version title); empty or "*" = any AI tool
+	Pattern string `yaml:"pattern"` // Go regexp, matched against the lines joined with newlines
+	State   string `yaml:"state"`   // input | busy | idle
+	Lines   int    `yaml:"lines"`   // last non-blank lines searched (default 15)
 }
 
 type TerminalTitle struct {
@@ -99,6 +200,140 @@ type Widgets struct {
 	Claude  ClaudeWidget  `yaml:"claude"`
 
 	TeamClaude TeamClaudeWidget `yaml:"teamclaude"`
+	Mirror     MirrorWidget     `yaml:"mirror"`
+	Focus      FocusWidget      `yaml:"focus"`
+	Calendar   CalendarWidget   `yaml:"calendar"`
+	Top        TopWidget        `yaml:"top"`
+	Todo       TodoWidget       `yaml:"todo"`
+
+	Custom []CustomWidget `yaml:"custom"` // Widgets that show a command's output
+}
+
+// CalendarWidget lists the next events from local .ics files or directories
+// of them (e.g. vdirsyncer output). Nothing is fetched over the network.
+type CalendarWidget struct {
+	Enabled        bool     `yaml:"enabled"`
+	Paths          []string `yaml:"paths"`           // .ics files or directories, ~ expanded
+	Count          int      `yaml:"count"`           // Events shown (default 2)
+	Lookahead      int      `yaml:"lookahead"`       // Hours ahead to look (default 24)
+	WarnMinutes    int      `yaml:"warn_minutes"`    // Countdown turns WarnFg this close to the start (default 5)
+	WarnFg         string   `yaml:"warn_fg"`         // Countdown color near the start (default red)
+	Toast          bool     `yaml:"toast"`           // Post a notification when an event starts
+	AllDay         bool     `yaml:"all_day"`         // Also list all-day events
+	UpdateInterval int      `yaml:"update_interval"` // Seconds between checks for changed files (default 60)
+
+	Position   string `yaml:"position"`       // top | bottom
+	Pin        bool   `yaml:"pin"`            // Pin to position
+	Priority   int    `yaml:"priority"`       // Order among widgets
+	Fg         string `yaml:"fg"`             // Text color
+	Bg         string `yaml:"bg"`             // Background color
+	Divider    string `yaml:"divider"`        // Divider line above widget
+	DividerFg  string `yaml:"divider_fg"`     // Divider color
+	PaddingTop int    `yaml:"padding_top"`    // Blank lines above content
+	PaddingBot int    `yaml:"padding_bottom"` // Blank lines below content
+	MarginTop  int    `yaml:"margin_top"`     // Lines above top divider
+	MarginBot  int    `yaml:"margin_bottom"`  // Lines below bottom divider
+}
+
+// TopWidget lists the windows whose processes use the most CPU or memory.
+// Usage is the sum over each window's panes and all their descendants, from
+// one ps run per refresh.
+type TopWidget struct {
+	Enabled         bool   `yaml:"enabled"`
+	Count           int    `yaml:"count"`             // Windows listed (default 5)
+	Sort            string `yaml:"sort"`              // cpu | rss (default cpu)
+	ShowWindowUsage bool   `yaml:"show_window_usage"` // Show each window's CPU% and RSS after its tab name
+	UpdateInterval  int    `yaml:"update_interval"`   // Seconds between samples (default 3)
+
+	Position   string `yaml:"position"`       // top | bottom
+	Pin        bool   `yaml:"pin"`            // Pin to position
+	Priority   int    `yaml:"priority"`       // Order among widgets
+	Fg         string `yaml:"fg"`             // Text color
+	Bg         string `yaml:"bg"`             // Background color
+	Divider    string `yaml:"divider"`        // Divider line above widget
+	DividerFg  string `yaml:"divider_fg"`     // Divider color
+	PaddingTop int    `yaml:"padding_top"`    // Blank lines above content
+	PaddingBot int    `yaml:"padding_bottom"` // Blank lines below content
+	MarginTop  int    `yaml:"margin_top"`     // Lines above top divider
+	MarginBot  int    `yaml:"margin_bottom"`  // Lines below bottom divider
+}
+
+// TodoWidget is a checklist backed by a markdown task file ("- [ ] item"),
+// shared with whatever editor the user keeps it open in.
+type TodoWidget struct {
+	Enabled        bool              `yaml:"enabled"`
+	Path           string            `yaml:"path"`            // Task file, ~ expanded (default todo.md in the config dir)
+	Groups         map[string]string `yaml:"groups"`          // Group name -> task file used while a window of that group is active
+	Count          int               `yaml:"count"`           // Open items shown (default 8)
+	UpdateInterval int               `yaml:"update_interval"` // Seconds between checks for edits (default 2)
+
+	Position   string `yaml:"position"`       // top | bottom
+	Pin        bool   `yaml:"pin"`            // Pin to position
+	Priority   int    `yaml:"priority"`       // Order among widgets
+	Fg         string `yaml:"fg"`             // Text color
+	Bg         string `yaml:"bg"`             // Background color
+	Divider    string `yaml:"divider"`        // Divider line above widget
+	DividerFg  string `yaml:"divider_fg"`     // Divider color
+	PaddingTop int    `yaml:"padding_top"`    // Blank lines above content
+	PaddingBot int    `yaml:"padding_bottom"` // Blank lines below content
+	MarginTop  int    `yaml:"margin_top"`     // Lines above top divider
+	MarginBot  int    `yaml:"margin_bottom"`  // Lines below bottom divider
+}
+
+// FocusWidget is a pomodoro timer (pkg/focus) with start/pause/reset buttons,
+// also driven by `tabby ctl focus`. While a work period runs, notification
+// sinks and toasts are held back for every event kind not in Allow (events
+// are still recorded in the history) and the pet keeps its thoughts to
+// itself.
+type FocusWidget struct {
+	Enabled        bool     `yaml:"enabled"`
+	Work           int      `yaml:"work"`             // Work period in minutes (default 25)
+	Break          int      `yaml:"break"`            // Short break in minutes (default 5)
+	LongBreak      int      `yaml:"long_break"`       // Long break in minutes (default 15)
+	LongBreakEvery int      `yaml:"long_break_every"` // Work periods per long break (default 4, -1 never)
+	AutoStartWork  bool     `yaml:"auto_start_work"`  // Start the next work period when a break ends
+	Bell           *bool    `yaml:"bell"`             // Ring the terminal bell on phase change (default true)
+	Toast          *bool    `yaml:"toast"`            // Post a notification on phase change (default true)
+	DoNotDisturb   *bool    `yaml:"do_not_disturb"`   // Hold back notifications while focusing (default true)
+	Allow          []string `yaml:"allow"`            // Event kinds delivered anyway (default: crash, notify)
+	QuietPet       *bool    `yaml:"quiet_pet"`        // Hide the pet's thoughts while focusing (default true)
+
+	Position   string `yaml:"position"`       // top | bottom
+	Pin        bool   `yaml:"pin"`            // Pin to position
+	Priority   int    `yaml:"priority"`       // Order among widgets
+	Fg         string `yaml:"fg"`             // Text color
+	Bg         string `yaml:"bg"`             // Background color
+	WorkFg     string `yaml:"work_fg"`        // Countdown color while working
+	BreakFg    string `yaml:"break_fg"`       // Countdown color on a break
+	Divider    string `yaml:"divider"`        // Divider line above widget
+	DividerFg  string `yaml:"divider_fg"`     // Divider color
+	PaddingTop int    `yaml:"padding_top"`    // Blank lines above content
+	PaddingBot int    `yaml:"padding_bottom"` // Blank lines below content
+	MarginTop  int    `yaml:"margin_top"`     // Lines above top divider
+	MarginBot  int    `yaml:"margin_bottom"`  // Lines below bottom divider
+}
+
+// MirrorWidget shows the last lines of a pane pinned from its context menu
+// ("Mirror in Sidebar"), captured about once a second. Clicking it jumps to
+// the pane.
+type MirrorWidget struct {
+	Enabled        *bool `yaml:"enabled"`         // Offer pinning (default true)
+	Lines          int   `yaml:"lines"`           // Pane lines shown (default 8)
+	Wrap           bool  `yaml:"wrap"`            // Wrap long lines instead of truncating
+	Colors         *bool `yaml:"colors"`          // Keep the pane's colors (default true)
+	UpdateInterval int   `yaml:"update_interval"` // Seconds between captures (default 1)
+
+	Position   string `yaml:"position"`       // top | bottom
+	Pin        bool   `yaml:"pin"`            // Pin to position
+	Priority   int    `yaml:"priority"`       // Order among widgets
+	Fg         string `yaml:"fg"`             // Header color
+	Bg         string `yaml:"bg"`             // Background color
+	Divider    string `yaml:"divider"`        // Divider line above widget
+	DividerFg  string `yaml:"divider_fg"`     // Divider color
+	PaddingTop int    `yaml:"padding_top"`    // Blank lines above content
+	PaddingBot int    `yaml:"padding_bottom"` // Blank lines below content
+	MarginTop  int    `yaml:"margin_top"`     // Lines above top divider
+	MarginBot  int    `yaml:"margin_bottom"`  // Lines below bottom divider
 }
 
 // StatsWidget shows system stats (CPU, memory, battery)
@@ -130,26 +365,30 @@ type StatsWidget struct {
 // ClaudeWidget shows Claude Code API usage stats
 type ClaudeWidget struct {
 	Enabled        bool   `yaml:"enabled"`
-	Style          string `yaml:"style"`           // nerd | emoji | ascii | minimal
-	ShowToday      bool   `yaml:"show_today"`      // Show today's cost (default: true)
-	ShowWeek       bool   `yaml:"show_week"`       // Show this week's cost
-	ShowMonth      bool   `yaml:"show_month"`      // Show this month's cost
-	ShowTotal      bool   `yaml:"show_total"`      // Show all-time cost
-	ShowMessages   bool   `yaml:"show_messages"`   // Show message count
-	DBPath         string `yaml:"db_path"`         // Custom path to Claude DB (default: ~/.claude/__store.db)
-	UpdateInterval int    `yaml:"update_interval"` // Seconds between updates (default: 30)
-	Position       string `yaml:"position"`        // top | bottom
-	Pin            bool   `yaml:"pin"`             // Pin to position
-	Priority       int    `yaml:"priority"`        // Order among widgets
-	Fg             string `yaml:"fg"`              // Text color
-	Bg             string `yaml:"bg"`              // Background color
-	CostFg         string `yaml:"cost_fg"`         // Cost value color
-	Divider        string `yaml:"divider"`         // Divider line above widget
-	DividerFg      string `yaml:"divider_fg"`      // Divider color
-	PaddingTop     int    `yaml:"padding_top"`     // Blank lines above content
-	PaddingBot     int    `yaml:"padding_bottom"`  // Blank lines below content
-	MarginTop      int    `yaml:"margin_top"`      // Lines above top divider
-	MarginBot      int    `yaml:"margin_bottom"`   // Lines below bottom divider
+	Style          string `yaml:"style"`            // nerd | emoji | ascii | minimal
+	ShowToday      bool   `yaml:"show_today"`       // Show today's cost (default: true)
+	ShowWeek       bool   `yaml:"show_week"`        // Show this week's cost
+	ShowMonth      bool   `yaml:"show_month"`       // Show this month's cost
+	ShowTotal      bool   `yaml:"show_total"`       // Show all-time cost
+	ShowMessages   bool   `yaml:"show_messages"`    // Show message count
+	DBPath         string `yaml:"db_path"`          // Legacy Claude DB, used only when no transcripts are found (default: ~/.claude/__store.db)
+	TranscriptsDir string `yaml:"transcripts_dir"`  // Claude Code JSONL transcripts (default: ~/.claude/projects)
+	ShowWindowCost bool   `yaml:"show_window_cost"` // Show each window's session cost after its tab name
+	ShowBreakdown  bool   `yaml:"show_breakdown"`   // Show cost per day and group
+	BreakdownDays  int    `yaml:"breakdown_days"`   // Days in the breakdown (default: 3)
+	UpdateInterval int    `yaml:"update_interval"`  // Seconds between updates (default: 30)
+	Position       string `yaml:"position"`         // top | bottom
+	Pin            bool   `yaml:"pin"`              // Pin to position
+	Priority       int    `yaml:"priority"`         // Order among widgets
+	Fg             string `yaml:"fg"`               // Text color
+	Bg             string `yaml:"bg"`               // Background color
+	CostFg         string `yaml:"cost_fg"`          // Cost value color
+	Divider        string `yaml:"divider"`          // Divider line above widget
+	DividerFg      string `yaml:"divider_fg"`       // Divider color
+	PaddingTop     int    `yaml:"padding_top"`      // Blank lines above content
+	PaddingBot     int    `yaml:"padding_bottom"`   // Blank lines below content
+	MarginTop      int    `yaml:"margin_top"`       // Lines above top divider
+	MarginBot      int    `yaml:"margin_bottom"`    // Lines below bottom divider
 }
 
 // TeamClaudeWidget shows per-account Claude quota left, pulled from a
@@ -204,28 +443,57 @@ type SessionWidget struct {
 
 // GitWidget shows git repository status
 type GitWidget struct {
-	Enabled        bool   `yaml:"enabled"`
-	Style          string `yaml:"style"`           // nerd | emoji | ascii | minimal
-	ShowCounts     bool   `yaml:"show_counts"`     // Show file counts (+3 -2)
-	ShowInsertions bool   `yaml:"show_insertions"` // Show line changes
-	ShowStash      bool   `yaml:"show_stash"`      // Show stash count
-	UpdateInterval int    `yaml:"update_interval"` // Seconds between updates (default: 5)
-	Position       string `yaml:"position"`        // top | bottom
-	Pin            bool   `yaml:"pin"`             // Pin to position
-	Priority       int    `yaml:"priority"`        // Order among widgets
-	Fg             string `yaml:"fg"`              // Text color
-	Bg             string `yaml:"bg"`              // Background color
-	BranchFg       string `yaml:"branch_fg"`       // Branch name color
-	CleanFg        string `yaml:"clean_fg"`        // Clean status color
-	DirtyFg        string `yaml:"dirty_fg"`        // Dirty status color
-	AheadFg        string `yaml:"ahead_fg"`        // Ahead indicator color
-	BehindFg       string `yaml:"behind_fg"`       // Behind indicator color
-	Divider        string `yaml:"divider"`         // Divider line above widget
-	DividerFg      string `yaml:"divider_fg"`      // Divider color
-	PaddingTop     int    `yaml:"padding_top"`     // Blank lines above content
-	PaddingBot     int    `yaml:"padding_bottom"`  // Blank lines below content
-	MarginTop      int    `yaml:"margin_top"`      // Lines above top divider
-	MarginBot      int    `yaml:"margin_bottom"`   // Lines below bottom divider
+	Enabled          bool   `yaml:"enabled"`
+	Style            string `yaml:"style"`              // nerd | emoji | ascii | minimal
+	ShowCounts       bool   `yaml:"show_counts"`        // Show file counts (+3 -2)
+	ShowInsertions   bool   `yaml:"show_insertions"`    // Show line changes
+	ShowStash        bool   `yaml:"show_stash"`         // Show stash count
+	ShowWindowStatus bool   `yaml:"show_window_status"` // Show each window's branch, dirty mark and ahead/behind after its tab name
+	UpdateInterval   int    `yaml:"update_interval"`    // Seconds between updates (default: 5)
+	Position         string `yaml:"position"`           // top | bottom
+	Pin              bool   `yaml:"pin"`                // Pin to position
+	Priority         int    `yaml:"priority"`           // Order among widgets
+	Fg               string `yaml:"fg"`                 // Text color
+	Bg               string `yaml:"bg"`                 // Background color
+	BranchFg         string `yaml:"branch_fg"`          // Branch name color
+	CleanFg          string `yaml:"clean_fg"`           // Clean status color
+	DirtyFg          string `yaml:"dirty_fg"`           // Dirty status color
+	AheadFg          string `yaml:"ahead_fg"`           // Ahead indicator color
+	BehindFg         string `yaml:"behind_fg"`          // Behind indicator color
+	Divider          string `yaml:"divider"`            // Divider line above widget
+	DividerFg        string `yaml:"divider_fg"`         // Divider color
+	PaddingTop       int    `yaml:"padding_top"`        // Blank lines above content
+	PaddingBot       int    `yaml:"padding_bottom"`     // Blank lines below content
+	MarginTop        int    `yaml:"margin_top"`         // Lines above top divider
+	MarginBot        int    `yaml:"margin_bottom"`      // Lines below bottom divider
+}
+
+// CustomWidget shows the output of a user command, run with sh -c in the
+// active pane's working directory. Output is plain text, ANSI, or JSON:
+//
+//	{"lines": [{"text": "...", "fg": "#hex", "bg": "#hex", "bold": true, "action": "cmd"}]}
+//
+// where a line's action is a shell command run when the line is clicked.
+type CustomWidget struct {
+	Name       string   `yaml:"name"`              // Identifies the widget (default custom-N)
+	Enabled    *bool    `yaml:"enabled,omitempty"` // Default true
+	Command    string   `yaml:"command"`           // Run with sh -c
+	Interval   int      `yaml:"interval"`          // Seconds between runs (default 30; 0 with on: runs on events only)
+	On         []string `yaml:"on"`                // Also run on: window_change | cwd_change
+	Timeout    int      `yaml:"timeout"`           // Seconds before the command is killed (default 5)
+	Format     string   `yaml:"format"`            // text | ansi | json (default text)
+	MaxLines   int      `yaml:"max_lines"`         // Lines shown (default 10)
+	Position   string   `yaml:"position"`          // top | bottom
+	Pin        bool     `yaml:"pin"`               // Pin to position
+	Priority   int      `yaml:"priority"`          // Order among widgets
+	Fg         string   `yaml:"fg"`                // Text color (text and json formats)
+	Bg         string   `yaml:"bg"`                // Background color
+	Divider    string   `yaml:"divider"`           // Divider line above widget
+	DividerFg  string   `yaml:"divider_fg"`        // Divider color
+	PaddingTop int      `yaml:"padding_top"`       // Blank lines above content
+	PaddingBot int      `yaml:"padding_bottom"`    // Blank lines below content
+	MarginTop  int      `yaml:"margin_top"`        // Lines above top divider
+	MarginBot  int      `yaml:"margin_bottom"`     // Lines below bottom divider
 }
 
 // PetWidget configures the virtual pet (cat, dog, etc.)
@@ -419,6 +687,28 @@ type Sidebar struct {
 	SSHIcon              string           `yaml:"ssh_icon"`               // When set, remote tabs render on ONE line prefixed with this glyph instead of a host-name row above the tab name (e.g. a nerdfont ""). Empty = legacy two-line host/name.
 	RemoteHosts          []RemoteHostRule `yaml:"remote_hosts"`           // Color/icon a tab by its ssh/mosh DESTINATION host — matched locally from the ssh command line, so NO remote-cwd hook is needed on the host. First matching rule wins.
 	NewTabInheritSSH     *bool            `yaml:"new_tab_inherit_ssh"`    // When a new tab is opened from a tab that's currently in an ssh/mosh session, re-run the same connection in the new tab (so it lands on the same host and, via remote_hosts, the same group/color). Default true; set false for a plain local shell.
+	ContextBadges        ContextBadges    `yaml:"context_badges"`         // Per-tab badge with the kube context/namespace, AWS profile and chosen env vars of the window's active pane, colored by rules.
+}
+
+// ContextBadges shows which cluster or account a window's active pane talks
+// to, read from the pane's environment (Linux /proc) and kubectl config. A
+// rule whose glob matches any badge value colors the tab's left edge (and,
+// with pane_border, the window's pane borders) and can prefix an icon.
+type ContextBadges struct {
+	Enabled bool               `yaml:"enabled"`
+	Kube    *bool              `yaml:"kube"` // Kube context/namespace from KUBECONFIG or ~/.kube/config (default true)
+	AWS     *bool              `yaml:"aws"`  // AWS_PROFILE / AWS_VAULT (default true)
+	Env     []string           `yaml:"env"`  // Other env vars whose values are shown, e.g. TF_WORKSPACE
+	Rules   []ContextBadgeRule `yaml:"rules"`
+}
+
+// ContextBadgeRule colors windows whose badge matches. The first rule to
+// match any value wins.
+type ContextBadgeRule struct {
+	Match      string `yaml:"match"`       // glob (filepath.Match) against each value, case-insensitive, e.g. "*prod*"
+	Color      string `yaml:"color"`       // color of the tab's left edge (and pane borders)
+	Icon       string `yaml:"icon"`        // shown before the badge
+	PaneBorder bool   `yaml:"pane_border"` // also color the window's pane borders (native borders)
 }
 
 // RemoteHostRule colors/marks a tab based on the ssh (or mosh) destination the
@@ -505,12 +795,50 @@ type Bindings struct {
 }
 
 type Indicators struct {
-	Activity Indicator `yaml:"activity"`
-	Bell     Indicator `yaml:"bell"`
-	Silence  Indicator `yaml:"silence"`
-	Last     Indicator `yaml:"last"`
-	Busy     Indicator `yaml:"busy"`  // Foreground process running (auto-detected)
-	Input    Indicator `yaml:"input"` // Waiting for user input (e.g., Claude needs response)
+	Activity Indicator       `yaml:"activity"`
+	Bell     Indicator       `yaml:"bell"`
+	Silence  Indicator       `yaml:"silence"`
+	Last     Indicator       `yaml:"last"`
+	Busy     Indicator       `yaml:"busy"`    // Foreground process running (auto-detected)
+	Input    Indicator       `yaml:"input"`   // Waiting for user input (e.g., Claude needs response)
+	Agent    AgentIndicators `yaml:"agent"`   // Per-state icons for `tabby hook agent-state`
+	Command  CmdIndicators   `yaml:"command"` // Finished long-running commands (scripts/tabby-cmd.sh)
+	Crash    CrashIndicator  `yaml:"crash"`   // Panes whose process died
+}
+
+// CrashIndicator marks windows with a pane whose process died and saves the
+// pane's last lines to the state dir before it is closed.
+type CrashIndicator struct {
+	Enabled    *bool  `yaml:"enabled,omitempty"` // Detect dead panes (default true)
+	Icon       string `yaml:"icon"`
+	Color      string `yaml:"color"`
+	Lines      int    `yaml:"lines"`                 // Scrollback lines saved per crash (default 200)
+	KeepFailed *bool  `yaml:"keep_failed,omitempty"` // Set remain-on-exit to "failed" so a pane exiting non-zero stays to be captured (default false)
+}
+
+// CmdIndicators configures long-running command tracking for shell panes
+// with the tabby-cmd shell integration. Only Icon and Color of Done and
+// Failed are used.
+type CmdIndicators struct {
+	Enabled      *bool     `yaml:"enabled,omitempty"`        // Track commands (default true)
+	MinDuration  int       `yaml:"min_duration"`             // Seconds a command must run to be recorded (default 10)
+	ShowInHeader *bool     `yaml:"show_in_header,omitempty"` // Last command and duration in the pane header (default true)
+	Done         Indicator `yaml:"done"`
+	Failed       Indicator `yaml:"failed"`
+}
+
+// AgentIndicators styles the AI agent states reported by
+// `tabby hook agent-state`. Only Icon, Color and Frames of each state are
+// used; thinking with no icon animates with the busy frames.
+type AgentIndicators struct {
+	Enabled    *bool     `yaml:"enabled,omitempty"`   // Per-state icons (default true); false falls back to busy/input
+	ShowTime   *bool     `yaml:"show_time,omitempty"` // Time-in-state after the tab name (default true)
+	Thinking   Indicator `yaml:"thinking"`
+	Tool       Indicator `yaml:"tool"`
+	Permission Indicator `yaml:"permission"`
+	Prompt     Indicator `yaml:"prompt"`
+	Error      Indicator `yaml:"error"`
+	Done       Indicator `yaml:"done"`
 }
 
 type Indicator struct {
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/brendandebeasi/tabby/pkg/colors"
 	"gopkg.in/yaml.v3"
@@ -221,6 +222,25 @@ func DefaultGroupWithIndex(name string, groupIndex int) Group {
 	}
 }
 
+// NewGroup returns a new group named name for adding to groups. Its color
+// comes from colors.AllocateGroupColors, keyed by the name and kept distinct
+// from every existing group, so it is the color the sidebar gives a group
+// with no bg configured.
+func NewGroup(name string, groups []Group, opts colors.PaletteOptions) Group {
+	var names, reserved []string
+	for _, g := range groups {
+		switch bg := strings.TrimSpace(g.Theme.Bg); {
+		case bg == "":
+			names = append(names, g.Name)
+		case !strings.EqualFold(bg, "transparent"):
+			reserved = append(reserved, bg)
+		}
+	}
+	group := DefaultGroupWithIndex(name, 0)
+	group.Theme.Bg = colors.AllocateGroupColors(append(names, name), reserved, opts)[name]
+	return group
+}
+
 func applyDefaults(cfg *Config) {
 	// Top-level layout defaults
 	if cfg.Position == "" {
@@ -270,10 +290,42 @@ func applyDefaults(cfg *Config) {
 	if cfg.BusyDetection.IdleTimeout == 0 {
 		cfg.BusyDetection.IdleTimeout = 10
 	}
+	if cfg.BusyDetection.OutputMatchers == nil {
+		cfg.BusyDetection.OutputMatchers = defaultOutputMatchers()
+	}
+
+	// Notification sink defaults
+	if cfg.Notifications.RateLimitSeconds == 0 {
+		cfg.Notifications.RateLimitSeconds = 30
+	}
+	if cfg.Notifications.MaxPerMinute == 0 {
+		cfg.Notifications.MaxPerMinute = 10
+	}
+	if len(cfg.Notifications.Toast.On) == 0 {
+		cfg.Notifications.Toast.On = []string{"input", "bell", "crash"}
+	}
+	if cfg.Notifications.Toast.Position == "" {
+		cfg.Notifications.Toast.Position = "top-right"
+	}
+	if cfg.Notifications.Toast.Duration == 0 {
+		cfg.Notifications.Toast.Duration = 5
+	}
+	if cfg.Notifications.Toast.Width == 0 {
+		cfg.Notifications.Toast.Width = 44
+	}
+	for i := range cfg.Notifications.Sinks {
+		if len(cfg.Notifications.Sinks[i].On) == 0 {
+			cfg.Notifications.Sinks[i].On = []string{"input", "bell", "crash"}
+		}
+	}
 
 	if cfg.AI.TabSummary.MaxWords == 0 {
 		cfg.AI.TabSummary.MaxWords = 3
 	}
+	if cfg.AI.AgentLog.Sessions <= 0 {
+		cfg.AI.AgentLog.Sessions = 5
+	}
+	applyQuickReplyDefaults(&cfg.AI.QuickReply)
 
 	// AutoTheme defaults
 	if cfg.AutoTheme.Mode == "" {
@@ -317,6 +369,28 @@ func applyDefaults(cfg *Config) {
 		cfg.Indicators.Last.Icon = "-"
 		cfg.Indicators.Last.Color = "#3498db"
 	}
+	applyAgentIndicatorDefaults(&cfg.Indicators)
+	applyCmdIndicatorDefaults(&cfg.Indicators.Command)
+	if cfg.Indicators.Crash.Icon == "" {
+		cfg.Indicators.Crash.Icon = "✖"
+	}
+	if cfg.Indicators.Crash.Color == "" {
+		cfg.Indicators.Crash.Color = "#e74c3c"
+	}
+	if cfg.Indicators.Crash.Lines <= 0 {
+		cfg.Indicators.Crash.Lines = 200
+	}
+	applyCustomWidgetDefaults(cfg.Widgets.Custom)
+	if cfg.Widgets.Mirror.Lines <= 0 {
+		cfg.Widgets.Mirror.Lines = 8
+	}
+	if cfg.Widgets.Mirror.UpdateInterval <= 0 {
+		cfg.Widgets.Mirror.UpdateInterval = 1
+	}
+	applyFocusDefaults(&cfg.Widgets.Focus)
+	applyCalendarDefaults(&cfg.Widgets.Calendar)
+	applyTopDefaults(&cfg.Widgets.Top)
+	applyTodoDefaults(&cfg.Widgets.Todo)
 	if cfg.PaneHeader.ResizeGrowIcon == "" {
 		cfg.PaneHeader.ResizeGrowIcon = ">"
 	}
@@ -516,3 +590,190 @@ func applyIconStyleDefaults(cfg *Config) {
 		cfg.Widgets.Git.Style = style
 	}
 }
+
+// applyAgentIndicatorDefaults fills unset agent-state icons and colors. The
+// thinking state keeps an empty icon so it animates with the busy frames.
+func applyAgentIndicatorDefaults(ind *Indicators) {
+	a := &ind.Agent
+	fill := func(i *Indicator, icon, color string) {
+		if i.Icon == "" && len(i.Frames) == 0 {
+			i.Icon = icon
+		}
+		if i.Color == "" {
+			i.Color = color
+		}
+	}
+	busyColor := ind.Busy.Color
+	if busyColor == "" {
+		busyColor = "#e0af68"
+	}
+	inputColor := ind.Input.Color
+	if inputColor == "" {
+		inputColor = "#bb9af7"
+	}
+	if a.Thinking.Color == "" {
+		a.Thinking.Color = busyColor
+	}
+	fill(&a.Tool, "⚙", "#7aa2f7")
+	fill(&a.Permission, "!", "#ff9e64")
+	fill(&a.Prompt, "?", inputColor)
+	fill(&a.Error, "✗", "#f7768e")
+	fill(&a.Done, "✓", "#6bcb77")
+}
+
+// applyCmdIndicatorDefaults fills the long-command threshold and the
+// done/failed marks.
+func applyCmdIndicatorDefaults(c *CmdIndicators) {
+	if c.MinDuration <= 0 {
+		c.MinDuration = 10
+	}
+	if c.Done.Icon == "" {
+		c.Done.Icon = "✓"
+	}
+	if c.Done.Color == "" {
+		c.Done.Color = "#6bcb77"
+	}
+	if c.Failed.Icon == "" {
+		c.Failed.Icon = "✗"
+	}
+	if c.Failed.Color == "" {
+		c.Failed.Color = "#f7768e"
+	}
+}
+
+// applyCustomWidgetDefaults names unnamed custom widgets and fills in their
+// run cadence, timeout, format and line limit. A widget with on: events and
+// no interval runs on those events only.
+func applyCustomWidgetDefaults(widgets []CustomWidget) {
+	for i := range widgets {
+		w := &widgets[i]
+		if w.Name == "" {
+			w.Name = fmt.Sprintf("custom-%d", i+1)
+		}
+		if w.Interval < 0 {
+			w.Interval = 0
+		}
+		if w.Interval == 0 && len(w.On) == 0 {
+			w.Interval = 30
+		}
+		if w.Timeout <= 0 {
+			w.Timeout = 5
+		}
+		if w.Format == "" {
+			w.Format = "text"
+		}
+		if w.MaxLines <= 0 {
+			w.MaxLines = 10
+		}
+	}
+}
+
+// applyCalendarDefaults fills in how many events to list, how far ahead and
+// when the countdown turns red.
+func applyCalendarDefaults(cal *CalendarWidget) {
+	if cal.Count <= 0 {
+		cal.Count = 2
+	}
+	if cal.Lookahead <= 0 {
+		cal.Lookahead = 24
+	}
+	if cal.WarnMinutes <= 0 {
+		cal.WarnMinutes = 5
+	}
+	if cal.WarnFg == "" {
+		cal.WarnFg = "#f7768e"
+	}
+	if cal.UpdateInterval <= 0 {
+		cal.UpdateInterval = 60
+	}
+}
+
+// applyFocusDefaults fills in the pomodoro lengths and the event kinds that
+// get through while focusing.
+func applyFocusDefaults(f *FocusWidget) {
+	if f.Work <= 0 {
+		f.Work = 25
+	}
+	if f.Break <= 0 {
+		f.Break = 5
+	}
+	if f.LongBreak <= 0 {
+		f.LongBreak = 15
+	}
+	if f.LongBreakEvery == 0 {
+		f.LongBreakEvery = 4
+	}
+	if f.Allow == nil {
+		f.Allow = []string{"crash", "notify"}
+	}
+}
+
+// applyTopDefaults fills in how many windows to list, what to sort them by
+// and how often to sample.
+func applyTopDefaults(top *TopWidget) {
+	if top.Count <= 0 {
+		top.Count = 5
+	}
+	top.Sort = strings.ToLower(top.Sort)
+	if top.Sort != "rss" {
+		top.Sort = "cpu"
+	}
+	if top.UpdateInterval <= 0 {
+		top.UpdateInterval = 3
+	}
+}
+
+// applyTodoDefaults fills in how many items to list and how often to check
+// the task file for changes.
+func applyTodoDefaults(todo *TodoWidget) {
+	if todo.Count <= 0 {
+		todo.Count = 8
+	}
+	if todo.UpdateInterval <= 0 {
+		todo.UpdateInterval = 2
+	}
+}
+
+// applyQuickReplyDefaults fills in the prompt length and the replies for the
+// agents tabby knows, keeping any tool the user configured.
+func applyQuickReplyDefaults(q *QuickReply) {
+	if q.Lines <= 0 {
+		q.Lines = 8
+	}
+	if q.Tools == nil {
+		q.Tools = make(map[string][]QuickReplyOption)
+	}
+	defaults := map[string][]QuickReplyOption{
+		// Claude Code's permission prompt: numbered choices, Esc declines.
+		"claude": {
+			{Label: "Yes", Keys: []string{"1"}},
+			{Label: "Yes, don't ask again", Keys: []string{"2"}},
+			{Label: "No", Keys: []string{"Escape"}},
+		},
+		"codex": {
+			{Label: "Yes", Keys: []string{"y"}},
+			{Label: "Yes, always", Keys: []string{"a"}},
+			{Label: "No", Keys: []string{"Escape"}},
+		},
+		"default": {
+			{Label: "Yes", Keys: []string{"y", "Enter"}},
+			{Label: "No", Keys: []string{"n", "Enter"}},
+		},
+	}
+	for tool, replies := range defaults {
+		if _, ok := q.Tools[tool]; !ok {
+			q.Tools[tool] = replies
+		}
+	}
+}
+
+// defaultOutputMatchers are the busy_detection.output_matchers used when the
+// config sets none (an explicit empty list turns them off).
+func defaultOutputMatchers() []OutputMatcher {
+	return []OutputMatcher{
+		{Name: "claude-permission", Tool: "claude", State: "input",
+			Pattern: `Do you want to (proceed|make this edit|create|allow)`},
+		{Name: "claude-working", Tool: "claude", State: "busy",
+			Pattern: `esc to interrupt`},
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/brendandebeasi/tabby/pkg/colors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -227,6 +228,69 @@ widgets:
 	assert.Equal(t, 72, cfg.Widgets.Pet.QA.ExpireHours)
 }
 
+func TestApplyDefaults_Notifications(t *testing.T) {
+	cfg, err := loadYAML(t, `
+notifications:
+  max_per_minute: -1
+  sinks:
+    - type: dbus
+    - type: command
+      on: [done]
+      command: "true"
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, 30, cfg.Notifications.RateLimitSeconds)
+	assert.Equal(t, -1, cfg.Notifications.MaxPerMinute, "negative disables the cap and is kept")
+	assert.Equal(t, []string{"input", "bell", "crash"}, cfg.Notifications.Sinks[0].On)
+	assert.Equal(t, []string{"done"}, cfg.Notifications.Sinks[1].On)
+}
+
+func TestApplyDefaults_AgentIndicators(t *testing.T) {
+	cfg, err := loadYAML(t, `
+indicators:
+  busy:
+    color: "#ff0000"
+  agent:
+    error:
+      icon: "E"
+    done:
+      frames: ["+", "x"]
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a := cfg.Indicators.Agent
+	assert.Empty(t, a.Thinking.Icon, "thinking animates with the busy frames")
+	assert.Equal(t, "#ff0000", a.Thinking.Color)
+	assert.Equal(t, "E", a.Error.Icon)
+	assert.NotEmpty(t, a.Error.Color)
+	assert.Empty(t, a.Done.Icon, "frames win over the default icon")
+	assert.Equal(t, "!", a.Permission.Icon)
+}
+
+func TestApplyDefaults_QuickReply(t *testing.T) {
+	cfg, err := loadYAML(t, `
+ai:
+  quick_reply:
+    tools:
+      claude:
+        - label: "Approve"
+          keys: ["1"]
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q := cfg.AI.QuickReply
+	assert.Equal(t, 8, q.Lines)
+	if assert.Len(t, q.Tools["claude"], 1, "a configured tool keeps its own replies") {
+		assert.Equal(t, "Approve", q.Tools["claude"][0].Label)
+	}
+	assert.NotEmpty(t, q.Tools["codex"])
+	assert.NotEmpty(t, q.Tools["default"])
+}
+
 func TestApplyDefaults_UserValuesNotOverwritten(t *testing.T) {
 	cfg, err := loadYAML(t, `
 indicators:
@@ -517,6 +581,24 @@ func TestDefaultGroupWithIndex(t *testing.T) {
 	})
 }
 
+func TestNewGroup_AllocatesByName(t *testing.T) {
+	existing := []Group{
+		{Name: "Work", Theme: Theme{Bg: "#e74c3c"}},
+		{Name: "Play"},
+		{Name: "Default", Theme: Theme{Bg: "transparent"}},
+	}
+	opts := colors.PaletteOptions{Dark: true}
+	g := NewGroup("Backend", existing, opts)
+	assert.Equal(t, "Backend", g.Name)
+	assert.Contains(t, g.Pattern, "Backend")
+
+	// The same color the sidebar allocates for an uncolored group.
+	want := colors.AllocateGroupColors([]string{"Play", "Backend"}, []string{"#e74c3c"}, opts)["Backend"]
+	assert.Equal(t, want, g.Theme.Bg)
+	assert.Equal(t, g.Theme.Bg, NewGroup("Backend", existing, opts).Theme.Bg, "stable")
+	assert.Equal(t, g.Theme.Bg, NewGroup("Backend", []Group{existing[2], existing[1], existing[0]}, opts).Theme.Bg, "independent of order")
+}
+
 func TestErrorSentinels_AreDistinct(t *testing.T) {
 	assert.NotEqual(t, ErrGroupNotFound, ErrGroupExists)
 	assert.NotEqual(t, ErrGroupExists, ErrCannotDeleteGroup)
@@ -549,3 +631,180 @@ func TestIconPresets_AsciiHasNonEmptyValues(t *testing.T) {
 	assert.NotEmpty(t, preset.ActivityIcon)
 	assert.NotEmpty(t, preset.TreeBranch)
 }
+
+func TestApplyDefaults_OutputMatchers(t *testing.T) {
+	cfg, err := loadYAML(t, "busy_detection:\n  idle_timeout: 5\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.NotEmpty(t, cfg.BusyDetection.OutputMatchers)
+
+	cfg, err = loadYAML(t, "busy_detection:\n  output_matchers: []\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Empty(t, cfg.BusyDetection.OutputMatchers, "an explicit empty list turns the defaults off")
+}
+
+func TestApplyDefaults_CmdIndicators(t *testing.T) {
+	cfg, err := loadYAML(t, `
+indicators:
+  command:
+    min_duration: 30
+    failed:
+      icon: "!"
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := cfg.Indicators.Command
+	assert.Equal(t, 30, c.MinDuration)
+	assert.Equal(t, "✓", c.Done.Icon)
+	assert.Equal(t, "!", c.Failed.Icon)
+	assert.NotEmpty(t, c.Failed.Color)
+}
+
+func TestApplyDefaults_CrashIndicator(t *testing.T) {
+	cfg, err := loadYAML(t, `
+indicators:
+  crash:
+    lines: 40
+    keep_failed: false
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := cfg.Indicators.Crash
+	assert.Equal(t, 40, c.Lines)
+	assert.Equal(t, "✖", c.Icon)
+	assert.NotEmpty(t, c.Color)
+	if assert.NotNil(t, c.KeepFailed) {
+		assert.False(t, *c.KeepFailed)
+	}
+	assert.Nil(t, c.Enabled)
+}
+
+func TestApplyDefaults_MirrorWidget(t *testing.T) {
+	cfg, err := loadYAML(t, `
+widgets:
+  mirror:
+    wrap: true
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m := cfg.Widgets.Mirror
+	assert.Nil(t, m.Enabled, "enabled unless set to false")
+	assert.Equal(t, 8, m.Lines)
+	assert.Equal(t, 1, m.UpdateInterval)
+	assert.True(t, m.Wrap)
+}
+
+func TestApplyDefaults_CalendarWidget(t *testing.T) {
+	cfg, err := loadYAML(t, `
+widgets:
+  calendar:
+    enabled: true
+    paths: [~/.calendars/work]
+    count: 1
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cal := cfg.Widgets.Calendar
+	assert.Equal(t, []string{"~/.calendars/work"}, cal.Paths)
+	assert.Equal(t, 1, cal.Count)
+	assert.Equal(t, 24, cal.Lookahead)
+	assert.Equal(t, 5, cal.WarnMinutes)
+	assert.Equal(t, "#f7768e", cal.WarnFg)
+	assert.Equal(t, 60, cal.UpdateInterval)
+}
+
+func TestApplyDefaults_TopWidget(t *testing.T) {
+	cfg, err := loadYAML(t, `
+widgets:
+  top:
+    enabled: true
+    sort: RSS
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	top := cfg.Widgets.Top
+	assert.Equal(t, 5, top.Count)
+	assert.Equal(t, "rss", top.Sort)
+	assert.Equal(t, 3, top.UpdateInterval)
+}
+
+func TestApplyDefaults_TodoWidget(t *testing.T) {
+	cfg, err := loadYAML(t, `
+widgets:
+  todo:
+    enabled: true
+    groups:
+      Work: ~/notes/work.md
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	todo := cfg.Widgets.Todo
+	assert.Equal(t, map[string]string{"Work": "~/notes/work.md"}, todo.Groups)
+	assert.Empty(t, todo.Path, "resolved to the config dir by the daemon")
+	assert.Equal(t, 8, todo.Count)
+	assert.Equal(t, 2, todo.UpdateInterval)
+}
+
+func TestApplyDefaults_FocusWidget(t *testing.T) {
+	cfg, err := loadYAML(t, `
+widgets:
+  focus:
+    work: 50
+    long_break_every: -1
+    allow: []
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f := cfg.Widgets.Focus
+	assert.Equal(t, 50, f.Work)
+	assert.Equal(t, 5, f.Break)
+	assert.Equal(t, 15, f.LongBreak)
+	assert.Equal(t, -1, f.LongBreakEvery, "never")
+	assert.Empty(t, f.Allow, "an explicit empty list holds back everything")
+
+	cfg, err = loadYAML(t, "widgets:\n  focus:\n    enabled: true\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, 4, cfg.Widgets.Focus.LongBreakEvery)
+	assert.Equal(t, []string{"crash", "notify"}, cfg.Widgets.Focus.Allow)
+}
+
+func TestApplyDefaults_CustomWidgets(t *testing.T) {
+	cfg, err := loadYAML(t, `
+widgets:
+  custom:
+    - command: "date +%H:%M"
+    - name: branch
+      command: "git branch --show-current"
+      on: [cwd_change]
+      format: json
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !assert.Len(t, cfg.Widgets.Custom, 2) {
+		return
+	}
+	w := cfg.Widgets.Custom[0]
+	assert.Equal(t, "custom-1", w.Name)
+	assert.Equal(t, 30, w.Interval)
+	assert.Equal(t, 5, w.Timeout)
+	assert.Equal(t, "text", w.Format)
+	assert.Equal(t, 10, w.MaxLines)
+
+	w = cfg.Widgets.Custom[1]
+	assert.Equal(t, "branch", w.Name)
+	assert.Equal(t, 0, w.Interval, "on: events only")
+	assert.Equal(t, "json", w.Format)
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFileName is the project-local settings file looked up at a
+// project's git toplevel. Unlike config.yaml it is meant to be committed, so
+// everyone who opens the project gets the same defaults.
+const ProjectFileName = ".tabby.yaml"
+
+// ProjectConfig is the contents of a .tabby.yaml. It applies to every window
+// whose pane cwd resolves to that git toplevel:
+//
+//	color: "#e74c3c"
+//	icon: "🐱"
+//	group: Tabby
+//	tab_names:
+//	  abbreviations: ["TBY>tabby"]
+//	ai:
+//	  tab_summary:
+//	    project_names: ["tby>tabby"]
+//	menu:
+//	  - label: Run tests
+//	    key: t
+//	    command: make test
+//	  - label: Dev server
+//	    command: npm run dev
+//	    mode: split
+//
+// Precedence, highest first: what the user set on the window itself, their
+// remembered appearance for the project (cwd-colors.json), this file, then
+// config.yaml. The exception is group: a new window opened inside a group's
+// working_dir joins that group before this file's group is considered.
+// tab_names and ai entries are merged over the global lists entry by entry:
+// a folder listed here wins over the same folder in config.yaml. menu items
+// are appended to the window context menu once the file is trusted (see
+// ProjectTrusted).
+type ProjectConfig struct {
+	Color string `yaml:"color,omitempty"`
+	Icon  string `yaml:"icon,omitempty"`
+	Group string `yaml:"group,omitempty"`
+
+	TabNames TabNames          `yaml:"tab_names,omitempty"`
+	AI       ProjectAIConfig   `yaml:"ai,omitempty"`
+	Menu     []ProjectMenuItem `yaml:"menu,omitempty"`
+}
+
+// ProjectAIConfig is the subset of AIConfig a project may override.
+type ProjectAIConfig struct {
+	TabSummary struct {
+		ProjectNames []string `yaml:"project_names,omitempty"`
+	} `yaml:"tab_summary,omitempty"`
+}
+
+// ProjectMenuItem is one project command in the window context menu.
+type ProjectMenuItem struct {
+	Label   string `yaml:"label"`
+	Key     string `yaml:"key,omitempty"`
+	Command string `yaml:"command"`
+	// Mode is "send" (default: type the command + Enter into the window's
+	// content pane) or "split" (run it in a new pane at the project root).
+	Mode string `yaml:"mode,omitempty"`
+}
+
+// ProjectConfigPath returns the .tabby.yaml path for a git toplevel.
+func ProjectConfigPath(toplevel string) string {
+	return filepath.Join(toplevel, ProjectFileName)
+}
+
+// LoadProjectConfig reads <toplevel>/.tabby.yaml. ok is false when the file
+// does not exist; a file that exists but fails to parse is an error.
+func LoadProjectConfig(toplevel string) (pc ProjectConfig, ok bool, err error) {
+	data, err := os.ReadFile(ProjectConfigPath(toplevel))
+	if os.IsNotExist(err) {
+		return ProjectConfig{}, false, nil
+	}
+	if err != nil {
+		return ProjectConfig{}, false, err
+	}
+	if err := yaml.Unmarshal(data, &pc); err != nil {
+		return ProjectConfig{}, false, fmt.Errorf("parse %s: %w", ProjectConfigPath(toplevel), err)
+	}
+	pc.Color = strings.TrimSpace(pc.Color)
+	pc.Icon = strings.TrimSpace(pc.Icon)
+	pc.Group = strings.TrimSpace(pc.Group)
+	menu := pc.Menu[:0]
+	for _, item := range pc.Menu {
+		item.Label = strings.TrimSpace(item.Label)
+		item.Command = strings.TrimSpace(item.Command)
+		item.Mode = strings.ToLower(strings.TrimSpace(item.Mode))
+		if item.Label == "" || item.Command == "" {
+			continue
+		}
+		menu = append(menu, item)
+	}
+	pc.Menu = menu
+	return pc, true, nil
+}
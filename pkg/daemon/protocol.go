@@ -36,6 +36,11 @@ const (
 	// PetQAResponse below. Phase 1 of the Q&A loop; see
 	// /Users/b/.claude/plans/wiggly-discovering-starlight.md.
 	MsgPetQA MessageType = "pet_qa"
+	// MsgCtl is the request-response envelope used by `tabby ctl` (and
+	// other CLI front-ends that drive daemon-owned state). Same shape as
+	// MsgPetQA: one CtlRequest in, one CtlResponse back on the same
+	// connection, no subscribe.
+	MsgCtl MessageType = "ctl"
 )
 
 // TargetKind identifies what KIND of renderer a message is for or from.
@@ -416,6 +421,23 @@ type PetQAResponse struct {
 	Removed       bool               `json:"removed,omitempty"`
 }
 
+// CtlRequest is the CLI->daemon envelope for `tabby ctl`. Op names the
+// command (e.g. "group-run", "group-sync"); Args carries its positional
+// arguments verbatim so new ops don't need new wire fields.
+type CtlRequest struct {
+	Op   string   `json:"op"`
+	Args []string `json:"args,omitempty"`
+}
+
+// CtlResponse is the daemon->CLI reply for a CtlRequest. Output is
+// human-readable text the CLI prints as-is on success; Error carries the
+// reason when OK is false.
+type CtlResponse struct {
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+	Output string `json:"output,omitempty"`
+}
+
 // MenuItemPayload represents a single menu item sent to a renderer
 type MenuItemPayload struct {
 	Label     string `json:"label"`
@@ -84,6 +84,11 @@ type Server struct {
 	// /Users/b/.claude/plans/wiggly-discovering-starlight.md.
 	OnPetQA func(req *PetQARequest) *PetQAResponse
 
+	// Callback for `tabby ctl` requests. Same synchronous contract as
+	// OnPetQA: invoked on the un-subscribed connection, reply written back
+	// on it, nil treated as "no handler wired".
+	OnCtl func(req *CtlRequest) *CtlResponse
+
 	// Debug logging callback (set by daemon for diagnostics)
 	DebugLog func(format string, args ...interface{})
 }
@@ -515,6 +520,43 @@ func (s *Server) handleClient(conn net.Conn) {
 				resp = &PetQAResponse{OK: false, Error: "pet Q&A not available"}
 			}
 			s.sendMessage(conn, Message{Type: MsgPetQA, Payload: resp})
+
+		case MsgCtl:
+			// MsgCtl carries a `tabby ctl` request. Same one-shot
+			// request/response contract as MsgPetQA above.
+			var req CtlRequest
+			if msg.Payload != nil {
+				payloadBytes, err := json.Marshal(msg.Payload)
+				if err == nil {
+					err = json.Unmarshal(payloadBytes, &req)
+				}
+				if err != nil {
+					if s.DebugLog != nil {
+						s.DebugLog("SOCKET_CTL_DROP reason=payload remote=%s err=%v", remoteAddr, err)
+					}
+					s.sendMessage(conn, Message{Type: MsgCtl, Payload: &CtlResponse{OK: false, Error: "invalid request payload"}})
+					continue
+				}
+			}
+			if s.DebugLog != nil {
+				s.DebugLog("SOCKET_CTL op=%s args=%v remote=%s", req.Op, req.Args, remoteAddr)
+			}
+			var resp *CtlResponse
+			if s.OnCtl != nil {
+				func() {
+					defer func() {
+						if r := recover(); r != nil {
+							fmt.Fprintf(os.Stderr, "PANIC in OnCtl (op=%s): %v\n", req.Op, r)
+							resp = &CtlResponse{OK: false, Error: "internal error"}
+						}
+					}()
+					resp = s.OnCtl(&req)
+				}()
+			}
+			if resp == nil {
+				resp = &CtlResponse{OK: false, Error: "ctl not available"}
+			}
+			s.sendMessage(conn, Message{Type: MsgCtl, Payload: resp})
 		}
 	}
 
@@ -0,0 +1,181 @@
+// Package focus is the pomodoro timer behind the sidebar's focus widget and
+// `tabby ctl focus`: a work period, then a short break (a long one after
+// every few work periods), then back to work when the break ends or the user
+// starts it, depending on AutoStartWork.
+//
+// The timer is a plain value advanced by Tick; it holds an end time while
+// running and the time left while paused, so it can be persisted as JSON in
+// focus.json under paths.StateDir() and picked up again after a daemon
+// restart. It is shared by every tabby session on the host.
+package focus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/paths"
+)
+
+// Phase is what the timer is counting down.
+type Phase string
+
+const (
+	Idle      Phase = ""
+	Work      Phase = "work"
+	Break     Phase = "break"
+	LongBreak Phase = "long_break"
+)
+
+// Settings are the period lengths.
+type Settings struct {
+	Work           time.Duration
+	Break          time.Duration
+	LongBreak      time.Duration
+	LongBreakEvery int  // work periods per long break; 0 never
+	AutoStartWork  bool // start the next work period when a break ends
+}
+
+// Timer is the persisted timer state.
+type Timer struct {
+	Phase     Phase         `json:"phase,omitempty"`
+	EndsAt    time.Time     `json:"ends_at,omitzero"`   // while running
+	Remaining time.Duration `json:"remaining,omitzero"` // while paused
+	Paused    bool          `json:"paused,omitempty"`
+	Completed int           `json:"completed,omitempty"` // work periods finished
+}
+
+// Focusing reports whether a work period is running (not paused).
+func (t Timer) Focusing() bool {
+	return t.Phase == Work && !t.Paused
+}
+
+// Left is the time left in the current phase.
+func (t Timer) Left(now time.Time) time.Duration {
+	switch {
+	case t.Phase == Idle:
+		return 0
+	case t.Paused:
+		return t.Remaining
+	}
+	return max(t.EndsAt.Sub(now), 0)
+}
+
+// Start begins a work period of d, or resumes a paused timer when d is 0.
+func (t *Timer) Start(now time.Time, d time.Duration, s Settings) {
+	if d == 0 && t.Paused {
+		t.Resume(now)
+		return
+	}
+	if d <= 0 {
+		d = s.Work
+	}
+	t.Phase, t.EndsAt, t.Remaining, t.Paused = Work, now.Add(d), 0, false
+}
+
+// Pause stops the countdown; Resume continues it.
+func (t *Timer) Pause(now time.Time) {
+	if t.Phase == Idle || t.Paused {
+		return
+	}
+	t.Remaining, t.EndsAt, t.Paused = t.Left(now), time.Time{}, true
+}
+
+func (t *Timer) Resume(now time.Time) {
+	if !t.Paused {
+		return
+	}
+	t.EndsAt, t.Remaining, t.Paused = now.Add(t.Remaining), 0, false
+}
+
+// Reset stops the timer and forgets the completed count.
+func (t *Timer) Reset() {
+	*t = Timer{}
+}
+
+// Tick ends the current phase when its time is up and starts the next one.
+// It returns the phase that ended, or Idle when nothing changed.
+func (t *Timer) Tick(now time.Time, s Settings) Phase {
+	if t.Phase == Idle || t.Paused || now.Before(t.EndsAt) {
+		return Idle
+	}
+	ended := t.Phase
+	switch ended {
+	case Work:
+		t.Completed++
+		next, d := Break, s.Break
+		if s.LongBreakEvery > 0 && t.Completed%s.LongBreakEvery == 0 {
+			next, d = LongBreak, s.LongBreak
+		}
+		t.Phase, t.EndsAt = next, now.Add(d)
+	default:
+		if s.AutoStartWork {
+			t.Phase, t.EndsAt = Work, now.Add(s.Work)
+		} else {
+			t.Phase, t.EndsAt = Idle, time.Time{}
+		}
+	}
+	return ended
+}
+
+// ParseDuration reads a period length: a Go duration ("25m", "1h30m") or a
+// bare number of minutes ("25").
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		if n <= 0 {
+			return 0, fmt.Errorf("duration must be positive: %s", s)
+		}
+		return time.Duration(n) * time.Minute, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q (want e.g. 25m or 25)", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive: %s", s)
+	}
+	return d, nil
+}
+
+// Path returns the location of the timer file.
+func Path() string {
+	return paths.StatePath("focus.json")
+}
+
+// Load reads the timer from path. A missing file is an idle timer.
+func Load(path string) (Timer, error) {
+	var t Timer
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return t, err
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Timer{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Save writes the timer to path atomically.
+func Save(path string, t Timer) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
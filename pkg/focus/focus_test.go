@@ -0,0 +1,90 @@
+package focus
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSettings = Settings{Work: 25 * time.Minute, Break: 5 * time.Minute, LongBreak: 15 * time.Minute, LongBreakEvery: 2}
+
+func TestTimerCycle(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	var tm Timer
+	assert.Equal(t, Idle, tm.Tick(now, testSettings))
+
+	tm.Start(now, 0, testSettings)
+	assert.True(t, tm.Focusing())
+	assert.Equal(t, 25*time.Minute, tm.Left(now))
+	assert.Equal(t, Idle, tm.Tick(now.Add(time.Minute), testSettings))
+
+	now = now.Add(25 * time.Minute)
+	assert.Equal(t, Work, tm.Tick(now, testSettings))
+	assert.Equal(t, Break, tm.Phase)
+	assert.Equal(t, 1, tm.Completed)
+
+	now = now.Add(5 * time.Minute)
+	assert.Equal(t, Break, tm.Tick(now, testSettings))
+	assert.Equal(t, Idle, tm.Phase, "work waits for a start without auto_start")
+
+	tm.Start(now, 10*time.Minute, testSettings)
+	now = now.Add(10 * time.Minute)
+	tm.Tick(now, testSettings)
+	assert.Equal(t, LongBreak, tm.Phase, "every second work period")
+	assert.Equal(t, 15*time.Minute, tm.Left(now))
+
+	s := testSettings
+	s.AutoStartWork = true
+	tm.Tick(now.Add(15*time.Minute), s)
+	assert.Equal(t, Work, tm.Phase)
+
+	tm.Reset()
+	assert.Equal(t, Timer{}, tm)
+}
+
+func TestTimerPauseResume(t *testing.T) {
+	now := time.Now()
+	var tm Timer
+	tm.Start(now, 0, testSettings)
+	tm.Pause(now.Add(10 * time.Minute))
+	assert.False(t, tm.Focusing())
+	assert.Equal(t, 15*time.Minute, tm.Left(now.Add(time.Hour)), "frozen while paused")
+	assert.Equal(t, Idle, tm.Tick(now.Add(time.Hour), testSettings))
+
+	tm.Start(now.Add(time.Hour), 0, testSettings)
+	assert.False(t, tm.Paused, "start resumes")
+	assert.Equal(t, 15*time.Minute, tm.Left(now.Add(time.Hour)))
+}
+
+func TestParseDuration(t *testing.T) {
+	d, err := ParseDuration("25m")
+	require.NoError(t, err)
+	assert.Equal(t, 25*time.Minute, d)
+	d, err = ParseDuration("50")
+	require.NoError(t, err)
+	assert.Equal(t, 50*time.Minute, d)
+	_, err = ParseDuration("soon")
+	assert.Error(t, err)
+	_, err = ParseDuration("-5m")
+	assert.Error(t, err)
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "focus.json")
+	tm, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, Timer{}, tm, "missing file is idle")
+
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	tm.Start(now, 0, testSettings)
+	tm.Completed = 3
+	require.NoError(t, Save(path, tm))
+	got, err := Load(path)
+	require.NoError(t, err)
+	assert.True(t, tm.EndsAt.Equal(got.EndsAt))
+	assert.Equal(t, Work, got.Phase)
+	assert.Equal(t, 3, got.Completed)
+}
@@ -170,6 +170,71 @@ func FindGroupTheme(groupName string, groups []config.Group) config.Theme {
 	}
 }
 
+// AssignDefaultColors gives every group in grouped that has no bg a color
+// from DefaultColors. Groups are updated in place; their Theme is a per-call
+// copy, so config is untouched. Groups that already have a bg are left alone,
+// so calling it again after appending rows only fills the new ones.
+func AssignDefaultColors(grouped []GroupedWindows, groups []config.Group, opts colors.PaletteOptions) {
+	var extra, reserved []string
+	for _, g := range grouped {
+		switch bg := strings.TrimSpace(g.Theme.Bg); {
+		case bg == "":
+			extra = append(extra, g.Name)
+		case !strings.EqualFold(bg, "transparent"):
+			reserved = append(reserved, bg)
+		}
+	}
+	if len(extra) == 0 {
+		return
+	}
+	assigned := DefaultColors(groups, extra, reserved, opts)
+	for i := range grouped {
+		if strings.TrimSpace(grouped[i].Theme.Bg) == "" {
+			grouped[i].Theme.Bg = assigned[grouped[i].Name]
+		}
+	}
+}
+
+// DefaultColors returns the palette color of every configured group without
+// a bg, and of every name in extra that is not configured. Configured groups
+// are allocated first, over the whole config, so their colors do not depend
+// on which groups are on screen: a group losing its last window (with
+// show_empty_groups off) or an archived row appearing never recolors the
+// rest. Extra names then fill the remaining room, kept distinct from the
+// configured colors and from reserved.
+func DefaultColors(groups []config.Group, extra, reserved []string, opts colors.PaletteOptions) map[string]string {
+	var names, cfgReserved []string
+	configured := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		configured[g.Name] = true
+		switch bg := strings.TrimSpace(g.Theme.Bg); {
+		case bg == "":
+			names = append(names, g.Name)
+		case !strings.EqualFold(bg, "transparent"):
+			cfgReserved = append(cfgReserved, bg)
+		}
+	}
+	assigned := colors.AllocateGroupColors(names, cfgReserved, opts)
+
+	var others []string
+	for _, name := range extra {
+		if !configured[name] {
+			others = append(others, name)
+		}
+	}
+	if len(others) == 0 {
+		return assigned
+	}
+	taken := append(append([]string(nil), cfgReserved...), reserved...)
+	for _, hex := range assigned {
+		taken = append(taken, hex)
+	}
+	for name, hex := range colors.AllocateGroupColors(others, taken, opts) {
+		assigned[name] = hex
+	}
+	return assigned
+}
+
 // FindGroupThemeWithDefaults finds a group theme and auto-fills missing colors
 // using intelligent derivation based on terminal background
 func FindGroupThemeWithDefaults(groupName string, groups []config.Group, isDarkTerminalBg bool, groupIndex int) config.Theme {
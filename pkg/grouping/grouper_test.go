@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/brendandebeasi/tabby/pkg/colors"
 	"github.com/brendandebeasi/tabby/pkg/config"
 	"github.com/brendandebeasi/tabby/pkg/tmux"
 )
@@ -1766,3 +1767,87 @@ func TestGroupWindowsWithOptions_PinnedAndNonPinnedMixed(t *testing.T) {
 		t.Errorf("Backend group should have 1 window, got %d", len(result[3].Windows))
 	}
 }
+
+func TestAssignDefaultColors(t *testing.T) {
+	groups := []config.Group{
+		{Name: "Default", Theme: config.Theme{Bg: "#3498db"}},
+		{Name: "Backend"},
+		{Name: "Docs", Theme: config.Theme{Bg: "transparent"}},
+		{Name: "Infra"},
+	}
+	grouped := []GroupedWindows{
+		{Name: "Default", Theme: config.Theme{Bg: "#3498db"}},
+		{Name: "Backend"},
+		{Name: "Docs", Theme: config.Theme{Bg: "transparent"}},
+		{Name: "Infra"},
+	}
+	opts := colors.PaletteOptions{Dark: true}
+	AssignDefaultColors(grouped, groups, opts)
+
+	if grouped[0].Theme.Bg != "#3498db" || grouped[2].Theme.Bg != "transparent" {
+		t.Fatalf("explicit colors must be kept, got %q / %q", grouped[0].Theme.Bg, grouped[2].Theme.Bg)
+	}
+	if grouped[1].Theme.Bg == "" || grouped[3].Theme.Bg == "" {
+		t.Fatalf("expected palette colors, got %q / %q", grouped[1].Theme.Bg, grouped[3].Theme.Bg)
+	}
+	if grouped[1].Theme.Bg == grouped[3].Theme.Bg {
+		t.Errorf("expected distinct colors, both %q", grouped[1].Theme.Bg)
+	}
+
+	// Same names in a different order keep their colors.
+	again := []GroupedWindows{{Name: "Infra"}, {Name: "Backend"}, {Name: "Default", Theme: config.Theme{Bg: "#3498db"}}}
+	AssignDefaultColors(again, groups, opts)
+	if again[0].Theme.Bg != grouped[3].Theme.Bg || again[1].Theme.Bg != grouped[1].Theme.Bg {
+		t.Errorf("colors shifted on reorder: %q/%q vs %q/%q", again[0].Theme.Bg, again[1].Theme.Bg, grouped[3].Theme.Bg, grouped[1].Theme.Bg)
+	}
+}
+
+func TestAssignDefaultColors_StableWhenGroupEmpties(t *testing.T) {
+	groups := []config.Group{
+		{Name: "Default"},
+		{Name: "Backend"},
+		{Name: "Frontend"},
+		{Name: "Infra"},
+	}
+	opts := colors.PaletteOptions{Dark: true}
+	colorsOf := func(windows []tmux.Window) map[string]string {
+		grouped := GroupWindowsWithOptions(windows, groups, false)
+		AssignDefaultColors(grouped, groups, opts)
+		got := make(map[string]string, len(grouped))
+		for _, g := range grouped {
+			got[g.Name] = g.Theme.Bg
+		}
+		return got
+	}
+
+	all := colorsOf([]tmux.Window{
+		{Name: "api", Index: 0, Group: "Backend"},
+		{Name: "web", Index: 1, Group: "Frontend"},
+		{Name: "k8s", Index: 2, Group: "Infra"},
+		{Name: "scratch", Index: 3},
+	})
+	// Frontend loses its last window and drops off the sidebar.
+	fewer := colorsOf([]tmux.Window{
+		{Name: "api", Index: 0, Group: "Backend"},
+		{Name: "k8s", Index: 2, Group: "Infra"},
+		{Name: "scratch", Index: 3},
+	})
+
+	if _, ok := fewer["Frontend"]; ok {
+		t.Fatalf("empty Frontend should be hidden, got %v", fewer)
+	}
+	for _, name := range []string{"Default", "Backend", "Infra"} {
+		if all[name] == "" || fewer[name] != all[name] {
+			t.Errorf("%s changed color when Frontend emptied: %q -> %q", name, all[name], fewer[name])
+		}
+	}
+
+	// An archived row appended later gets the color its group has when live.
+	rows := GroupWindowsWithOptions([]tmux.Window{{Name: "api", Index: 0, Group: "Backend"}}, groups, false)
+	AssignDefaultColors(rows, groups, opts)
+	rows = append(rows, GroupedWindows{Name: "Frontend"})
+	AssignDefaultColors(rows, groups, opts)
+	if got := rows[len(rows)-1].Theme.Bg; got != all["Frontend"] {
+		t.Errorf("archived Frontend row got %q, live color %q", got, all["Frontend"])
+	}
+}
@@ -0,0 +1,501 @@
+// Package ics reads the events of local iCalendar (.ics) files for the
+// calendar widget, e.g. the per-event files vdirsyncer writes. It understands
+// what calendar exports commonly use: folded lines, DTSTART/DTEND/DURATION
+// as UTC, TZID-qualified, floating or all-day values, EXDATE, overridden
+// instances (RECURRENCE-ID) and RRULEs with FREQ DAILY, WEEKLY, MONTHLY or
+// YEARLY, INTERVAL, COUNT, UNTIL, BYDAY (with ordinals for MONTHLY, e.g.
+// 1MO or -1FR) and BYMONTHDAY. Anything else in a rule is ignored, so an
+// exotic rule yields approximate occurrences rather than none.
+package ics
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is one VEVENT.
+type Event struct {
+	UID          string
+	Summary      string
+	Location     string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID time.Time // set on an overridden instance
+}
+
+// Occurrence is one instance of an event.
+type Occurrence struct {
+	Summary  string
+	Location string
+	Start    time.Time
+	End      time.Time
+	AllDay   bool
+}
+
+// Parse reads the VEVENTs of one calendar. Cancelled events are left out.
+// Components nested in an event, such as a VALARM with its own SUMMARY and
+// DURATION, are skipped.
+func Parse(r io.Reader) ([]Event, error) {
+	var events []Event
+	var cur *Event
+	cancelled := false
+	depth := 0 // components open inside the current VEVENT
+	for _, line := range unfold(r) {
+		name, params, value := splitProperty(line)
+		switch {
+		case name == "BEGIN" && cur != nil:
+			depth++
+			continue
+		case name == "END" && cur != nil && depth > 0:
+			depth--
+			continue
+		case name == "BEGIN" && value == "VEVENT":
+			cur, cancelled, depth = &Event{}, false, 0
+			continue
+		case name == "END" && value == "VEVENT":
+			if cur != nil && !cur.Start.IsZero() && !cancelled {
+				if cur.End.Before(cur.Start) {
+					cur.End = cur.Start
+				}
+				events = append(events, *cur)
+			}
+			cur = nil
+			continue
+		case cur == nil, depth > 0:
+			continue
+		}
+		switch name {
+		case "UID":
+			cur.UID = value
+		case "SUMMARY":
+			cur.Summary = unescape(value)
+		case "LOCATION":
+			cur.Location = unescape(value)
+		case "STATUS":
+			cancelled = strings.EqualFold(value, "CANCELLED")
+		case "DTSTART":
+			cur.Start, cur.AllDay = parseTime(value, params)
+			if cur.End.IsZero() {
+				cur.End = cur.Start
+				if cur.AllDay {
+					cur.End = cur.Start.AddDate(0, 0, 1)
+				}
+			}
+		case "DTEND":
+			cur.End, _ = parseTime(value, params)
+		case "DURATION":
+			if d, ok := parseDuration(value); ok {
+				cur.End = cur.Start.Add(d)
+			}
+		case "RRULE":
+			cur.RRule = value
+		case "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				if t, _ := parseTime(v, params); !t.IsZero() {
+					cur.ExDates = append(cur.ExDates, t)
+				}
+			}
+		case "RECURRENCE-ID":
+			cur.RecurrenceID, _ = parseTime(value, params)
+		}
+	}
+	return events, nil
+}
+
+// ParseFile reads one .ics file.
+func ParseFile(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Files lists the .ics files at path: the file itself, or every .ics file
+// under a directory.
+func Files(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".ics") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// Between expands events into the occurrences that overlap [from, to),
+// sorted by start time.
+func Between(events []Event, from, to time.Time) []Occurrence {
+	overridden := map[string]map[int64]bool{}
+	for _, e := range events {
+		if !e.RecurrenceID.IsZero() {
+			if overridden[e.UID] == nil {
+				overridden[e.UID] = map[int64]bool{}
+			}
+			overridden[e.UID][e.RecurrenceID.Unix()] = true
+		}
+	}
+
+	var out []Occurrence
+	for _, e := range events {
+		d := e.End.Sub(e.Start)
+		add := func(start time.Time) {
+			if start.Before(to) && start.Add(d).After(from) {
+				out = append(out, Occurrence{Summary: e.Summary, Location: e.Location, Start: start, End: start.Add(d), AllDay: e.AllDay})
+			}
+		}
+		if e.RRule == "" || !e.RecurrenceID.IsZero() {
+			add(e.Start)
+			continue
+		}
+		skip := overridden[e.UID]
+		expand(e.Start, parseRule(e.RRule, e.Start.Location()), from.Add(-d), to, func(start time.Time) {
+			if skip[start.Unix()] {
+				return
+			}
+			for _, x := range e.ExDates {
+				if x.Equal(start) {
+					return
+				}
+			}
+			add(start)
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// rule is the supported part of an RRULE.
+type rule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []weekdayNum
+	byMonthDay []int
+}
+
+// weekdayNum is a BYDAY entry: a weekday, and for MONTHLY an optional
+// ordinal (1 first, -1 last, 0 every).
+type weekdayNum struct {
+	n   int
+	day time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+func parseRule(s string, loc *time.Location) rule {
+	r := rule{interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		k, v, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(k) {
+		case "FREQ":
+			r.freq = strings.ToUpper(v)
+		case "INTERVAL":
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				r.interval = n
+			}
+		case "COUNT":
+			r.count, _ = strconv.Atoi(v)
+		case "UNTIL":
+			var all bool
+			r.until, all = parseTime(v, map[string]string{"TZID": loc.String()})
+			if all {
+				r.until = r.until.AddDate(0, 0, 1).Add(-time.Nanosecond) // the whole day
+			}
+		case "BYDAY":
+			for _, d := range strings.Split(strings.ToUpper(v), ",") {
+				if len(d) < 2 {
+					continue
+				}
+				wd, ok := weekdays[d[len(d)-2:]]
+				if !ok {
+					continue
+				}
+				n, _ := strconv.Atoi(d[:len(d)-2])
+				r.byDay = append(r.byDay, weekdayNum{n, wd})
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(v, ",") {
+				if n, err := strconv.Atoi(d); err == nil && n != 0 {
+					r.byMonthDay = append(r.byMonthDay, n)
+				}
+			}
+		}
+	}
+	return r
+}
+
+// maxPeriods bounds the expansion of a rule without COUNT or UNTIL that
+// started long ago.
+const maxPeriods = 100000
+
+// expand calls fn for each start of the recurrence beginning at start, up to
+// to, skipping (but counting) those before from.
+func expand(start time.Time, r rule, from, to time.Time, fn func(time.Time)) {
+	switch r.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		// Unsupported frequency: only the first instance.
+		if !start.Before(from) && start.Before(to) {
+			fn(start)
+		}
+		return
+	}
+	n := 0
+	for p := 0; p < maxPeriods; p++ {
+		for _, t := range r.period(start, p*r.interval) {
+			if t.Before(start) {
+				continue
+			}
+			if (!r.until.IsZero() && t.After(r.until)) || !t.Before(to) || (r.count > 0 && n >= r.count) {
+				return
+			}
+			n++
+			if !t.Before(from) {
+				fn(t)
+			}
+		}
+	}
+}
+
+// period returns the starts in the k-th day, week, month or year after
+// start's, in order.
+func (r rule) period(start time.Time, k int) []time.Time {
+	y, m, d := start.Date()
+	hh, mm, ss := start.Clock()
+	loc := start.Location()
+	at := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, hh, mm, ss, 0, loc) }
+
+	switch r.freq {
+	case "DAILY":
+		t := at(y, m, d+k)
+		if len(r.byDay) > 0 && !r.hasWeekday(t.Weekday()) {
+			return nil
+		}
+		return []time.Time{t}
+	case "WEEKLY":
+		if len(r.byDay) == 0 {
+			return []time.Time{at(y, m, d+7*k)}
+		}
+		// Weeks start on Monday (the RFC 5545 default WKST).
+		monday := d - (int(start.Weekday())+6)%7 + 7*k
+		var out []time.Time
+		for i := 0; i < 7; i++ {
+			t := at(y, m, monday+i)
+			if r.hasWeekday(t.Weekday()) {
+				out = append(out, t)
+			}
+		}
+		return out
+	case "MONTHLY":
+		first := time.Date(y, m+time.Month(k), 1, 0, 0, 0, 0, loc)
+		var days []int
+		switch {
+		case len(r.byDay) > 0:
+			days = monthWeekdays(first, r.byDay)
+		case len(r.byMonthDay) > 0:
+			days = monthDays(first, r.byMonthDay)
+		default:
+			days = monthDays(first, []int{d})
+		}
+		var out []time.Time
+		for _, day := range days {
+			out = append(out, at(first.Year(), first.Month(), day))
+		}
+		return out
+	case "YEARLY":
+		t := at(y+k, m, d)
+		if t.Day() != d {
+			return nil // Feb 29 in a non-leap year
+		}
+		return []time.Time{t}
+	}
+	return nil
+}
+
+func (r rule) hasWeekday(wd time.Weekday) bool {
+	for _, b := range r.byDay {
+		if b.day == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// daysIn returns the number of days in first's month.
+func daysIn(first time.Time) int {
+	return time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// monthDays resolves BYMONTHDAY values (negative from the end) in first's
+// month, sorted; days the month lacks are dropped.
+func monthDays(first time.Time, byMonthDay []int) []int {
+	n := daysIn(first)
+	var days []int
+	for _, d := range byMonthDay {
+		if d < 0 {
+			d = n + 1 + d
+		}
+		if d >= 1 && d <= n {
+			days = append(days, d)
+		}
+	}
+	sort.Ints(days)
+	return days
+}
+
+// monthWeekdays resolves BYDAY entries in first's month: every such weekday,
+// or the n-th (from the end when negative).
+func monthWeekdays(first time.Time, byDay []weekdayNum) []int {
+	n := daysIn(first)
+	seen := map[int]bool{}
+	var days []int
+	for _, b := range byDay {
+		var matches []int
+		for d := 1; d <= n; d++ {
+			if time.Weekday((int(first.Weekday())+d-1)%7) == b.day {
+				matches = append(matches, d)
+			}
+		}
+		switch {
+		case b.n == 0:
+		case b.n > 0 && b.n <= len(matches):
+			matches = matches[b.n-1 : b.n]
+		case b.n < 0 && -b.n <= len(matches):
+			matches = matches[len(matches)+b.n : len(matches)+b.n+1]
+		default:
+			matches = nil
+		}
+		for _, d := range matches {
+			if !seen[d] {
+				seen[d] = true
+				days = append(days, d)
+			}
+		}
+	}
+	sort.Ints(days)
+	return days
+}
+
+// unfold joins folded content lines (continuations start with a space or
+// tab).
+func unfold(r io.Reader) []string {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitProperty splits "NAME;PARAM=x;PARAM=y:value".
+func splitProperty(line string) (name string, params map[string]string, value string) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", nil, ""
+	}
+	parts := strings.Split(head, ";")
+	params = map[string]string{}
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, strings.TrimSpace(value)
+}
+
+// parseTime reads a DATE or DATE-TIME value: UTC with a trailing Z, in the
+// TZID parameter's zone, or floating (local time). all is true for a DATE.
+func parseTime(value string, params map[string]string) (t time.Time, all bool) {
+	loc := time.Local
+	if tz := params["TZID"]; tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		}
+	}
+	value = strings.TrimSpace(value)
+	switch {
+	case params["VALUE"] == "DATE" || len(value) == 8:
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return t, true
+	case strings.HasSuffix(value, "Z"):
+		t, _ = time.Parse("20060102T150405Z", value)
+	default:
+		t, _ = time.ParseInLocation("20060102T150405", value, loc)
+	}
+	return t, false
+}
+
+// parseDuration reads a DURATION value such as PT30M, P1D or -PT5M.
+func parseDuration(s string) (time.Duration, bool) {
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+	if !strings.HasPrefix(s, "P") {
+		return 0, false
+	}
+	var d time.Duration
+	num := 0
+	for _, c := range s[1:] {
+		switch {
+		case c >= '0' && c <= '9':
+			num = num*10 + int(c-'0')
+		case c == 'T':
+		case c == 'W':
+			d, num = d+time.Duration(num)*7*24*time.Hour, 0
+		case c == 'D':
+			d, num = d+time.Duration(num)*24*time.Hour, 0
+		case c == 'H':
+			d, num = d+time.Duration(num)*time.Hour, 0
+		case c == 'M':
+			d, num = d+time.Duration(num)*time.Minute, 0
+		case c == 'S':
+			d, num = d+time.Duration(num)*time.Second, 0
+		default:
+			return 0, false
+		}
+	}
+	if neg {
+		d = -d
+	}
+	return d, true
+}
+
+// unescape undoes TEXT escaping.
+func unescape(s string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
@@ -0,0 +1,166 @@
+package ics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, body string) []Event {
+	t.Helper()
+	events, err := Parse(strings.NewReader("BEGIN:VCALENDAR\r\n" + body + "END:VCALENDAR\r\n"))
+	require.NoError(t, err)
+	return events
+}
+
+func starts(occ []Occurrence) []string {
+	var out []string
+	for _, o := range occ {
+		out = append(out, o.Start.UTC().Format("2006-01-02 15:04"))
+	}
+	return out
+}
+
+func TestParse(t *testing.T) {
+	events := parse(t, `BEGIN:VEVENT
+UID:1
+SUMMARY:Design review\, round 2
+DESCRIPTION:long text
+ folded onto the next line
+LOCATION:Room
+  4
+DTSTART;TZID=Europe/Berlin:20261019T100000
+DURATION:PT45M
+END:VEVENT
+BEGIN:VEVENT
+UID:2
+SUMMARY:Cancelled
+STATUS:CANCELLED
+DTSTART:20261019T120000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:3
+SUMMARY:Offsite
+DTSTART;VALUE=DATE:20261020
+END:VEVENT
+`)
+	require.Len(t, events, 2)
+	e := events[0]
+	assert.Equal(t, "Design review, round 2", e.Summary)
+	assert.Equal(t, "Room 4", e.Location)
+	assert.Equal(t, "2026-10-19 08:00", e.Start.UTC().Format("2006-01-02 15:04"))
+	assert.Equal(t, 45*time.Minute, e.End.Sub(e.Start))
+	assert.True(t, events[1].AllDay)
+	assert.Equal(t, 24*time.Hour, events[1].End.Sub(events[1].Start))
+}
+
+func TestParseSkipsNestedAlarm(t *testing.T) {
+	events := parse(t, `BEGIN:VEVENT
+UID:standup
+SUMMARY:Standup
+DTSTART:20261019T090000Z
+BEGIN:VALARM
+ACTION:EMAIL
+SUMMARY:Reminder mail
+DESCRIPTION:Standup soon
+DURATION:PT5M
+TRIGGER:-PT10M
+END:VALARM
+DTEND:20261019T100000Z
+LOCATION:Room 1
+END:VEVENT
+`)
+	require.Len(t, events, 1)
+	e := events[0]
+	assert.Equal(t, "Standup", e.Summary)
+	assert.Equal(t, "Room 1", e.Location, "properties after the alarm still apply")
+	assert.Equal(t, time.Hour, e.End.Sub(e.Start))
+}
+
+func TestBetweenRecurrence(t *testing.T) {
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name, dtstart, rule string
+		from                time.Time
+		want                []string
+	}{
+		{"daily count", "20261012T090000Z", "FREQ=DAILY;COUNT=3", monday.AddDate(0, 0, -7), []string{"2026-10-12 09:00", "2026-10-13 09:00", "2026-10-14 09:00"}},
+		{"weekdays", "20261012T090000Z", "FREQ=WEEKLY;BYDAY=MO,WE,FR;UNTIL=20261023T235959Z", monday, []string{"2026-10-19 09:00", "2026-10-21 09:00", "2026-10-23 09:00"}},
+		{"biweekly", "20261012T090000Z", "FREQ=WEEKLY;INTERVAL=2", monday, []string{"2026-10-26 09:00"}},
+		{"third tuesday", "20261012T090000Z", "FREQ=MONTHLY;BYDAY=3TU", monday, []string{"2026-10-20 09:00"}},
+		{"last friday", "20261012T090000Z", "FREQ=MONTHLY;BYDAY=-1FR", monday, []string{"2026-10-30 09:00"}},
+		{"monthly day", "20261012T090000Z", "FREQ=MONTHLY;BYMONTHDAY=-1", monday, []string{"2026-10-31 09:00"}},
+		{"yearly", "20231026T090000Z", "FREQ=YEARLY", monday, []string{"2026-10-26 09:00"}},
+		{"until date", "20261012T090000Z", "FREQ=DAILY;UNTIL=20261019", monday, []string{"2026-10-19 09:00"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			events := parse(t, "BEGIN:VEVENT\nUID:r\nSUMMARY:x\nDTSTART:"+tc.dtstart+"\nDURATION:PT30M\nRRULE:"+tc.rule+"\nEND:VEVENT\n")
+			assert.Equal(t, tc.want, starts(Between(events, tc.from, monday.AddDate(0, 0, 14))))
+		})
+	}
+}
+
+func TestBetweenExceptionsAndOverrides(t *testing.T) {
+	events := parse(t, `BEGIN:VEVENT
+UID:standup
+SUMMARY:Standup
+DTSTART;TZID=America/New_York:20261001T093000
+DTEND;TZID=America/New_York:20261001T094500
+RRULE:FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR
+EXDATE;TZID=America/New_York:20261102T093000
+END:VEVENT
+BEGIN:VEVENT
+UID:standup
+RECURRENCE-ID;TZID=America/New_York:20261103T093000
+SUMMARY:Standup (moved)
+DTSTART;TZID=America/New_York:20261103T110000
+DTEND;TZID=America/New_York:20261103T111500
+END:VEVENT
+`)
+	from := time.Date(2026, 10, 30, 0, 0, 0, 0, time.UTC)
+	occ := Between(events, from, from.AddDate(0, 0, 6))
+	// Fri 30th, (weekend), Mon 2nd excluded, Tue 3rd moved, Wed 4th. New York
+	// leaves DST on Nov 1, so the UTC time shifts by an hour.
+	assert.Equal(t, []string{"2026-10-30 13:30", "2026-11-03 16:00", "2026-11-04 14:30"}, starts(occ))
+	assert.Equal(t, "Standup (moved)", occ[1].Summary)
+
+	// An event in progress overlaps the window.
+	now := time.Date(2026, 10, 30, 13, 40, 0, 0, time.UTC)
+	occ = Between(events, now, now.Add(time.Hour))
+	require.Len(t, occ, 1)
+	assert.True(t, occ[0].Start.Before(now))
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "work"), 0755))
+	for _, name := range []string{"a.ics", "work/b.ICS", "work/notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+	files, err := Files(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "a.ics"), filepath.Join(dir, "work/b.ICS")}, files)
+
+	files, err = Files(filepath.Join(dir, "a.ics"))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+	_, err = Files(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestParseDuration(t *testing.T) {
+	d, ok := parseDuration("P1DT2H30M")
+	assert.True(t, ok)
+	assert.Equal(t, 26*time.Hour+30*time.Minute, d)
+	d, _ = parseDuration("-PT15M")
+	assert.Equal(t, -15*time.Minute, d)
+	_, ok = parseDuration("1h")
+	assert.False(t, ok)
+}
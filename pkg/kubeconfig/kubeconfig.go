@@ -0,0 +1,64 @@
+// Package kubeconfig reads the current context and its namespace from
+// kubectl config files, for the sidebar's context badges. Only the fields
+// needed for that are parsed; credentials are never looked at.
+package kubeconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// file is the part of a kubeconfig file that is read.
+type file struct {
+	CurrentContext string `yaml:"current-context"`
+	Contexts       []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// Paths returns the files kubectl would read: the entries of a KUBECONFIG
+// value, or ~/.kube/config under home when it is empty.
+func Paths(kubeconfig, home string) []string {
+	var paths []string
+	for _, p := range filepath.SplitList(kubeconfig) {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) == 0 && home != "" {
+		paths = []string{filepath.Join(home, ".kube", "config")}
+	}
+	return paths
+}
+
+// Current returns the current context and its namespace, merging paths the
+// way kubectl does: the first file to set current-context wins, and so does
+// the first definition of a context. Missing or unreadable files are skipped.
+func Current(paths []string) (context, namespace string) {
+	namespaces := map[string]string{}
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		var f file
+		if yaml.Unmarshal(data, &f) != nil {
+			continue
+		}
+		if context == "" {
+			context = f.CurrentContext
+		}
+		for _, c := range f.Contexts {
+			if _, ok := namespaces[c.Name]; !ok {
+				namespaces[c.Name] = c.Context.Namespace
+			}
+		}
+	}
+	return context, namespaces[context]
+}
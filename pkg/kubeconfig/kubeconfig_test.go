@@ -0,0 +1,50 @@
+package kubeconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaths(t *testing.T) {
+	assert.Equal(t, []string{"/home/u/.kube/config"}, Paths("", "/home/u"))
+	assert.Equal(t, []string{"/a", "/b"}, Paths("/a"+string(filepath.ListSeparator)+" /b", "/home/u"))
+}
+
+func TestCurrent(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(p, []byte(body), 0600))
+		return p
+	}
+	first := write("first", `
+current-context: prod-eu
+contexts:
+  - name: prod-eu
+    context:
+      cluster: eu
+      namespace: payments
+`)
+	second := write("second", `
+current-context: staging
+contexts:
+  - name: staging
+    context: {cluster: staging}
+  - name: prod-eu
+    context: {namespace: ignored}
+`)
+	ctx, ns := Current([]string{first, second})
+	assert.Equal(t, "prod-eu", ctx)
+	assert.Equal(t, "payments", ns)
+
+	ctx, ns = Current([]string{filepath.Join(dir, "missing"), second})
+	assert.Equal(t, "staging", ctx)
+	assert.Empty(t, ns)
+
+	ctx, _ = Current([]string{write("bad", "{{{")})
+	assert.Empty(t, ctx)
+}
@@ -0,0 +1,257 @@
+// Package notifications keeps the history of indicator transitions (a window
+// raising its bell, an AI pane starting to wait for input, a busy window going
+// idle, ...). tmux only holds the CURRENT indicator state as window options,
+// and those are cleared as soon as the window is viewed; the daemon appends
+// every transition here so nothing is lost while the user is away.
+//
+// The history is a ring buffer of the last Max events, persisted as JSON lines
+// in notifications.jsonl under paths.StateDir(). It is shared by every tabby
+// session on the host; each event carries the session it came from.
+package notifications
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/brendandebeasi/tabby/pkg/paths"
+)
+
+// Max is the default number of events kept.
+const Max = 500
+
+// Event kinds. Rising edges of each indicator, plus KindDone for a busy
+// window or AI pane that went idle without asking for input, and KindCrash
+// for a pane whose process exited with an error. KindNotify is a message sent
+// with `tabby notify`.
+const (
+	KindBell     = "bell"
+	KindInput    = "input"
+	KindBusy     = "busy"
+	KindDone     = "done"
+	KindActivity = "activity"
+	KindSilence  = "silence"
+	KindCrash    = "crash"
+	KindNotify   = "notify"
+)
+
+// Event is one recorded indicator transition.
+type Event struct {
+	Time        time.Time `json:"time"`
+	Session     string    `json:"session,omitempty"`
+	WindowID    string    `json:"window_id"`
+	WindowIndex int       `json:"window_index"`
+	WindowName  string    `json:"window_name,omitempty"`
+	PaneID      string    `json:"pane_id,omitempty"`
+	Group       string    `json:"group,omitempty"`
+	Kind        string    `json:"kind"`
+	// Title is the window's AI title (@tabby_ai_title) when the event fired.
+	Title string `json:"title,omitempty"`
+	// Message is the text of a KindNotify event.
+	Message string `json:"message,omitempty"`
+}
+
+// Label is the best human name for the event's window.
+func (e Event) Label() string {
+	if e.Title != "" {
+		return e.Title
+	}
+	return e.WindowName
+}
+
+// Path returns the location of the history file.
+func Path() string {
+	return paths.StatePath("notifications.jsonl")
+}
+
+// Log is the in-memory ring buffer backed by a JSON-lines file. New events are
+// appended to the file; once it holds twice the capacity it is compacted to
+// the newest max events. Safe for concurrent use.
+type Log struct {
+	mu     sync.Mutex
+	path   string
+	max    int
+	events []Event
+	lines  int // lines currently in the file
+}
+
+// Open loads the history at path, keeping at most max events (Max when
+// max <= 0). A missing or unreadable file starts an empty history.
+func Open(path string, max int) *Log {
+	if max <= 0 {
+		max = Max
+	}
+	l := &Log{path: path, max: max}
+	events, lines, _ := readFile(path)
+	l.lines = lines
+	if len(events) > max {
+		events = events[len(events)-max:]
+	}
+	l.events = events
+	return l
+}
+
+// Load reads every event at path, oldest first. A missing file is an empty
+// history.
+func Load(path string) ([]Event, error) {
+	events, _, err := readFile(path)
+	return events, err
+}
+
+func readFile(path string) ([]Event, int, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	var events []Event
+	lines := 0
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		lines++
+		var e Event
+		// A torn last line (crash mid-write) is skipped, not fatal.
+		if json.Unmarshal(line, &e) == nil && e.Kind != "" {
+			events = append(events, e)
+		}
+	}
+	return events, lines, sc.Err()
+}
+
+// Append records events, oldest first.
+func (l *Log) Append(events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, events...)
+	if len(l.events) > l.max {
+		l.events = append([]Event(nil), l.events[len(l.events)-l.max:]...)
+	}
+	if l.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	if l.lines+len(events) > 2*l.max {
+		return l.compactLocked(events)
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	l.lines += len(events)
+	return nil
+}
+
+// compactLocked rewrites the file with its newest max events, including the
+// ones being appended. The file is re-read rather than rebuilt from memory
+// because daemons of other sessions append to it too. Caller holds mu.
+func (l *Log) compactLocked(appended []Event) error {
+	onDisk, _, err := readFile(l.path)
+	if err != nil {
+		return err
+	}
+	all := append(onDisk, appended...)
+	if len(all) > l.max {
+		all = all[len(all)-l.max:]
+	}
+	var buf bytes.Buffer
+	for _, e := range all {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return err
+	}
+	l.lines = len(all)
+	return nil
+}
+
+// Recent returns up to n events, newest first (all of them when n <= 0).
+func (l *Log) Recent(n int) []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return newestFirst(l.events, n)
+}
+
+// Filter returns the events of session (any session when "") at or after
+// since (no bound when zero), oldest first.
+func Filter(events []Event, session string, since time.Time) []Event {
+	var out []Event
+	for _, e := range events {
+		if session != "" && e.Session != session {
+			continue
+		}
+		if !since.IsZero() && e.Time.Before(since) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func newestFirst(events []Event, n int) []Event {
+	if n <= 0 || n > len(events) {
+		n = len(events)
+	}
+	out := make([]Event, 0, n)
+	for i := len(events) - 1; i >= 0 && len(out) < n; i-- {
+		out = append(out, events[i])
+	}
+	return out
+}
+
+// Ago renders how long before now t was, compactly: "now", "45s", "12m",
+// "3h", "2d".
+func Ago(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < 5*time.Second:
+		return "now"
+	case d < time.Minute:
+		return strconv.Itoa(int(d/time.Second)) + "s"
+	case d < time.Hour:
+		return strconv.Itoa(int(d/time.Minute)) + "m"
+	case d < 48*time.Hour:
+		return strconv.Itoa(int(d/time.Hour)) + "h"
+	default:
+		return strconv.Itoa(int(d/(24*time.Hour))) + "d"
+	}
+}
@@ -0,0 +1,92 @@
+package notifications
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ev(kind, win string, at time.Time) Event {
+	return Event{Time: at, Session: "$0", WindowID: win, Kind: kind}
+}
+
+func TestLogAppendPersistsAndReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "n.jsonl")
+	t0 := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	l := Open(path, 10)
+	require.NoError(t, l.Append(ev(KindBell, "@1", t0), ev(KindInput, "@2", t0.Add(time.Second))))
+
+	recent := l.Recent(0)
+	require.Len(t, recent, 2)
+	assert.Equal(t, KindInput, recent[0].Kind, "newest first")
+
+	events, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "@1", events[0].WindowID)
+	assert.True(t, events[0].Time.Equal(t0))
+
+	again := Open(path, 10)
+	assert.Len(t, again.Recent(0), 2)
+}
+
+func TestLogRingAndCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "n.jsonl")
+	t0 := time.Now()
+	l := Open(path, 3)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, l.Append(ev(KindBusy, "@1", t0.Add(time.Duration(i)*time.Second))))
+	}
+	assert.Len(t, l.Recent(0), 3)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Count(string(data), "\n")
+	assert.LessOrEqual(t, lines, 6, "file is compacted at twice the capacity")
+
+	reopened := Open(path, 3)
+	recent := reopened.Recent(0)
+	require.Len(t, recent, 3)
+	assert.True(t, recent[0].Time.Equal(t0.Add(9*time.Second)))
+}
+
+func TestLoadSkipsTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "n.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"time":"2026-01-02T03:04:05Z","window_id":"@1","kind":"bell"}`+"\n"+`{"time":"2026-`), 0644))
+	events, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	missing, err := Load(filepath.Join(t.TempDir(), "none.jsonl"))
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Now()
+	events := []Event{
+		{Time: now.Add(-2 * time.Hour), Session: "$0", Kind: KindBell},
+		{Time: now.Add(-10 * time.Minute), Session: "$1", Kind: KindInput},
+		{Time: now.Add(-time.Minute), Session: "$0", Kind: KindDone},
+	}
+	assert.Len(t, Filter(events, "", time.Time{}), 3)
+	assert.Len(t, Filter(events, "$0", time.Time{}), 2)
+	got := Filter(events, "", now.Add(-time.Hour))
+	require.Len(t, got, 2)
+	assert.Equal(t, KindInput, got[0].Kind)
+}
+
+func TestAgo(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, "now", Ago(now.Add(-time.Second), now))
+	assert.Equal(t, "45s", Ago(now.Add(-45*time.Second), now))
+	assert.Equal(t, "12m", Ago(now.Add(-12*time.Minute), now))
+	assert.Equal(t, "3h", Ago(now.Add(-3*time.Hour), now))
+	assert.Equal(t, "3d", Ago(now.Add(-72*time.Hour), now))
+}
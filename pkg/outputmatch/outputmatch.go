@@ -0,0 +1,146 @@
+// Package outputmatch evaluates busy_detection.output_matchers: regular
+// expressions run against the last lines of an AI tool's pane to tell that
+// it is asking for input ("Do you want to proceed?") or still working
+// ("esc to interrupt") without waiting for an idle timer.
+//
+// The daemon evaluates the rules whenever a pane produces output; `tabby
+// debug match-pane` runs the same evaluation on demand and reports which rule
+// fired.
+package outputmatch
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+	"github.com/brendandebeasi/tabby/pkg/tmux"
+)
+
+// States a rule can set.
+const (
+	Input = "input"
+	Busy  = "busy"
+	Idle  = "idle"
+)
+
+// DefaultLines is how many lines a rule searches when it sets none.
+const DefaultLines = 15
+
+// Rule is a compiled output matcher.
+type Rule struct {
+	Name  string
+	Tool  string
+	State string
+	Lines int
+	re    *regexp.Regexp
+}
+
+// Result is the rule that fired and the line it matched.
+type Result struct {
+	Rule Rule
+	Line string
+}
+
+// Compile compiles the configured matchers. Invalid rules are skipped and
+// reported together in err; the valid ones are still returned.
+func Compile(ms []config.OutputMatcher) ([]Rule, error) {
+	var rules []Rule
+	var errs []error
+	for i, m := range ms {
+		name := m.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+		state := strings.ToLower(strings.TrimSpace(m.State))
+		switch state {
+		case Input, Busy, Idle:
+		default:
+			errs = append(errs, fmt.Errorf("%s: state %q is not input, busy or idle", name, m.State))
+			continue
+		}
+		re, err := regexp.Compile(m.Pattern)
+		if err != nil || m.Pattern == "" {
+			if err == nil {
+				err = errors.New("empty pattern")
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		lines := m.Lines
+		if lines <= 0 {
+			lines = DefaultLines
+		}
+		rules = append(rules, Rule{Name: name, Tool: m.Tool, State: state, Lines: lines, re: re})
+	}
+	return rules, errors.Join(errs...)
+}
+
+// AppliesTo reports whether the rule covers a pane running command.
+func (r Rule) AppliesTo(command string) bool {
+	switch r.Tool {
+	case "", "*":
+		return true
+	case "claude":
+		return tmux.IsClaudeCommand(command)
+	}
+	return r.Tool == command
+}
+
+// LinesFor is the most lines any rule for command searches, or 0 when no
+// rule applies (the pane need not be captured).
+func LinesFor(rules []Rule, command string) int {
+	n := 0
+	for _, r := range rules {
+		if r.AppliesTo(command) && r.Lines > n {
+			n = r.Lines
+		}
+	}
+	return n
+}
+
+// Match runs the rule against captured pane text and returns the matching
+// line.
+func (r Rule) Match(text string) (string, bool) {
+	tail := strings.Join(LastLines(text, r.Lines), "\n")
+	loc := r.re.FindStringIndex(tail)
+	if loc == nil {
+		return "", false
+	}
+	start := strings.LastIndex(tail[:loc[0]], "\n") + 1
+	end := strings.Index(tail[loc[0]:], "\n")
+	if end < 0 {
+		end = len(tail)
+	} else {
+		end += loc[0]
+	}
+	return strings.TrimSpace(tail[start:end]), true
+}
+
+// Evaluate returns the first rule for command that matches text.
+func Evaluate(rules []Rule, command, text string) (Result, bool) {
+	for _, r := range rules {
+		if !r.AppliesTo(command) {
+			continue
+		}
+		if line, ok := r.Match(text); ok {
+			return Result{Rule: r, Line: line}, true
+		}
+	}
+	return Result{}, false
+}
+
+// LastLines returns the last n non-blank lines of text, right-trimmed.
+func LastLines(text string, n int) []string {
+	var lines []string
+	for _, l := range strings.Split(text, "\n") {
+		if l = strings.TrimRight(l, " \t\r"); strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
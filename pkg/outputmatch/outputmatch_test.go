@@ -0,0 +1,80 @@
+package outputmatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brendandebeasi/tabby/pkg/config"
+)
+
+const permissionPrompt = `
+ Bash command
+   rm -rf build/
+
+ Do you want to proceed?
+ ❯ 1. Yes
+   2. No, and tell Claude what to do differently (esc)
+
+
+`
+
+func TestCompileSkipsInvalidRules(t *testing.T) {
+	rules, err := Compile([]config.OutputMatcher{
+		{Name: "ok", Pattern: "Press Enter", State: "Input"},
+		{Name: "bad-re", Pattern: "(", State: "input"},
+		{Name: "bad-state", Pattern: "x", State: "waiting"},
+		{Pattern: "", State: "busy"},
+	})
+	require.Len(t, rules, 1)
+	assert.Equal(t, Input, rules[0].State)
+	assert.Equal(t, DefaultLines, rules[0].Lines)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad-re")
+	assert.Contains(t, err.Error(), "bad-state")
+	assert.Contains(t, err.Error(), "rule 4")
+}
+
+func TestEvaluateFirstMatchWins(t *testing.T) {
+	rules, err := Compile([]config.OutputMatcher{
+		{Name: "codex", Tool: "codex", Pattern: "proceed", State: "input"},
+		{Name: "permission", Tool: "claude", Pattern: `Do you want to proceed\?`, State: "input"},
+		{Name: "any", Pattern: "Yes", State: "busy"},
+	})
+	require.NoError(t, err)
+
+	res, ok := Evaluate(rules, "2.1.17", permissionPrompt)
+	require.True(t, ok)
+	assert.Equal(t, "permission", res.Rule.Name)
+	assert.Equal(t, "Do you want to proceed?", res.Line)
+
+	res, ok = Evaluate(rules, "aider", permissionPrompt)
+	require.True(t, ok)
+	assert.Equal(t, "any", res.Rule.Name, "tool-specific rules are skipped for other tools")
+	assert.Equal(t, "❯ 1. Yes", res.Line)
+
+	_, ok = Evaluate(rules, "aider", "nothing here")
+	assert.False(t, ok)
+}
+
+func TestRuleOnlySearchesItsLines(t *testing.T) {
+	rules, err := Compile([]config.OutputMatcher{{Pattern: "Bash command", State: "input", Lines: 2}})
+	require.NoError(t, err)
+	_, ok := rules[0].Match(permissionPrompt)
+	assert.False(t, ok, "the match is above the last two non-blank lines")
+}
+
+func TestLinesFor(t *testing.T) {
+	rules, _ := Compile([]config.OutputMatcher{
+		{Tool: "claude", Pattern: "x", State: "input", Lines: 30},
+		{Tool: "codex", Pattern: "x", State: "input", Lines: 5},
+	})
+	assert.Equal(t, 30, LinesFor(rules, "claude"))
+	assert.Equal(t, 5, LinesFor(rules, "codex"))
+	assert.Zero(t, LinesFor(rules, "aider"))
+}
+
+func TestLastLines(t *testing.T) {
+	assert.Equal(t, []string{" ❯ 1. Yes", "   2. No, and tell Claude what to do differently (esc)"}, LastLines(permissionPrompt, 2))
+}
@@ -0,0 +1,122 @@
+package sysstats
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// clockTicks is USER_HZ, the unit of the CPU times in /proc/<pid>/stat. It is
+// 100 on every Linux architecture tabby runs on.
+const clockTicks = 100
+
+// Process is one process's reading.
+type Process struct {
+	PID, PPID  int
+	CPUPercent float64 // CPU time since the previous read over the wall time between them (since start for the first)
+	RSS        int64   // KiB
+}
+
+// ProcessReader samples every process from /proc/<pid>/stat. CPU usage is
+// the utime+stime a process gained between two reads divided by the time
+// between them, the per-process counterpart of Reader's host CPU, so one
+// busy core is 100%. A ProcessReader keeps the previous CPU times; it is not
+// safe for concurrent use.
+type ProcessReader struct {
+	Proc string // procfs root (default /proc)
+
+	prevTicks  map[int]uint64
+	prevUptime float64
+}
+
+// NewProcessReader returns a ProcessReader for the live system.
+func NewProcessReader() *ProcessReader {
+	return &ProcessReader{Proc: "/proc"}
+}
+
+// Read returns every process. ok is false when procfs is unavailable, e.g.
+// on macOS.
+func (r *ProcessReader) Read() (procs []Process, ok bool) {
+	uptime, ok := r.uptime()
+	if !ok {
+		return nil, false
+	}
+	entries, err := os.ReadDir(r.Proc)
+	if err != nil {
+		return nil, false
+	}
+	pageKiB := int64(os.Getpagesize() / 1024)
+	ticks := make(map[int]uint64, len(entries))
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		st, ok := readProcStat(filepath.Join(r.Proc, e.Name(), "stat"))
+		if !ok {
+			continue // exited since ReadDir
+		}
+		ticks[pid] = st.ticks
+		p := Process{PID: pid, PPID: st.ppid, RSS: st.rssPages * pageKiB}
+		prev, seen := r.prevTicks[pid]
+		elapsed := uptime - r.prevUptime
+		if !seen || prev > st.ticks {
+			// New since the last read, or a reused PID.
+			prev, elapsed = 0, uptime-float64(st.start)/clockTicks
+		}
+		if elapsed > 0 {
+			p.CPUPercent = float64(st.ticks-prev) * 100 / clockTicks / elapsed
+		}
+		procs = append(procs, p)
+	}
+	r.prevTicks, r.prevUptime = ticks, uptime
+	return procs, true
+}
+
+// uptime returns the seconds since boot from /proc/uptime.
+func (r *ProcessReader) uptime() (float64, bool) {
+	fields := strings.Fields(readFile(r.Proc, "uptime"))
+	if len(fields) == 0 {
+		return 0, false
+	}
+	secs, err := strconv.ParseFloat(fields[0], 64)
+	return secs, err == nil
+}
+
+// procStat is the part of /proc/<pid>/stat a ProcessReader uses.
+type procStat struct {
+	ppid     int
+	ticks    uint64 // utime + stime
+	start    uint64 // starttime, in ticks since boot
+	rssPages int64
+}
+
+// readProcStat parses /proc/<pid>/stat. The command name (field 2) is in
+// parentheses and may itself contain spaces and parentheses, so fields are
+// counted from the last ')'.
+func readProcStat(path string) (procStat, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return procStat{}, false
+	}
+	s := string(data)
+	i := strings.LastIndexByte(s, ')')
+	if i < 0 {
+		return procStat{}, false
+	}
+	// fields[0] is field 3 (state), so field n is fields[n-3].
+	fields := strings.Fields(s[i+1:])
+	if len(fields) < 22 {
+		return procStat{}, false
+	}
+	ppid, err1 := strconv.Atoi(fields[1])
+	utime, err2 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err3 := strconv.ParseUint(fields[12], 10, 64)
+	start, err4 := strconv.ParseUint(fields[19], 10, 64)
+	rss, err5 := strconv.ParseInt(fields[21], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil || err5 != nil {
+		return procStat{}, false
+	}
+	return procStat{ppid: ppid, ticks: utime + stime, start: start, rssPages: rss}, true
+}
@@ -0,0 +1,165 @@
+// Package sysstats samples CPU, memory and battery for the stats widget, and
+// per-process CPU and memory for the top widget. It reads Linux's /proc/stat,
+// /proc/meminfo, /proc/<pid>/stat and /sys/class/power_supply; on systems
+// without them the corresponding value is reported as unavailable.
+package sysstats
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Sample is one reading. The *OK fields report whether the value could be
+// read.
+type Sample struct {
+	CPUPercent float64 // busy time since the previous sample (since boot for the first)
+	CPUOK      bool
+
+	MemUsed    uint64 // bytes: MemTotal - MemAvailable
+	MemTotal   uint64 // bytes
+	MemPercent float64
+	MemOK      bool
+
+	BatteryPercent int
+	BatteryStatus  string // "Charging", "Discharging", "Full", ...
+	BatteryOK      bool
+}
+
+// Reader samples the system. CPU usage is the busy share of the time between
+// two reads, so a Reader keeps the previous /proc/stat totals; it is not safe
+// for concurrent use.
+type Reader struct {
+	Proc string // procfs root (default /proc)
+	Sys  string // sysfs root (default /sys)
+
+	prevTotal, prevIdle uint64
+}
+
+// NewReader returns a Reader for the live system.
+func NewReader() *Reader {
+	return &Reader{Proc: "/proc", Sys: "/sys"}
+}
+
+// Read takes a sample.
+func (r *Reader) Read() Sample {
+	var s Sample
+	s.CPUPercent, s.CPUOK = r.cpu()
+	s.MemUsed, s.MemTotal, s.MemOK = r.memory()
+	if s.MemOK && s.MemTotal > 0 {
+		s.MemPercent = float64(s.MemUsed) * 100 / float64(s.MemTotal)
+	}
+	s.BatteryPercent, s.BatteryStatus, s.BatteryOK = r.battery()
+	return s
+}
+
+// cpu returns the busy percentage from the aggregate "cpu" line of
+// /proc/stat. Idle time is idle + iowait.
+func (r *Reader) cpu() (float64, bool) {
+	f, err := os.Open(filepath.Join(r.Proc, "stat"))
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	if !sc.Scan() {
+		return 0, false
+	}
+	fields := strings.Fields(sc.Text())
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, false
+	}
+	var total, idle uint64
+	for i, v := range fields[1:] {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		// guest and guest_nice (fields 9 and 10) are already counted in user.
+		if i >= 8 {
+			break
+		}
+		total += n
+		if i == 3 || i == 4 {
+			idle += n
+		}
+	}
+	dTotal, dIdle := total-r.prevTotal, idle-r.prevIdle
+	if total < r.prevTotal || idle < r.prevIdle {
+		dTotal, dIdle = total, idle
+	}
+	r.prevTotal, r.prevIdle = total, idle
+	if dTotal == 0 {
+		return 0, true
+	}
+	return float64(dTotal-dIdle) * 100 / float64(dTotal), true
+}
+
+// memory returns used and total bytes from /proc/meminfo.
+func (r *Reader) memory() (used, total uint64, ok bool) {
+	f, err := os.Open(filepath.Join(r.Proc, "meminfo"))
+	if err != nil {
+		return 0, 0, false
+	}
+	defer f.Close()
+	var avail uint64
+	haveTotal, haveAvail := false, false
+	sc := bufio.NewScanner(f)
+	for sc.Scan() && !(haveTotal && haveAvail) {
+		key, rest, found := strings.Cut(sc.Text(), ":")
+		if !found || (key != "MemTotal" && key != "MemAvailable") {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if key == "MemTotal" {
+			total, haveTotal = kb*1024, true
+		} else {
+			avail, haveAvail = kb*1024, true
+		}
+	}
+	if !haveTotal || !haveAvail || avail > total {
+		return 0, 0, false
+	}
+	return total - avail, total, true
+}
+
+// battery returns the first battery's capacity and status from
+// /sys/class/power_supply. Mains adapters and peripherals report a type
+// other than "Battery" or have a scope of "Device" and are skipped.
+func (r *Reader) battery() (int, string, bool) {
+	dir := filepath.Join(r.Sys, "class", "power_supply")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, "", false
+	}
+	for _, e := range entries {
+		supply := filepath.Join(dir, e.Name())
+		if readFile(supply, "type") != "Battery" || readFile(supply, "scope") == "Device" {
+			continue
+		}
+		pct, err := strconv.Atoi(readFile(supply, "capacity"))
+		if err != nil {
+			continue
+		}
+		return min(max(pct, 0), 100), readFile(supply, "status"), true
+	}
+	return 0, "", false
+}
+
+// readFile returns the trimmed contents of dir/name, or "".
+func readFile(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
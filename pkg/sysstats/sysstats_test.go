@@ -0,0 +1,129 @@
+package sysstats
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func testReader(t *testing.T) *Reader {
+	root := t.TempDir()
+	return &Reader{Proc: filepath.Join(root, "proc"), Sys: filepath.Join(root, "sys")}
+}
+
+func TestReadCPUUsesDelta(t *testing.T) {
+	r := testReader(t)
+	stat := filepath.Join(r.Proc, "stat")
+	writeFile(t, stat, "cpu  100 0 100 700 100 0 0 0 0 0\ncpu0 100 0 100 700 100 0 0 0 0 0\n")
+	s := r.Read()
+	require.True(t, s.CPUOK)
+	assert.InDelta(t, 20.0, s.CPUPercent, 0.01, "first sample is the since-boot average")
+
+	// +100 busy, +300 idle since the last read.
+	writeFile(t, stat, "cpu  150 0 150 900 200 0 0 0 0 0\n")
+	s = r.Read()
+	assert.InDelta(t, 25.0, s.CPUPercent, 0.01)
+
+	// No time elapsed.
+	s = r.Read()
+	assert.True(t, s.CPUOK)
+	assert.Zero(t, s.CPUPercent)
+}
+
+func TestReadMemory(t *testing.T) {
+	r := testReader(t)
+	writeFile(t, filepath.Join(r.Proc, "meminfo"), "MemTotal:       16000 kB\nMemFree:         1000 kB\nMemAvailable:    4000 kB\n")
+	s := r.Read()
+	require.True(t, s.MemOK)
+	assert.Equal(t, uint64(16000*1024), s.MemTotal)
+	assert.Equal(t, uint64(12000*1024), s.MemUsed)
+	assert.InDelta(t, 75.0, s.MemPercent, 0.01)
+}
+
+func TestReadBatterySkipsMainsAndDevices(t *testing.T) {
+	r := testReader(t)
+	supplies := filepath.Join(r.Sys, "class", "power_supply")
+	writeFile(t, filepath.Join(supplies, "AC", "type"), "Mains\n")
+	writeFile(t, filepath.Join(supplies, "hid-mouse", "type"), "Battery\n")
+	writeFile(t, filepath.Join(supplies, "hid-mouse", "scope"), "Device\n")
+	writeFile(t, filepath.Join(supplies, "hid-mouse", "capacity"), "5\n")
+	writeFile(t, filepath.Join(supplies, "BAT0", "type"), "Battery\n")
+	writeFile(t, filepath.Join(supplies, "BAT0", "capacity"), "87\n")
+	writeFile(t, filepath.Join(supplies, "BAT0", "status"), "Charging\n")
+
+	s := r.Read()
+	require.True(t, s.BatteryOK)
+	assert.Equal(t, 87, s.BatteryPercent)
+	assert.Equal(t, "Charging", s.BatteryStatus)
+}
+
+func TestReadMissingFiles(t *testing.T) {
+	s := testReader(t).Read()
+	assert.False(t, s.CPUOK)
+	assert.False(t, s.MemOK)
+	assert.False(t, s.BatteryOK)
+}
+
+// procStatLine is a /proc/<pid>/stat line with the given ppid, utime, stime,
+// starttime and rss (pages); comm holds a space and a ')'.
+func procStatLine(ppid, utime, stime, start, rss int) string {
+	f := []string{"S", strconv.Itoa(ppid)}
+	for n := 5; n <= 24; n++ {
+		switch n {
+		case 14:
+			f = append(f, strconv.Itoa(utime))
+		case 15:
+			f = append(f, strconv.Itoa(stime))
+		case 22:
+			f = append(f, strconv.Itoa(start))
+		case 24:
+			f = append(f, strconv.Itoa(rss))
+		default:
+			f = append(f, "0")
+		}
+	}
+	return "42 (my (cmd)) " + strings.Join(f, " ") + "\n"
+}
+
+func TestProcessReaderUsesDelta(t *testing.T) {
+	r := &ProcessReader{Proc: t.TempDir()}
+	writeFile(t, filepath.Join(r.Proc, "self", "stat"), "not a pid\n")
+	writeFile(t, filepath.Join(r.Proc, "uptime"), "1000.00 4000.00\n")
+	// Started at 900s: 50s of CPU over 100s of life.
+	writeFile(t, filepath.Join(r.Proc, "42", "stat"), procStatLine(1, 3000, 2000, 90000, 10))
+	procs, ok := r.Read()
+	require.True(t, ok)
+	require.Len(t, procs, 1)
+	assert.Equal(t, 42, procs[0].PID)
+	assert.Equal(t, 1, procs[0].PPID)
+	assert.Equal(t, int64(10*os.Getpagesize()/1024), procs[0].RSS)
+	assert.InDelta(t, 50.0, procs[0].CPUPercent, 0.01, "first sample is the since-start average")
+
+	// Idle for the next 10s: the lifetime average would still read ~45%.
+	writeFile(t, filepath.Join(r.Proc, "uptime"), "1010.00 4000.00\n")
+	procs, _ = r.Read()
+	assert.Zero(t, procs[0].CPUPercent)
+
+	// Then a full core for 2s.
+	writeFile(t, filepath.Join(r.Proc, "uptime"), "1012.00 4000.00\n")
+	writeFile(t, filepath.Join(r.Proc, "42", "stat"), procStatLine(1, 3150, 2050, 90000, 10))
+	procs, _ = r.Read()
+	assert.InDelta(t, 100.0, procs[0].CPUPercent, 0.01)
+}
+
+func TestProcessReaderWithoutProcfs(t *testing.T) {
+	r := &ProcessReader{Proc: filepath.Join(t.TempDir(), "missing")}
+	_, ok := r.Read()
+	assert.False(t, ok)
+}
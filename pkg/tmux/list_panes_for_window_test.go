@@ -15,10 +15,15 @@ import (
 //	pane_current_path, @tabby_pane_collapsed, @tabby_pane_prev_height,
 //	pane_start_command, pane_dead
 //
+// and lays them out in paneListFormat (window index first; no size, remote
+// cwd, agent state, last command or exit details).
+//
 // The separator is `"|||"` for Linux tmux compatibility — see the FieldSep
 // declaration in windows.go.
 func listPanesFields(parts ...string) string {
-	return fields(parts...)
+	line := append([]string{"1"}, parts[:14]...)
+	line = append(line, "", "", "", "", "", parts[14])
+	return fields(line...)
 }
 
 func TestListPanesForWindow_BasicParse(t *testing.T) {
@@ -86,7 +91,7 @@ func TestListPanesForWindow_SkipsSidebarPane(t *testing.T) {
 	assert.Equal(t, "bash", panes[0].Command)
 }
 
-func TestListPanesForWindow_SkipsDeadPane(t *testing.T) {
+func TestListPanesForWindow_KeepsDeadPane(t *testing.T) {
 	restoreState(t)
 	mock := newMock()
 	deadLine := listPanesFields(
@@ -104,8 +109,10 @@ func TestListPanesForWindow_SkipsDeadPane(t *testing.T) {
 
 	panes, err := ListPanesForWindow(1)
 	assert.NoError(t, err)
-	assert.Len(t, panes, 1)
-	assert.Equal(t, "alive", panes[0].Title)
+	if assert.Len(t, panes, 2, "parsed like ListAllPanes") {
+		assert.False(t, panes[0].Dead)
+		assert.True(t, panes[1].Dead)
+	}
 }
 
 func TestListPanesForWindow_BusyPaneDetected(t *testing.T) {
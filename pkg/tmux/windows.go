@@ -70,6 +70,13 @@ type Pane struct {
 	Height       int    // Pane height
 	CurrentPath  string // Current working directory of pane
 	RemoteCWD    string // Remote "host\x1ftopmost" reported by the remote-cwd shell hook (from @tabby_remote_cwd); empty for local panes
+	AgentState   string // Encoded AI agent state from `tabby hook agent-state` (@tabby_agent_state; see pkg/agentstate)
+	LastCmd      string // Encoded last long-running command from the tabby-cmd shell integration (@tabby_cmd; see pkg/cmdtrack)
+	Dead         bool   // Pane's process exited and tmux kept the pane (remain-on-exit)
+	DeadStatus   int    // Exit status of a dead pane (pane_dead_status); 0 when killed by a signal
+	DeadSignal   int    // Signal that killed a dead pane (pane_dead_signal), or 0
+	CrashLog     string // Path of the saved crash output for a dead pane (@tabby_crash_log)
+	ShellEnv     string // Context variables the tabby-cmd shell integration reported at the last prompt (@tabby_env), "NAME=value" joined by \x1f
 	LastActivity int64  // Unix timestamp of last pane output (for idle detection)
 	PID          int    // Process ID of the shell in this pane
 	Collapsed    bool   // Pane is collapsed to header only
@@ -134,6 +141,12 @@ func IsAITool(command string) bool {
 	return semverRegex.MatchString(command)
 }
 
+// IsClaudeCommand reports whether a pane's foreground command is Claude Code,
+// which shows up as "claude" or as its semver version.
+func IsClaudeCommand(command string) bool {
+	return command == "claude" || semverRegex.MatchString(command)
+}
+
 // HasSpinner returns true if the title starts with a braille pattern dot (U+2800-U+28FF),
 // which AI tools like Claude Code use as a working/thinking spinner.
 // Note: ✳ (U+2733) is Claude Code's idle icon, NOT a spinner.
@@ -380,6 +393,8 @@ type Window struct {
 	Icon        string // Custom icon/emoji for window (set via @tabby_icon option)
 	Minimized   bool   // Window is hidden from next/prev cycling (set via @tabby_minimized option)
 	AITitle     string // AI-supplied display title (set via @tabby_ai_title option, takes precedence over Name)
+	CmdAlert    string // "done" or "failed" after a long command finished unseen (set via @tabby_cmd_alert option)
+	Crash       bool   // A pane's process died (set via @tabby_crash option)
 	RemoteHost  string // Hostname if active pane is in an ssh/mosh session; populated by ListWindowsWithPanes
 	Panes       []Pane
 	Layout      string // Window layout string from tmux (e.g., "abc1,80x24,0,0{40x24,0,0,1,39x24,41,0,2}")
@@ -407,7 +422,7 @@ func ListWindows() ([]Window, error) {
 		args = append(args, "-t", sessionTarget)
 	}
 	args = append(args, "-F",
-		strings.Join([]string{"#{window_id}", "#{window_index}", "#{window_name}", "#{window_active}", "#{window_activity_flag}", "#{window_bell_flag}", "#{window_silence_flag}", "#{window_last_flag}", "#{@tabby_color}", "#{@tabby_group}", "#{@tabby_busy}", "#{@tabby_bell}", "#{@tabby_activity}", "#{@tabby_silence}", "#{@tabby_collapsed}", "#{@tabby_input}", "#{@tabby_name_locked}", "#{@tabby_sync_width}", "#{session_id}", "#{@tabby_pinned}", "#{@tabby_icon}", "#{window_layout}", "#{@tabby_minimized}", "#{@tabby_ai_title}", "#{@tabby_color_seeded}", "#{@tabby_appearance_key}"}, tmuxFieldSep))
+		strings.Join([]string{"#{window_id}", "#{window_index}", "#{window_name}", "#{window_active}", "#{window_activity_flag}", "#{window_bell_flag}", "#{window_silence_flag}", "#{window_last_flag}", "#{@tabby_color}", "#{@tabby_group}", "#{@tabby_busy}", "#{@tabby_bell}", "#{@tabby_activity}", "#{@tabby_silence}", "#{@tabby_collapsed}", "#{@tabby_input}", "#{@tabby_name_locked}", "#{@tabby_sync_width}", "#{session_id}", "#{@tabby_pinned}", "#{@tabby_icon}", "#{window_layout}", "#{@tabby_minimized}", "#{@tabby_ai_title}", "#{@tabby_color_seeded}", "#{@tabby_appearance_key}", "#{@tabby_cmd_alert}", "#{@tabby_crash}"}, tmuxFieldSep))
 	out, err := DefaultRunner.Run(args...)
 	if err != nil {
 		return nil, fmt.Errorf("tmux list-windows failed: %w", err)
@@ -530,6 +545,17 @@ func ListWindows() ([]Window, error) {
 		if len(parts) >= 26 {
 			appearanceKey = strings.TrimSpace(parts[25])
 		}
+		// Finished-command alert from @tabby_cmd_alert option.
+		cmdAlert := ""
+		if len(parts) >= 27 {
+			cmdAlert = strings.TrimSpace(parts[26])
+		}
+		// Crash state from @tabby_crash option.
+		crash := false
+		if len(parts) >= 28 {
+			crashVal := strings.TrimSpace(parts[27])
+			crash = crashVal == "1" || crashVal == "true"
+		}
 		// Session ID safety net: skip windows that belong to a different session.
 		// tmux list-windows -t $SESSION can transiently return wrong-session windows.
 		if sessionTarget != "" && len(parts) >= 19 {
@@ -568,6 +594,8 @@ func ListWindows() ([]Window, error) {
 			Layout:           layout,
 			AppearanceSeeded: appearanceSeeded,
 			AppearanceKey:    appearanceKey,
+			CmdAlert:         cmdAlert,
+			Crash:            crash,
 		})
 	}
 
@@ -583,119 +611,13 @@ func ListPanesForWindow(windowIndex int) ([]Pane, error) {
 	if sessionTarget != "" {
 		windowTarget = fmt.Sprintf("%s:%d", sessionTarget, windowIndex)
 	}
-	out, err := DefaultRunner.Run("list-panes", "-t", windowTarget, "-F",
-		strings.Join([]string{"#{pane_id}", "#{pane_index}", "#{pane_active}", "#{pane_current_command}", "#{pane_title}", "#{pane_pid}", "#{pane_last_activity}", "#{@tabby_pane_title}", "#{pane_top}", "#{pane_left}", "#{pane_current_path}", "#{@tabby_pane_collapsed}", "#{@tabby_pane_prev_height}", "#{pane_start_command}", "#{pane_dead}", "#{@tabby_remote_cwd}"}, tmuxFieldSep))
+	out, err := DefaultRunner.Run("list-panes", "-t", windowTarget, "-F", paneListFormat)
 	if err != nil {
 		return nil, err
 	}
-
-	// Get current process PID to filter out our own pane
-	myPID := fmt.Sprintf("%d", os.Getpid())
-
-	// Current time for activity comparison
-	now := time.Now().Unix()
-
 	var panes []Pane
-	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		parts := strings.Split(line, tmuxFieldSep)
-		if len(parts) < 5 {
-			continue
-		}
-		index, err := strconv.Atoi(parts[1])
-		if err != nil {
-			continue
-		}
-		// Skip sidebar/daemon panes by command name
-		// Check for exact matches and also suffix matches (for path-qualified commands)
-		cmd := parts[3]
-		startCommand := ""
-		if len(parts) >= 14 {
-			startCommand = parts[13]
-		}
-		if len(parts) >= 15 && parts[14] == "1" {
-			continue
-		}
-		if isSidebarCommand(cmd) || isSidebarCommand(startCommand) {
-			continue
-		}
-		// Skip our own pane (in case command name hasn't updated yet)
-		if len(parts) >= 6 && parts[5] == myPID {
-			continue
-		}
-		command := stripANSI(parts[3])
-		isRemote := remoteCommands[command]
-		busy := isPaneBusy(command)
-
-		// Parse last activity timestamp
-		var lastActivityTS int64
-		if len(parts) >= 7 {
-			lastActivityTS, _ = strconv.ParseInt(parts[6], 10, 64)
-		}
-
-		// For remote connections, check if there's been activity in the last 3 seconds
-		if isRemote && lastActivityTS > 0 {
-			if now-lastActivityTS <= 3 {
-				busy = true
-			}
-		}
-
-		// Get locked title if set
-		var lockedTitle string
-		if len(parts) >= 8 {
-			lockedTitle = strings.TrimSpace(parts[7])
-		}
-
-		top := 0
-		if len(parts) >= 9 {
-			top, _ = strconv.Atoi(parts[8])
-		}
-
-		left := 0
-		if len(parts) >= 10 {
-			left, _ = strconv.Atoi(parts[9])
-		}
-
-		currentPath := ""
-		if len(parts) >= 11 {
-			currentPath = parts[10]
-		}
-
-		panePID := 0
-		if len(parts) >= 6 {
-			panePID, _ = strconv.Atoi(parts[5])
-		}
-
-		collapsed := false
-		if len(parts) >= 11 {
-			collapsedVal := strings.TrimSpace(parts[10])
-			collapsed = collapsedVal == "1" || strings.EqualFold(collapsedVal, "true")
-		}
-		remoteCWD := ""
-		if len(parts) >= 16 {
-			remoteCWD = strings.TrimSpace(parts[15])
-		}
-		panes = append(panes, Pane{
-			ID:           parts[0],
-			Index:        index,
-			Active:       parts[2] == "1",
-			Command:      command,
-			StartCommand: startCommand,
-			Title:        stripANSI(parts[4]),
-			LockedTitle:  lockedTitle,
-			Busy:         busy,
-			Remote:       isRemote,
-			Top:          top,
-			Left:         left,
-			CurrentPath:  currentPath,
-			RemoteCWD:    remoteCWD,
-			LastActivity: lastActivityTS,
-			PID:          panePID,
-			Collapsed:    collapsed,
-		})
+	for _, ps := range parsePaneList(out) {
+		panes = append(panes, ps...)
 	}
 	return panes, nil
 }
@@ -706,7 +628,6 @@ func ListAllPanes() (map[int][]Pane, error) {
 	t := perf.Start("tmux.ListAllPanes")
 	defer t.Stop()
 
-	// Added pane_start_command, pane_width, pane_height
 	// Use -s (session) instead of -a (all) when scoped to a session,
 	// to prevent cross-session pane mixing via window index collision.
 	args := []string{"list-panes"}
@@ -715,13 +636,21 @@ func ListAllPanes() (map[int][]Pane, error) {
 	} else {
 		args = append(args, "-a")
 	}
-	args = append(args, "-F",
-		strings.Join([]string{"#{window_index}", "#{pane_id}", "#{pane_index}", "#{pane_active}", "#{pane_current_command}", "#{pane_title}", "#{pane_pid}", "#{pane_last_activity}", "#{@tabby_pane_title}", "#{pane_top}", "#{pane_left}", "#{pane_current_path}", "#{@tabby_pane_collapsed}", "#{@tabby_pane_prev_height}", "#{pane_start_command}", "#{pane_width}", "#{pane_height}", "#{@tabby_remote_cwd}"}, tmuxFieldSep))
+	args = append(args, "-F", paneListFormat)
 	out, err := DefaultRunner.Run(args...)
 	if err != nil {
 		return nil, err
 	}
+	return parsePaneList(out), nil
+}
 
+// paneListFormat is the list-panes format shared by ListAllPanes and
+// ListPanesForWindow.
+var paneListFormat = strings.Join([]string{"#{window_index}", "#{pane_id}", "#{pane_index}", "#{pane_active}", "#{pane_current_command}", "#{pane_title}", "#{pane_pid}", "#{pane_last_activity}", "#{@tabby_pane_title}", "#{pane_top}", "#{pane_left}", "#{pane_current_path}", "#{@tabby_pane_collapsed}", "#{@tabby_pane_prev_height}", "#{pane_start_command}", "#{pane_width}", "#{pane_height}", "#{@tabby_remote_cwd}", "#{@tabby_agent_state}", "#{@tabby_cmd}", "#{pane_dead}", "#{pane_dead_status}", "#{pane_dead_signal}", "#{@tabby_crash_log}", "#{@tabby_env}"}, tmuxFieldSep)
+
+// parsePaneList parses paneListFormat output into panes by window index,
+// skipping sidebar/daemon panes and the daemon's own pane.
+func parsePaneList(out []byte) map[int][]Pane {
 	myPID := fmt.Sprintf("%d", os.Getpid())
 	now := time.Now().Unix()
 	result := make(map[int][]Pane)
@@ -813,6 +742,33 @@ func ListAllPanes() (map[int][]Pane, error) {
 		if len(parts) >= 18 {
 			remoteCWD = strings.TrimSpace(parts[17])
 		}
+		agentState := ""
+		if len(parts) >= 19 {
+			agentState = parts[18]
+		}
+		lastCmd := ""
+		if len(parts) >= 20 {
+			lastCmd = parts[19]
+		}
+		// Dead pane kept by remain-on-exit: its exit, and the crash output
+		// the daemon saved for it. A dead pane is never busy.
+		dead := len(parts) >= 21 && parts[20] == "1"
+		deadStatus, deadSignal := 0, 0
+		if len(parts) >= 23 {
+			deadStatus, _ = strconv.Atoi(parts[21])
+			deadSignal, _ = strconv.Atoi(parts[22])
+		}
+		crashLog := ""
+		if len(parts) >= 24 {
+			crashLog = strings.TrimSpace(parts[23])
+		}
+		if dead {
+			busy = false
+		}
+		shellEnv := ""
+		if len(parts) >= 25 {
+			shellEnv = parts[24]
+		}
 
 		pane := Pane{
 			ID:           parts[1],
@@ -830,6 +786,13 @@ func ListAllPanes() (map[int][]Pane, error) {
 			Height:       height,
 			CurrentPath:  currentPath,
 			RemoteCWD:    remoteCWD,
+			AgentState:   agentState,
+			LastCmd:      lastCmd,
+			Dead:         dead,
+			DeadStatus:   deadStatus,
+			DeadSignal:   deadSignal,
+			CrashLog:     crashLog,
+			ShellEnv:     shellEnv,
 			LastActivity: lastActivityTS,
 			PID:          panePID,
 		}
@@ -843,7 +806,7 @@ func ListAllPanes() (map[int][]Pane, error) {
 		result[windowIdx] = append(result[windowIdx], pane)
 	}
 
-	return result, nil
+	return result
 }
 
 // ListWindowsWithPanes returns all windows with their panes
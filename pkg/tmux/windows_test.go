@@ -162,6 +162,14 @@ func TestIsAITool(t *testing.T) {
 	})
 }
 
+func TestIsClaudeCommand(t *testing.T) {
+	assert.True(t, IsClaudeCommand("claude"))
+	assert.True(t, IsClaudeCommand("2.1.17"))
+	assert.False(t, IsClaudeCommand("codex"))
+	assert.False(t, IsClaudeCommand("zsh"))
+	assert.False(t, IsClaudeCommand("v2.1.17"))
+}
+
 func TestHasSpinner(t *testing.T) {
 	t.Run("braille_dot_is_spinner", func(t *testing.T) {
 		assert.True(t, HasSpinner("⠋working"))
@@ -953,6 +961,34 @@ func TestListPanesForWindow_InvalidPaneIndex(t *testing.T) {
 	}
 }
 
+func TestListAllPanes_DeadPane(t *testing.T) {
+	restoreState(t)
+	mock := newMock()
+	// ListAllPanes fields: window_index first, then the pane fields through
+	// @tabby_cmd, then pane_dead, pane_dead_status, pane_dead_signal,
+	// @tabby_crash_log and @tabby_env.
+	deadLine := fields(
+		"1", "%3", "0", "1", "make", "",
+		"99996", "1700000000", "", "0", "0",
+		"/src", "", "", "make test", "80", "24",
+		"", "", "", "1", "2", "", "/state/crashes/x.log", "AWS_PROFILE=prod\x1fKUBECONFIG=",
+	)
+	mock.set("list-panes", deadLine+"\n", nil)
+	DefaultRunner = mock
+
+	panes, err := ListAllPanes()
+	assert.NoError(t, err)
+	if assert.Len(t, panes[1], 1) {
+		p := panes[1][0]
+		assert.True(t, p.Dead)
+		assert.Equal(t, 2, p.DeadStatus)
+		assert.Equal(t, 0, p.DeadSignal)
+		assert.Equal(t, "/state/crashes/x.log", p.CrashLog)
+		assert.Equal(t, "AWS_PROFILE=prod\x1fKUBECONFIG=", p.ShellEnv)
+		assert.False(t, p.Busy, "a dead pane is not busy")
+	}
+}
+
 // ListAllPanes: session target → uses -s -t flags instead of -a
 func TestListAllPanes_SessionTargetUsesDashS(t *testing.T) {
 	restoreState(t)
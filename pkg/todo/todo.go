@@ -0,0 +1,143 @@
+// Package todo reads and edits a markdown task list, the file behind the
+// sidebar's todo widget and `tabby ctl todo`. Tasks are list items with a
+// checkbox, "- [ ] open item" or "- [x] done item", with "-", "*" or "+"
+// bullets at any indentation. Every other line (headings, notes, blank
+// lines) is left exactly as it is when an item is ticked or added, so the
+// file stays pleasant to edit by hand.
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Item is one task.
+type Item struct {
+	Text string
+	Done bool
+	Line int // 0-based line in the file
+}
+
+// taskLine matches "- [ ] text", capturing the prefix up to the box, the
+// mark and the text.
+var taskLine = regexp.MustCompile(`^(\s*[-*+]\s+\[)([ xX])\](?:\s+(.*))?$`)
+
+// Parse returns the tasks in data, in file order.
+func Parse(data []byte) []Item {
+	var items []Item
+	for i, line := range strings.Split(string(data), "\n") {
+		m := taskLine.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		text := strings.TrimSpace(m[3])
+		if text == "" {
+			continue
+		}
+		items = append(items, Item{Text: text, Done: m[2] != " ", Line: i})
+	}
+	return items
+}
+
+// Load reads the tasks in path. A missing file has none.
+func Load(path string) ([]Item, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data), nil
+}
+
+// SetDone ticks or unticks a task read earlier, found by its line and text.
+// If the file was edited since and that line no longer holds the task, the
+// first task with the same text and state is changed instead.
+func SetDone(path string, it Item, done bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(data), "\n")
+	target := -1
+	for _, cur := range Parse(data) {
+		if cur.Text != it.Text || cur.Done != it.Done {
+			continue
+		}
+		if cur.Line == it.Line {
+			target = cur.Line
+			break
+		}
+		if target < 0 {
+			target = cur.Line
+		}
+	}
+	if target < 0 {
+		return fmt.Errorf("todo: %q is no longer in %s", it.Text, path)
+	}
+	mark := " "
+	if done {
+		mark = "x"
+	}
+	m := taskLine.FindStringSubmatchIndex(strings.TrimRight(lines[target], "\r"))
+	line := lines[target]
+	lines[target] = line[:m[4]] + mark + line[m[5]:]
+	return write(path, []byte(strings.Join(lines, "\n")))
+}
+
+// Add appends an open task to path, creating the file if needed.
+func Add(path, text string) error {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return errors.New("todo: empty item")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	data = append(data, "- [ ] "+text+"\n"...)
+	return write(path, data)
+}
+
+// write replaces path atomically, keeping its permissions. A symlinked task
+// file (e.g. into a dotfiles repo) has its target replaced, not the link.
+func write(path string, data []byte) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
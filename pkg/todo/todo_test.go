@@ -0,0 +1,77 @@
+package todo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sample = "# Today\n\n- [ ] ship the release\n  * [x] write notes\n+ [X] tag\n- [ ]\n- not a task\n- [ ] call Sam\r\n"
+
+func TestParse(t *testing.T) {
+	assert.Equal(t, []Item{
+		{Text: "ship the release", Line: 2},
+		{Text: "write notes", Done: true, Line: 3},
+		{Text: "tag", Done: true, Line: 4},
+		{Text: "call Sam", Line: 7},
+	}, Parse([]byte(sample)))
+}
+
+func TestSetDoneAndAdd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo.md")
+	items, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, items, "missing file")
+
+	require.NoError(t, os.WriteFile(path, []byte(sample), 0600))
+	items, err = Load(path)
+	require.NoError(t, err)
+
+	require.NoError(t, SetDone(path, items[3], true))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# Today\n\n- [ ] ship the release\n  * [x] write notes\n+ [X] tag\n- [ ]\n- not a task\n- [x] call Sam\r\n", string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "permissions kept")
+
+	// The file changed in an editor: the item is found by its text.
+	require.NoError(t, os.WriteFile(path, []byte("- [ ] new first\n- [ ] ship the release\n"), 0600))
+	require.NoError(t, SetDone(path, items[0], true))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "- [ ] new first\n- [x] ship the release\n", string(data))
+	assert.Error(t, SetDone(path, items[0], true), "already done")
+
+	require.NoError(t, os.WriteFile(path, []byte("notes"), 0600))
+	require.NoError(t, Add(path, "  buy\tmilk "))
+	assert.Error(t, Add(path, " "))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "notes\n- [ ] buy milk\n", string(data))
+}
+
+func TestWriteThroughSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "todo.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(target), 0755))
+	require.NoError(t, os.WriteFile(target, []byte("- [ ] one\n"), 0600))
+	link := filepath.Join(dir, "todo.md")
+	require.NoError(t, os.Symlink(target, link))
+
+	require.NoError(t, Add(link, "two"))
+	info, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink, "the link is kept")
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "- [ ] one\n- [ ] two\n", string(data))
+
+	entries, err := os.ReadDir(filepath.Dir(target))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temp file left behind")
+}
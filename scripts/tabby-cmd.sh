@@ -0,0 +1,156 @@
+# tabby-cmd.sh — report when shell commands start and finish, so tabby can
+# flag a window when a long build, test run or deploy is done (or failed) and
+# show the last long command and its duration in the pane header.
+#
+# Source this from your shell rc (locally, or on a remote host you ssh into
+# from a tabby pane), e.g.:
+#
+#     # ~/.bashrc or ~/.zshrc
+#     [ -f ~/.tabby-cmd.sh ] && . ~/.tabby-cmd.sh
+#
+# How it works: before a command runs it prints an OSC 7700
+# ";tabby-cmd;start;COMMAND" escape, and at the next prompt
+# ";tabby-cmd;end;STATUS". tabby's pipe-pane handler (`tabby hook
+# osc-handler`) times the command; one that ran at least
+# indicators.command.min_duration seconds is recorded. No tabby binary is
+# needed where the shell runs — this is pure shell. BEL (\007) terminates.
+#
+# At each prompt it also reports KUBECONFIG, the AWS profile variables and
+# those named in TABBY_ENV_VARS (set by tabby from sidebar.context_badges.env)
+# as ";tabby-env;NAME=value\037NAME=value...", whenever they changed, so the
+# context badges follow an `export` made at the prompt.
+
+__tabby_cmd_emit() {
+	# $1 = start|end, $2 = command line or exit status. Control characters
+	# would end the sequence early, so they become spaces.
+	local __tb_arg=${2//[$'\001'-$'\037']/ }
+	__tb_arg=${__tb_arg:0:200}
+	if [ -n "$TMUX" ]; then
+		# Inside an inner tmux: wrap in a DCS passthrough envelope (double the
+		# ESC). Mirrors emitOSCFallback in set_indicator.go.
+		printf '\033Ptmux;\033\033]7700;tabby-cmd;%s;%s\007\033\\' "$1" "$__tb_arg"
+	else
+		printf '\033]7700;tabby-cmd;%s;%s\007' "$1" "$__tb_arg"
+	fi
+}
+
+__tabby_env_exported() {
+	# Is $1 exported, not just a shell variable?
+	if [ -n "$ZSH_VERSION" ]; then
+		eval '[[ ${(Pt)1} == *export* ]]'
+	elif ((BASH_VERSINFO[0] > 4 || BASH_VERSINFO[0] == 4 && BASH_VERSINFO[1] >= 4)); then
+		eval '[[ ${!1@a} == *x* ]]'
+	else
+		local __tb_decl
+		__tb_decl=$(declare -p "$1" 2>/dev/null) || return 1
+		__tb_decl=${__tb_decl#declare -}
+		[[ ${__tb_decl%% *} == *x* ]]
+	fi
+}
+
+__tabby_env_report() {
+	# Word-split TABBY_ENV_VARS in zsh too.
+	[ -n "$ZSH_VERSION" ] && setopt localoptions shwordsplit
+	local __tb_env= __tb_name __tb_val
+	for __tb_name in KUBECONFIG AWS_PROFILE AWS_VAULT AWS_DEFAULT_PROFILE $TABBY_ENV_VARS; do
+		case "$__tb_name" in
+			'' | [0-9]* | *[!A-Za-z0-9_]*) continue ;;
+		esac
+		__tb_val=
+		# Only exported variables reach kubectl, aws and friends.
+		__tabby_env_exported "$__tb_name" && eval "__tb_val=\${$__tb_name-}"
+		__tb_env="$__tb_env${__tb_env:+$'\037'}$__tb_name=${__tb_val//[$'\001'-$'\036']/ }"
+	done
+	[ "$__tb_env" = "$__TABBY_ENV_LAST" ] && return 0
+	__TABBY_ENV_LAST=$__tb_env
+	__tb_env=${__tb_env:0:1000}
+	if [ -n "$TMUX" ]; then
+		printf '\033Ptmux;\033\033]7700;tabby-env;%s\007\033\\' "$__tb_env"
+	else
+		printf '\033]7700;tabby-env;%s\007' "$__tb_env"
+	fi
+}
+
+__tabby_cmd_precmd() {
+	local __tb_status=$?
+	if [ -n "$__TABBY_CMD_RUNNING" ]; then
+		__tabby_cmd_emit end "$__tb_status"
+		unset __TABBY_CMD_RUNNING
+	fi
+	__tabby_env_report
+	return $__tb_status
+}
+
+if [ -n "$ZSH_VERSION" ]; then
+	__tabby_cmd_preexec() {
+		__TABBY_CMD_RUNNING=1
+		__tabby_cmd_emit start "$1"
+	}
+	# add-zsh-hook is idempotent — sourcing twice won't double-register.
+	autoload -Uz add-zsh-hook 2>/dev/null
+	add-zsh-hook preexec __tabby_cmd_preexec 2>/dev/null
+	add-zsh-hook precmd __tabby_cmd_precmd 2>/dev/null
+elif [ -n "$BASH_VERSION" ] && [ -n "${bash_preexec_imported:-}${__bp_imported:-}" ]; then
+	# bash-preexec owns the DEBUG trap: register through its hook arrays.
+	__tabby_cmd_bp_preexec() {
+		__TABBY_CMD_RUNNING=1
+		__tabby_cmd_emit start "$1"
+	}
+	case " ${preexec_functions[*]} " in
+		*" __tabby_cmd_bp_preexec "*) ;; # already wired
+		*)
+			preexec_functions+=(__tabby_cmd_bp_preexec)
+			precmd_functions+=(__tabby_cmd_precmd)
+			;;
+	esac
+elif [ -n "$BASH_VERSION" ]; then
+	# bash has no preexec: a DEBUG trap fires before every simple command.
+	# __tabby_cmd_arm is the last PROMPT_COMMAND step, so only the first
+	# command after a prompt (not PROMPT_COMMAND's own) starts a timer.
+	# The label is the line from history, unless its entry number has not
+	# grown since the prompt: HISTCONTROL dropped it or history is off, and
+	# history 1 is still the previous command. $BASH_COMMAND is used then.
+	__tabby_cmd_hist() {
+		__tb_hist=$(HISTTIMEFORMAT= builtin history 1 2>/dev/null)
+		__tb_histno=${__tb_hist#"${__tb_hist%%[0-9]*}"}
+		__tb_histno=${__tb_histno%%[!0-9]*}
+	}
+	__tabby_cmd_arm() {
+		local __tb_hist __tb_histno
+		__tabby_cmd_hist
+		__TABBY_CMD_HISTNO=$__tb_histno
+		__TABBY_CMD_ARMED=1
+	}
+	__tabby_cmd_preexec() {
+		[ -n "$__TABBY_CMD_ARMED" ] || return 0
+		[ -z "$COMP_LINE" ] || return 0
+		unset __TABBY_CMD_ARMED
+		local __tb_hist __tb_histno __tb_cmd=$BASH_COMMAND
+		__tabby_cmd_hist
+		if [ -n "$__tb_histno" ] && [ "$__tb_histno" -gt "${__TABBY_CMD_HISTNO:-0}" ]; then
+			__tb_cmd=${__tb_hist#*[0-9]  }
+		fi
+		__TABBY_CMD_RUNNING=1
+		__tabby_cmd_emit start "${__tb_cmd:-$BASH_COMMAND}"
+	}
+	# Chain a DEBUG trap the shell already has: it runs after ours, so under
+	# extdebug its status still decides whether the command runs. It is read
+	# at the first prompt because bash hides the DEBUG trap inside a sourced
+	# file and inside functions; trap -p prints it as  trap -- '...' DEBUG.
+	__tabby_cmd_trap() {
+		__TABBY_CMD_TRAPPED=1
+		local __tb_prev=${1#"trap -- '"}
+		__tb_prev=${__tb_prev%"' DEBUG"}
+		__TABBY_CMD_PREV_DEBUG=${__tb_prev//"'\\''"/"'"}
+		case "$__TABBY_CMD_PREV_DEBUG" in
+			'' | *__tabby_cmd_preexec*) trap '__tabby_cmd_preexec' DEBUG ;;
+			*) trap '__tabby_cmd_preexec; eval "$__TABBY_CMD_PREV_DEBUG"' DEBUG ;;
+		esac
+	}
+	case "$PROMPT_COMMAND" in
+		*__tabby_cmd_precmd*) ;; # already wired
+		*)
+			PROMPT_COMMAND="__tabby_cmd_precmd${PROMPT_COMMAND:+; $PROMPT_COMMAND}; [ -n \"\$__TABBY_CMD_TRAPPED\" ] || __tabby_cmd_trap \"\$(trap -p DEBUG)\"; __tabby_cmd_arm"
+			;;
+	esac
+fi